@@ -68,6 +68,13 @@ const (
 	DBUS_IMAGE_ACTIVATE
 	DBUS_DOCKER_LOAD
 	DBUS_CONFIG_REPLACE
+	CRL_DOWNLOAD
+	CRL_DOWNLOAD_FAIL
+	OCSP_QUERY
+	OCSP_QUERY_FAIL
+	REVOCATION_CHECK
+	REVOCATION_REVOKED
+	REVOCATION_UNDETERMINED
 	COUNTER_SIZE
 )
 
@@ -133,6 +140,20 @@ func (c CounterType) String() string {
 		return "DBUS docker load"
 	case DBUS_CONFIG_REPLACE:
 		return "DBUS config replace"
+	case CRL_DOWNLOAD:
+		return "CRL download"
+	case CRL_DOWNLOAD_FAIL:
+		return "CRL download fail"
+	case OCSP_QUERY:
+		return "OCSP query"
+	case OCSP_QUERY_FAIL:
+		return "OCSP query fail"
+	case REVOCATION_CHECK:
+		return "Revocation check"
+	case REVOCATION_REVOKED:
+		return "Revocation revoked"
+	case REVOCATION_UNDETERMINED:
+		return "Revocation undetermined"
 	default:
 		return ""
 	}
@@ -0,0 +1,183 @@
+package gnmi
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	log "github.com/golang/glog"
+	certz "github.com/openconfig/gnsi/certz"
+	"github.com/sonic-net/sonic-gnmi/pkg/wildcard"
+)
+
+// authPolicy is the certz AuthenticationPolicy. It maps the CAs of the trust
+// bundle to the client identities they are allowed to authenticate, so that
+// a CA trusted during a rollover can be limited to the clients it issues for.
+// Clients whose chain has no CA listed in the policy are not restricted.
+//
+// The policy is uploaded serialized as JSON:
+//
+//	{"policies": [{"ca_subject": "CN=new-ca,O=Example", "identities": ["*.example.org"]}]}
+type authPolicy struct {
+	Policies []*authPolicyEntry `json:"policies"`
+}
+
+type authPolicyEntry struct {
+	CaSubject     string   `json:"ca_subject,omitempty"`     // Subject of the CA, as formatted by pkix.Name.String
+	CaFingerprint string   `json:"ca_fingerprint,omitempty"` // Hex SHA-256 of the DER CA certificate
+	Identities    []string `json:"identities"`               // Wildcard patterns of the allowed identities
+
+	patterns []*regexp.Regexp
+}
+
+// parseAuthPolicy parses and validates a serialized authentication policy.
+// Empty data is a policy without restrictions.
+func parseAuthPolicy(data []byte) (*authPolicy, error) {
+	policy := &authPolicy{}
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return policy, nil
+	}
+	if err := json.Unmarshal(data, policy); err != nil {
+		return nil, fmt.Errorf("invalid authentication policy: %v", err)
+	}
+	for i, entry := range policy.Policies {
+		if entry == nil || (entry.CaSubject == "" && entry.CaFingerprint == "") {
+			return nil, fmt.Errorf("authentication policy %d has no ca_subject or ca_fingerprint", i)
+		}
+		entry.CaFingerprint = strings.ToLower(strings.ReplaceAll(entry.CaFingerprint, ":", ""))
+		for _, identity := range entry.Identities {
+			entry.patterns = append(entry.patterns, wildcard.Compile(identity))
+		}
+	}
+	return policy, nil
+}
+
+// verifyAuthPolicy checks the verified chains of a client against the
+// authentication policy file, which certz may be rotating.
+func verifyAuthPolicy(path string, chains [][]*x509.Certificate) error {
+	muPath.RLock()
+	policy, err := loadAuthPolicy(path)
+	muPath.RUnlock()
+	if err != nil {
+		return fmt.Errorf("could not load authentication policy: %s", err)
+	}
+	return policy.verify(chains)
+}
+
+// loadAuthPolicy reads the authentication policy file. Returns nil if the
+// file is not configured or does not exist.
+func loadAuthPolicy(path string) (*authPolicy, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseAuthPolicy(data)
+}
+
+func (e *authPolicyEntry) matchCA(ca *x509.Certificate) bool {
+	if e.CaSubject != "" && e.CaSubject != ca.Subject.String() {
+		return false
+	}
+	if e.CaFingerprint != "" {
+		sum := sha256.Sum256(ca.Raw)
+		if e.CaFingerprint != hex.EncodeToString(sum[:]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *authPolicyEntry) allows(identity string) bool {
+	for _, re := range e.patterns {
+		if re.MatchString(identity) {
+			return true
+		}
+	}
+	return false
+}
+
+// verify checks that the client certificate of the verified chains is
+// allowed by the policy of the CAs of at least one chain. The identity is
+// taken from the client cert identity sources, CommonName by default.
+func (p *authPolicy) verify(chains [][]*x509.Certificate) error {
+	if p == nil || len(p.Policies) == 0 || len(chains) == 0 || len(chains[0]) == 0 {
+		return nil
+	}
+	sources := GetCertIdentitySources()
+	if len(sources) == 0 {
+		sources = []string{CertIdentityCN}
+	}
+	identity, _ := certIdentity(chains[0][0], sources)
+
+	for _, chain := range chains {
+		restricted := false
+		for _, ca := range chain[1:] {
+			for _, entry := range p.Policies {
+				if !entry.matchCA(ca) {
+					continue
+				}
+				if identity != "" && entry.allows(identity) {
+					return nil
+				}
+				restricted = true
+			}
+		}
+		if !restricted {
+			return nil
+		}
+	}
+	return fmt.Errorf("client identity %q is not allowed by the authentication policy", identity)
+}
+
+// readTrustBundle returns the PEM trust bundle of a certz certificate chain.
+// Every certificate of the chain is a trust anchor, and each can hold several
+// PEM certificates, so that the old and new CAs can be trusted together
+// during a CA rollover.
+func readTrustBundle(chain *certz.CertificateChain) ([]byte, error) {
+	var bundle []byte
+	for ; chain != nil; chain = chain.GetParent() {
+		tb := chain.GetCertificate()
+		if tb.GetType() != certz.CertificateType_CERTIFICATE_TYPE_X509 {
+			return nil, fmt.Errorf("trustBundle type has to be X.509")
+		}
+		if tb.GetEncoding() != certz.CertificateEncoding_CERTIFICATE_ENCODING_PEM {
+			return nil, fmt.Errorf("trustBundle encoding has to be PEM")
+		}
+		rest := tb.GetCertificate()
+		if len(rest) == 0 {
+			return nil, fmt.Errorf("trustBundle cannot be empty")
+		}
+		for {
+			var block *pem.Block
+			block, rest = pem.Decode(rest)
+			if block == nil {
+				break
+			}
+			if block.Type != "CERTIFICATE" {
+				continue
+			}
+			ca, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trustBundle certificate: %v", err)
+			}
+			log.V(2).Infof("Trust bundle CA: %s", ca.Subject)
+			bundle = append(bundle, pem.EncodeToMemory(block)...)
+		}
+	}
+	if len(bundle) == 0 {
+		return nil, fmt.Errorf("trustBundle has no certificates")
+	}
+	return bundle, nil
+}
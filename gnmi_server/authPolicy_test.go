@@ -0,0 +1,196 @@
+package gnmi
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	certz "github.com/openconfig/gnsi/certz"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// verifiedChains verifies cert against a trust bundle, as done in the handshake.
+func verifiedChains(t *testing.T, bundle []byte, cert *x509.Certificate) [][]*x509.Certificate {
+	t.Helper()
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(bundle) {
+		t.Fatal("Failed to load the trust bundle")
+	}
+	chains, err := cert.Verify(x509.VerifyOptions{Roots: pool, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}})
+	if err != nil {
+		t.Fatalf("Failed to verify %s: %v", cert.Subject, err)
+	}
+	return chains
+}
+
+func TestParseAuthPolicy(t *testing.T) {
+	policy, err := parseAuthPolicy([]byte(`{"policies": [{"ca_fingerprint": "AB:CD", "identities": ["*.example.org"]}]}`))
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if len(policy.Policies) != 1 || policy.Policies[0].CaFingerprint != "abcd" || len(policy.Policies[0].patterns) != 1 {
+		t.Errorf("Unexpected policy %+v", policy.Policies[0])
+	}
+	if policy, err := parseAuthPolicy([]byte(" \n")); err != nil || len(policy.Policies) != 0 {
+		t.Errorf("Expected an empty policy, got %+v, err %v", policy, err)
+	}
+	for _, data := range []string{
+		`{"policies": [`,
+		`{"policies": [{"identities": ["*"]}]}`,
+		`{"policies": [null]}`,
+	} {
+		if _, err := parseAuthPolicy([]byte(data)); err == nil {
+			t.Errorf("Expected an error for %s", data)
+		}
+	}
+}
+
+func TestLoadAuthPolicy(t *testing.T) {
+	if policy, err := loadAuthPolicy(""); policy != nil || err != nil {
+		t.Errorf("Expected no policy without a file, got %v, err %v", policy, err)
+	}
+	path := filepath.Join(t.TempDir(), "authentication_policy.json")
+	if policy, err := loadAuthPolicy(path); policy != nil || err != nil {
+		t.Errorf("Expected no policy for a missing file, got %v, err %v", policy, err)
+	}
+	os.WriteFile(path, []byte(`{"policies": [{"ca_subject": "CN=ca", "identities": ["a"]}]}`), 0600)
+	if policy, err := loadAuthPolicy(path); err != nil || len(policy.Policies) != 1 {
+		t.Errorf("Expected the policy, got %v, err %v", policy, err)
+	}
+	os.WriteFile(path, []byte(`invalid`), 0600)
+	if _, err := loadAuthPolicy(path); err == nil {
+		t.Errorf("Expected an error for an invalid policy")
+	}
+}
+
+func TestAuthPolicyVerify(t *testing.T) {
+	oldCA := newTestCA(t, "old-ca")
+	newCA := newTestCA(t, "new-ca")
+	bundle := append(oldCA.pem(), newCA.pem()...)
+	sum := sha256.Sum256(newCA.cert.Raw)
+
+	// The new CA may only authenticate the lab clients during the rollover
+	policy, err := parseAuthPolicy([]byte(`{"policies": [
+		{"ca_fingerprint": "` + hex.EncodeToString(sum[:]) + `", "identities": ["*.lab.example.org", "admin"]}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		ca      *testCA
+		cn      string
+		allowed bool
+	}{
+		{ca: oldCA, cn: "host.prod.example.org", allowed: true},
+		{ca: newCA, cn: "host.lab.example.org", allowed: true},
+		{ca: newCA, cn: "admin", allowed: true},
+		{ca: newCA, cn: "host.prod.example.org"},
+		{ca: newCA, cn: ""},
+	}
+	for _, tc := range testCases {
+		chains := verifiedChains(t, bundle, tc.ca.issue(t, tc.cn))
+		if err := policy.verify(chains); (err == nil) != tc.allowed {
+			t.Errorf("%s from %s: expected allowed %v, got %v", tc.cn, tc.ca.cert.Subject, tc.allowed, err)
+		}
+	}
+
+	// Entries match the CA by subject too
+	policy, _ = parseAuthPolicy([]byte(`{"policies": [{"ca_subject": "CN=new-ca,O=Example", "identities": ["admin"]}]}`))
+	if err := policy.verify(verifiedChains(t, bundle, newCA.issue(t, "operator"))); err == nil {
+		t.Errorf("Expected operator to be rejected")
+	}
+
+	// The identity follows the client cert identity sources
+	useCertIdentitySources(t, "spiffe")
+	if err := policy.verify(verifiedChains(t, bundle, newCA.issue(t, "admin"))); err == nil {
+		t.Errorf("Expected a client without a SPIFFE ID to be rejected")
+	}
+
+	var empty *authPolicy
+	if err := empty.verify(verifiedChains(t, bundle, newCA.issue(t, "operator"))); err != nil {
+		t.Errorf("Expected no restriction without a policy, got %v", err)
+	}
+}
+
+func TestReadTrustBundle(t *testing.T) {
+	oldCA := newTestCA(t, "old-ca")
+	newCA := newTestCA(t, "new-ca")
+	pemCert := func(data []byte) *certz.Certificate {
+		return &certz.Certificate{
+			Type:        certz.CertificateType_CERTIFICATE_TYPE_X509,
+			Encoding:    certz.CertificateEncoding_CERTIFICATE_ENCODING_PEM,
+			Certificate: data,
+		}
+	}
+
+	// Both CAs are trusted, whether sent in one certificate or as a chain
+	for _, chain := range []*certz.CertificateChain{
+		{Certificate: pemCert(append(oldCA.pem(), newCA.pem()...))},
+		{Certificate: pemCert(oldCA.pem()), Parent: &certz.CertificateChain{Certificate: pemCert(newCA.pem())}},
+	} {
+		bundle, err := readTrustBundle(chain)
+		if err != nil {
+			t.Fatalf("Expected success, got %v", err)
+		}
+		verifiedChains(t, bundle, oldCA.issue(t, "old-client"))
+		verifiedChains(t, bundle, newCA.issue(t, "new-client"))
+	}
+
+	for _, chain := range []*certz.CertificateChain{
+		nil,
+		{Certificate: pemCert(nil)},
+		{Certificate: pemCert([]byte("test"))},
+		{Certificate: pemCert(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("test")}))},
+		{Certificate: &certz.Certificate{Type: certz.CertificateType_CERTIFICATE_TYPE_X509, Certificate: oldCA.pem()}},
+		{Certificate: pemCert(oldCA.pem()), Parent: &certz.CertificateChain{Certificate: &certz.Certificate{Certificate: newCA.pem()}}},
+	} {
+		if _, err := readTrustBundle(chain); err == nil {
+			t.Errorf("Expected an error for %v", chain)
+		}
+	}
+}
+
+func TestSaveAuthPolicy(t *testing.T) {
+	srv := &GNSICertzServer{profiles: map[string]*profile{defaultProfile: {ID: defaultProfile}}}
+	path := filepath.Join(t.TempDir(), "authentication_policy.json")
+	entity := &genericEntity{EType: apType, CertPath: path}
+	policyEntity := func(data string) *certz.Entity {
+		return &certz.Entity{Entity: &certz.Entity_AuthenticationPolicy{
+			AuthenticationPolicy: &certz.AuthenticationPolicy{Policy: &certz.AuthenticationPolicy_Serialized{
+				Serialized: &anypb.Any{Value: []byte(data)},
+			}},
+		}}
+	}
+
+	err := srv.saveEntities(defaultProfile, policyEntity(`{"policies": [{"ca_subject": "CN=ca"}]`), entity)
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for an invalid policy, got %v", err)
+	}
+
+	// Saving without a previous policy, then reverting, leaves no policy
+	policy := `{"policies": [{"ca_subject": "CN=ca", "identities": ["admin"]}]}`
+	if err := srv.saveEntities(defaultProfile, policyEntity(policy), entity); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != policy {
+		t.Errorf("Expected the policy to be saved, got %q", data)
+	}
+	srv.profiles[defaultProfile].ActiveEntities = entityGroup{
+		Cert:        &genericEntity{Final: true},
+		TrustBundle: &genericEntity{Final: true},
+		CrlBundle:   &genericEntity{Final: true},
+		AuthPolicy:  entity,
+	}
+	srv.profiles[defaultProfile].LastEntities.AuthPolicy = &genericEntity{EType: apType, CertPath: path, Final: true}
+	srv.revertProfile(defaultProfile)
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected the policy to be removed, got %v", err)
+	}
+}
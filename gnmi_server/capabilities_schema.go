@@ -0,0 +1,63 @@
+package gnmi
+
+import (
+	"encoding/json"
+
+	gnmi_extpb "github.com/openconfig/gnmi/proto/gnmi_ext"
+	operationalhandler "github.com/sonic-net/sonic-gnmi/pkg/server/operational-handler"
+	spb "github.com/sonic-net/sonic-gnmi/proto"
+	sdc "github.com/sonic-net/sonic-gnmi/sonic_data_client"
+)
+
+// Capabilities schema
+//
+// The SHOW, OTHERS, OPERATIONAL and EVENTS targets are not described by YANG
+// models, so they are missing from the supported models. A CapabilityRequest
+// carrying the SCHEMA_EXT registered extension gets a SCHEMA_EXT extension in
+// the CapabilityResponse, whose message is the JSON encoded targetsSchema:
+// every registered path pattern of these targets, with its options.
+
+// targetSchema lists the paths of a target.
+type targetSchema struct {
+	Target string      `json:"target"`
+	Paths  interface{} `json:"paths"`
+}
+
+type targetsSchema struct {
+	Targets []targetSchema `json:"targets"`
+}
+
+// wantsSchema checks if the Capabilities extensions request the schema.
+func wantsSchema(extensions []*gnmi_extpb.Extension) bool {
+	for _, e := range extensions {
+		if v, ok := e.Ext.(*gnmi_extpb.Extension_RegisteredExt); ok && v.RegisteredExt.GetId() == spb.SCHEMA_EXT {
+			return true
+		}
+	}
+	return false
+}
+
+// schemaExtension encodes the paths of the non YANG targets as a
+// CapabilityResponse extension.
+func schemaExtension() (*gnmi_extpb.Extension, error) {
+	schema := targetsSchema{
+		Targets: []targetSchema{
+			{Target: "SHOW", Paths: sdc.ShowPathSchemas()},
+			{Target: "OTHERS", Paths: sdc.OthersPathSchemas()},
+			{Target: "OPERATIONAL", Paths: operationalhandler.RegisteredPaths()},
+			{Target: "EVENTS", Paths: sdc.EventsPathSchemas()},
+		},
+	}
+	msg, err := json.Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+	return &gnmi_extpb.Extension{
+		Ext: &gnmi_extpb.Extension_RegisteredExt{
+			RegisteredExt: &gnmi_extpb.RegisteredExtension{
+				Id:  spb.SCHEMA_EXT,
+				Msg: msg,
+			},
+		},
+	}, nil
+}
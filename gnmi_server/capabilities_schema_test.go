@@ -0,0 +1,63 @@
+package gnmi
+
+import (
+	"encoding/json"
+	"testing"
+
+	gnmi_extpb "github.com/openconfig/gnmi/proto/gnmi_ext"
+	spb "github.com/sonic-net/sonic-gnmi/proto"
+)
+
+func TestWantsSchema(t *testing.T) {
+	if wantsSchema(nil) {
+		t.Error("Expected no schema without extensions")
+	}
+	if wantsSchema([]*gnmi_extpb.Extension{registeredExt(spb.BUNDLE_VERSION_EXT)}) {
+		t.Error("Expected no schema with bundle version extension")
+	}
+	if !wantsSchema([]*gnmi_extpb.Extension{registeredExt(spb.BUNDLE_VERSION_EXT), registeredExt(spb.SCHEMA_EXT)}) {
+		t.Error("Expected schema with schema extension")
+	}
+}
+
+func TestSchemaExtension(t *testing.T) {
+	ext, err := schemaExtension()
+	if err != nil {
+		t.Fatalf("schemaExtension failed: %v", err)
+	}
+	reg := ext.GetRegisteredExt()
+	if reg.GetId() != spb.SCHEMA_EXT {
+		t.Errorf("Expected extension id %d, got %d", spb.SCHEMA_EXT, reg.GetId())
+	}
+
+	var got struct {
+		Targets []struct {
+			Target string `json:"target"`
+			Paths  []struct {
+				Path    string            `json:"path"`
+				Options []json.RawMessage `json:"options"`
+			} `json:"paths"`
+		} `json:"targets"`
+	}
+	if err := json.Unmarshal(reg.GetMsg(), &got); err != nil {
+		t.Fatalf("Failed to decode schema: %v", err)
+	}
+
+	paths := map[string]map[string]bool{}
+	for _, target := range got.Targets {
+		paths[target.Target] = map[string]bool{}
+		for _, p := range target.Paths {
+			paths[target.Target][p.Path] = true
+		}
+	}
+	for target, path := range map[string]string{
+		"SHOW":        "/clock",
+		"OTHERS":      "/proc/uptime",
+		"OPERATIONAL": "/sonic/system/filesystem[path=*]/disk-space",
+		"EVENTS":      "/all",
+	} {
+		if !paths[target][path] {
+			t.Errorf("Expected %s in %s paths, got %v", path, target, paths[target])
+		}
+	}
+}
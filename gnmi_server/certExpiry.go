@@ -0,0 +1,260 @@
+package gnmi
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/redis/go-redis/v9"
+	"github.com/sonic-net/sonic-gnmi/pkg/certexpiry"
+	sdc "github.com/sonic-net/sonic-gnmi/sonic_data_client"
+)
+
+// Certificate expiry monitoring periodically checks the server certificate
+// and CA of the configuration, the certificates and trust bundles of the
+// active certz profiles and the cached CRLs. Their expiry is published in
+// STATE_DB and at the OPERATIONAL path /sonic/system/certificates/expiry, and
+// a SONiC event is raised when one gets within an alert threshold.
+
+const DEFAULT_CERT_EXPIRY_CHECK_INTERVAL time.Duration = time.Hour
+
+const (
+	// STATE_DB table exposing the expiry of the certificates and CRLs
+	certExpiryTable = "TELEMETRY_CERT_EXPIRY"
+	// Source and tag of the expiry alert events
+	certExpiryEventSource = "sonic-events-host"
+	certExpiryEventTag    = "cert-expiry"
+)
+
+var (
+	certExpiryCheckInterval = DEFAULT_CERT_EXPIRY_CHECK_INTERVAL
+	certExpiryThresholds    = certexpiry.DefaultThresholds
+
+	// Allow DI for testing
+	publishCertExpiryEvent = sdc.PublishEvent
+	getCertExpiryDbClient  = func() (*redis.Client, error) { return newDbClient("STATE_DB") }
+)
+
+func SetCertExpiryCheckInterval(interval time.Duration) {
+	certExpiryCheckInterval = interval
+}
+
+func SetCertExpiryThresholds(thresholds []int) {
+	certExpiryThresholds = thresholds
+}
+
+// certFileExpiry reads the PEM certificates of path, and returns the expiry
+// of the one expiring first.
+func certFileExpiry(name string, kind certexpiry.Kind, path string) certexpiry.Cert {
+	cert := certexpiry.Cert{Name: name, Kind: kind, Path: path}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		cert.Error = err.Error()
+		return cert
+	}
+	sum := sha256.Sum256(data)
+	cert.Fingerprint = hex.EncodeToString(sum[:])
+
+	for rest := data; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		c, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			cert.Error = fmt.Sprintf("invalid certificate: %v", err)
+			cert.Expiry = time.Time{}
+			return cert
+		}
+		if cert.Expiry.IsZero() || c.NotAfter.Before(cert.Expiry) {
+			cert.Expiry = c.NotAfter
+			cert.Subject = c.Subject.String()
+		}
+	}
+	if cert.Expiry.IsZero() {
+		cert.Error = "no certificate found"
+	}
+	return cert
+}
+
+// collectCertExpiry returns the certificates and CRLs to check for expiry.
+func collectCertExpiry(config *Config) []certexpiry.Cert {
+	// Wait for certz rotations to complete
+	muPath.RLock()
+	defer muPath.RUnlock()
+
+	var certs []certexpiry.Cert
+	// The default certz profile usually holds the files of the configuration
+	files := map[string]bool{}
+	addFile := func(name string, kind certexpiry.Kind, path string) {
+		if path == "" {
+			return
+		}
+		resolved, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			resolved = path
+		}
+		if files[resolved] {
+			return
+		}
+		files[resolved] = true
+		certs = append(certs, certFileExpiry(name, kind, path))
+	}
+	addFile("server", certexpiry.KindCertificate, config.SrvCertFile)
+	addFile("ca", certexpiry.KindTrustBundle, config.CaCertFile)
+
+	if config.CertzMetaFile != "" {
+		profiles := map[string]*profile{}
+		if err := loadCertzMetadata(config.CertzMetaFile, profiles); err != nil && !os.IsNotExist(err) {
+			glog.V(1).Infof("Failed to load certz profiles for expiry checks: %v", err)
+		}
+		ids := make([]string, 0, len(profiles))
+		for id := range profiles {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			if e := profiles[id].ActiveEntities.Cert; e != nil {
+				addFile("certz|"+id+"|certificate", certexpiry.KindCertificate, e.CertPath)
+			}
+			if e := profiles[id].ActiveEntities.TrustBundle; e != nil {
+				addFile("certz|"+id+"|trust_bundle", certexpiry.KindTrustBundle, e.CertPath)
+			}
+		}
+	}
+
+	crlMu.RLock()
+	for key, crl := range CrlCache {
+		// CRLs without a next update do not expire
+		if !crl.nextUpdate.After(crl.thisUpdate) {
+			continue
+		}
+		sum := sha256.Sum256(crl.crl)
+		certs = append(certs, certexpiry.Cert{
+			Name:        "crl|" + key,
+			Kind:        certexpiry.KindCrl,
+			Path:        key,
+			Subject:     crl.issuer,
+			Fingerprint: hex.EncodeToString(sum[:]),
+			Expiry:      crl.nextUpdate,
+		})
+	}
+	crlMu.RUnlock()
+
+	sort.Slice(certs, func(i, j int) bool { return certs[i].Name < certs[j].Name })
+	return certs
+}
+
+// certExpiryFields returns the STATE_DB fields of an expiry status.
+func certExpiryFields(st certexpiry.Status) map[string]string {
+	fields := map[string]string{
+		"type": string(st.Kind),
+		"path": st.Path,
+	}
+	if st.Error != "" {
+		fields["error"] = st.Error
+		return fields
+	}
+	fields["subject"] = st.Subject
+	fields["expiry"] = st.Expiry.UTC().Format(time.RFC3339)
+	fields["days_remaining"] = strconv.Itoa(st.DaysRemaining)
+	fields["threshold"] = strconv.Itoa(st.Threshold)
+	return fields
+}
+
+// alertCertExpiry raises the expiry event of a certificate.
+func alertCertExpiry(st certexpiry.Status) error {
+	if st.DaysRemaining < 0 {
+		glog.Errorf("Certificate %s (%s) expired on %v", st.Name, st.Subject, st.Expiry)
+	} else {
+		glog.Warningf("Certificate %s (%s) expires in %d days on %v", st.Name, st.Subject, st.DaysRemaining, st.Expiry)
+	}
+	params := certExpiryFields(st)
+	params["name"] = st.Name
+	if err := publishCertExpiryEvent(certExpiryEventSource, certExpiryEventTag, params); err != nil {
+		glog.V(1).Infof("Failed to publish the expiry event of %s: %v", st.Name, err)
+		return err
+	}
+	return nil
+}
+
+// publishCertExpiryStatus mirrors the expiry status in STATE_DB.
+func publishCertExpiryStatus(statuses []certexpiry.Status) error {
+	client, err := getCertExpiryDbClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	keys, err := client.Keys(ctx, certExpiryTable+"|*").Result()
+	if err != nil {
+		return err
+	}
+	pipe := client.TxPipeline()
+	for _, key := range keys {
+		pipe.Del(ctx, key)
+	}
+	for _, st := range statuses {
+		pipe.HSet(ctx, certExpiryTable+"|"+st.Name, certExpiryFields(st))
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// StartCertExpiryMonitor checks the expiry of the certificates and CRLs of
+// config every check interval until the returned function is called.
+func StartCertExpiryMonitor(config *Config) (stop func()) {
+	m := certexpiry.NewMonitor(func() []certexpiry.Cert {
+		return collectCertExpiry(config)
+	}, alertCertExpiry, certExpiryThresholds)
+	certexpiry.SetDefaultMonitor(m)
+
+	check := func() {
+		statuses := m.Check(time.Now())
+		if err := publishCertExpiryStatus(statuses); err != nil {
+			glog.V(1).Infof("Failed to publish certificate expiry status: %v", err)
+		}
+	}
+	check()
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(certExpiryCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				check()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			wg.Wait()
+			certexpiry.SetDefaultMonitor(nil)
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package gnmi
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/sonic-net/sonic-gnmi/pkg/certexpiry"
+)
+
+// writeExpiringCert writes a PEM certificate of ca expiring at notAfter to path.
+func writeExpiringCert(t *testing.T, ca *testCA, path string, cn string, notAfter time.Time) {
+	t.Helper()
+	cert := ca.sign(t, &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: cn},
+		NotAfter:     notAfter,
+	})
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]})
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCertFileExpiry(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, "ca")
+	soon := time.Now().Add(48 * time.Hour).Truncate(time.Second)
+
+	// A bundle expires with its first certificate
+	path := filepath.Join(dir, "bundle.pem")
+	writeExpiringCert(t, ca, path, "later", time.Now().Add(72*time.Hour))
+	later, _ := os.ReadFile(path)
+	writeExpiringCert(t, ca, path, "sooner", soon)
+	sooner, _ := os.ReadFile(path)
+	key := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: []byte("key")})
+	os.WriteFile(path, append(append(later, key...), sooner...), 0600)
+	cert := certFileExpiry("ca", certexpiry.KindTrustBundle, path)
+	if cert.Error != "" || cert.Subject != "CN=sooner" || !cert.Expiry.Equal(soon) || cert.Fingerprint == "" {
+		t.Errorf("Unexpected expiry %+v", cert)
+	}
+
+	invalid := filepath.Join(dir, "invalid.pem")
+	for _, data := range [][]byte{
+		[]byte("test"),
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("test")}),
+	} {
+		os.WriteFile(invalid, data, 0600)
+		if cert := certFileExpiry("invalid", certexpiry.KindCertificate, invalid); cert.Error == "" || !cert.Expiry.IsZero() {
+			t.Errorf("Expected an error for %q, got %+v", data, cert)
+		}
+	}
+	if cert := certFileExpiry("missing", certexpiry.KindCertificate, filepath.Join(dir, "missing")); cert.Error == "" {
+		t.Errorf("Expected an error for a missing file")
+	}
+}
+
+func TestCollectCertExpiry(t *testing.T) {
+	resetRevocation(t)
+	dir := t.TempDir()
+	ca := newTestCA(t, "ca")
+	in := func(days int) time.Time { return time.Now().Add(time.Duration(days)*24*time.Hour + time.Hour) }
+
+	srvCert := filepath.Join(dir, "server.cer")
+	writeExpiringCert(t, ca, srvCert, "server", in(60))
+	caCert := filepath.Join(dir, "ca.cer")
+	os.WriteFile(caCert, ca.pem(), 0600)
+	certzCert := filepath.Join(dir, "default_V2_cert.pem")
+	writeExpiringCert(t, ca, certzCert, "certz", in(5))
+	os.WriteFile(filepath.Join(dir, "default_V2_key.pem"), []byte("key"), 0600)
+	// The certz trust bundle is the CA of the configuration
+	caLink := filepath.Join(dir, "ca.lnk")
+	os.Symlink(caCert, caLink)
+
+	metaFile := filepath.Join(dir, "certz.json")
+	profiles := map[string]*profile{defaultProfile: {
+		ID: defaultProfile,
+		ActiveEntities: entityGroup{
+			Cert:        &genericEntity{EType: certType, CertPath: certzCert, KeyPath: filepath.Join(dir, "default_V2_key.pem"), Final: true},
+			TrustBundle: &genericEntity{EType: tbType, CertPath: caLink, Final: true},
+			CrlBundle:   &genericEntity{EType: crlType, Final: true},
+			AuthPolicy:  &genericEntity{EType: apType, Final: true},
+		},
+	}}
+	if err := saveCertzMetadata(metaFile, profiles); err != nil {
+		t.Fatal(err)
+	}
+
+	crl := newCrl(newTestCA(t, "revocation test CA").crl(t, in(1)))
+	crlMu.Lock()
+	CrlCache["http://crl.example.org/ca.crl"] = crl
+	// Without a next update
+	CrlCache["http://crl.example.org/none.crl"] = &Crl{thisUpdate: crl.thisUpdate, nextUpdate: crl.thisUpdate, crl: crl.crl}
+	crlMu.Unlock()
+
+	config := &Config{SrvCertFile: srvCert, CaCertFile: caCert, CertzMetaFile: metaFile}
+	certs := collectCertExpiry(config)
+	expected := []struct {
+		name    string
+		kind    certexpiry.Kind
+		subject string
+	}{
+		{"ca", certexpiry.KindTrustBundle, "CN=ca,O=Example"},
+		{"certz|" + defaultProfile + "|certificate", certexpiry.KindCertificate, "CN=certz"},
+		{"crl|http://crl.example.org/ca.crl", certexpiry.KindCrl, "CN=revocation test CA,O=Example"},
+		{"server", certexpiry.KindCertificate, "CN=server"},
+	}
+	if len(certs) != len(expected) {
+		t.Fatalf("Expected %d certificates, got %+v", len(expected), certs)
+	}
+	for i, e := range expected {
+		if certs[i].Name != e.name || certs[i].Kind != e.kind || certs[i].Subject != e.subject || certs[i].Error != "" {
+			t.Errorf("Expected %s, got %+v", e.name, certs[i])
+		}
+	}
+
+	// Without certz metadata
+	config.CertzMetaFile = filepath.Join(dir, "missing.json")
+	if certs := collectCertExpiry(config); len(certs) != 3 {
+		t.Errorf("Expected 3 certificates, got %+v", certs)
+	}
+}
+
+func TestPublishCertExpiryStatus(t *testing.T) {
+	mr := miniredis.RunT(t)
+	addr := mr.Addr()
+	original := getCertExpiryDbClient
+	t.Cleanup(func() { getCertExpiryDbClient = original })
+	getCertExpiryDbClient = func() (*redis.Client, error) {
+		return redis.NewClient(&redis.Options{Addr: addr, MaxRetries: -1}), nil
+	}
+
+	expiry := time.Date(2026, 1, 31, 12, 0, 0, 0, time.UTC)
+	mr.HSet(certExpiryTable+"|removed", "type", "certificate")
+	statuses := []certexpiry.Status{
+		{Cert: certexpiry.Cert{Name: "server", Kind: certexpiry.KindCertificate, Path: "/etc/server.cer", Subject: "CN=server", Expiry: expiry},
+			DaysRemaining: 5, Threshold: 7},
+		{Cert: certexpiry.Cert{Name: "ca", Kind: certexpiry.KindTrustBundle, Path: "/etc/ca.cer", Error: "no such file"}},
+	}
+	if err := publishCertExpiryStatus(statuses); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	for field, value := range map[string]string{
+		"type":           "certificate",
+		"path":           "/etc/server.cer",
+		"subject":        "CN=server",
+		"expiry":         "2026-01-31T12:00:00Z",
+		"days_remaining": "5",
+		"threshold":      "7",
+	} {
+		if got := mr.HGet(certExpiryTable+"|server", field); got != value {
+			t.Errorf("Expected %s %q, got %q", field, value, got)
+		}
+	}
+	if mr.HGet(certExpiryTable+"|ca", "error") != "no such file" || mr.HGet(certExpiryTable+"|ca", "expiry") != "" {
+		t.Errorf("Expected the CA error only")
+	}
+	if mr.Exists(certExpiryTable + "|removed") {
+		t.Errorf("Expected the previous status to be replaced")
+	}
+
+	mr.Close()
+	if err := publishCertExpiryStatus(statuses); err == nil {
+		t.Errorf("Expected an error when STATE_DB is unavailable")
+	}
+}
+
+func TestStartCertExpiryMonitor(t *testing.T) {
+	resetRevocation(t)
+	dir := t.TempDir()
+	ca := newTestCA(t, "ca")
+	srvCert := filepath.Join(dir, "server.cer")
+	writeExpiringCert(t, ca, srvCert, "server", time.Now().Add(3*24*time.Hour+time.Hour))
+
+	originalPublish, originalClient := publishCertExpiryEvent, getCertExpiryDbClient
+	originalThresholds := certExpiryThresholds
+	t.Cleanup(func() {
+		publishCertExpiryEvent, getCertExpiryDbClient = originalPublish, originalClient
+		SetCertExpiryThresholds(originalThresholds)
+	})
+	getCertExpiryDbClient = func() (*redis.Client, error) {
+		return nil, fmt.Errorf("STATE_DB is not available")
+	}
+	type event struct {
+		source, tag string
+		params      map[string]string
+	}
+	var events []event
+	publishCertExpiryEvent = func(source, tag string, params map[string]string) error {
+		events = append(events, event{source, tag, params})
+		return nil
+	}
+	SetCertExpiryThresholds([]int{1, 7})
+
+	stop := StartCertExpiryMonitor(&Config{SrvCertFile: srvCert})
+	statuses, err := certexpiry.GetStatus()
+	if err != nil || len(statuses) != 1 || statuses[0].DaysRemaining != 3 || statuses[0].Threshold != 7 {
+		t.Errorf("Unexpected status %+v, err %v", statuses, err)
+	}
+	if len(events) != 1 || events[0].source != "sonic-events-host" || events[0].tag != "cert-expiry" ||
+		events[0].params["name"] != "server" || events[0].params["days_remaining"] != "3" || events[0].params["threshold"] != "7" {
+		t.Errorf("Unexpected events %+v", events)
+	}
+
+	stop()
+	stop()
+	if _, err := certexpiry.GetStatus(); err == nil {
+		t.Errorf("Expected the monitor to be unregistered once stopped")
+	}
+}
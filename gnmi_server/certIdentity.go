@@ -0,0 +1,389 @@
+package gnmi
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/redis/go-redis/v9"
+	"github.com/sonic-net/sonic-gnmi/common_utils"
+	"github.com/sonic-net/sonic-gnmi/pkg/wildcard"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// Sources of the client identity in a certificate.
+const (
+	CertIdentityCN     = "cn"     // Subject CommonName
+	CertIdentityDNS    = "dns"    // DNS SAN
+	CertIdentityURI    = "uri"    // URI SAN
+	CertIdentitySPIFFE = "spiffe" // URI SAN with the spiffe scheme
+	CertIdentityEmail  = "email"  // email SAN
+)
+
+// Match rules of the client cert role table entries, in the "match" field.
+// Entries without the field are matched exactly.
+const (
+	CertMatchExact    = "exact"
+	CertMatchWildcard = "wildcard"
+	CertMatchRegex    = "regex"
+)
+
+// Identity sources in order of preference. When empty, authentication uses
+// the CommonName and pathz the last element of the SPIFFE ID, and authz
+// matches its principals against the whole certificate.
+var certIdentitySources []string
+
+// ParseCertIdentitySources parses a comma separated list of identity sources.
+func ParseCertIdentitySources(s string) ([]string, error) {
+	var sources []string
+	for _, source := range strings.Split(s, ",") {
+		source = strings.ToLower(strings.TrimSpace(source))
+		switch source {
+		case "":
+			continue
+		case CertIdentityCN, CertIdentityDNS, CertIdentityURI, CertIdentitySPIFFE, CertIdentityEmail:
+			sources = append(sources, source)
+		default:
+			return nil, fmt.Errorf("invalid client cert identity source %q, expect cn, dns, uri, spiffe or email", source)
+		}
+	}
+	return sources, nil
+}
+
+func SetCertIdentitySources(sources []string) {
+	certIdentitySources = sources
+}
+
+func GetCertIdentitySources() []string {
+	return certIdentitySources
+}
+
+// certIdentity returns the identity of cert from the first of sources the
+// certificate has, with that source. Returns empty strings if it has none.
+func certIdentity(cert *x509.Certificate, sources []string) (string, string) {
+	for _, source := range sources {
+		switch source {
+		case CertIdentityCN:
+			if cert.Subject.CommonName != "" {
+				return cert.Subject.CommonName, source
+			}
+		case CertIdentityDNS:
+			if len(cert.DNSNames) > 0 {
+				return cert.DNSNames[0], source
+			}
+		case CertIdentityURI:
+			if len(cert.URIs) > 0 {
+				return cert.URIs[0].String(), source
+			}
+		case CertIdentitySPIFFE:
+			for _, uri := range cert.URIs {
+				if strings.EqualFold(uri.Scheme, "spiffe") {
+					return uri.String(), source
+				}
+			}
+		case CertIdentityEmail:
+			if len(cert.EmailAddresses) > 0 {
+				return cert.EmailAddresses[0], source
+			}
+		}
+	}
+	return "", ""
+}
+
+// verifiedLeaf returns the verified client certificate of the connection, nil
+// if the client did not present one.
+func verifiedLeaf(state tls.ConnectionState) *x509.Certificate {
+	if len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return state.VerifiedChains[0][0]
+}
+
+var (
+	// Allow DI for testing
+	getCertRoleDbClient = func() (*redis.Client, error) { return newDbClient("CONFIG_DB") }
+)
+
+// certRoleRule is a wildcard or regex entry of the client cert role table.
+type certRoleRule struct {
+	key   string
+	match string
+	re    *regexp.Regexp
+	roles []string
+}
+
+// Returns the roles of a role table entry.
+func certEntryRoles(entry map[string]string) []string {
+	if role, ok := entry["role@"]; ok {
+		return strings.Split(role, ",")
+	}
+	if role, ok := entry["role"]; ok {
+		// Backward compatibility for single role DB schema
+		return []string{role}
+	}
+	return nil
+}
+
+// Loads the wildcard and regex entries of the role table, wildcard entries
+// first, each sorted by key. Invalid entries are skipped.
+func loadCertRoleRules(ctx context.Context, client *redis.Client, table string) ([]*certRoleRule, error) {
+	prefix := table + "|"
+	keys, err := client.Keys(ctx, prefix+"*").Result()
+	if err != nil {
+		return nil, err
+	}
+	var rules []*certRoleRule
+	for _, key := range keys {
+		entry, err := client.HGetAll(ctx, key).Result()
+		if err != nil {
+			return nil, err
+		}
+		rule := &certRoleRule{
+			key:   strings.TrimPrefix(key, prefix),
+			match: strings.ToLower(entry["match"]),
+			roles: certEntryRoles(entry),
+		}
+		switch rule.match {
+		case "", CertMatchExact:
+			continue
+		case CertMatchWildcard:
+			rule.re = wildcard.Compile(rule.key)
+		case CertMatchRegex:
+			rule.re, err = regexp.Compile("^(?:" + rule.key + ")$")
+		default:
+			err = fmt.Errorf("unknown match %q", rule.match)
+		}
+		if err != nil {
+			glog.Warningf("Skipping client cert role entry '%s': %v", key, err)
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].match != rules[j].match {
+			return rules[i].match == CertMatchWildcard
+		}
+		return rules[i].key < rules[j].key
+	})
+	return rules, nil
+}
+
+// certRoleRulesRefreshInterval is how long the rules of a role table are
+// cached, in case a keyspace notification was missed.
+var certRoleRulesRefreshInterval = time.Minute
+
+// cachedCertRoleRules are the rules of a role table, loaded at a time.
+type cachedCertRoleRules struct {
+	rules  []*certRoleRule
+	loaded time.Time
+}
+
+var (
+	certRoleRulesMu    sync.Mutex
+	certRoleRulesCache = map[string]*cachedCertRoleRules{}
+	// Number of invalidations of each role table, so that rules loaded
+	// before an invalidation are not cached
+	certRoleRulesGen = map[string]uint64{}
+)
+
+// getCertRoleRules returns the rules of a role table, loading them if they are
+// not cached or were cached more than certRoleRulesRefreshInterval ago.
+func getCertRoleRules(table string) ([]*certRoleRule, error) {
+	certRoleRulesMu.Lock()
+	cached, ok := certRoleRulesCache[table]
+	gen := certRoleRulesGen[table]
+	certRoleRulesMu.Unlock()
+	if ok && time.Since(cached.loaded) < certRoleRulesRefreshInterval {
+		return cached.rules, nil
+	}
+
+	client, err := getCertRoleDbClient()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	loaded := time.Now()
+	rules, err := loadCertRoleRules(context.Background(), client, table)
+	if err != nil {
+		return nil, err
+	}
+	certRoleRulesMu.Lock()
+	if certRoleRulesGen[table] == gen {
+		certRoleRulesCache[table] = &cachedCertRoleRules{rules: rules, loaded: loaded}
+	}
+	certRoleRulesMu.Unlock()
+	return rules, nil
+}
+
+// invalidateCertRoleRules drops the cached rules of a role table.
+func invalidateCertRoleRules(table string) {
+	certRoleRulesMu.Lock()
+	defer certRoleRulesMu.Unlock()
+	delete(certRoleRulesCache, table)
+	certRoleRulesGen[table]++
+}
+
+// StartCertRoleRulesWatcher subscribes to the CONFIG_DB keyspace notifications
+// of the client cert role table, so that its cached wildcard and regex rules
+// are reloaded as soon as an entry changes, until the returned function is
+// called.
+func StartCertRoleRulesWatcher(table string) (stop func()) {
+	client, err := getCertRoleDbClient()
+	if err != nil {
+		glog.Warningf("Failed to watch client cert role table %s: %v", table, err)
+		return func() {}
+	}
+	pattern := fmt.Sprintf("__keyspace@%d__:%s|*", client.Options().DB, table)
+	pubsub := client.PSubscribe(context.Background(), pattern)
+	invalidateCertRoleRules(table)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for msg := range pubsub.Channel() {
+			glog.V(2).Infof("Client cert role entry changed: %s %s", msg.Channel, msg.Payload)
+			invalidateCertRoleRules(table)
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			pubsub.Close()
+			wg.Wait()
+			client.Close()
+		})
+	}
+}
+
+// matchCertRoleRules returns the roles of the first wildcard or regex entry
+// of the role table matching identity, with the entry key. Returns no roles
+// if none matches.
+func matchCertRoleRules(identity string, table string) ([]string, string, error) {
+	rules, err := getCertRoleRules(table)
+	if err != nil {
+		return nil, "", err
+	}
+	for _, rule := range rules {
+		if rule.re.MatchString(identity) && len(rule.roles) > 0 {
+			return rule.roles, rule.key, nil
+		}
+	}
+	return nil, "", nil
+}
+
+// PopulateAuthStructByIdentity populates auth with the roles of the role table
+// entry matching identity. Exact entries take precedence over wildcard and
+// regex entries.
+func PopulateAuthStructByIdentity(identity string, auth *common_utils.AuthInfo, serviceConfigTableName string) error {
+	err := PopulateAuthStructByCommonName(identity, auth, serviceConfigTableName)
+	if err == nil {
+		auth.User = identity
+		return nil
+	}
+	if serviceConfigTableName == "" {
+		return err
+	}
+	roles, key, perr := matchCertRoleRules(identity, serviceConfigTableName)
+	if perr != nil {
+		glog.Warningf("Failed to match client cert role rules; %v", perr)
+		return err
+	}
+	if len(roles) == 0 {
+		return err
+	}
+	glog.V(2).Infof("Client cert identity '%s' matched role entry '%s'", identity, key)
+	auth.User = identity
+	auth.Roles = roles
+	return nil
+}
+
+// Returns ctx with a peer whose client certificate only carries the identity
+// selected by the identity sources, so that authz principals are matched
+// against the same identity as authentication and pathz. Email identities are
+// presented as mailto URIs.
+func certIdentityPeerContext(ctx context.Context) context.Context {
+	sources := GetCertIdentitySources()
+	if len(sources) == 0 {
+		return ctx
+	}
+	pr, ok := peer.FromContext(ctx)
+	if !ok {
+		return ctx
+	}
+	tlsInfo, ok := pr.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.PeerCertificates) == 0 {
+		return ctx
+	}
+
+	leaf := *tlsInfo.State.PeerCertificates[0]
+	leaf.Subject = pkix.Name{}
+	leaf.DNSNames = nil
+	leaf.URIs = nil
+	leaf.EmailAddresses = nil
+	if cert := verifiedLeaf(tlsInfo.State); cert != nil {
+		identity, source := certIdentity(cert, sources)
+		switch source {
+		case CertIdentityCN:
+			leaf.Subject = cert.Subject
+		case CertIdentityDNS:
+			leaf.DNSNames = []string{identity}
+		case CertIdentityURI, CertIdentitySPIFFE:
+			if uri, err := url.Parse(identity); err == nil {
+				leaf.URIs = []*url.URL{uri}
+			}
+		case CertIdentityEmail:
+			leaf.EmailAddresses = []string{identity}
+			leaf.URIs = []*url.URL{{Scheme: "mailto", Opaque: identity}}
+		}
+	}
+
+	certs := append([]*x509.Certificate{&leaf}, tlsInfo.State.PeerCertificates[1:]...)
+	tlsInfo.State.PeerCertificates = certs
+	p := *pr
+	p.AuthInfo = tlsInfo
+	return peer.NewContext(ctx, &p)
+}
+
+// certIdentityUnaryInterceptor runs the authz interceptor next against the
+// configured client cert identity, and the handler with the original context.
+func certIdentityUnaryInterceptor(next grpc.UnaryServerInterceptor) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return next(certIdentityPeerContext(ctx), req, info, func(_ context.Context, req interface{}) (interface{}, error) {
+			return handler(ctx, req)
+		})
+	}
+}
+
+type certIdentityStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *certIdentityStream) Context() context.Context {
+	return s.ctx
+}
+
+// certIdentityStreamInterceptor is the stream variant of
+// certIdentityUnaryInterceptor.
+func certIdentityStreamInterceptor(next grpc.StreamServerInterceptor) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		stream := &certIdentityStream{ServerStream: ss, ctx: certIdentityPeerContext(ss.Context())}
+		return next(srv, stream, info, func(srv interface{}, _ grpc.ServerStream) error {
+			return handler(srv, ss)
+		})
+	}
+}
@@ -0,0 +1,286 @@
+package gnmi
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func identityCert() *x509.Certificate {
+	spiffe, _ := url.Parse("spiffe://example.org/ns/prod/sa/alice")
+	web, _ := url.Parse("https://example.org/alice")
+	return &x509.Certificate{
+		Subject:        pkix.Name{CommonName: "alice-cn", Organization: []string{"Example"}},
+		DNSNames:       []string{"alice.example.org", "bob.example.org"},
+		URIs:           []*url.URL{web, spiffe},
+		EmailAddresses: []string{"alice@example.org"},
+	}
+}
+
+func identityPeerCtx(cert *x509.Certificate) context.Context {
+	state := tls.ConnectionState{}
+	if cert != nil {
+		state.PeerCertificates = []*x509.Certificate{cert}
+		state.VerifiedChains = [][]*x509.Certificate{{cert}}
+	}
+	return peer.NewContext(context.Background(), &peer.Peer{AuthInfo: credentials.TLSInfo{State: state}})
+}
+
+func useCertIdentitySources(t *testing.T, sources ...string) {
+	original := GetCertIdentitySources()
+	t.Cleanup(func() { SetCertIdentitySources(original) })
+	SetCertIdentitySources(sources)
+}
+
+func TestParseCertIdentitySources(t *testing.T) {
+	sources, err := ParseCertIdentitySources(" SPIFFE,dns,, email ")
+	if err != nil || !reflect.DeepEqual(sources, []string{"spiffe", "dns", "email"}) {
+		t.Errorf("Unexpected sources %v, err %v", sources, err)
+	}
+	if sources, err := ParseCertIdentitySources(""); err != nil || len(sources) != 0 {
+		t.Errorf("Expected no sources, got %v, err %v", sources, err)
+	}
+	if _, err := ParseCertIdentitySources("cn,serial"); err == nil {
+		t.Errorf("Expected an error for an unknown source")
+	}
+}
+
+func TestCertIdentity(t *testing.T) {
+	cert := identityCert()
+	testCases := []struct {
+		sources  []string
+		identity string
+		source   string
+	}{
+		{sources: []string{"cn"}, identity: "alice-cn", source: "cn"},
+		{sources: []string{"dns"}, identity: "alice.example.org", source: "dns"},
+		{sources: []string{"uri"}, identity: "https://example.org/alice", source: "uri"},
+		{sources: []string{"spiffe", "cn"}, identity: "spiffe://example.org/ns/prod/sa/alice", source: "spiffe"},
+		{sources: []string{"email"}, identity: "alice@example.org", source: "email"},
+	}
+	for _, tc := range testCases {
+		identity, source := certIdentity(cert, tc.sources)
+		if identity != tc.identity || source != tc.source {
+			t.Errorf("Sources %v: expected %s from %s, got %s from %s", tc.sources, tc.identity, tc.source, identity, source)
+		}
+	}
+
+	// Empty CN, as issued with SPIFFE IDs, falls back to the next source
+	cert.Subject.CommonName = ""
+	if identity, _ := certIdentity(cert, []string{"cn", "spiffe"}); identity != "spiffe://example.org/ns/prod/sa/alice" {
+		t.Errorf("Expected the SPIFFE ID, got %q", identity)
+	}
+	cert.URIs = nil
+	if identity, source := certIdentity(cert, []string{"cn", "spiffe"}); identity != "" || source != "" {
+		t.Errorf("Expected no identity, got %q from %q", identity, source)
+	}
+}
+
+func TestMatchCertRoleRules(t *testing.T) {
+	mr := miniredis.RunT(t)
+	addr := mr.Addr()
+	useCertRoleMiniredis(t, addr)
+
+	mr.HSet("GNMI_CLIENT_CERT|alice.example.org", "role@", "gnmi_readonly")
+	mr.HSet("GNMI_CLIENT_CERT|*.example.org", "match", "wildcard", "role@", "gnmi_readwrite,gnoi_readonly")
+	mr.HSet("GNMI_CLIENT_CERT|host-?.lab", "match", "wildcard", "role", "gnmi_readonly")
+	mr.HSet("GNMI_CLIENT_CERT|spiffe://example\\.org/ns/(prod|staging)/sa/[a-z]+", "match", "regex", "role@", "gnmi_readwrite")
+	mr.HSet("GNMI_CLIENT_CERT|[invalid", "match", "regex", "role@", "gnmi_readwrite")
+	mr.HSet("GNMI_CLIENT_CERT|*", "match", "glob", "role@", "gnmi_readwrite")
+	mr.HSet("GNMI_CLIENT_CERT|spiffe://*", "match", "wildcard", "role@", "gnmi_noaccess")
+	mr.HSet("OTHER_TABLE|*", "match", "wildcard", "role@", "gnmi_readwrite")
+
+	testCases := []struct {
+		identity string
+		roles    []string
+		key      string
+	}{
+		// Exact entries are not rules, they are looked up directly
+		{identity: "alice.example.org", roles: []string{"gnmi_readwrite", "gnoi_readonly"}, key: "*.example.org"},
+		{identity: "host-1.lab", roles: []string{"gnmi_readonly"}, key: "host-?.lab"},
+		{identity: "host-10.lab"},
+		{identity: "example.org"},
+		// Wildcard entries come before regex entries
+		{identity: "spiffe://example.org/ns/prod/sa/alice", roles: []string{"gnmi_noaccess"}, key: "spiffe://*"},
+	}
+	for _, tc := range testCases {
+		roles, key, err := matchCertRoleRules(tc.identity, "GNMI_CLIENT_CERT")
+		if err != nil {
+			t.Fatalf("Expected success for %s, got error: %v", tc.identity, err)
+		}
+		if !reflect.DeepEqual(roles, tc.roles) || key != tc.key {
+			t.Errorf("%s: expected roles %v of %q, got %v of %q", tc.identity, tc.roles, tc.key, roles, key)
+		}
+	}
+
+	mr.Del("GNMI_CLIENT_CERT|spiffe://*")
+	invalidateCertRoleRules("GNMI_CLIENT_CERT")
+	roles, key, err := matchCertRoleRules("spiffe://example.org/ns/staging/sa/bob", "GNMI_CLIENT_CERT")
+	if err != nil || !reflect.DeepEqual(roles, []string{"gnmi_readwrite"}) || key != "spiffe://example\\.org/ns/(prod|staging)/sa/[a-z]+" {
+		t.Errorf("Expected the regex entry to match, got %v of %q, err %v", roles, key, err)
+	}
+	if roles, _, _ := matchCertRoleRules("spiffe://example.org/ns/dev/sa/bob", "GNMI_CLIENT_CERT"); roles != nil {
+		t.Errorf("Expected no match, got %v", roles)
+	}
+
+	mr.Close()
+	invalidateCertRoleRules("GNMI_CLIENT_CERT")
+	if _, _, err := matchCertRoleRules("alice.example.org", "GNMI_CLIENT_CERT"); err == nil {
+		t.Errorf("Expected an error when CONFIG_DB is unavailable")
+	}
+}
+
+// useCertRoleMiniredis serves CONFIG_DB from a miniredis address for the
+// duration of a test, with no cached role rules.
+func useCertRoleMiniredis(t *testing.T, addr string) {
+	original := getCertRoleDbClient
+	t.Cleanup(func() {
+		getCertRoleDbClient = original
+		certRoleRulesMu.Lock()
+		certRoleRulesCache = map[string]*cachedCertRoleRules{}
+		certRoleRulesMu.Unlock()
+	})
+	getCertRoleDbClient = func() (*redis.Client, error) {
+		return redis.NewClient(&redis.Options{Addr: addr, MaxRetries: -1}), nil
+	}
+	certRoleRulesMu.Lock()
+	certRoleRulesCache = map[string]*cachedCertRoleRules{}
+	certRoleRulesMu.Unlock()
+}
+
+func TestCertRoleRulesCache(t *testing.T) {
+	mr := miniredis.RunT(t)
+	useCertRoleMiniredis(t, mr.Addr())
+	mr.HSet("GNMI_CLIENT_CERT|*.example.org", "match", "wildcard", "role@", "gnmi_readonly")
+
+	stop := StartCertRoleRulesWatcher("GNMI_CLIENT_CERT")
+	defer stop()
+
+	if roles, _, err := matchCertRoleRules("alice.example.org", "GNMI_CLIENT_CERT"); err != nil || !reflect.DeepEqual(roles, []string{"gnmi_readonly"}) {
+		t.Fatalf("Expected gnmi_readonly, got %v, err %v", roles, err)
+	}
+
+	// The rules are cached
+	mr.HSet("GNMI_CLIENT_CERT|*.example.org", "role@", "gnmi_readwrite")
+	if roles, _, _ := matchCertRoleRules("alice.example.org", "GNMI_CLIENT_CERT"); !reflect.DeepEqual(roles, []string{"gnmi_readonly"}) {
+		t.Errorf("Expected cached gnmi_readonly, got %v", roles)
+	}
+
+	// and reloaded on keyspace notifications
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mr.Publish("__keyspace@0__:GNMI_CLIENT_CERT|*.example.org", "hset")
+		roles, _, _ := matchCertRoleRules("alice.example.org", "GNMI_CLIENT_CERT")
+		if reflect.DeepEqual(roles, []string{"gnmi_readwrite"}) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected gnmi_readwrite after notification, got %v", roles)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// or once they expire
+	saved := certRoleRulesRefreshInterval
+	certRoleRulesRefreshInterval = 0
+	defer func() { certRoleRulesRefreshInterval = saved }()
+	mr.Del("GNMI_CLIENT_CERT|*.example.org")
+	if roles, _, _ := matchCertRoleRules("alice.example.org", "GNMI_CLIENT_CERT"); roles != nil {
+		t.Errorf("Expected no roles after refresh, got %v", roles)
+	}
+}
+
+func TestGetUsernameCertIdentity(t *testing.T) {
+	spiffeOnly := &x509.Certificate{URIs: identityCert().URIs[1:]}
+	ctx := peer.NewContext(context.Background(), &peer.Peer{AuthInfo: credentials.TLSInfo{
+		State:    tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{spiffeOnly}}},
+		SPIFFEID: spiffeOnly.URIs[0],
+	}})
+
+	// Without identity sources the user is the last element of the SPIFFE ID
+	if user, err := getUsername(ctx); err != nil || user != "alice" {
+		t.Errorf("Expected alice, got %q, err %v", user, err)
+	}
+
+	useCertIdentitySources(t, "cn", "spiffe")
+	if user, err := getUsername(ctx); err != nil || user != "spiffe://example.org/ns/prod/sa/alice" {
+		t.Errorf("Expected the SPIFFE ID, got %q, err %v", user, err)
+	}
+	if user, err := getUsername(identityPeerCtx(identityCert())); err != nil || user != "alice-cn" {
+		t.Errorf("Expected alice-cn, got %q, err %v", user, err)
+	}
+
+	useCertIdentitySources(t, "email")
+	if _, err := getUsername(ctx); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected Unauthenticated without an email SAN, got %v", err)
+	}
+	if _, err := getUsername(identityPeerCtx(nil)); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected Unauthenticated without a client certificate, got %v", err)
+	}
+}
+
+func peerLeaf(t *testing.T, ctx context.Context) *x509.Certificate {
+	t.Helper()
+	pr, ok := peer.FromContext(ctx)
+	if !ok {
+		t.Fatal("Expected a peer")
+	}
+	return pr.AuthInfo.(credentials.TLSInfo).State.PeerCertificates[0]
+}
+
+func TestCertIdentityPeerContext(t *testing.T) {
+	cert := identityCert()
+	ctx := identityPeerCtx(cert)
+	if certIdentityPeerContext(ctx) != ctx {
+		t.Errorf("Expected the context to be unchanged without identity sources")
+	}
+
+	useCertIdentitySources(t, "spiffe")
+	leaf := peerLeaf(t, certIdentityPeerContext(ctx))
+	if len(leaf.URIs) != 1 || leaf.URIs[0].String() != "spiffe://example.org/ns/prod/sa/alice" ||
+		len(leaf.DNSNames) != 0 || leaf.Subject.String() != "" {
+		t.Errorf("Expected only the SPIFFE ID, got %v %v %q", leaf.URIs, leaf.DNSNames, leaf.Subject.String())
+	}
+	if len(cert.URIs) != 2 || len(cert.DNSNames) != 2 || cert.Subject.CommonName != "alice-cn" {
+		t.Errorf("Expected the client certificate to be unchanged")
+	}
+
+	useCertIdentitySources(t, "cn")
+	leaf = peerLeaf(t, certIdentityPeerContext(ctx))
+	if len(leaf.URIs) != 0 || len(leaf.DNSNames) != 0 || leaf.Subject.String() != cert.Subject.String() {
+		t.Errorf("Expected only the subject, got %v %v %q", leaf.URIs, leaf.DNSNames, leaf.Subject.String())
+	}
+
+	useCertIdentitySources(t, "email")
+	leaf = peerLeaf(t, certIdentityPeerContext(ctx))
+	if len(leaf.URIs) != 1 || leaf.URIs[0].String() != "mailto:alice@example.org" {
+		t.Errorf("Expected the email as a mailto URI, got %v", leaf.URIs)
+	}
+
+	// The handler runs with the original context
+	var authzCtx, handlerCtx context.Context
+	authz := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		authzCtx = ctx
+		return handler(ctx, req)
+	}
+	_, err := certIdentityUnaryInterceptor(authz)(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		handlerCtx = ctx
+		return nil, nil
+	})
+	if err != nil || handlerCtx != ctx || len(peerLeaf(t, authzCtx).DNSNames) != 0 {
+		t.Errorf("Expected authz with the identity and the handler with the original context, err %v", err)
+	}
+}
@@ -0,0 +1,238 @@
+package gnmi
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/redis/go-redis/v9"
+)
+
+// STATE_DB key of the certificate currently served by the gNMI server.
+const servedCertKey = "TELEMETRY_CERT|server"
+
+// ServerCertReloader serves the server certificate and the client CAs for
+// every new TLS connection, so that rotated certificates apply to new
+// connections without restarting the server and dropping existing streams.
+type ServerCertReloader struct {
+	load func() (tls.Certificate, *x509.CertPool, error)
+
+	mu             sync.RWMutex
+	cert           *tls.Certificate
+	getCert        func(*tls.ClientHelloInfo) (*tls.Certificate, error)
+	clientCAs      *x509.CertPool
+	authPolicyFile string
+}
+
+var (
+	serverCertReloaderMu sync.Mutex
+	serverCertReloader   *ServerCertReloader
+
+	// Allow DI for testing
+	getServedCertDbClient = func() (*redis.Client, error) { return newDbClient("STATE_DB") }
+)
+
+// NewServerCertReloader returns a reloader loading the server certificate and
+// the client CAs with load. Reload must be called, or Set, before serving.
+func NewServerCertReloader(load func() (tls.Certificate, *x509.CertPool, error)) *ServerCertReloader {
+	return &ServerCertReloader{load: load}
+}
+
+// SetServerCertReloader registers the reloader of the running server, which
+// certz rotations reload. nil unregisters it.
+func SetServerCertReloader(r *ServerCertReloader) {
+	serverCertReloaderMu.Lock()
+	defer serverCertReloaderMu.Unlock()
+	serverCertReloader = r
+}
+
+// reloadServerCerts reloads the certificates of the running server, if any, in
+// the background. It is called with muPath held when certz swaps credentials.
+func reloadServerCerts() {
+	serverCertReloaderMu.Lock()
+	r := serverCertReloader
+	serverCertReloaderMu.Unlock()
+	if r == nil {
+		return
+	}
+	go func() {
+		// Wait for the rotation to release the credential files
+		muPath.RLock()
+		muPath.RUnlock()
+		if err := r.Reload(); err != nil {
+			glog.Errorf("Failed to reload server certificates, serving the previous ones: %v", err)
+		}
+	}()
+}
+
+// Set serves cert and verifies clients against clientCAs for new connections.
+func (r *ServerCertReloader) Set(cert tls.Certificate, clientCAs *x509.CertPool) {
+	getCert := func(*tls.ClientHelloInfo) (*tls.Certificate, error) { return &cert, nil }
+	if GetOcspEnabled() {
+		getCert = StapleOCSP(cert)
+	}
+	r.mu.Lock()
+	r.cert = &cert
+	r.getCert = getCert
+	r.clientCAs = clientCAs
+	r.mu.Unlock()
+
+	if err := publishServedCert(cert); err != nil {
+		glog.V(1).Infof("Failed to publish the served certificate: %v", err)
+	}
+}
+
+// Reload loads the certificate and the client CAs, and serves them if they are
+// valid. The previous ones are kept otherwise.
+func (r *ServerCertReloader) Reload() error {
+	cert, clientCAs, err := r.load()
+	if err != nil {
+		return err
+	}
+	r.Set(cert, clientCAs)
+	glog.V(1).Infof("Reloaded server certificates")
+	return nil
+}
+
+// SetAuthPolicyFile checks the clients of new connections against the certz
+// authentication policy in path. An empty path disables the check.
+func (r *ServerCertReloader) SetAuthPolicyFile(path string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.authPolicyFile = path
+}
+
+// GetCertificate returns the certificate to serve.
+func (r *ServerCertReloader) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	getCert := r.getCert
+	r.mu.RUnlock()
+	if getCert == nil {
+		return nil, fmt.Errorf("no server certificate loaded")
+	}
+	return getCert(hello)
+}
+
+// ClientCAs returns the CAs verifying client certificates.
+func (r *ServerCertReloader) ClientCAs() *x509.CertPool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.clientCAs
+}
+
+// GetConfigForClient returns a tls.Config GetConfigForClient callback serving
+// base with the current certificate and client CAs, and checking the clients
+// against the authentication policy.
+func (r *ServerCertReloader) GetConfigForClient(base *tls.Config) func(*tls.ClientHelloInfo) (*tls.Config, error) {
+	base = base.Clone()
+	base.GetConfigForClient = nil
+	base.Certificates = nil
+	base.GetCertificate = r.GetCertificate
+	verifyConnection := base.VerifyConnection
+	base.VerifyConnection = func(cs tls.ConnectionState) error {
+		if verifyConnection != nil {
+			if err := verifyConnection(cs); err != nil {
+				return err
+			}
+		}
+		r.mu.RLock()
+		path := r.authPolicyFile
+		r.mu.RUnlock()
+		return verifyAuthPolicy(path, cs.VerifiedChains)
+	}
+	// The returned config replaces the one of the gRPC credentials, which
+	// negotiate HTTP/2
+	hasH2 := false
+	for _, proto := range base.NextProtos {
+		hasH2 = hasH2 || proto == "h2"
+	}
+	if !hasH2 {
+		base.NextProtos = append(base.NextProtos, "h2")
+	}
+	return func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		cfg := base.Clone()
+		cfg.ClientCAs = r.ClientCAs()
+		return cfg, nil
+	}
+}
+
+// ClientConfig returns the TLS configuration of the connections the NPU opens
+// to its DPUs. The served certificate is presented as client certificate, and
+// DPUs are verified against the client CAs; their host name is not checked, as
+// they are addressed by a midplane IP their certificates need not name.
+func (r *ServerCertReloader) ClientConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+			if r.cert == nil {
+				return nil, fmt.Errorf("no server certificate loaded")
+			}
+			return r.cert, nil
+		},
+		// The chain is verified by VerifyConnection
+		InsecureSkipVerify: true,
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			if len(cs.PeerCertificates) == 0 {
+				return fmt.Errorf("DPU presented no certificate")
+			}
+			opts := x509.VerifyOptions{
+				Roots:         r.ClientCAs(),
+				Intermediates: x509.NewCertPool(),
+			}
+			for _, cert := range cs.PeerCertificates[1:] {
+				opts.Intermediates.AddCert(cert)
+			}
+			_, err := cs.PeerCertificates[0].Verify(opts)
+			return err
+		},
+	}
+}
+
+// servedCertFields returns the STATE_DB fields describing cert.
+func servedCertFields(cert tls.Certificate) (map[string]string, error) {
+	if len(cert.Certificate) == 0 {
+		return nil, fmt.Errorf("empty certificate")
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(leaf.Raw)
+	return map[string]string{
+		"fingerprint_sha256": hex.EncodeToString(sum[:]),
+		"subject":            leaf.Subject.String(),
+		"issuer":             leaf.Issuer.String(),
+		"serial":             leaf.SerialNumber.String(),
+		"not_before":         leaf.NotBefore.UTC().Format(time.RFC3339),
+		"not_after":          leaf.NotAfter.UTC().Format(time.RFC3339),
+		"loaded_on":          time.Now().UTC().Format(time.RFC3339),
+	}, nil
+}
+
+// publishServedCert records the served certificate in STATE_DB.
+func publishServedCert(cert tls.Certificate) error {
+	fields, err := servedCertFields(cert)
+	if err != nil {
+		return err
+	}
+	client, err := getServedCertDbClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	pipe := client.TxPipeline()
+	pipe.Del(ctx, servedCertKey)
+	pipe.HSet(ctx, servedCertKey, fields)
+	_, err = pipe.Exec(ctx)
+	return err
+}
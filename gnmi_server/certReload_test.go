@@ -0,0 +1,275 @@
+package gnmi
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func servingCert(t *testing.T, ca *testCA, serial int64) tls.Certificate {
+	t.Helper()
+	return ca.sign(t, &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "switch"},
+		DNSNames:     []string{"switch"},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+}
+
+func clientCert(t *testing.T, ca *testCA, cn string) tls.Certificate {
+	t.Helper()
+	return ca.sign(t, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: cn},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+}
+
+// noServedCertDb keeps the tests from publishing to STATE_DB.
+func noServedCertDb(t *testing.T) {
+	original := getServedCertDbClient
+	t.Cleanup(func() { getServedCertDbClient = original })
+	getServedCertDbClient = func() (*redis.Client, error) {
+		return nil, fmt.Errorf("STATE_DB is not available")
+	}
+}
+
+// handshake connects a client presenting cert to a server with serverCfg, and
+// returns the serial of the server certificate.
+func handshake(t *testing.T, serverCfg *tls.Config, roots *x509.CertPool, cert tls.Certificate) (int64, error) {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		serverConn, err := lis.Accept()
+		if err != nil {
+			serverErr <- err
+			return
+		}
+		defer serverConn.Close()
+		serverErr <- tls.Server(serverConn, serverCfg).Handshake()
+	}()
+	clientConn, err := net.Dial("tcp", lis.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer clientConn.Close()
+	client := tls.Client(clientConn, &tls.Config{
+		ServerName:   "switch",
+		RootCAs:      roots,
+		Certificates: []tls.Certificate{cert},
+		NextProtos:   []string{"h2"},
+	})
+	clientErr := client.Handshake()
+	if err := <-serverErr; err != nil {
+		return 0, err
+	}
+	if clientErr != nil {
+		return 0, clientErr
+	}
+	if proto := client.ConnectionState().NegotiatedProtocol; proto != "h2" {
+		return 0, fmt.Errorf("negotiated %q", proto)
+	}
+	return client.ConnectionState().PeerCertificates[0].SerialNumber.Int64(), nil
+}
+
+func TestServerCertReloader(t *testing.T) {
+	noServedCertDb(t)
+	oldCA := newTestCA(t, "old-ca")
+	newCA := newTestCA(t, "new-ca")
+	roots := x509.NewCertPool()
+	roots.AddCert(oldCA.cert)
+	roots.AddCert(newCA.cert)
+	oldClients := x509.NewCertPool()
+	oldClients.AddCert(oldCA.cert)
+	newClients := x509.NewCertPool()
+	newClients.AddCert(newCA.cert)
+
+	var fail atomic.Bool
+	next := servingCert(t, newCA, 20)
+	r := NewServerCertReloader(func() (tls.Certificate, *x509.CertPool, error) {
+		if fail.Load() {
+			return tls.Certificate{}, nil, fmt.Errorf("could not load server key pair")
+		}
+		return next, newClients, nil
+	})
+	if _, err := r.GetCertificate(nil); err == nil {
+		t.Errorf("Expected an error before loading a certificate")
+	}
+	r.Set(servingCert(t, oldCA, 10), oldClients)
+
+	serverCfg := &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, MinVersion: tls.VersionTLS12}
+	serverCfg.GetConfigForClient = r.GetConfigForClient(serverCfg)
+	if serial, err := handshake(t, serverCfg, roots, clientCert(t, oldCA, "client")); err != nil || serial != 10 {
+		t.Errorf("Expected the first certificate, got %d, err %v", serial, err)
+	}
+	if _, err := handshake(t, serverCfg, roots, clientCert(t, newCA, "client")); err == nil {
+		t.Errorf("Expected a client of the new CA to be rejected before the rotation")
+	}
+
+	if err := r.Reload(); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if serial, err := handshake(t, serverCfg, roots, clientCert(t, newCA, "client")); err != nil || serial != 20 {
+		t.Errorf("Expected the rotated certificate, got %d, err %v", serial, err)
+	}
+	if _, err := handshake(t, serverCfg, roots, clientCert(t, oldCA, "client")); err == nil {
+		t.Errorf("Expected a client of the old CA to be rejected after the rotation")
+	}
+
+	// A failed reload keeps serving the loaded certificate
+	fail.Store(true)
+	if err := r.Reload(); err == nil {
+		t.Errorf("Expected the reload to fail")
+	}
+	if serial, err := handshake(t, serverCfg, roots, clientCert(t, newCA, "client")); err != nil || serial != 20 {
+		t.Errorf("Expected the rotated certificate, got %d, err %v", serial, err)
+	}
+}
+
+func TestServerCertReloaderAuthPolicy(t *testing.T) {
+	noServedCertDb(t)
+	oldCA := newTestCA(t, "old-ca")
+	newCA := newTestCA(t, "new-ca")
+	roots := x509.NewCertPool()
+	roots.AddCert(oldCA.cert)
+	roots.AddCert(newCA.cert)
+
+	r := NewServerCertReloader(nil)
+	r.Set(servingCert(t, oldCA, 10), roots)
+	serverCfg := &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, MinVersion: tls.VersionTLS12}
+	serverCfg.GetConfigForClient = r.GetConfigForClient(serverCfg)
+
+	// The new CA may only authenticate the lab clients
+	path := filepath.Join(t.TempDir(), "auth_policy.json")
+	policy := `{"policies": [{"ca_subject": "CN=new-ca,O=Example", "identities": ["*.lab.example.org"]}]}`
+	if err := os.WriteFile(path, []byte(policy), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := handshake(t, serverCfg, roots, clientCert(t, newCA, "host.prod.example.org")); err != nil {
+		t.Errorf("Expected no restriction without a policy, got %v", err)
+	}
+	r.SetAuthPolicyFile(path)
+
+	testCases := []struct {
+		ca      *testCA
+		cn      string
+		allowed bool
+	}{
+		{ca: oldCA, cn: "host.prod.example.org", allowed: true},
+		{ca: newCA, cn: "host.lab.example.org", allowed: true},
+		{ca: newCA, cn: "host.prod.example.org"},
+	}
+	for _, tc := range testCases {
+		_, err := handshake(t, serverCfg, roots, clientCert(t, tc.ca, tc.cn))
+		if (err == nil) != tc.allowed {
+			t.Errorf("%s from %s: expected allowed %v, got %v", tc.cn, tc.ca.cert.Subject, tc.allowed, err)
+		}
+	}
+
+	// An unreadable policy rejects the clients
+	if err := os.WriteFile(path, []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := handshake(t, serverCfg, roots, clientCert(t, oldCA, "host.prod.example.org")); err == nil {
+		t.Errorf("Expected the client to be rejected with an invalid policy")
+	}
+}
+
+func TestReloadServerCerts(t *testing.T) {
+	noServedCertDb(t)
+	ca := newTestCA(t, "ca")
+	var loads atomic.Int32
+	r := NewServerCertReloader(func() (tls.Certificate, *x509.CertPool, error) {
+		loads.Add(1)
+		return servingCert(t, ca, 1), nil, nil
+	})
+
+	// Nothing to reload without a running server
+	reloadServerCerts()
+
+	reloaded := make(chan struct{})
+	getServedCertDbClient = func() (*redis.Client, error) {
+		close(reloaded)
+		return nil, fmt.Errorf("STATE_DB is not available")
+	}
+	SetServerCertReloader(r)
+	defer SetServerCertReloader(nil)
+	muPath.Lock()
+	reloadServerCerts()
+	time.Sleep(50 * time.Millisecond)
+	if loads.Load() != 0 {
+		t.Errorf("Expected the reload to wait for the certz rotation")
+	}
+	muPath.Unlock()
+	select {
+	case <-reloaded:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the certificates to be reloaded")
+	}
+	if loads.Load() != 1 {
+		t.Errorf("Expected the certificates to be reloaded once, got %d", loads.Load())
+	}
+	if cert, err := r.GetCertificate(nil); err != nil || cert == nil {
+		t.Errorf("Expected the reloaded certificate, err %v", err)
+	}
+}
+
+func TestPublishServedCert(t *testing.T) {
+	mr := miniredis.RunT(t)
+	addr := mr.Addr()
+	original := getServedCertDbClient
+	t.Cleanup(func() { getServedCertDbClient = original })
+	getServedCertDbClient = func() (*redis.Client, error) {
+		return redis.NewClient(&redis.Options{Addr: addr, MaxRetries: -1}), nil
+	}
+
+	cert := servingCert(t, newTestCA(t, "ca"), 42)
+	mr.HSet(servedCertKey, "stale", "true")
+	r := NewServerCertReloader(nil)
+	r.Set(cert, nil)
+
+	leaf, _ := x509.ParseCertificate(cert.Certificate[0])
+	sum := sha256.Sum256(leaf.Raw)
+	expected := map[string]string{
+		"fingerprint_sha256": hex.EncodeToString(sum[:]),
+		"subject":            "CN=switch",
+		"issuer":             "CN=ca,O=Example",
+		"serial":             "42",
+		"not_after":          leaf.NotAfter.UTC().Format(time.RFC3339),
+	}
+	for field, value := range expected {
+		if got := mr.HGet(servedCertKey, field); got != value {
+			t.Errorf("Expected %s %q, got %q", field, value, got)
+		}
+	}
+	if mr.HGet(servedCertKey, "stale") != "" {
+		t.Errorf("Expected the previous record to be replaced")
+	}
+
+	if err := publishServedCert(tls.Certificate{}); err == nil {
+		t.Errorf("Expected an error for an empty certificate")
+	}
+	mr.Close()
+	if err := publishServedCert(cert); err == nil {
+		t.Errorf("Expected an error when STATE_DB is unavailable")
+	}
+}
@@ -3,9 +3,12 @@ package gnmi
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/pem"
+	"fmt"
 	"io"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"context"
@@ -22,36 +25,66 @@ import (
 const DEFAULT_CRL_EXPIRE_DURATION time.Duration = 24 * 60 * 60 * time.Second
 
 type Crl struct {
-	thisUpdate time.Time
-	nextUpdate time.Time
+	thisUpdate time.Time // when the CRL was cached, or its ThisUpdate for bundle CRLs
+	nextUpdate time.Time // next update of the CRL, thisUpdate if it has none
 	crl        []byte
+	issuer     string // issuer RDN sequence of the CRL, empty if it does not parse
+	bundle     bool   // loaded from the certz CRL bundle rather than downloaded
 }
 
-// CRL content cache
+// CRL content cache, by distribution point URL or bundle file path
 var CrlCache map[string]*Crl = nil
 
+// crlMu guards CrlCache, which is shared with the revocation refresher
+var crlMu sync.RWMutex
+
 // CRL content cache
 var CrlDxpireDuration time.Duration = DEFAULT_CRL_EXPIRE_DURATION
 
 func InitCrlCache() {
+	crlMu.Lock()
+	defer crlMu.Unlock()
 	if CrlCache == nil {
 		CrlCache = make(map[string]*Crl)
 	}
 }
 
 func ReleaseCrlCache() {
+	crlMu.Lock()
+	defer crlMu.Unlock()
 	for mapkey, _ := range CrlCache {
 		delete(CrlCache, mapkey)
 	}
 }
 
 func AppendCrlToCache(url string, rawCRL []byte) {
+	crl := newCrl(rawCRL)
+
+	crlMu.Lock()
+	defer crlMu.Unlock()
+	CrlCache[url] = crl
+}
+
+// Creates a cache entry for rawCRL, in PEM or DER, fetched now.
+func newCrl(rawCRL []byte) *Crl {
 	crl := new(Crl)
 	crl.thisUpdate = time.Now()
-	crl.nextUpdate = time.Now()
+	crl.nextUpdate = crl.thisUpdate
 	crl.crl = rawCRL
+	if list, err := parseCrl(rawCRL); err == nil {
+		crl.issuer = list.Issuer.ToRDNSequence().String()
+		if list.NextUpdate.After(crl.thisUpdate) {
+			crl.nextUpdate = list.NextUpdate
+		}
+	}
+	return crl
+}
 
-	CrlCache[url] = crl
+func parseCrl(rawCRL []byte) (*x509.RevocationList, error) {
+	if block, _ := pem.Decode(rawCRL); block != nil {
+		rawCRL = block.Bytes
+	}
+	return x509.ParseRevocationList(rawCRL)
 }
 
 func GetCrlExpireDuration() time.Duration {
@@ -63,9 +96,14 @@ func SetCrlExpireDuration(duration time.Duration) {
 }
 
 func CrlExpired(crl *Crl) bool {
+	if crl.bundle {
+		// Bundle CRLs are replaced on rotation, and undetermined once past
+		// their next update
+		return false
+	}
 	now := time.Now()
 	expireTime := crl.thisUpdate.Add(GetCrlExpireDuration())
-	glog.Infof("CrlExpired expireTime: %s, now: %s", expireTime.Format(time.ANSIC), now.Format(time.ANSIC))
+	glog.V(2).Infof("CrlExpired expireTime: %s, now: %s", expireTime.Format(time.ANSIC), now.Format(time.ANSIC))
 	// CRL expiresion policy follow the policy of Get-CRLFreshness command in following doc:
 	// 		https://learn.microsoft.com/en-us/archive/blogs/russellt/get-crlfreshness
 	// The policy are:
@@ -76,11 +114,13 @@ func CrlExpired(crl *Crl) bool {
 
 func CrlNeedUpdate(crl *Crl) bool {
 	now := time.Now()
-	glog.Infof("CrlNeedUpdate nextUpdate: %s, now: %s", crl.nextUpdate.Format(time.ANSIC), now.Format(time.ANSIC))
+	glog.V(2).Infof("CrlNeedUpdate nextUpdate: %s, now: %s", crl.nextUpdate.Format(time.ANSIC), now.Format(time.ANSIC))
 	return now.After(crl.nextUpdate)
 }
 
 func RemoveExpiredCrl() {
+	crlMu.Lock()
+	defer crlMu.Unlock()
 	for mapkey, crl := range CrlCache {
 		if CrlExpired(crl) {
 			glog.Infof("RemoveExpiredCrl key: %s", mapkey)
@@ -90,6 +130,8 @@ func RemoveExpiredCrl() {
 }
 
 func SearchCrlCache(url string) (bool, *Crl) {
+	crlMu.Lock()
+	defer crlMu.Unlock()
 	crl, exist := CrlCache[url]
 	if !exist {
 		glog.Infof("SearchCrlCache not found cache for url: %s", url)
@@ -102,13 +144,14 @@ func SearchCrlCache(url string) (bool, *Crl) {
 		return false, nil
 	}
 
-	if CrlNeedUpdate(crl) {
+	// Keep using the cached CRL while it is downloaded again in the
+	// background, rather than blocking the connection on the CRL server
+	if CrlNeedUpdate(crl) && time.Since(crl.thisUpdate) >= revocationRefreshInterval {
 		glog.Infof("SearchCrlCache crl need update: %s", url)
-		delete(CrlCache, url)
-		return false, nil
+		refreshCrl(url)
 	}
 
-	glog.Infof("SearchCrlCache found cache for url: %s", url)
+	glog.V(2).Infof("SearchCrlCache found cache for url: %s", url)
 	return true, crl
 }
 
@@ -122,20 +165,23 @@ func ClientCertAuthenAndAuthor(ctx context.Context, serviceConfigTableName strin
 	if !ok {
 		return ctx, status.Error(codes.Unauthenticated, "unexpected peer transport credentials")
 	}
-	if len(tlsAuth.State.VerifiedChains) == 0 || len(tlsAuth.State.VerifiedChains[0]) == 0 {
+	cert := verifiedLeaf(tlsAuth.State)
+	if cert == nil {
 		return ctx, status.Error(codes.Unauthenticated, "could not verify peer certificate")
 	}
 
-	var username string
-
-	username = tlsAuth.State.VerifiedChains[0][0].Subject.CommonName
+	sources := GetCertIdentitySources()
+	if len(sources) == 0 {
+		sources = []string{CertIdentityCN}
+	}
+	username, _ := certIdentity(cert, sources)
 
 	if len(username) == 0 {
-		return ctx, status.Error(codes.Unauthenticated, "invalid username in certificate common name.")
+		return ctx, status.Errorf(codes.Unauthenticated, "no %s identity in client certificate", strings.Join(sources, ", "))
 	}
 
 	if serviceConfigTableName != "" {
-		if err := PopulateAuthStructByCommonName(username, &rc.Auth, serviceConfigTableName); err != nil {
+		if err := PopulateAuthStructByIdentity(username, &rc.Auth, serviceConfigTableName); err != nil {
 			return ctx, err
 		}
 	} else {
@@ -153,27 +199,44 @@ func ClientCertAuthenAndAuthor(ctx context.Context, serviceConfigTableName strin
 		}
 	}
 
+	if GetOcspEnabled() {
+		err := VerifyCertOcsp(tlsAuth.State)
+		if err != nil {
+			glog.Infof("[%s] Failed to verify cert with OCSP; %v", rc.ID, err)
+			return ctx, err
+		}
+	}
+
 	return ctx, nil
 }
 
 func TryDownload(url string) bool {
 	glog.Infof("Download CRL start: %s", url)
-	resp, err := http.Get(url)
+	common_utils.IncCounter(common_utils.CRL_DOWNLOAD)
+	resp, err := revocationHTTPClient.Get(url)
 
 	if resp != nil {
 		defer resp.Body.Close()
 	}
 
-	if err != nil || resp.StatusCode != http.StatusOK {
+	if err == nil && resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("HTTP status %s", resp.Status)
+	}
+	if err != nil {
 		glog.Infof("Download CRL: %s failed: %v", url, err)
+		common_utils.IncCounter(common_utils.CRL_DOWNLOAD_FAIL)
+		setRevocationError(url, err)
 		return false
 	}
 
-	crlContent, err := io.ReadAll(resp.Body)
+	crlContent, err := io.ReadAll(io.LimitReader(resp.Body, maxRevocationResponseSize))
 	if err != nil {
 		glog.Infof("Download CRL: %s failed: %v", url, err)
+		common_utils.IncCounter(common_utils.CRL_DOWNLOAD_FAIL)
+		setRevocationError(url, err)
 		return false
 	}
+	setRevocationError(url, nil)
 
 	glog.Infof("Download CRL: %s successed", url)
 	AppendCrlToCache(url, crlContent)
@@ -188,15 +251,30 @@ func GetCrlUrls(cert x509.Certificate) []string {
 
 func DownloadNotCachedCrl(crlUrlArray []string) bool {
 	crlAvaliable := false
+	var pending []string
 	for _, crlUrl := range crlUrlArray {
 		exist, _ := SearchCrlCache(crlUrl)
 		if exist {
 			crlAvaliable = true
 		} else {
-			downloaded := TryDownload(crlUrl)
-			if downloaded {
-				crlAvaliable = true
-			}
+			pending = append(pending, crlUrl)
+		}
+	}
+	if crlAvaliable {
+		return true
+	}
+
+	// Download all distribution points at once, waiting a bounded time for
+	// them. Downloads still running complete in the background for the
+	// next connections.
+	var dones []<-chan struct{}
+	for _, crlUrl := range pending {
+		dones = append(dones, refreshCrl(crlUrl))
+	}
+	waitFetches(dones)
+	for _, crlUrl := range pending {
+		if exist, _ := SearchCrlCache(crlUrl); exist {
+			crlAvaliable = true
 		}
 	}
 
@@ -204,13 +282,15 @@ func DownloadNotCachedCrl(crlUrlArray []string) bool {
 }
 
 func CreateStaticCRLProvider() *advancedtls.StaticCRLProvider {
+	crlMu.Lock()
+	defer crlMu.Unlock()
 	crlArray := make([][]byte, 1)
 	for mapkey, item := range CrlCache {
 		if CrlExpired(item) {
 			glog.Infof("CreateStaticCRLProvider remove expired crl: %s", mapkey)
 			delete(CrlCache, mapkey)
 		} else {
-			glog.Infof("CreateStaticCRLProvider add crl: %s content: %v", mapkey, item.crl)
+			glog.V(2).Infof("CreateStaticCRLProvider add crl: %s content: %v", mapkey, item.crl)
 			crlArray = append(crlArray, item.crl)
 		}
 	}
@@ -221,17 +301,23 @@ func CreateStaticCRLProvider() *advancedtls.StaticCRLProvider {
 func VerifyCertCrl(tlsConnState tls.ConnectionState) error {
 	InitCrlCache()
 	// Check if any CRL already exist in local
+	common_utils.IncCounter(common_utils.REVOCATION_CHECK)
 	crlUriArray := GetCrlUrls(*tlsConnState.VerifiedChains[0][0])
-	if len(crlUriArray) == 0 {
+	inBundle, bundleCurrent := crlBundleStatus(tlsConnState.VerifiedChains[0][0], time.Now())
+	if len(crlUriArray) == 0 && !inBundle {
 		glog.Infof("Cert does not contains and CRL distribution points")
 		return nil
 	}
+	if inBundle && !bundleCurrent {
+		glog.Infof("VerifyCertCrl CRL bundle of the issuer is past its next update")
+	}
 
-	crlAvaliable := DownloadNotCachedCrl(crlUriArray)
+	crlAvaliable := bundleCurrent || DownloadNotCachedCrl(crlUriArray)
 	if !crlAvaliable {
 		// Every certificate will contain multiple CRL distribution points.
 		// If all CRLs are not available, the certificate validation should be blocked.
 		glog.Infof("VerifyCertCrl can't download CRL and verify cert: %v", crlUriArray)
+		common_utils.IncCounter(common_utils.REVOCATION_UNDETERMINED)
 		return status.Errorf(codes.Unauthenticated, "Can't download CRL and verify cert")
 	}
 
@@ -244,6 +330,7 @@ func VerifyCertCrl(tlsConnState tls.ConnectionState) error {
 
 	if err != nil {
 		glog.Infof("VerifyCertCrl peer certificate revoked: %v", err.Error())
+		common_utils.IncCounter(common_utils.REVOCATION_REVOKED)
 		return status.Error(codes.Unauthenticated, "Peer certificate revoked")
 	}
 
@@ -178,8 +178,12 @@ func (c *Client) Run(stream gnmipb.GNMI_SubscribeServer, config *Config) (err er
 		authTarget = "gnmi_others"
 	} else if target == "SHOW" {
 		return grpc.Errorf(codes.Unimplemented, "SHOW does not support subscribe operations")
-	} else if (target == "EVENTS") && (mode == gnmipb.SubscriptionList_STREAM) {
-		dc, err = sdc.NewEventClient(paths, prefix, c.logLevel)
+	} else if target == "EVENTS" {
+		if mode == gnmipb.SubscriptionList_STREAM {
+			dc, err = sdc.NewEventClient(paths, prefix, c.logLevel)
+		} else {
+			dc, err = sdc.NewEventCacheClient(paths, prefix)
+		}
 		authTarget = "gnmi_events"
 	} else if targetDbName, ok, _, _ := sdc.IsTargetDb(target); ok {
 		dc, err = sdc.NewDbClient(paths, prefix)
@@ -0,0 +1,230 @@
+package gnmi
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/golang/glog"
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+	gnmi_extpb "github.com/openconfig/gnmi/proto/gnmi_ext"
+	"github.com/sonic-net/sonic-gnmi/pkg/commitconfirm"
+	sdc "github.com/sonic-net/sonic-gnmi/sonic_data_client"
+	ssc "github.com/sonic-net/sonic-gnmi/sonic_service_client"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// Commit confirmed
+//
+// A SetRequest carrying the gNMI Commit extension with a commit action applies
+// its changes on top of a checkpoint of CONFIG_DB. Unless a SetRequest with the
+// confirm action and the same commit ID arrives within the rollback duration,
+// the checkpoint is restored. The cancel action restores the checkpoint at
+// once and set_rollback_duration restarts the rollback timer. The state of the
+// pending commit is available at the OPERATIONAL path /sonic/system/commit/status.
+
+const (
+	// commitExtFieldNumber is the field number of commit in the Extension oneof
+	commitExtFieldNumber protowire.Number = 4
+)
+
+// commitAction is the action of a gNMI Commit extension.
+type commitAction int
+
+const (
+	commitActionCommit commitAction = iota + 1
+	commitActionConfirm
+	commitActionCancel
+	commitActionSetRollbackDuration
+)
+
+// commitExt is a decoded gNMI Commit extension.
+type commitExt struct {
+	id       string
+	action   commitAction
+	rollback time.Duration
+}
+
+// getCommitExt returns the Commit extension of a SetRequest, nil if there is
+// none. The gNMI protos the server is built with predate the Commit extension,
+// so it is decoded from the unknown fields of the extensions.
+func getCommitExt(extensions []*gnmi_extpb.Extension) (*commitExt, error) {
+	var commit *commitExt
+	for _, e := range extensions {
+		b := e.ProtoReflect().GetUnknown()
+		for len(b) > 0 {
+			num, typ, n := protowire.ConsumeTag(b)
+			if n < 0 {
+				return nil, protowire.ParseError(n)
+			}
+			b = b[n:]
+			if num == commitExtFieldNumber && typ == protowire.BytesType {
+				v, n := protowire.ConsumeBytes(b)
+				if n < 0 {
+					return nil, protowire.ParseError(n)
+				}
+				if commit != nil {
+					return nil, fmt.Errorf("multiple commit extensions")
+				}
+				c, err := parseCommitExt(v)
+				if err != nil {
+					return nil, err
+				}
+				commit = c
+				b = b[n:]
+				continue
+			}
+			n = protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return nil, protowire.ParseError(n)
+			}
+			b = b[n:]
+		}
+	}
+	return commit, nil
+}
+
+// parseCommitExt decodes a gnmi_ext.Commit message:
+//
+//	message Commit {
+//	  string id = 1;
+//	  oneof action {
+//	    CommitRequest commit = 2;                             // rollback_duration = 1
+//	    CommitConfirm confirm = 3;
+//	    CommitCancel cancel = 4;
+//	    CommitSetRollbackDuration set_rollback_duration = 5;  // rollback_duration = 1
+//	  }
+//	}
+func parseCommitExt(b []byte) (*commitExt, error) {
+	commit := &commitExt{}
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		b = b[n:]
+		if typ != protowire.BytesType {
+			n = protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return nil, protowire.ParseError(n)
+			}
+			b = b[n:]
+			continue
+		}
+		v, n := protowire.ConsumeBytes(b)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		switch num {
+		case 1:
+			commit.id = string(v)
+		case 2, 5:
+			rollback, err := parseRollbackDuration(v)
+			if err != nil {
+				return nil, err
+			}
+			commit.rollback = rollback
+			commit.action = commitActionCommit
+			if num == 5 {
+				commit.action = commitActionSetRollbackDuration
+			}
+		case 3:
+			commit.action = commitActionConfirm
+		case 4:
+			commit.action = commitActionCancel
+		}
+	}
+	if commit.action == 0 {
+		return nil, fmt.Errorf("commit extension has no action")
+	}
+	return commit, nil
+}
+
+// parseRollbackDuration decodes the rollback_duration of a CommitRequest or
+// CommitSetRollbackDuration message.
+func parseRollbackDuration(b []byte) (time.Duration, error) {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return 0, protowire.ParseError(n)
+		}
+		b = b[n:]
+		if num == 1 && typ == protowire.BytesType {
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return 0, protowire.ParseError(n)
+			}
+			d := &durationpb.Duration{}
+			if err := proto.Unmarshal(v, d); err != nil {
+				return 0, err
+			}
+			if err := d.CheckValid(); err != nil {
+				return 0, err
+			}
+			return d.AsDuration(), nil
+		}
+		n = protowire.ConsumeFieldValue(num, typ, b)
+		if n < 0 {
+			return 0, protowire.ParseError(n)
+		}
+		b = b[n:]
+	}
+	return 0, nil
+}
+
+var (
+	commitManagerOnce sync.Once
+	commitManager     *commitconfirm.Manager
+)
+
+// getCommitManager returns the commit-confirmed manager of the process. It
+// outlives the servers, which are rebuilt on restarts, so that a pending commit
+// can still be confirmed. The commit left pending by a previous process is
+// recovered when it is created.
+func getCommitManager() *commitconfirm.Manager {
+	commitManagerOnce.Do(func() {
+		commitManager = commitconfirm.NewManager(func() (commitconfirm.Service, error) {
+			return ssc.NewDbusClient()
+		}, sdc.CHECK_POINT_PATH)
+		if err := commitManager.Recover(); err != nil {
+			log.Errorf("Failed to recover the pending commit: %v", err)
+		}
+		commitconfirm.SetDefaultManager(commitManager)
+	})
+	return commitManager
+}
+
+// commitControl handles the confirm, cancel and set_rollback_duration actions,
+// which are sent in a SetRequest without changes.
+func (s *Server) commitControl(ctx context.Context, req *gnmipb.SetRequest, commit *commitExt) (*gnmipb.SetResponse, error) {
+	if len(req.GetDelete())+len(req.GetReplace())+len(req.GetUpdate()) != 0 {
+		return nil, status.Error(codes.InvalidArgument, "commit confirm, cancel and set_rollback_duration cannot carry changes")
+	}
+	if _, err := authenticate(s.config, ctx, "gnmi", true); err != nil {
+		return nil, err
+	}
+
+	var err error
+	switch commit.action {
+	case commitActionConfirm:
+		if err = s.commitConfirm.Confirm(commit.id); err == nil {
+			s.SaveStartupConfig()
+		}
+	case commitActionCancel:
+		err = s.commitConfirm.Cancel(commit.id)
+	case commitActionSetRollbackDuration:
+		err = s.commitConfirm.SetRollbackDuration(commit.id, commit.rollback)
+	}
+	if err != nil {
+		return nil, err
+	}
+	log.V(2).Infof("Commit %s action %d done", commit.id, commit.action)
+	return &gnmipb.SetResponse{Prefix: req.GetPrefix()}, nil
+}
@@ -0,0 +1,122 @@
+package gnmi
+
+import (
+	"testing"
+	"time"
+
+	gnmi_extpb "github.com/openconfig/gnmi/proto/gnmi_ext"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// commitExtension builds a gNMI Commit extension, as sent by a client built
+// with newer gnmi_ext protos. action is the field number of the action and
+// rollback its rollback_duration, if any.
+func commitExtension(t *testing.T, id string, action protowire.Number, rollback time.Duration) *gnmi_extpb.Extension {
+	var msg []byte
+	if rollback != 0 {
+		d, err := proto.Marshal(durationpb.New(rollback))
+		if err != nil {
+			t.Fatalf("Marshal failed: %v", err)
+		}
+		msg = protowire.AppendTag(msg, 1, protowire.BytesType)
+		msg = protowire.AppendBytes(msg, d)
+	}
+
+	var commit []byte
+	commit = protowire.AppendTag(commit, 1, protowire.BytesType)
+	commit = protowire.AppendString(commit, id)
+	commit = protowire.AppendTag(commit, action, protowire.BytesType)
+	commit = protowire.AppendBytes(commit, msg)
+
+	var b []byte
+	b = protowire.AppendTag(b, commitExtFieldNumber, protowire.BytesType)
+	b = protowire.AppendBytes(b, commit)
+
+	ext := &gnmi_extpb.Extension{}
+	if err := proto.Unmarshal(b, ext); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	return ext
+}
+
+func TestGetCommitExt(t *testing.T) {
+	tests := []struct {
+		name     string
+		action   protowire.Number
+		rollback time.Duration
+		want     commitExt
+	}{
+		{
+			name:     "commit",
+			action:   2,
+			rollback: 90 * time.Second,
+			want:     commitExt{id: "c1", action: commitActionCommit, rollback: 90 * time.Second},
+		},
+		{
+			name:   "commit with default rollback duration",
+			action: 2,
+			want:   commitExt{id: "c1", action: commitActionCommit},
+		},
+		{
+			name:   "confirm",
+			action: 3,
+			want:   commitExt{id: "c1", action: commitActionConfirm},
+		},
+		{
+			name:   "cancel",
+			action: 4,
+			want:   commitExt{id: "c1", action: commitActionCancel},
+		},
+		{
+			name:     "set rollback duration",
+			action:   5,
+			rollback: time.Hour,
+			want:     commitExt{id: "c1", action: commitActionSetRollbackDuration, rollback: time.Hour},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dryRun := &gnmi_extpb.Extension{Ext: &gnmi_extpb.Extension_RegisteredExt{
+				RegisteredExt: &gnmi_extpb.RegisteredExtension{Id: 702},
+			}}
+			got, err := getCommitExt([]*gnmi_extpb.Extension{dryRun, commitExtension(t, "c1", tt.action, tt.rollback)})
+			if err != nil {
+				t.Fatalf("getCommitExt failed: %v", err)
+			}
+			if got == nil || *got != tt.want {
+				t.Errorf("Expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestGetCommitExt_Errors(t *testing.T) {
+	got, err := getCommitExt(nil)
+	if err != nil || got != nil {
+		t.Errorf("Expected no commit extension, got %v, %v", got, err)
+	}
+
+	// A Commit message without action
+	var b []byte
+	b = protowire.AppendTag(b, commitExtFieldNumber, protowire.BytesType)
+	b = protowire.AppendBytes(b, protowire.AppendString(protowire.AppendTag(nil, 1, protowire.BytesType), "c1"))
+	ext := &gnmi_extpb.Extension{}
+	if err := proto.Unmarshal(b, ext); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if _, err := getCommitExt([]*gnmi_extpb.Extension{ext}); err == nil {
+		t.Errorf("Expected error for commit extension without action")
+	}
+
+	// More than one Commit extension
+	exts := []*gnmi_extpb.Extension{
+		commitExtension(t, "c1", 2, 0),
+		commitExtension(t, "c1", 3, 0),
+	}
+	if _, err := getCommitExt(exts); err == nil {
+		t.Errorf("Expected error for multiple commit extensions")
+	}
+}
@@ -17,6 +17,26 @@ const table = "TELEMETRY_CONNECTIONS"
 
 var rclient *redis.Client
 
+// newDbClient returns a client of the database dbName in the default namespace.
+func newDbClient(dbName string) (*redis.Client, error) {
+	ns, _ := sdcfg.GetDbDefaultNamespace()
+	addr, err := sdcfg.GetDbTcpAddr(dbName, ns)
+	if err != nil {
+		return nil, err
+	}
+	db, err := sdcfg.GetDbId(dbName, ns)
+	if err != nil {
+		return nil, err
+	}
+	return redis.NewClient(&redis.Options{
+		Network:     "tcp",
+		Addr:        addr,
+		Password:    "",
+		DB:          db,
+		DialTimeout: 0,
+	}), nil
+}
+
 type ConnectionManager struct {
 	connections map[string]struct{}
 	mu          sync.RWMutex
@@ -0,0 +1,64 @@
+package gnmi
+
+import (
+	"context"
+	"strings"
+
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// AuthorizeDPUForward authenticates the caller of a gNMI request the DPU proxy
+// forwards to a DPU, and checks that the caller has the role the NPU requires
+// for the same request: write access to gnmi or gnmi_<db> for Set, read access
+// otherwise.
+func (s *Server) AuthorizeDPUForward(ctx context.Context, method string, req interface{}) error {
+	target := "gnmi"
+	writeAccess := false
+	switch r := req.(type) {
+	case *gnmipb.CapabilityRequest:
+	case *gnmipb.GetRequest:
+		target = dpuAuthTarget(r.GetPrefix(), r.GetPath())
+	case *gnmipb.SetRequest:
+		unionReplace, err := getUnionReplace(r)
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "invalid union_replace: %v", err)
+		}
+		paths := append([]*gnmipb.Path{}, r.GetDelete()...)
+		for _, updates := range [][]*gnmipb.Update{r.GetReplace(), r.GetUpdate(), unionReplace} {
+			for _, update := range updates {
+				paths = append(paths, update.GetPath())
+			}
+		}
+		target = dpuAuthTarget(r.GetPrefix(), paths)
+		writeAccess = true
+	case *gnmipb.SubscribeRequest:
+		var paths []*gnmipb.Path
+		for _, sub := range r.GetSubscribe().GetSubscription() {
+			paths = append(paths, sub.GetPath())
+		}
+		target = dpuAuthTarget(r.GetSubscribe().GetPrefix(), paths)
+	default:
+		return status.Errorf(codes.PermissionDenied, "%s cannot be authorized for DPU routing", method)
+	}
+
+	_, err := authenticate(s.config, ctx, target, writeAccess)
+	return err
+}
+
+// dpuAuthTarget returns the authentication target of a request for a DPU,
+// gnmi_<db> when it addresses a database and gnmi otherwise. The database is
+// the prefix target, or the first path element for the sonic-db origin.
+func dpuAuthTarget(prefix *gnmipb.Path, paths []*gnmipb.Path) string {
+	db := strings.Split(prefix.GetTarget(), "/")[0]
+	if !strings.HasSuffix(db, "_DB") && len(paths) != 0 && IsNativeOrigin(paths[0].GetOrigin()) {
+		if elems := paths[0].GetElem(); len(elems) != 0 {
+			db = elems[0].GetName()
+		}
+	}
+	if strings.HasSuffix(db, "_DB") {
+		return "gnmi_" + db
+	}
+	return "gnmi"
+}
@@ -0,0 +1,75 @@
+package gnmi
+
+import (
+	"testing"
+
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/sonic-net/sonic-gnmi/swsscommon"
+)
+
+func TestDpuAuthTarget(t *testing.T) {
+	tests := []struct {
+		prefix *gnmipb.Path
+		paths  []*gnmipb.Path
+		target string
+	}{
+		{nil, nil, "gnmi"},
+		{&gnmipb.Path{Target: "CONFIG_DB"}, nil, "gnmi_CONFIG_DB"},
+		{&gnmipb.Path{Target: "APPL_DB/asic0"}, nil, "gnmi_APPL_DB"},
+		{&gnmipb.Path{Target: "OC-YANG"}, nil, "gnmi"},
+		{nil, []*gnmipb.Path{{Origin: "sonic-db", Elem: []*gnmipb.PathElem{{Name: "STATE_DB"}}}}, "gnmi_STATE_DB"},
+		{nil, []*gnmipb.Path{{Origin: "openconfig", Elem: []*gnmipb.PathElem{{Name: "interfaces"}}}}, "gnmi"},
+	}
+	for _, test := range tests {
+		if target := dpuAuthTarget(test.prefix, test.paths); target != test.target {
+			t.Errorf("dpuAuthTarget(%v, %v) = %s, expected %s", test.prefix, test.paths, target, test.target)
+		}
+	}
+}
+
+func TestAuthorizeDPUForward(t *testing.T) {
+	if !swsscommon.SonicDBConfigIsInit() {
+		swsscommon.SonicDBConfigInitialize()
+	}
+
+	var tableName = "GNMI_CLIENT_CERT"
+	var configDb = swsscommon.NewDBConnector("CONFIG_DB", uint(0), true)
+	var gnmiTable = swsscommon.NewTable(configDb, tableName)
+	defer swsscommon.DeleteTable(gnmiTable)
+	defer swsscommon.DeleteDBConnector(configDb)
+	configDb.Flushdb()
+	defer configDb.Flushdb()
+
+	s := &Server{config: &Config{ConfigTableName: tableName, UserAuth: AuthTypes{"password": false, "cert": true, "jwt": false}}}
+	ctx, cancel := CreateAuthorizationCtx()
+	defer cancel()
+
+	setReq := &gnmipb.SetRequest{
+		Update: []*gnmipb.Update{{Path: &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "interfaces"}}}}},
+	}
+	getReq := &gnmipb.GetRequest{Path: []*gnmipb.Path{{Elem: []*gnmipb.PathElem{{Name: "interfaces"}}}}}
+
+	// A read-only user may read from DPUs but not write to them
+	gnmiTable.Hset("certname1", "role@", "gnmi_readonly")
+	if err := s.AuthorizeDPUForward(ctx, "/gnmi.gNMI/Set", setReq); err == nil {
+		t.Errorf("Forwarded Set with readonly role should fail")
+	}
+	if err := s.AuthorizeDPUForward(ctx, "/gnmi.gNMI/Get", getReq); err != nil {
+		t.Errorf("Forwarded Get with readonly role should pass: %v", err)
+	}
+
+	gnmiTable.Hset("certname1", "role@", "gnmi_readwrite")
+	if err := s.AuthorizeDPUForward(ctx, "/gnmi.gNMI/Set", setReq); err != nil {
+		t.Errorf("Forwarded Set with readwrite role should pass: %v", err)
+	}
+
+	// Database writes need the role of the database
+	dbSetReq := &gnmipb.SetRequest{Prefix: &gnmipb.Path{Target: "STATE_DB"}, Delete: []*gnmipb.Path{{}}}
+	if err := s.AuthorizeDPUForward(ctx, "/gnmi.gNMI/Set", dbSetReq); err == nil {
+		t.Errorf("Forwarded STATE_DB Set with gnmi readwrite role should fail")
+	}
+
+	if err := s.AuthorizeDPUForward(ctx, "/gnmi.gNMI/Other", &gnmipb.Path{}); err == nil {
+		t.Errorf("Unknown request should not be authorized")
+	}
+}
@@ -0,0 +1,55 @@
+package gnmi
+
+import (
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// stateDataTargets are the native targets holding state and operational data:
+// the databases written by the daemons, on the switch, the chassis supervisor
+// and the DPUs, and the virtual targets.
+var stateDataTargets = []string{
+	"STATE_DB", "APPL_DB", "APPL_STATE_DB", "COUNTERS_DB",
+	"CHASSIS_APP_DB", "CHASSIS_STATE_DB", "BMP_STATE_DB",
+	"DPU_APPL_DB", "DPU_APPL_STATE_DB", "DPU_STATE_DB", "DPU_COUNTERS_DB",
+	"OTHERS", "SHOW", "EVENTS", "OPERATIONAL",
+}
+
+// getDataTypeTargets lists, for each GetRequest DataType other than ALL, the
+// native targets holding data of that type. CONFIG data lives in CONFIG_DB.
+var getDataTypeTargets = map[gnmipb.GetRequest_DataType][]string{
+	gnmipb.GetRequest_CONFIG:      {"CONFIG_DB"},
+	gnmipb.GetRequest_STATE:       stateDataTargets,
+	gnmipb.GetRequest_OPERATIONAL: stateDataTargets,
+}
+
+// checkGetDataType verifies that a native target has data of the requested type.
+func checkGetDataType(dataType gnmipb.GetRequest_DataType, target string) error {
+	if dataType == gnmipb.GetRequest_ALL {
+		return nil
+	}
+	targets, ok := getDataTypeTargets[dataType]
+	if !ok {
+		return status.Errorf(codes.InvalidArgument, "unsupported request type: %s", dataType)
+	}
+	for _, t := range targets {
+		if t == target {
+			return nil
+		}
+	}
+	return status.Errorf(codes.InvalidArgument, "target %s has no %s data, use one of %v", target, dataType, targets)
+}
+
+// translContent maps a GetRequest DataType to the translib content query parameter.
+func translContent(dataType gnmipb.GetRequest_DataType) string {
+	switch dataType {
+	case gnmipb.GetRequest_CONFIG:
+		return "config"
+	case gnmipb.GetRequest_STATE:
+		return "nonconfig"
+	case gnmipb.GetRequest_OPERATIONAL:
+		return "operational"
+	}
+	return ""
+}
@@ -0,0 +1,60 @@
+package gnmi
+
+import (
+	"testing"
+
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCheckGetDataType(t *testing.T) {
+	tests := []struct {
+		dataType gnmipb.GetRequest_DataType
+		target   string
+		wantErr  bool
+	}{
+		{gnmipb.GetRequest_ALL, "APPL_DB", false},
+		{gnmipb.GetRequest_ALL, "ASIC_DB", false},
+		{gnmipb.GetRequest_CONFIG, "CONFIG_DB", false},
+		{gnmipb.GetRequest_CONFIG, "STATE_DB", true},
+		{gnmipb.GetRequest_CONFIG, "SHOW", true},
+		{gnmipb.GetRequest_STATE, "STATE_DB", false},
+		{gnmipb.GetRequest_STATE, "APPL_DB", false},
+		{gnmipb.GetRequest_STATE, "COUNTERS_DB", false},
+		{gnmipb.GetRequest_STATE, "CHASSIS_STATE_DB", false},
+		{gnmipb.GetRequest_STATE, "DPU_APPL_DB", false},
+		{gnmipb.GetRequest_STATE, "DPU_STATE_DB", false},
+		{gnmipb.GetRequest_STATE, "CONFIG_DB", true},
+		{gnmipb.GetRequest_STATE, "ASIC_DB", true},
+		{gnmipb.GetRequest_OPERATIONAL, "COUNTERS_DB", false},
+		{gnmipb.GetRequest_OPERATIONAL, "OTHERS", false},
+		{gnmipb.GetRequest_OPERATIONAL, "DPU_COUNTERS_DB", false},
+		{gnmipb.GetRequest_OPERATIONAL, "DPU_APPL_DB", false},
+		{gnmipb.GetRequest_OPERATIONAL, "CONFIG_DB", true},
+	}
+
+	for _, tt := range tests {
+		err := checkGetDataType(tt.dataType, tt.target)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("checkGetDataType(%s, %s) = %v, wantErr %v", tt.dataType, tt.target, err, tt.wantErr)
+		}
+		if err != nil && status.Code(err) != codes.InvalidArgument {
+			t.Errorf("checkGetDataType(%s, %s) code = %v, want InvalidArgument", tt.dataType, tt.target, status.Code(err))
+		}
+	}
+}
+
+func TestTranslContent(t *testing.T) {
+	tests := map[gnmipb.GetRequest_DataType]string{
+		gnmipb.GetRequest_ALL:         "",
+		gnmipb.GetRequest_CONFIG:      "config",
+		gnmipb.GetRequest_STATE:       "nonconfig",
+		gnmipb.GetRequest_OPERATIONAL: "operational",
+	}
+	for dataType, want := range tests {
+		if got := translContent(dataType); got != want {
+			t.Errorf("translContent(%s) = %q, want %q", dataType, got, want)
+		}
+	}
+}
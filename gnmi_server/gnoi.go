@@ -3,7 +3,6 @@ package gnmi
 import (
 	"context"
 	"encoding/json"
-	jwt "github.com/dgrijalva/jwt-go"
 	log "github.com/golang/glog"
 	spb "github.com/sonic-net/sonic-gnmi/proto/gnoi"
 	spb_jwt "github.com/sonic-net/sonic-gnmi/proto/gnoi/jwt"
@@ -59,14 +58,18 @@ func (srv *Server) Refresh(ctx context.Context, req *spb_jwt.RefreshRequest) (*s
 		return nil, err
 	}
 
-	claims := &Claims{}
-	jwt.ParseWithClaims(token.AccessToken, claims, func(token *jwt.Token) (interface{}, error) {
-		return hmacSampleSecret, nil
-	})
+	claims, err := jwtKeys.parse(token.AccessToken)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, err.Error())
+	}
 	if time.Unix(claims.ExpiresAt, 0).Sub(time.Now()) > JwtRefreshInt {
 		return nil, status.Errorf(codes.InvalidArgument, "Invalid JWT Token")
 	}
 
+	// The refreshed token is replaced by the new one
+	if err := RevokeJwt(claims.Id, time.Unix(claims.ExpiresAt, 0)); err != nil {
+		log.Errorf("Failed to revoke refreshed JWT token: %v", err)
+	}
 	return &spb_jwt.RefreshResponse{Token: tokenResp(claims.Username, claims.Roles)}, nil
 
 }
@@ -1,9 +1,12 @@
 package gnmi
 
 import (
+	"context"
+
 	log "github.com/golang/glog"
 	"github.com/sonic-net/sonic-gnmi/common_utils"
 	gnoi_debug "github.com/sonic-net/sonic-gnmi/pkg/gnoi/debug"
+	spb_gnoi "github.com/sonic-net/sonic-gnmi/proto/gnoi"
 	gnoi_debug_pb "github.com/sonic-net/sonic-gnmi/proto/gnoi/debug"
 )
 
@@ -13,19 +16,39 @@ func (srv *DebugServer) Debug(req *gnoi_debug_pb.DebugRequest, stream gnoi_debug
 	common_utils.GetUsername(stream.Context(), &username)
 	log.Infof("gNOI Debug RPC called by '%s': %+v", username, req)
 
-	_, readAccessErr := authenticate(srv.config, stream.Context(), "gnoi", false)
+	policy, err := srv.debugPolicy(stream.Context())
+	if err != nil {
+		log.Errorf("authentication failed in Debug RPC: %v", err)
+		return err
+	}
+	return gnoi_debug.HandleCommandRequest(req, stream, policy)
+}
+
+func (srv *DebugServer) Shell(stream spb_gnoi.DebugShell_ShellServer) error {
+	username := "invalid"
+	common_utils.GetUsername(stream.Context(), &username)
+	log.Infof("DebugShell Shell RPC called by '%s'", username)
+
+	policy, err := srv.debugPolicy(stream.Context())
+	if err != nil {
+		log.Errorf("authentication failed in Shell RPC: %v", err)
+		return err
+	}
+	return gnoi_debug.HandleShellStream(stream, policy, username)
+}
+
+// debugPolicy authenticates the user and returns the whitelist policy of their
+// roles, or of their access level.
+func (srv *DebugServer) debugPolicy(ctx context.Context) (*gnoi_debug.Policy, error) {
+	ctx, readAccessErr := authenticate(srv.config, ctx, "gnoi", false)
 	if readAccessErr != nil {
 		// User cannot do anything, abort
-		log.Errorf("authentication failed in Debug RPC: %v", readAccessErr)
-		return readAccessErr
+		return nil, readAccessErr
 	}
 
-	_, writeAccessErr := authenticate(srv.config, stream.Context(), "gnoi", true)
-	if writeAccessErr != nil {
-		// User has read-only access
-		return gnoi_debug.HandleCommandRequest(req, stream, srv.readWhitelist)
-	}
+	// Users without write access have read-only access
+	_, writeAccessErr := authenticate(srv.config, ctx, "gnoi", true)
 
-	// Otherwise, this user has write access
-	return gnoi_debug.HandleCommandRequest(req, stream, srv.writeWhitelist)
+	rc, _ := common_utils.GetContext(ctx)
+	return gnoi_debug.CurrentWhitelists().PolicyFor(rc.Auth.Roles, writeAccessErr == nil), nil
 }
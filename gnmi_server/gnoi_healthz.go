@@ -97,6 +97,28 @@ func getDebugData(p *types.Path) (*healthz.GetResponse, error) {
 		c, _ = elems[1].GetKey()["name"]
 		ll = strings.TrimSuffix(elems[3].GetName(), ddLogLvlSuf)
 	}
+	id, healthStatus, header, err := collectDebugArtifact(c, ll)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Infof("Construct Get Response structure\n")
+	resp := &healthz.GetResponse{}
+	resp.Component = &healthz.ComponentStatus{
+		Path:      p,
+		Id:        id,
+		Status:    healthStatus,
+		Artifacts: []*healthz.ArtifactHeader{header},
+	}
+	return resp, nil
+}
+
+// collectDebugArtifact asks the host to collect the debug data of a component
+// at a log level, and waits for the artifact file.
+//
+// Returns the artifact ID, the health status reported by the host and the
+// artifact header.
+func collectDebugArtifact(c string, ll string) (string, healthz.Status, *healthz.ArtifactHeader, error) {
 	req := map[string]string{
 		ddComponentKey: c,
 		ddLogLvlKey:    ll,
@@ -104,25 +126,25 @@ func getDebugData(p *types.Path) (*healthz.GetResponse, error) {
 	b, err := json.Marshal(req)
 	if err != nil {
 		log.Errorf("getDebugData(): JSON marshal failed: %v", err)
-		return nil, err
+		return "", healthz.Status_STATUS_UNSPECIFIED, nil, err
 	}
 	sc, err := ssc.NewDbusClient()
 	if err != nil {
 		log.Errorf("NewDbusClient error: %v\n", err)
-		return nil, err
+		return "", healthz.Status_STATUS_UNSPECIFIED, nil, err
 	}
 	defer sc.Close()
 	s, err := sc.HealthzCollect(string(b))
 	if err != nil {
 		log.Errorf("HealthzCollect() Dbus failed: %v", err)
-		return nil, status.Errorf(codes.Internal, "Host service error: %v", err)
+		return "", healthz.Status_STATUS_UNSPECIFIED, nil, status.Errorf(codes.Internal, "Host service error: %v", err)
 	}
 	// Wait for artifact file to be ready.
 	result, err := waitForArtifact(s)
 	if err != nil {
 		log.Errorf("waitForArtifact failed: %v", err)
 		//return nil, status.Errorf(codes.Internal, "Error: %v", err)
-		return nil, err
+		return "", healthz.Status_STATUS_UNSPECIFIED, nil, err
 	}
 	fmt.Printf("waitForArtifact result from HealthzCheck: %s\n", result)
 
@@ -142,7 +164,7 @@ func getDebugData(p *types.Path) (*healthz.GetResponse, error) {
 	allowedDir := "/tmp/dump"
 	cleanPath := filepath.Clean(s)
 	if !strings.HasPrefix(cleanPath, allowedDir) {
-		return nil, status.Errorf(codes.InvalidArgument, "Invalid artifact path")
+		return "", healthz.Status_STATUS_UNSPECIFIED, nil, status.Errorf(codes.InvalidArgument, "Invalid artifact path")
 	}
 	file_path := filepath.Join("/mnt/host", cleanPath)
 	fmt.Printf("Artifact filepath inside gnmi container: %s\n", file_path)
@@ -150,40 +172,31 @@ func getDebugData(p *types.Path) (*healthz.GetResponse, error) {
 	// Stream-hash instead of loading entire file
 	f, err := os.Open(file_path)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "Error: [%v]", err)
+		return "", healthz.Status_STATUS_UNSPECIFIED, nil, status.Errorf(codes.Internal, "Error: [%v]", err)
 	}
 	defer f.Close()
 
 	hasher := sha256.New()
 	size, err := io.Copy(hasher, f) // Streams through hasher, constant memory
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "Error hashing: [%v]", err)
+		return "", healthz.Status_STATUS_UNSPECIFIED, nil, status.Errorf(codes.Internal, "Error hashing: [%v]", err)
 	}
 	hashSum := hasher.Sum(nil)
 
-	log.Infof("Construct Get Response structure\n")
-	resp := &healthz.GetResponse{}
-	resp.Component = &healthz.ComponentStatus{
-		Path:   p,
-		Id:     s,
-		Status: healthStatus,
-		Artifacts: []*healthz.ArtifactHeader{
-			&healthz.ArtifactHeader{
-				Id: s,
-				ArtifactType: &healthz.ArtifactHeader_File{
-					File: &healthz.FileArtifactType{
-						Name: s,
-						Size: size,
-						Hash: &types.HashType{
-							Method: types.HashType_SHA256,
-							Hash:   hashSum[:],
-						},
-					},
+	header := &healthz.ArtifactHeader{
+		Id: s,
+		ArtifactType: &healthz.ArtifactHeader_File{
+			File: &healthz.FileArtifactType{
+				Name: s,
+				Size: size,
+				Hash: &types.HashType{
+					Method: types.HashType_SHA256,
+					Hash:   hashSum[:],
 				},
 			},
 		},
 	}
-	return resp, nil
+	return s, healthStatus, header, nil
 }
 
 // Get implements the corresponding RPC.
@@ -203,6 +216,9 @@ func (srv *HealthzServer) Get(ctx context.Context, req *healthz.GetRequest) (*he
 	if isDebugData(path) {
 		return getDebugData(path)
 	}
+	if name, ok := componentName(path); ok {
+		return getComponentHealth(ctx, path, name)
+	}
 	log.Warning("Healthz.Get received unsupported component path")
 	return nil, status.Errorf(codes.Unimplemented, "Healthz.Get is unimplemented for component: [%s].", path.GetElem())
 }
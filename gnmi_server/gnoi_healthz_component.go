@@ -0,0 +1,479 @@
+package gnmi
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/golang/glog"
+	"github.com/openconfig/gnoi/healthz"
+	types "github.com/openconfig/gnoi/types"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Component health
+//
+// The health of a component is derived from the STATE_DB tables written by
+// the platform daemons (psud, thermalctld, chassisd) and by the system-health
+// daemon (healthd). Components form a tree through PHYSICAL_ENTITY_INFO, and a
+// component is unhealthy if it, or any of its subcomponents, is.
+
+const (
+	systemHealthTable   string = "SYSTEM_HEALTH_INFO"
+	systemHealthSummary string = "summary"
+	systemHealthOK      string = "OK"
+	physicalEntityTable string = "PHYSICAL_ENTITY_INFO"
+	chassisInfoTable    string = "CHASSIS_INFO"
+	// Format of the timestamp field of the platform tables
+	platformTimestampLayout string = "20060102 15:04:05"
+)
+
+// componentTable is a STATE_DB table holding the state of a type of component,
+// keyed by component name.
+type componentTable struct {
+	name string
+	// Returns the reasons the component is unhealthy, if any
+	check func(fields map[string]string) []string
+}
+
+var componentTables = []componentTable{
+	{name: chassisInfoTable, check: func(map[string]string) []string { return nil }},
+	{name: "CHASSIS_MODULE_TABLE", check: checkModule},
+	{name: "PSU_INFO", check: checkPsu},
+	{name: "FAN_DRAWER_INFO", check: checkPresenceAndStatus},
+	{name: "FAN_INFO", check: checkFan},
+	{name: "TEMPERATURE_INFO", check: checkTemperature},
+}
+
+var (
+	// Allow DI for testing
+	getHealthzStateDbClient = func() (*redis.Client, error) { return newDbClient(stateDB) }
+	collectHealthzArtifact  = collectDebugArtifact
+
+	// Debug data collected for unhealthy components, kept while their reasons
+	// do not change
+	unhealthyMu         sync.Mutex
+	unhealthyComponents = map[string]*unhealthyComponent{}
+
+	// Debug data is collected in the background, one component at a time
+	healthzCollectSem = make(chan struct{}, 1)
+	healthzCollectWg  sync.WaitGroup
+)
+
+const (
+	// Backoff between failed debug data collections of a component
+	healthzCollectMinBackoff = 30 * time.Second
+	healthzCollectMaxBackoff = 10 * time.Minute
+)
+
+type unhealthyComponent struct {
+	reasons  string
+	since    time.Time
+	id       string
+	artifact *healthz.ArtifactHeader
+
+	// Debug data collection state, the collection is retried after retryAt
+	// if it failed
+	collecting bool
+	failures   int
+	retryAt    time.Time
+}
+
+func isTrue(val string) bool {
+	return strings.EqualFold(val, "true")
+}
+
+func checkPresenceAndStatus(fields map[string]string) []string {
+	if val, ok := fields["presence"]; ok && !isTrue(val) {
+		return []string{"not present"}
+	}
+	if val, ok := fields["status"]; ok && !isTrue(val) {
+		return []string{"status is not OK"}
+	}
+	return nil
+}
+
+func checkPsu(fields map[string]string) []string {
+	reasons := checkPresenceAndStatus(fields)
+	if isTrue(fields["power_overload"]) {
+		reasons = append(reasons, "power exceeds threshold")
+	}
+	return reasons
+}
+
+func checkFan(fields map[string]string) []string {
+	reasons := checkPresenceAndStatus(fields)
+	if isTrue(fields["is_under_speed"]) {
+		reasons = append(reasons, fmt.Sprintf("speed %s%% is below target %s%%", fields["speed"], fields["speed_target"]))
+	}
+	if isTrue(fields["is_over_speed"]) {
+		reasons = append(reasons, fmt.Sprintf("speed %s%% is above target %s%%", fields["speed"], fields["speed_target"]))
+	}
+	return reasons
+}
+
+func checkTemperature(fields map[string]string) []string {
+	if isTrue(fields["warning_status"]) {
+		return []string{fmt.Sprintf("temperature %s is above high threshold %s", fields["temperature"], fields["high_threshold"])}
+	}
+	return nil
+}
+
+func checkModule(fields map[string]string) []string {
+	if val, ok := fields["oper_status"]; ok && !strings.EqualFold(val, "Online") && !strings.EqualFold(val, "Empty") {
+		return []string{fmt.Sprintf("oper status is %s", val)}
+	}
+	return nil
+}
+
+// componentHealth is the health of a component and its subcomponents.
+type componentHealth struct {
+	name          string
+	reasons       []string
+	timestamp     time.Time
+	subcomponents []*componentHealth
+}
+
+func (h *componentHealth) healthy() bool {
+	if len(h.reasons) > 0 {
+		return false
+	}
+	for _, sub := range h.subcomponents {
+		if !sub.healthy() {
+			return false
+		}
+	}
+	return true
+}
+
+// allReasons returns the reasons of the component, followed by the ones of its
+// subcomponents prefixed with their name.
+func (h *componentHealth) allReasons() []string {
+	reasons := append([]string{}, h.reasons...)
+	for _, sub := range h.subcomponents {
+		for _, reason := range sub.allReasons() {
+			if !strings.HasPrefix(reason, sub.name+": ") {
+				reason = sub.name + ": " + reason
+			}
+			reasons = append(reasons, reason)
+		}
+	}
+	return reasons
+}
+
+// healthzState is a snapshot of the STATE_DB tables the health of the
+// components is derived from.
+type healthzState struct {
+	// Table name -> component name -> fields
+	tables       map[string]map[string]map[string]string
+	systemHealth map[string]string
+	// Component name -> names of its subcomponents
+	children map[string][]string
+}
+
+func loadHealthzState(ctx context.Context, rclient *redis.Client) (*healthzState, error) {
+	state := &healthzState{
+		tables:   map[string]map[string]map[string]string{},
+		children: map[string][]string{},
+	}
+
+	readTable := func(table string) (map[string]map[string]string, error) {
+		keys, err := rclient.Keys(ctx, table+"|*").Result()
+		if err != nil {
+			return nil, err
+		}
+		entries := map[string]map[string]string{}
+		for _, key := range keys {
+			fields, err := rclient.HGetAll(ctx, key).Result()
+			if err != nil {
+				return nil, err
+			}
+			entries[strings.TrimPrefix(key, table+"|")] = fields
+		}
+		return entries, nil
+	}
+
+	for _, table := range componentTables {
+		entries, err := readTable(table.name)
+		if err != nil {
+			return nil, err
+		}
+		state.tables[table.name] = entries
+	}
+
+	entities, err := readTable(physicalEntityTable)
+	if err != nil {
+		return nil, err
+	}
+	state.tables[physicalEntityTable] = entities
+	for name, fields := range entities {
+		if parent := fields["parent_name"]; parent != "" && parent != name {
+			state.children[parent] = append(state.children[parent], name)
+		}
+	}
+	for _, children := range state.children {
+		sort.Strings(children)
+	}
+
+	state.systemHealth, err = rclient.HGetAll(ctx, systemHealthTable).Result()
+	if err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// exists checks whether STATE_DB knows the component.
+func (s *healthzState) exists(name string) bool {
+	for _, entries := range s.tables {
+		if _, ok := entries[name]; ok {
+			return true
+		}
+	}
+	if _, ok := s.children[name]; ok {
+		return true
+	}
+	_, ok := s.systemHealth[name]
+	return ok && name != systemHealthSummary
+}
+
+// isComponent checks whether a system-health object is a component.
+func (s *healthzState) isComponent(name string) bool {
+	for _, entries := range s.tables {
+		if _, ok := entries[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// health derives the health of a component from the snapshot. The chassis
+// also reports the system-health objects which are not components, such as
+// services.
+func (s *healthzState) health(name string, seen map[string]bool) *componentHealth {
+	seen[name] = true
+	h := &componentHealth{name: name}
+
+	for _, table := range componentTables {
+		fields, ok := s.tables[table.name][name]
+		if !ok {
+			continue
+		}
+		h.reasons = append(h.reasons, table.check(fields)...)
+		if ts, err := time.ParseInLocation(platformTimestampLayout, fields["timestamp"], time.Local); err == nil && ts.After(h.timestamp) {
+			h.timestamp = ts
+		}
+	}
+
+	// healthd lists the objects which are not OK, with their reason
+	if msg, ok := s.systemHealth[name]; ok && name != systemHealthSummary && !containsReason(h.reasons, msg) {
+		h.reasons = append(h.reasons, msg)
+	}
+	if _, ok := s.tables[chassisInfoTable][name]; ok {
+		var objects []string
+		for object := range s.systemHealth {
+			if object != systemHealthSummary && !s.isComponent(object) {
+				objects = append(objects, object)
+			}
+		}
+		sort.Strings(objects)
+		for _, object := range objects {
+			h.reasons = append(h.reasons, object+": "+s.systemHealth[object])
+		}
+	}
+
+	for _, child := range s.children[name] {
+		if !seen[child] {
+			h.subcomponents = append(h.subcomponents, s.health(child, seen))
+		}
+	}
+
+	if _, ok := s.tables[chassisInfoTable][name]; ok {
+		if summary, ok := s.systemHealth[systemHealthSummary]; ok && summary != systemHealthOK && h.healthy() {
+			h.reasons = append(h.reasons, "system health is "+summary)
+		}
+	}
+	return h
+}
+
+func containsReason(reasons []string, reason string) bool {
+	for _, r := range reasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
+
+// componentName returns the name of the component of a component health path,
+// i.e. /components/component[name=<name>] with an optional /healthz element.
+func componentName(p *types.Path) (string, bool) {
+	elems := p.GetElem()
+	if len(elems) != 2 && len(elems) != 3 {
+		return "", false
+	}
+	if elems[0].GetName() != "components" || len(elems[0].GetKey()) > 0 {
+		return "", false
+	}
+	if elems[1].GetName() != "component" || len(elems[1].GetKey()) != 1 {
+		return "", false
+	}
+	name, ok := elems[1].GetKey()[compKey]
+	if !ok || name == "" {
+		return "", false
+	}
+	if len(elems) == 3 && (elems[2].GetName() != "healthz" || len(elems[2].GetKey()) > 0) {
+		return "", false
+	}
+	return name, true
+}
+
+func componentPath(origin string, name string) *types.Path {
+	return &types.Path{
+		Origin: origin,
+		Elem: []*types.PathElem{
+			{Name: "components"},
+			{Name: "component", Key: map[string]string{compKey: name}},
+			{Name: "healthz"},
+		},
+	}
+}
+
+// componentStatus converts the health of a component to its status. Reasons
+// are reported in the healthz field, as a struct with a "reasons" list.
+func componentStatus(origin string, h *componentHealth, now time.Time) (*healthz.ComponentStatus, error) {
+	cs := &healthz.ComponentStatus{
+		Path:    componentPath(origin, h.name),
+		Status:  healthz.Status_STATUS_HEALTHY,
+		Created: timestamppb.New(now),
+	}
+	if !h.timestamp.IsZero() {
+		cs.Created = timestamppb.New(h.timestamp)
+	}
+	if !h.healthy() {
+		cs.Status = healthz.Status_STATUS_UNHEALTHY
+		reasons := []interface{}{}
+		for _, reason := range h.allReasons() {
+			reasons = append(reasons, reason)
+		}
+		details, err := structpb.NewStruct(map[string]interface{}{"reasons": reasons})
+		if err != nil {
+			return nil, err
+		}
+		if cs.Healthz, err = anypb.New(details); err != nil {
+			return nil, err
+		}
+	}
+	for _, sub := range h.subcomponents {
+		subStatus, err := componentStatus(origin, sub, now)
+		if err != nil {
+			return nil, err
+		}
+		cs.Subcomponents = append(cs.Subcomponents, subStatus)
+	}
+	return cs, nil
+}
+
+// getComponentHealth returns the health of a component. Debug data is
+// collected in the background for an unhealthy component, once per set of
+// reasons, and attached as artifact to the statuses returned once collected;
+// the status is created when the reasons were first seen.
+func getComponentHealth(ctx context.Context, p *types.Path, name string) (*healthz.GetResponse, error) {
+	rclient, err := getHealthzStateDbClient()
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "cannot connect to STATE_DB: %v", err)
+	}
+	defer rclient.Close()
+
+	state, err := loadHealthzState(ctx, rclient)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "cannot read component state: %v", err)
+	}
+	if !state.exists(name) {
+		return nil, status.Errorf(codes.NotFound, "component %q not found", name)
+	}
+
+	h := state.health(name, map[string]bool{})
+	now := time.Now()
+	cs, err := componentStatus(p.GetOrigin(), h, now)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to build component status: %v", err)
+	}
+	cs.Path = p
+
+	if h.healthy() {
+		unhealthyMu.Lock()
+		delete(unhealthyComponents, name)
+		unhealthyMu.Unlock()
+		return &healthz.GetResponse{Component: cs}, nil
+	}
+
+	reasons := strings.Join(h.allReasons(), "\n")
+	unhealthyMu.Lock()
+	defer unhealthyMu.Unlock()
+	unhealthy, ok := unhealthyComponents[name]
+	if !ok || unhealthy.reasons != reasons {
+		unhealthy = &unhealthyComponent{reasons: reasons, since: now}
+		unhealthyComponents[name] = unhealthy
+	}
+	if unhealthy.artifact == nil && !unhealthy.collecting && !now.Before(unhealthy.retryAt) {
+		startHealthzCollection(name, unhealthy)
+	}
+	cs.Created = timestamppb.New(unhealthy.since)
+	if unhealthy.artifact != nil {
+		cs.Id = unhealthy.id
+		cs.Artifacts = []*healthz.ArtifactHeader{unhealthy.artifact}
+	}
+	return &healthz.GetResponse{Component: cs}, nil
+}
+
+// startHealthzCollection collects the debug data of an unhealthy component in
+// the background, unless another collection is running; it is then retried on
+// the next Get. Failed collections are retried with exponential backoff. Must
+// be called with unhealthyMu held.
+func startHealthzCollection(name string, unhealthy *unhealthyComponent) {
+	select {
+	case healthzCollectSem <- struct{}{}:
+	default:
+		return
+	}
+	unhealthy.collecting = true
+	healthzCollectWg.Add(1)
+	go func() {
+		defer healthzCollectWg.Done()
+		id, _, artifact, err := collectHealthzArtifact(name, ddLogLvlAlert)
+		<-healthzCollectSem
+
+		unhealthyMu.Lock()
+		defer unhealthyMu.Unlock()
+		unhealthy.collecting = false
+		if err != nil {
+			unhealthy.failures++
+			backoff := healthzCollectBackoff(unhealthy.failures)
+			unhealthy.retryAt = time.Now().Add(backoff)
+			log.Warningf("Healthz.Get failed to collect debug data of unhealthy component %q, retrying in %v: %v", name, backoff, err)
+			return
+		}
+		unhealthy.id, unhealthy.artifact = id, artifact
+	}()
+}
+
+// healthzCollectBackoff returns the delay before retrying a collection after
+// a number of failures.
+func healthzCollectBackoff(failures int) time.Duration {
+	backoff := healthzCollectMinBackoff
+	for i := 1; i < failures && backoff < healthzCollectMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > healthzCollectMaxBackoff {
+		backoff = healthzCollectMaxBackoff
+	}
+	return backoff
+}
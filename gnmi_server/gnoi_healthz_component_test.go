@@ -0,0 +1,273 @@
+package gnmi
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/openconfig/gnoi/healthz"
+	types "github.com/openconfig/gnoi/types"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// useHealthzMiniredis serves STATE_DB from miniredis, with a chassis holding
+// two PSUs, a fan drawer with a fan and a thermal, for the duration of a test.
+func useHealthzMiniredis(t *testing.T) *miniredis.Miniredis {
+	mr := miniredis.RunT(t)
+	addr := mr.Addr()
+	originalClient, originalCollect := getHealthzStateDbClient, collectHealthzArtifact
+	t.Cleanup(func() {
+		healthzCollectWg.Wait()
+		getHealthzStateDbClient, collectHealthzArtifact = originalClient, originalCollect
+		unhealthyComponents = map[string]*unhealthyComponent{}
+	})
+	getHealthzStateDbClient = func() (*redis.Client, error) {
+		return redis.NewClient(&redis.Options{Addr: addr}), nil
+	}
+
+	mr.HSet("CHASSIS_INFO|chassis 1", "model", "x")
+	mr.HSet("PSU_INFO|PSU 1", "presence", "true", "status", "true", "power_overload", "False")
+	mr.HSet("PSU_INFO|PSU 2", "presence", "true", "status", "true")
+	mr.HSet("FAN_DRAWER_INFO|FanTray1", "presence", "True", "status", "True")
+	mr.HSet("FAN_INFO|fan1", "presence", "True", "status", "True", "speed", "40", "speed_target", "40",
+		"is_under_speed", "False", "is_over_speed", "False", "timestamp", "20240102 03:04:05")
+	mr.HSet("TEMPERATURE_INFO|CPU", "temperature", "50", "high_threshold", "90", "warning_status", "False")
+	for name, parent := range map[string]string{"PSU 1": "chassis 1", "PSU 2": "chassis 1", "FanTray1": "chassis 1", "fan1": "FanTray1", "CPU": "chassis 1"} {
+		mr.HSet("PHYSICAL_ENTITY_INFO|"+name, "parent_name", parent, "position_in_parent", "1")
+	}
+	mr.HSet("SYSTEM_HEALTH_INFO", "summary", "OK")
+	return mr
+}
+
+func componentReq(name string) *types.Path {
+	return &types.Path{
+		Origin: "openconfig",
+		Elem: []*types.PathElem{
+			{Name: "components"},
+			{Name: "component", Key: map[string]string{"name": name}},
+			{Name: "healthz"},
+		},
+	}
+}
+
+func componentReasons(t *testing.T, cs *healthz.ComponentStatus) []string {
+	t.Helper()
+	if cs.GetHealthz() == nil {
+		return nil
+	}
+	details := &structpb.Struct{}
+	if err := cs.GetHealthz().UnmarshalTo(details); err != nil {
+		t.Fatalf("Failed to unmarshal reasons: %v", err)
+	}
+	var reasons []string
+	for _, reason := range details.GetFields()["reasons"].GetListValue().GetValues() {
+		reasons = append(reasons, reason.GetStringValue())
+	}
+	return reasons
+}
+
+func TestComponentName(t *testing.T) {
+	name, ok := componentName(componentReq("PSU 1"))
+	if !ok || name != "PSU 1" {
+		t.Errorf("Expected PSU 1, got %q %v", name, ok)
+	}
+	p := componentReq("PSU 1")
+	p.Elem = p.Elem[:2]
+	if name, ok := componentName(p); !ok || name != "PSU 1" {
+		t.Errorf("Expected PSU 1 without healthz element, got %q %v", name, ok)
+	}
+	for _, elems := range [][]*types.PathElem{
+		{{Name: "components"}},
+		{{Name: "components"}, {Name: "component", Key: map[string]string{"id": "x"}}},
+		{{Name: "components"}, {Name: "component", Key: map[string]string{"name": "x"}}, {Name: "alert-info"}},
+	} {
+		if _, ok := componentName(&types.Path{Elem: elems}); ok {
+			t.Errorf("Expected %v not to be a component path", elems)
+		}
+	}
+}
+
+func TestGetComponentHealthHealthy(t *testing.T) {
+	useHealthzMiniredis(t)
+	collectHealthzArtifact = func(string, string) (string, healthz.Status, *healthz.ArtifactHeader, error) {
+		t.Fatal("Expected no debug data collection for a healthy component")
+		return "", 0, nil, nil
+	}
+
+	resp, err := getComponentHealth(context.Background(), componentReq("chassis 1"), "chassis 1")
+	if err != nil {
+		t.Fatalf("Expected success, got error: %v", err)
+	}
+	cs := resp.GetComponent()
+	if cs.GetStatus() != healthz.Status_STATUS_HEALTHY || len(cs.GetArtifacts()) != 0 || cs.GetHealthz() != nil {
+		t.Errorf("Expected healthy chassis, got %+v", cs)
+	}
+	var subs []string
+	for _, sub := range cs.GetSubcomponents() {
+		subs = append(subs, sub.GetPath().GetElem()[1].GetKey()["name"])
+	}
+	if !reflect.DeepEqual(subs, []string{"CPU", "FanTray1", "PSU 1", "PSU 2"}) {
+		t.Errorf("Unexpected subcomponents %v", subs)
+	}
+
+	resp, err = getComponentHealth(context.Background(), componentReq("fan1"), "fan1")
+	if err != nil {
+		t.Fatalf("Expected success, got error: %v", err)
+	}
+	want := time.Date(2024, 1, 2, 3, 4, 5, 0, time.Local)
+	if created := resp.GetComponent().GetCreated().AsTime(); !created.Equal(want) {
+		t.Errorf("Expected created %v from the fan timestamp, got %v", want, created)
+	}
+}
+
+func TestGetComponentHealthUnhealthy(t *testing.T) {
+	mr := useHealthzMiniredis(t)
+	mr.HSet("FAN_INFO|fan1", "is_under_speed", "True", "speed", "10")
+	mr.HSet("PSU_INFO|PSU 2", "status", "false")
+	mr.HSet("SYSTEM_HEALTH_INFO", "summary", "Not OK", "PSU 2", "PSU 2 is out of power", "swss", "Container 'swss' is not running")
+
+	collected := 0
+	release := make(chan struct{})
+	artifact := &healthz.ArtifactHeader{Id: "/tmp/dump/chassis.tar.gz"}
+	collectHealthzArtifact = func(component string, level string) (string, healthz.Status, *healthz.ArtifactHeader, error) {
+		<-release
+		collected++
+		if level != ddLogLvlAlert {
+			t.Errorf("Expected alert level, got %s", level)
+		}
+		return artifact.Id, healthz.Status_STATUS_HEALTHY, artifact, nil
+	}
+
+	// The status is returned while the debug data is collected
+	resp, err := getComponentHealth(context.Background(), componentReq("chassis 1"), "chassis 1")
+	if err != nil {
+		t.Fatalf("Expected success, got error: %v", err)
+	}
+	if len(resp.GetComponent().GetArtifacts()) != 0 {
+		t.Errorf("Expected no artifact while collecting, got %+v", resp.GetComponent().GetArtifacts())
+	}
+	created := resp.GetComponent().GetCreated().AsTime()
+	close(release)
+	healthzCollectWg.Wait()
+
+	resp, err = getComponentHealth(context.Background(), componentReq("chassis 1"), "chassis 1")
+	if err != nil {
+		t.Fatalf("Expected success, got error: %v", err)
+	}
+	cs := resp.GetComponent()
+	if cs.GetStatus() != healthz.Status_STATUS_UNHEALTHY {
+		t.Errorf("Expected unhealthy chassis, got %v", cs.GetStatus())
+	}
+	wantReasons := []string{
+		"swss: Container 'swss' is not running",
+		"FanTray1: fan1: speed 10% is below target 40%",
+		"PSU 2: status is not OK",
+		"PSU 2: PSU 2 is out of power",
+	}
+	if reasons := componentReasons(t, cs); !reflect.DeepEqual(reasons, wantReasons) {
+		t.Errorf("Expected reasons %q, got %q", wantReasons, reasons)
+	}
+	if cs.GetId() != artifact.Id || len(cs.GetArtifacts()) != 1 || cs.GetArtifacts()[0] != artifact {
+		t.Errorf("Expected debug data artifact, got %+v", cs.GetArtifacts())
+	}
+	for _, sub := range cs.GetSubcomponents() {
+		name := sub.GetPath().GetElem()[1].GetKey()["name"]
+		unhealthy := name == "PSU 2" || name == "FanTray1"
+		if (sub.GetStatus() == healthz.Status_STATUS_UNHEALTHY) != unhealthy {
+			t.Errorf("Unexpected status %v of %s", sub.GetStatus(), name)
+		}
+	}
+
+	// Same reasons reuse the collected debug data and creation time
+	if collected != 1 || !cs.GetCreated().AsTime().Equal(created) {
+		t.Errorf("Expected the debug data to be collected once, got %d collections", collected)
+	}
+
+	// Changed reasons collect again
+	mr.HSet("PSU_INFO|PSU 1", "presence", "false")
+	if _, err := getComponentHealth(context.Background(), componentReq("chassis 1"), "chassis 1"); err != nil {
+		t.Fatalf("Expected success, got error: %v", err)
+	}
+	healthzCollectWg.Wait()
+	if collected != 2 {
+		t.Errorf("Expected the debug data to be collected again, got %d collections", collected)
+	}
+}
+
+func TestGetComponentHealthCollectionFailure(t *testing.T) {
+	mr := useHealthzMiniredis(t)
+	mr.HSet("TEMPERATURE_INFO|CPU", "warning_status", "True", "temperature", "95")
+	collected := 0
+	collectHealthzArtifact = func(string, string) (string, healthz.Status, *healthz.ArtifactHeader, error) {
+		collected++
+		return "", healthz.Status_STATUS_UNSPECIFIED, nil, errors.New("dbus error")
+	}
+
+	resp, err := getComponentHealth(context.Background(), componentReq("CPU"), "CPU")
+	if err != nil {
+		t.Fatalf("Expected success, got error: %v", err)
+	}
+	cs := resp.GetComponent()
+	if cs.GetStatus() != healthz.Status_STATUS_UNHEALTHY || len(cs.GetArtifacts()) != 0 {
+		t.Errorf("Expected unhealthy status without artifact, got %+v", cs)
+	}
+	if reasons := componentReasons(t, cs); len(reasons) != 1 || !strings.Contains(reasons[0], "temperature 95 is above high threshold 90") {
+		t.Errorf("Unexpected reasons %q", reasons)
+	}
+
+	// Failed collections are retried after a backoff
+	healthzCollectWg.Wait()
+	if _, err := getComponentHealth(context.Background(), componentReq("CPU"), "CPU"); err != nil {
+		t.Fatalf("Expected success, got error: %v", err)
+	}
+	healthzCollectWg.Wait()
+	if collected != 1 {
+		t.Errorf("Expected no collection during backoff, got %d collections", collected)
+	}
+	unhealthyMu.Lock()
+	unhealthyComponents["CPU"].retryAt = time.Now()
+	unhealthyMu.Unlock()
+	if _, err := getComponentHealth(context.Background(), componentReq("CPU"), "CPU"); err != nil {
+		t.Fatalf("Expected success, got error: %v", err)
+	}
+	healthzCollectWg.Wait()
+	if collected != 2 {
+		t.Errorf("Expected the collection to be retried, got %d collections", collected)
+	}
+}
+
+func TestHealthzCollectBackoff(t *testing.T) {
+	for failures, want := range map[int]time.Duration{
+		1:  healthzCollectMinBackoff,
+		2:  2 * healthzCollectMinBackoff,
+		3:  4 * healthzCollectMinBackoff,
+		10: healthzCollectMaxBackoff,
+	} {
+		if backoff := healthzCollectBackoff(failures); backoff != want {
+			t.Errorf("Expected backoff %v after %d failures, got %v", want, failures, backoff)
+		}
+	}
+}
+
+func TestGetComponentHealthErrors(t *testing.T) {
+	useHealthzMiniredis(t)
+	_, err := getComponentHealth(context.Background(), componentReq("PSU 9"), "PSU 9")
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound, got %v", err)
+	}
+
+	getHealthzStateDbClient = func() (*redis.Client, error) {
+		return redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1}), nil
+	}
+	_, err = getComponentHealth(context.Background(), componentReq("PSU 1"), "PSU 1")
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("Expected Unavailable, got %v", err)
+	}
+}
@@ -3,15 +3,20 @@ package gnmi
 import (
 	"context"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/asn1"
 	"encoding/json"
 	"encoding/pem"
 	"fmt"
 	"io"
+	"math/bits"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -28,6 +33,9 @@ import (
 
 const (
 	defaultProfile string = "gnxi"
+	// jwtProfile rotates the JWT signing key with the private key of an
+	// uploaded certificate chain
+	jwtProfile string = "jwt"
 	// Prefixes for DB entries
 	certTbl string = "CERT"
 	certId  string = "certificate"
@@ -59,6 +67,9 @@ var (
 type GNSICertzServer struct {
 	*Server
 	profiles map[string]*profile
+	// jwtPrevKey is the JWT signing key restored if the rotation of the jwt
+	// profile is not finalized
+	jwtPrevKey []byte
 
 	certz.UnimplementedCertzServer
 }
@@ -284,6 +295,9 @@ func (srv *GNSICertzServer) Rotate(stream certz.Certz_RotateServer) error {
 }
 
 func (srv *GNSICertzServer) processRotateRequest(profileID string, req *certz.RotateCertificateRequest) (*certz.RotateCertificateResponse, error) {
+	if profileID == jwtProfile {
+		return srv.rotateJwtKey(req)
+	}
 	if _, ok := srv.profiles[profileID]; !ok {
 		return &certz.RotateCertificateResponse{}, status.Errorf(codes.InvalidArgument, "Rotate requested with invalid ssl_profile_id: %s", profileID)
 	}
@@ -307,6 +321,29 @@ func (srv *GNSICertzServer) processRotateRequest(profileID string, req *certz.Ro
 	return &rotateResp, nil
 }
 
+// rotateJwtKey rotates the JWT signing key with the private key of the
+// uploaded certificate chain. The certificate itself is not used.
+func (srv *GNSICertzServer) rotateJwtKey(req *certz.RotateCertificateRequest) (*certz.RotateCertificateResponse, error) {
+	entities := req.GetCertificates().GetEntities()
+	if len(entities) != 1 || entities[0].GetCertificateChain() == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%s profile requires one certificate chain", jwtProfile)
+	}
+	key := entities[0].GetCertificateChain().GetCertificate().GetPrivateKey()
+	if key == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Missing Key")
+	}
+	prev, err := RotateJwtKey(key)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	if srv.jwtPrevKey == nil {
+		srv.jwtPrevKey = prev
+	}
+	return &certz.RotateCertificateResponse{
+		RotateResponse: &certz.RotateCertificateResponse_Certificates{Certificates: &certz.UploadResponse{}},
+	}, nil
+}
+
 func (srv *GNSICertzServer) doGenerateCsr(profileID string, req *certz.GenerateCSRRequest) (*certz.GenerateCSRResponse, error) {
 	log.V(2).Info("Generating Csr")
 
@@ -322,6 +359,9 @@ func (srv *GNSICertzServer) doGenerateCsr(profileID string, req *certz.GenerateC
 		},
 		SignatureAlgorithm: sigAlgo,
 	}
+	if err := setCSRSubjectAltNames(&csrTemplate, req.GetParams()); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid SAN: %v", err)
+	}
 
 	var privKey any
 	var err error
@@ -350,13 +390,21 @@ func (srv *GNSICertzServer) doGenerateCsr(profileID string, req *certz.GenerateC
 			return nil, status.Errorf(codes.Internal, "GenerateKey failed: %v", err)
 		}
 	case x509.PureEd25519:
-		fallthrough
+		log.V(2).Infof("Generating keys for EdDSA: %v", req.GetParams().GetCsrSuite().String())
+		_, privKey, err = ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "GenerateKey failed: %v", err)
+		}
 	case x509.UnknownSignatureAlgorithm:
 		fallthrough
 	default:
 		return nil, status.Errorf(codes.InvalidArgument, "Unsupported Algorithm: %v", sigAlgo.String())
 
 	}
+	csrTemplate.ExtraExtensions, err = csrUsageExtensions(privKey)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to encode key usage: %v", err)
+	}
 
 	csrCert, err := x509.CreateCertificateRequest(rand.Reader, &csrTemplate, privKey)
 	if err != nil {
@@ -408,6 +456,9 @@ func (srv *GNSICertzServer) doUpload(req *certz.UploadRequest, overwrite bool, p
 			expEntity = srv.newGenericEntity(crlType, profileID, entityMsg)
 		}
 		if ap := entityMsg.GetAuthenticationPolicy(); ap != nil {
+			if srv.Server.config.FedPolicyFile == "" {
+				return nil, status.Error(codes.Aborted, "Authentication policy not configured")
+			}
 			expEntity = srv.newGenericEntity(apType, profileID, entityMsg)
 		}
 		if expEntity == nil {
@@ -516,16 +567,9 @@ func (srv *GNSICertzServer) saveEntities(profileID string, entityMsg *certz.Enti
 		}
 		return attemptWrite(expEntity.CertPath, cert, 0600)
 	case tbType:
-		tb := entityMsg.GetTrustBundle().GetCertificate()
-		if tb.Type != certz.CertificateType_CERTIFICATE_TYPE_X509 {
-			return status.Errorf(codes.InvalidArgument, "trustBundle type has to be X.509")
-		}
-		if tb.Encoding != certz.CertificateEncoding_CERTIFICATE_ENCODING_PEM {
-			return status.Errorf(codes.InvalidArgument, "trustBundle encoding has to be PEM")
-		}
-		bundle := tb.Certificate
-		if len(bundle) == 0 {
-			return status.Errorf(codes.InvalidArgument, "trustBundle cannot be empty")
+		bundle, err := readTrustBundle(entityMsg.GetTrustBundle())
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "%v", err)
 		}
 		return attemptWrite(expEntity.CertPath, bundle, 0600)
 	case crlType:
@@ -534,13 +578,17 @@ func (srv *GNSICertzServer) saveEntities(profileID string, entityMsg *certz.Enti
 		}
 		return nil
 	case apType:
+		policy := entityMsg.GetAuthenticationPolicy().GetSerialized().GetValue()
+		if _, err := parseAuthPolicy(policy); err != nil {
+			return status.Errorf(codes.InvalidArgument, "%v", err)
+		}
 		if _, state := os.Lstat(expEntity.CertPath + backupExt); os.IsNotExist(state) {
 			log.V(2).Info("Backing up Auth Policy")
-			if err := os.Rename(expEntity.CertPath, expEntity.CertPath+backupExt); err != nil {
+			if err := os.Rename(expEntity.CertPath, expEntity.CertPath+backupExt); err != nil && !os.IsNotExist(err) {
 				return err
 			}
 		}
-		if err := attemptWrite(expEntity.CertPath, entityMsg.GetAuthenticationPolicy().GetSerialized().GetValue(), 0600); err != nil {
+		if err := attemptWrite(expEntity.CertPath, policy, 0600); err != nil {
 			log.V(1).Infof("Failed to save Auth Policy: %v", err)
 			if e := os.Rename(expEntity.CertPath+backupExt, expEntity.CertPath); e != nil {
 				log.V(1).Infof("Failed to restore Auto Policy Backup: %v", e)
@@ -598,6 +646,16 @@ func (srv *GNSICertzServer) revertProfile(profileID string) {
 		profileID = defaultProfile
 		log.V(2).Infof("Reverting default profile: %v", defaultProfile)
 	}
+	if profileID == jwtProfile {
+		if srv.jwtPrevKey != nil {
+			log.V(2).Info("Rollback JWT key")
+			if _, err := RotateJwtKey(srv.jwtPrevKey); err != nil {
+				log.V(0).Infof("Failed to revert JWT key: %v", err)
+			}
+			srv.jwtPrevKey = nil
+		}
+		return
+	}
 	profile, ok := srv.profiles[profileID]
 	if !ok || profile == nil {
 		log.V(2).Infof("No profile to revert: %v", profileID)
@@ -635,7 +693,13 @@ func (srv *GNSICertzServer) revertProfile(profileID string) {
 	}
 	if profile.ActiveEntities.AuthPolicy.Final == false {
 		log.V(2).Info("Rollback AP")
-		if err := os.Rename(profile.ActiveEntities.AuthPolicy.CertPath+backupExt, profile.ActiveEntities.AuthPolicy.CertPath); err != nil {
+		path := profile.ActiveEntities.AuthPolicy.CertPath
+		if _, err := os.Lstat(path + backupExt); os.IsNotExist(err) {
+			// There was no policy before the rotation
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				log.V(0).Infof("Failed to revert Auth Policy: %v", err)
+			}
+		} else if err := os.Rename(path+backupExt, path); err != nil {
 			log.V(0).Infof("Failed to revert Auth Policy: %v", err)
 		}
 		writeEntityFreshness(profileID, profile.LastEntities.AuthPolicy)
@@ -645,6 +709,10 @@ func (srv *GNSICertzServer) revertProfile(profileID string) {
 
 func (srv *GNSICertzServer) finalizeProfile(profileID string) error {
 	log.V(2).Infof("Finalizing gRPC credentials for profile=%v", profileID)
+	if profileID == jwtProfile {
+		srv.jwtPrevKey = nil
+		return nil
+	}
 	profile, ok := srv.profiles[profileID]
 	if !ok || profile == nil {
 		return status.Errorf(codes.InvalidArgument, "Finalize requested with invalid ssl_profile_id: %s", profileID)
@@ -960,6 +1028,7 @@ func atomicSetSrvCertKeyPair(cfg *Config, sCert, sKey string) error {
 		return err
 	}
 	log.V(2).Infof("Succesful Set Cert: %s", sCert)
+	reloadServerCerts()
 	return nil
 }
 
@@ -985,6 +1054,7 @@ func atomicSetCACert(cfg *Config, caCert string) error {
 		return err
 	}
 	log.V(2).Infof("Succesful Set CA: %s", caCert)
+	reloadServerCerts()
 	return nil
 }
 
@@ -1058,6 +1128,83 @@ func writeCredentialsMetadataToDB(tbl, key, fld, val string) error {
 	return nil
 }
 
+// setCSRSubjectAltNames adds the SANs of params to the CSR template. The
+// ip_address and email_id fields are added along with the san extension.
+func setCSRSubjectAltNames(tmpl *x509.CertificateRequest, params *certz.CSRParams) error {
+	san := params.GetSan()
+	tmpl.DNSNames = append(tmpl.DNSNames, san.GetDns()...)
+
+	emails := san.GetEmails()
+	if params.GetEmailId() != "" {
+		emails = append([]string{params.GetEmailId()}, emails...)
+	}
+	for _, email := range emails {
+		if !strings.Contains(email, "@") {
+			return fmt.Errorf("invalid email %q", email)
+		}
+		tmpl.EmailAddresses = append(tmpl.EmailAddresses, email)
+	}
+
+	ips := san.GetIps()
+	if params.GetIpAddress() != "" {
+		ips = append([]string{params.GetIpAddress()}, ips...)
+	}
+	for _, s := range ips {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return fmt.Errorf("invalid IP address %q", s)
+		}
+		tmpl.IPAddresses = append(tmpl.IPAddresses, ip)
+	}
+
+	for _, s := range san.GetUris() {
+		uri, err := url.Parse(s)
+		if err != nil || uri.Scheme == "" {
+			return fmt.Errorf("invalid URI %q", s)
+		}
+		tmpl.URIs = append(tmpl.URIs, uri)
+	}
+	return nil
+}
+
+var (
+	oidExtensionKeyUsage    = asn1.ObjectIdentifier{2, 5, 29, 15}
+	oidExtensionExtKeyUsage = asn1.ObjectIdentifier{2, 5, 29, 37}
+	oidExtKeyUsageServer    = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 3, 1}
+	oidExtKeyUsageClient    = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 3, 2}
+)
+
+// csrUsageExtensions returns the key usage and extended key usage extensions
+// requested for the key. The certificate serves gNMI/gNOI/gNSI and is also
+// used as a client certificate, so both server and client auth are requested.
+// Key encipherment is only requested for RSA keys, which can do key transport.
+func csrUsageExtensions(key any) ([]pkix.Extension, error) {
+	usage := x509.KeyUsageDigitalSignature
+	if _, ok := key.(*rsa.PrivateKey); ok {
+		usage |= x509.KeyUsageKeyEncipherment
+	}
+	// KeyUsage is a DER bit string with bit 0 as the most significant bit
+	b := []byte{bits.Reverse8(byte(usage)), bits.Reverse8(byte(usage >> 8))}
+	if b[1] == 0 {
+		b = b[:1]
+	}
+	ku, err := asn1.Marshal(asn1.BitString{
+		Bytes:     b,
+		BitLength: len(b)*8 - bits.TrailingZeros8(b[len(b)-1]),
+	})
+	if err != nil {
+		return nil, err
+	}
+	eku, err := asn1.Marshal([]asn1.ObjectIdentifier{oidExtKeyUsageServer, oidExtKeyUsageClient})
+	if err != nil {
+		return nil, err
+	}
+	return []pkix.Extension{
+		{Id: oidExtensionKeyUsage, Critical: true, Value: ku},
+		{Id: oidExtensionExtKeyUsage, Value: eku},
+	}, nil
+}
+
 func parseCSRSuite(suite certz.CSRSuite) (int, x509.SignatureAlgorithm) {
 	switch suite {
 	case certz.CSRSuite_CSRSUITE_X509_KEY_TYPE_RSA_2048_SIGNATURE_ALGORITHM_SHA_2_256:
@@ -1067,13 +1214,13 @@ func parseCSRSuite(suite certz.CSRSuite) (int, x509.SignatureAlgorithm) {
 	case certz.CSRSuite_CSRSUITE_X509_KEY_TYPE_RSA_2048_SIGNATURE_ALGORITHM_SHA_2_512:
 		return 2048, x509.SHA512WithRSA
 	case certz.CSRSuite_CSRSUITE_X509_KEY_TYPE_RSA_3072_SIGNATURE_ALGORITHM_SHA_2_256:
-		return 3072, x509.SHA512WithRSA
+		return 3072, x509.SHA256WithRSA
 	case certz.CSRSuite_CSRSUITE_X509_KEY_TYPE_RSA_3072_SIGNATURE_ALGORITHM_SHA_2_384:
 		return 3072, x509.SHA384WithRSA
 	case certz.CSRSuite_CSRSUITE_X509_KEY_TYPE_RSA_3072_SIGNATURE_ALGORITHM_SHA_2_512:
 		return 3072, x509.SHA512WithRSA
 	case certz.CSRSuite_CSRSUITE_X509_KEY_TYPE_RSA_4096_SIGNATURE_ALGORITHM_SHA_2_256:
-		return 4096, x509.SHA512WithRSA
+		return 4096, x509.SHA256WithRSA
 	case certz.CSRSuite_CSRSUITE_X509_KEY_TYPE_RSA_4096_SIGNATURE_ALGORITHM_SHA_2_384:
 		return 4096, x509.SHA384WithRSA
 	case certz.CSRSuite_CSRSUITE_X509_KEY_TYPE_RSA_4096_SIGNATURE_ALGORITHM_SHA_2_512:
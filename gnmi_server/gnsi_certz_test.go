@@ -2,11 +2,21 @@ package gnmi
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
 	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
 	"io"
+	"math/big"
+	"net"
 	"os"
 	"path/filepath"
+	"reflect"
 	"regexp"
 	"strings"
 	"sync"
@@ -1608,6 +1618,206 @@ func TestGnsiCertzServer(t *testing.T) {
 	}
 }
 
+func TestGenerateCsrSuites(t *testing.T) {
+	testCases := []struct {
+		suite   certz.CSRSuite
+		keySize int
+		sigAlgo x509.SignatureAlgorithm
+	}{
+		{certz.CSRSuite_CSRSUITE_X509_KEY_TYPE_RSA_2048_SIGNATURE_ALGORITHM_SHA_2_256, 2048, x509.SHA256WithRSA},
+		{certz.CSRSuite_CSRSUITE_X509_KEY_TYPE_RSA_2048_SIGNATURE_ALGORITHM_SHA_2_384, 2048, x509.SHA384WithRSA},
+		{certz.CSRSuite_CSRSUITE_X509_KEY_TYPE_RSA_2048_SIGNATURE_ALGORITHM_SHA_2_512, 2048, x509.SHA512WithRSA},
+		{certz.CSRSuite_CSRSUITE_X509_KEY_TYPE_RSA_3072_SIGNATURE_ALGORITHM_SHA_2_256, 3072, x509.SHA256WithRSA},
+		{certz.CSRSuite_CSRSUITE_X509_KEY_TYPE_RSA_3072_SIGNATURE_ALGORITHM_SHA_2_384, 3072, x509.SHA384WithRSA},
+		{certz.CSRSuite_CSRSUITE_X509_KEY_TYPE_RSA_3072_SIGNATURE_ALGORITHM_SHA_2_512, 3072, x509.SHA512WithRSA},
+		{certz.CSRSuite_CSRSUITE_X509_KEY_TYPE_RSA_4096_SIGNATURE_ALGORITHM_SHA_2_256, 4096, x509.SHA256WithRSA},
+		{certz.CSRSuite_CSRSUITE_X509_KEY_TYPE_RSA_4096_SIGNATURE_ALGORITHM_SHA_2_384, 4096, x509.SHA384WithRSA},
+		{certz.CSRSuite_CSRSUITE_X509_KEY_TYPE_RSA_4096_SIGNATURE_ALGORITHM_SHA_2_512, 4096, x509.SHA512WithRSA},
+		{certz.CSRSuite_CSRSUITE_X509_KEY_TYPE_ECDSA_PRIME256V1_SIGNATURE_ALGORITHM_SHA_2_256, 256, x509.ECDSAWithSHA256},
+		{certz.CSRSuite_CSRSUITE_X509_KEY_TYPE_ECDSA_PRIME256V1_SIGNATURE_ALGORITHM_SHA_2_384, 256, x509.ECDSAWithSHA384},
+		{certz.CSRSuite_CSRSUITE_X509_KEY_TYPE_ECDSA_PRIME256V1_SIGNATURE_ALGORITHM_SHA_2_512, 256, x509.ECDSAWithSHA512},
+		{certz.CSRSuite_CSRSUITE_X509_KEY_TYPE_ECDSA_SECP384R1_SIGNATURE_ALGORITHM_SHA_2_256, 384, x509.ECDSAWithSHA256},
+		{certz.CSRSuite_CSRSUITE_X509_KEY_TYPE_ECDSA_SECP384R1_SIGNATURE_ALGORITHM_SHA_2_384, 384, x509.ECDSAWithSHA384},
+		{certz.CSRSuite_CSRSUITE_X509_KEY_TYPE_ECDSA_SECP384R1_SIGNATURE_ALGORITHM_SHA_2_512, 384, x509.ECDSAWithSHA512},
+		{certz.CSRSuite_CSRSUITE_X509_KEY_TYPE_ECDSA_SECP521R1_SIGNATURE_ALGORITHM_SHA_2_256, 521, x509.ECDSAWithSHA256},
+		{certz.CSRSuite_CSRSUITE_X509_KEY_TYPE_ECDSA_SECP521R1_SIGNATURE_ALGORITHM_SHA_2_384, 521, x509.ECDSAWithSHA384},
+		{certz.CSRSuite_CSRSUITE_X509_KEY_TYPE_ECDSA_SECP521R1_SIGNATURE_ALGORITHM_SHA_2_512, 521, x509.ECDSAWithSHA512},
+		{certz.CSRSuite_CSRSUITE_X509_KEY_TYPE_EDDSA_ED25519, 256, x509.PureEd25519},
+	}
+	srv := &GNSICertzServer{profiles: map[string]*profile{defaultProfile: {ID: defaultProfile}}}
+	for _, tc := range testCases {
+		t.Run(tc.suite.String(), func(t *testing.T) {
+			resp, err := srv.doGenerateCsr(defaultProfile, &certz.GenerateCSRRequest{
+				Params: &certz.CSRParams{
+					CsrSuite:   tc.suite,
+					CommonName: "switch.example.org",
+					IpAddress:  "10.0.0.1",
+					EmailId:    "admin@example.org",
+					San: &certz.V3ExtensionSAN{
+						Dns:    []string{"switch.example.org", "switch"},
+						Emails: []string{"noc@example.org"},
+						Ips:    []string{"fc00::1"},
+						Uris:   []string{"spiffe://example.org/switch"},
+					},
+				},
+			})
+			if err != nil {
+				t.Fatalf("GenerateCsr failed: %v", err)
+			}
+			block, _ := pem.Decode(resp.GetCertificateSigningRequest().GetCertificateSigningRequest())
+			if block == nil || block.Type != "CERTIFICATE REQUEST" {
+				t.Fatalf("Expected a PEM CSR, got %q", resp.GetCertificateSigningRequest().GetCertificateSigningRequest())
+			}
+			csr, err := x509.ParseCertificateRequest(block.Bytes)
+			if err != nil {
+				t.Fatalf("Failed to parse CSR: %v", err)
+			}
+			if err := csr.CheckSignature(); err != nil {
+				t.Errorf("Invalid CSR signature: %v", err)
+			}
+			if csr.SignatureAlgorithm != tc.sigAlgo {
+				t.Errorf("Expected signature algorithm %v, got %v", tc.sigAlgo, csr.SignatureAlgorithm)
+			}
+			switch pub := csr.PublicKey.(type) {
+			case *rsa.PublicKey:
+				if pub.N.BitLen() != tc.keySize {
+					t.Errorf("Expected a %d bit RSA key, got %d", tc.keySize, pub.N.BitLen())
+				}
+			case *ecdsa.PublicKey:
+				if pub.Curve.Params().BitSize != tc.keySize {
+					t.Errorf("Expected a %d bit ECDSA key, got %d", tc.keySize, pub.Curve.Params().BitSize)
+				}
+			case ed25519.PublicKey:
+				if tc.sigAlgo != x509.PureEd25519 {
+					t.Errorf("Unexpected Ed25519 key")
+				}
+			default:
+				t.Errorf("Unexpected public key %T", pub)
+			}
+
+			if csr.Subject.CommonName != "switch.example.org" {
+				t.Errorf("Unexpected subject %v", csr.Subject)
+			}
+			if !reflect.DeepEqual(csr.DNSNames, []string{"switch.example.org", "switch"}) {
+				t.Errorf("Unexpected DNS SANs %v", csr.DNSNames)
+			}
+			if !reflect.DeepEqual(csr.EmailAddresses, []string{"admin@example.org", "noc@example.org"}) {
+				t.Errorf("Unexpected email SANs %v", csr.EmailAddresses)
+			}
+			if len(csr.IPAddresses) != 2 || !csr.IPAddresses[0].Equal(net.ParseIP("10.0.0.1")) || !csr.IPAddresses[1].Equal(net.ParseIP("fc00::1")) {
+				t.Errorf("Unexpected IP SANs %v", csr.IPAddresses)
+			}
+			if len(csr.URIs) != 1 || csr.URIs[0].String() != "spiffe://example.org/switch" {
+				t.Errorf("Unexpected URI SANs %v", csr.URIs)
+			}
+
+			// The requested usages are carried over to a certificate signed with the CSR extensions
+			tmpl := &x509.Certificate{SerialNumber: big.NewInt(1), ExtraExtensions: csr.Extensions}
+			caKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, csr.PublicKey, caKey)
+			if err != nil {
+				t.Fatalf("Failed to sign the CSR: %v", err)
+			}
+			cert, err := x509.ParseCertificate(der)
+			if err != nil {
+				t.Fatalf("Failed to parse the certificate: %v", err)
+			}
+			usage := x509.KeyUsageDigitalSignature
+			if _, ok := csr.PublicKey.(*rsa.PublicKey); ok {
+				usage |= x509.KeyUsageKeyEncipherment
+			}
+			if cert.KeyUsage != usage {
+				t.Errorf("Expected key usage %v, got %v", usage, cert.KeyUsage)
+			}
+			if !reflect.DeepEqual(cert.ExtKeyUsage, []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}) {
+				t.Errorf("Unexpected extended key usage %v", cert.ExtKeyUsage)
+			}
+
+			key, _ := pem.Decode(srv.profiles[defaultProfile].generatedKey)
+			if key == nil {
+				t.Fatal("Expected the generated key to be saved")
+			}
+			if _, err := x509.ParsePKCS8PrivateKey(key.Bytes); err != nil {
+				t.Errorf("Failed to parse the generated key: %v", err)
+			}
+		})
+	}
+}
+
+func TestCertzRotateJwtKey(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "key.pem")
+	useJwtConfig(t, JwtConfig{Algorithm: "ES256", KeyFile: keyFile})
+	oldKey, _ := os.ReadFile(keyFile)
+
+	srv := &GNSICertzServer{profiles: map[string]*profile{defaultProfile: {ID: defaultProfile}}}
+	upload := func(key []byte) *certz.RotateCertificateRequest {
+		return &certz.RotateCertificateRequest{
+			SslProfileId: jwtProfile,
+			RotateRequest: &certz.RotateCertificateRequest_Certificates{Certificates: &certz.UploadRequest{
+				Entities: []*certz.Entity{{
+					Version:   "v1",
+					CreatedOn: uint64(time.Now().Unix()),
+					Entity: &certz.Entity_CertificateChain{CertificateChain: &certz.CertificateChain{
+						Certificate: &certz.Certificate{PrivateKey: key},
+					}},
+				}},
+			}},
+		}
+	}
+	newKey := func() []byte {
+		key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		der, _ := x509.MarshalPKCS8PrivateKey(key)
+		return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	}
+
+	if _, err := srv.processRotateRequest(jwtProfile, upload(nil)); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected missing key to be rejected, got %v", err)
+	}
+
+	// A rotation that is not finalized is reverted
+	if _, err := srv.processRotateRequest(jwtProfile, upload(newKey())); err != nil {
+		t.Fatalf("Rotating the JWT key failed: %v", err)
+	}
+	if _, err := srv.processRotateRequest(jwtProfile, upload(newKey())); err != nil {
+		t.Fatalf("Rotating the JWT key failed: %v", err)
+	}
+	srv.revertProfile(jwtProfile)
+	if data, _ := os.ReadFile(keyFile); string(data) != string(oldKey) {
+		t.Errorf("Expected JWT key to be reverted")
+	}
+
+	key := newKey()
+	if _, err := srv.processRotateRequest(jwtProfile, upload(key)); err != nil {
+		t.Fatalf("Rotating the JWT key failed: %v", err)
+	}
+	if err := srv.finalizeProfile(jwtProfile); err != nil {
+		t.Fatalf("Finalizing the JWT key failed: %v", err)
+	}
+	srv.revertProfile(jwtProfile)
+	if data, _ := os.ReadFile(keyFile); string(data) != string(key) {
+		t.Errorf("Expected finalized JWT key to be kept")
+	}
+}
+
+func TestGenerateCsrInvalidSan(t *testing.T) {
+	srv := &GNSICertzServer{profiles: map[string]*profile{defaultProfile: {ID: defaultProfile}}}
+	for _, params := range []*certz.CSRParams{
+		{IpAddress: "10.0.0.256"},
+		{EmailId: "admin"},
+		{San: &certz.V3ExtensionSAN{Ips: []string{"switch"}}},
+		{San: &certz.V3ExtensionSAN{Emails: []string{"example.org"}}},
+		{San: &certz.V3ExtensionSAN{Uris: []string{"switch"}}},
+		{San: &certz.V3ExtensionSAN{Uris: []string{"spiffe://%zz"}}},
+	} {
+		params.CsrSuite = certz.CSRSuite_CSRSUITE_X509_KEY_TYPE_ECDSA_PRIME256V1_SIGNATURE_ALGORITHM_SHA_2_256
+		params.CommonName = "switch"
+		_, err := srv.doGenerateCsr(defaultProfile, &certz.GenerateCSRRequest{Params: params})
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("Expected InvalidArgument for %v, got %v", params, err)
+		}
+	}
+}
+
 func deleteCredentialFiles(cfg *Config) error {
 	for _, f := range []string{SCertV2, SKeyV2, SCertV1, SKeyV1, CACertV1, srvTestKeyLink, srvTestCertLink} {
 		if err := os.Remove(f); err != nil {
@@ -39,12 +39,16 @@ func generateJWT(username string, roles []string, expire_dt time.Time) string {
 		StandardClaims: jwt.StandardClaims{
 			// In JWT, the expiry time is expressed as unix milliseconds
 			ExpiresAt: expire_dt.Unix(),
+			IssuedAt:  time.Now().Unix(),
+			Id:        newTokenID(),
 		},
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
-	// Sign and get the complete encoded token as a string using the secret
-	tokenString, _ := token.SignedString(hmacSampleSecret)
+	// Sign and get the complete encoded token as a string
+	tokenString, err := jwtKeys.sign(claims)
+	if err != nil {
+		glog.Errorf("Failed to sign JWT token: %v", err)
+	}
 
 	return tokenString
 }
@@ -72,16 +76,10 @@ func JwtAuthenAndAuthor(ctx context.Context) (*spb.JwtToken, context.Context, er
 		return nil, ctx, status.Errorf(codes.Unauthenticated, "No JWT Token Provided")
 	}
 
-	claims := &Claims{}
-	tkn, err := jwt.ParseWithClaims(token.AccessToken, claims, func(token *jwt.Token) (interface{}, error) {
-		return hmacSampleSecret, nil
-	})
+	claims, err := jwtKeys.parse(token.AccessToken)
 	if err != nil {
 		return &token, ctx, status.Errorf(codes.Unauthenticated, err.Error())
 	}
-	if !tkn.Valid {
-		return &token, ctx, status.Errorf(codes.Unauthenticated, "Invalid JWT Token")
-	}
 	if err := PopulateAuthStruct(claims.Username, &rc.Auth, claims.Roles); err != nil {
 		glog.Infof("[%s] Failed to retrieve authentication information; %v", rc.ID, err)
 		return &token, ctx, status.Errorf(codes.Unauthenticated, "")
@@ -0,0 +1,436 @@
+package gnmi
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	log "github.com/golang/glog"
+)
+
+// JwtConfig configures how JWT tokens are signed and validated.
+type JwtConfig struct {
+	// Algorithm is HS256, RS256 or ES256. HS256 signs with a random secret
+	// generated at startup, which dies with the process.
+	Algorithm string
+
+	// KeyFile is the PEM private key of RS256 and ES256, generated if missing.
+	// The key is rotated by replacing the file or with the gNSI certz Rotate
+	// RPC of the jwt profile: tokens signed with the previous key stay valid
+	// until they expire.
+	KeyFile string
+
+	// PublicKeyFile is where the PEM public key is published for other
+	// components to verify tokens, KeyFile with a .pub suffix if empty
+	PublicKeyFile string
+
+	// RevocationFile persists the IDs (jti) of revoked tokens, if set. Tokens
+	// can also be revoked by adding their ID to the file.
+	RevocationFile string
+
+	// Issuer and Audience are set in the iss and aud claims of the tokens and,
+	// if not empty, required on validation
+	Issuer   string
+	Audience string
+}
+
+// jwtKey is an asymmetric signing key.
+type jwtKey struct {
+	kid     string // key ID, set in the kid header of the tokens
+	private crypto.Signer
+}
+
+// jwtRetiredKey is a rotated key, valid for verification until its tokens expire.
+type jwtRetiredKey struct {
+	public crypto.PublicKey
+	until  time.Time
+}
+
+// jwtSigner signs and validates JWT tokens.
+type jwtSigner struct {
+	mu      sync.Mutex
+	cfg     JwtConfig
+	method  jwt.SigningMethod
+	current *jwtKey
+	retired map[string]jwtRetiredKey
+	keyMod  time.Time
+
+	revoked    map[string]int64 // token ID to expiry
+	revokedMod time.Time
+}
+
+var jwtKeys = &jwtSigner{method: jwt.SigningMethodHS256, revoked: map[string]int64{}}
+
+// ConfigureJwt sets up JWT signing. For RS256 and ES256, the private key is
+// loaded from or generated into KeyFile and its public key published. On
+// error, the previous signer is kept.
+func ConfigureJwt(cfg JwtConfig) error {
+	s := &jwtSigner{cfg: cfg, retired: map[string]jwtRetiredKey{}}
+	switch cfg.Algorithm {
+	case "", "HS256":
+		s.method = jwt.SigningMethodHS256
+	case "RS256":
+		s.method = jwt.SigningMethodRS256
+	case "ES256":
+		s.method = jwt.SigningMethodES256
+	default:
+		return fmt.Errorf("unsupported JWT signing algorithm %q", cfg.Algorithm)
+	}
+	if s.method != jwt.SigningMethodHS256 {
+		if cfg.KeyFile == "" {
+			return fmt.Errorf("JWT %s signing requires a key file", cfg.Algorithm)
+		}
+		if s.cfg.PublicKeyFile == "" {
+			s.cfg.PublicKeyFile = cfg.KeyFile + ".pub"
+		}
+		if err := s.loadKey(); err != nil {
+			return err
+		}
+	}
+	if err := s.loadRevoked(); err != nil {
+		return err
+	}
+
+	if s.method == jwt.SigningMethodHS256 {
+		GenerateJwtSecretKey()
+	}
+	jwtKeys = s
+	return nil
+}
+
+// keyID identifies a key by the hash of its public key.
+func keyID(public crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(der)
+	return base64.RawURLEncoding.EncodeToString(sum[:12]), nil
+}
+
+// generateKey generates a private key for the signing method.
+func (s *jwtSigner) generateKey() (crypto.Signer, error) {
+	if s.method == jwt.SigningMethodES256 {
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	}
+	return rsa.GenerateKey(rand.Reader, 2048)
+}
+
+// parsePrivateKey parses a PEM private key in PKCS #8, PKCS #1 or SEC 1 form.
+func parsePrivateKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM private key")
+	}
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		if signer, ok := key.(crypto.Signer); ok {
+			return signer, nil
+		}
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return x509.ParseECPrivateKey(block.Bytes)
+}
+
+// loadKey loads the private key of KeyFile, generating it if missing, and
+// publishes its public key. The current key, if any, is retired.
+func (s *jwtSigner) loadKey() error {
+	var key crypto.Signer
+	data, err := ioutil.ReadFile(s.cfg.KeyFile)
+	switch {
+	case err == nil:
+		if key, err = parsePrivateKey(data); err != nil {
+			return fmt.Errorf("invalid JWT key %s: %v", s.cfg.KeyFile, err)
+		}
+	case os.IsNotExist(err):
+		log.V(1).Infof("Generating JWT %s key %s", s.method.Alg(), s.cfg.KeyFile)
+		if key, err = s.generateKey(); err != nil {
+			return err
+		}
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return err
+		}
+		if err := writeKeyFile(s.cfg.KeyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})); err != nil {
+			return err
+		}
+	default:
+		return err
+	}
+
+	switch key.(type) {
+	case *rsa.PrivateKey:
+		if s.method != jwt.SigningMethodRS256 {
+			return fmt.Errorf("JWT key %s is not an %s key", s.cfg.KeyFile, s.method.Alg())
+		}
+	case *ecdsa.PrivateKey:
+		if s.method != jwt.SigningMethodES256 || key.Public().(*ecdsa.PublicKey).Curve != elliptic.P256() {
+			return fmt.Errorf("JWT key %s is not an %s key", s.cfg.KeyFile, s.method.Alg())
+		}
+	default:
+		return fmt.Errorf("unsupported JWT key type %T", key)
+	}
+
+	kid, err := keyID(key.Public())
+	if err != nil {
+		return err
+	}
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(s.cfg.PublicKeyFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0644); err != nil {
+		return fmt.Errorf("failed to publish JWT public key: %v", err)
+	}
+
+	if info, err := os.Stat(s.cfg.KeyFile); err == nil {
+		s.keyMod = info.ModTime()
+	}
+	if s.current != nil && s.current.kid != kid {
+		log.V(1).Infof("JWT key rotated from %s to %s", s.current.kid, kid)
+		s.retired[s.current.kid] = jwtRetiredKey{public: s.current.private.Public(), until: time.Now().Add(JwtValidInt)}
+	}
+	s.current = &jwtKey{kid: kid, private: key}
+	return nil
+}
+
+// writeKeyFile atomically replaces a private key file.
+func writeKeyFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// rotate replaces KeyFile with a PEM private key and loads it, returning the
+// previous content of KeyFile. The current key is kept if the new one is invalid.
+func (s *jwtSigner) rotate(key []byte) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.current == nil {
+		return nil, fmt.Errorf("JWT %s signing key cannot be rotated", s.method.Alg())
+	}
+	if _, err := parsePrivateKey(key); err != nil {
+		return nil, fmt.Errorf("invalid JWT key: %v", err)
+	}
+	prev, err := ioutil.ReadFile(s.cfg.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeKeyFile(s.cfg.KeyFile, key); err != nil {
+		return nil, err
+	}
+	if err := s.loadKey(); err != nil {
+		if e := writeKeyFile(s.cfg.KeyFile, prev); e != nil {
+			log.Errorf("Failed to restore JWT key %s: %v", s.cfg.KeyFile, e)
+		} else if e := s.loadKey(); e != nil {
+			log.Errorf("Failed to reload JWT key %s: %v", s.cfg.KeyFile, e)
+		}
+		return nil, err
+	}
+	return prev, nil
+}
+
+// RotateJwtKey replaces the RS256 or ES256 signing key with a PEM private key
+// and returns the previous one, e.g. to revert the rotation. Tokens signed
+// with the previous key stay valid until they expire.
+func RotateJwtKey(key []byte) ([]byte, error) {
+	return jwtKeys.rotate(key)
+}
+
+// refreshKey reloads KeyFile if it was replaced. The current key is kept if
+// the new one is invalid.
+func (s *jwtSigner) refreshKey() {
+	if s.current == nil {
+		return
+	}
+	info, err := os.Stat(s.cfg.KeyFile)
+	if err != nil || info.ModTime().Equal(s.keyMod) {
+		return
+	}
+	if err := s.loadKey(); err != nil {
+		log.Errorf("Failed to rotate JWT key: %v", err)
+		s.keyMod = info.ModTime()
+	}
+	for kid, key := range s.retired {
+		if time.Now().After(key.until) {
+			delete(s.retired, kid)
+		}
+	}
+}
+
+// sign signs the claims of a new token with the current key.
+func (s *jwtSigner) sign(claims *Claims) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	claims.Issuer = s.cfg.Issuer
+	claims.Audience = s.cfg.Audience
+	token := jwt.NewWithClaims(s.method, claims)
+	if s.method == jwt.SigningMethodHS256 {
+		return token.SignedString(hmacSampleSecret)
+	}
+	s.refreshKey()
+	token.Header["kid"] = s.current.kid
+	return token.SignedString(s.current.private)
+}
+
+// verificationKey returns the key verifying a token, rejecting tokens signed
+// with another algorithm.
+func (s *jwtSigner) verificationKey(token *jwt.Token) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if token.Method.Alg() != s.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %v", token.Method.Alg())
+	}
+	if s.method == jwt.SigningMethodHS256 {
+		return hmacSampleSecret, nil
+	}
+	s.refreshKey()
+	kid, _ := token.Header["kid"].(string)
+	if kid == s.current.kid {
+		return s.current.private.Public(), nil
+	}
+	if key, ok := s.retired[kid]; ok && time.Now().Before(key.until) {
+		return key.public, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// parse validates a token and returns its claims.
+func (s *jwtSigner) parse(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	tkn, err := jwt.ParseWithClaims(tokenString, claims, s.verificationKey)
+	if err != nil {
+		return nil, err
+	}
+	if !tkn.Valid {
+		return nil, errors.New("Invalid JWT Token")
+	}
+	if s.cfg.Issuer != "" && !claims.VerifyIssuer(s.cfg.Issuer, true) {
+		return nil, errors.New("Invalid JWT issuer")
+	}
+	if s.cfg.Audience != "" && !claims.VerifyAudience(s.cfg.Audience, true) {
+		return nil, errors.New("Invalid JWT audience")
+	}
+	if s.isRevoked(claims.Id) {
+		return nil, errors.New("JWT Token revoked")
+	}
+	return claims, nil
+}
+
+// newTokenID returns a random token ID.
+func newTokenID() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// loadRevoked loads the revoked token IDs from RevocationFile.
+func (s *jwtSigner) loadRevoked() error {
+	if s.revoked == nil {
+		s.revoked = map[string]int64{}
+	}
+	if s.cfg.RevocationFile == "" {
+		return nil
+	}
+	info, err := os.Stat(s.cfg.RevocationFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	data, err := ioutil.ReadFile(s.cfg.RevocationFile)
+	if err != nil {
+		return err
+	}
+	revoked := map[string]int64{}
+	if err := json.Unmarshal(data, &revoked); err != nil {
+		return fmt.Errorf("invalid JWT revocation list %s: %v", s.cfg.RevocationFile, err)
+	}
+	s.revoked = revoked
+	s.revokedMod = info.ModTime()
+	return nil
+}
+
+// isRevoked checks if a token ID was revoked, reloading RevocationFile if it changed.
+func (s *jwtSigner) isRevoked(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cfg.RevocationFile != "" {
+		if info, err := os.Stat(s.cfg.RevocationFile); err == nil && !info.ModTime().Equal(s.revokedMod) {
+			if err := s.loadRevoked(); err != nil {
+				log.Errorf("Failed to reload JWT revocation list: %v", err)
+			}
+		}
+	}
+	_, ok := s.revoked[id]
+	return ok
+}
+
+// revoke adds a token ID to the revocation list until the token expires.
+func (s *jwtSigner) revoke(id string, expiresAt int64) error {
+	if id == "" {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().Unix()
+	for jti, exp := range s.revoked {
+		if exp < now {
+			delete(s.revoked, jti)
+		}
+	}
+	s.revoked[id] = expiresAt
+	if s.cfg.RevocationFile == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(s.revoked)
+	if err != nil {
+		return err
+	}
+	tmp := s.cfg.RevocationFile + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.cfg.RevocationFile); err != nil {
+		return err
+	}
+	if info, err := os.Stat(s.cfg.RevocationFile); err == nil {
+		s.revokedMod = info.ModTime()
+	}
+	return nil
+}
+
+// RevokeJwt revokes a token by its ID until it expires.
+func RevokeJwt(id string, expiresAt time.Time) error {
+	return jwtKeys.revoke(id, expiresAt.Unix())
+}
@@ -0,0 +1,264 @@
+package gnmi
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+// useJwtConfig configures JWT signing for the duration of a test.
+func useJwtConfig(t *testing.T, cfg JwtConfig) {
+	saved := jwtKeys
+	t.Cleanup(func() { jwtKeys = saved })
+	if err := ConfigureJwt(cfg); err != nil {
+		t.Fatalf("ConfigureJwt failed: %v", err)
+	}
+}
+
+func TestJwtAsymmetricSigning(t *testing.T) {
+	for _, alg := range []string{"RS256", "ES256"} {
+		t.Run(alg, func(t *testing.T) {
+			dir := t.TempDir()
+			keyFile := filepath.Join(dir, "jwt", "key.pem")
+			useJwtConfig(t, JwtConfig{Algorithm: alg, KeyFile: keyFile, Issuer: "sonic-gnmi", Audience: "sonic"})
+
+			// The key is generated and its public key published
+			if _, err := os.Stat(keyFile); err != nil {
+				t.Fatalf("Expected generated key: %v", err)
+			}
+			data, err := ioutil.ReadFile(keyFile + ".pub")
+			if err != nil {
+				t.Fatalf("Expected published public key: %v", err)
+			}
+			block, _ := pem.Decode(data)
+			public, err := x509.ParsePKIXPublicKey(block.Bytes)
+			if err != nil {
+				t.Fatalf("Invalid public key: %v", err)
+			}
+
+			tokenString := generateJWT("admin", []string{"admin"}, time.Now().Add(time.Hour))
+			claims, err := jwtKeys.parse(tokenString)
+			if err != nil {
+				t.Fatalf("Failed to validate token: %v", err)
+			}
+			if claims.Username != "admin" || claims.Issuer != "sonic-gnmi" || claims.Audience != "sonic" || claims.Id == "" {
+				t.Errorf("Unexpected claims %+v", claims)
+			}
+
+			// Other components can verify tokens with the published key
+			tkn, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(*jwt.Token) (interface{}, error) {
+				return public, nil
+			})
+			if err != nil || !tkn.Valid || tkn.Method.Alg() != alg {
+				t.Errorf("Failed to verify token with published key: %v", err)
+			}
+
+			// Tokens survive a restart
+			useJwtConfig(t, JwtConfig{Algorithm: alg, KeyFile: keyFile, Issuer: "sonic-gnmi", Audience: "sonic"})
+			if _, err := jwtKeys.parse(tokenString); err != nil {
+				t.Errorf("Expected token valid after restart: %v", err)
+			}
+		})
+	}
+}
+
+func TestJwtValidationFailures(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "key.pem")
+	useJwtConfig(t, JwtConfig{Algorithm: "RS256", KeyFile: keyFile, Issuer: "sonic-gnmi", Audience: "sonic"})
+	valid := generateJWT("admin", nil, time.Now().Add(time.Hour))
+
+	// HS256 tokens are rejected, even signed with the public key
+	hmacToken, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{Username: "admin"}).SignedString(hmacSampleSecret)
+	if _, err := jwtKeys.parse(hmacToken); err == nil || !strings.Contains(err.Error(), "unexpected signing method") {
+		t.Errorf("Expected HS256 token to be rejected, got %v", err)
+	}
+
+	useJwtConfig(t, JwtConfig{Algorithm: "RS256", KeyFile: keyFile, Issuer: "other"})
+	if _, err := jwtKeys.parse(valid); err == nil || !strings.Contains(err.Error(), "issuer") {
+		t.Errorf("Expected issuer mismatch, got %v", err)
+	}
+	useJwtConfig(t, JwtConfig{Algorithm: "RS256", KeyFile: keyFile, Audience: "other"})
+	if _, err := jwtKeys.parse(valid); err == nil || !strings.Contains(err.Error(), "audience") {
+		t.Errorf("Expected audience mismatch, got %v", err)
+	}
+
+	// A key of another algorithm is rejected
+	err := ConfigureJwt(JwtConfig{Algorithm: "ES256", KeyFile: keyFile})
+	if err == nil || !strings.Contains(err.Error(), "is not an ES256 key") {
+		t.Errorf("Expected RSA key to be rejected for ES256, got %v", err)
+	}
+	if err := ConfigureJwt(JwtConfig{Algorithm: "PS256", KeyFile: keyFile}); err == nil {
+		t.Errorf("Expected PS256 to be rejected")
+	}
+	if err := ConfigureJwt(JwtConfig{Algorithm: "RS256"}); err == nil {
+		t.Errorf("Expected RS256 without key file to be rejected")
+	}
+	// Failed configurations keep the previous signer
+	if jwtKeys.method != jwt.SigningMethodRS256 || jwtKeys.cfg.Audience != "other" {
+		t.Errorf("Expected the previous signer to be kept, got %v %q", jwtKeys.method.Alg(), jwtKeys.cfg.Audience)
+	}
+}
+
+func TestJwtKeyRotation(t *testing.T) {
+	saved := JwtValidInt
+	JwtValidInt = time.Hour
+	defer func() { JwtValidInt = saved }()
+
+	keyFile := filepath.Join(t.TempDir(), "key.pem")
+	useJwtConfig(t, JwtConfig{Algorithm: "ES256", KeyFile: keyFile})
+	oldToken := generateJWT("admin", nil, time.Now().Add(time.Hour))
+	oldKid := jwtKeys.current.kid
+
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der, _ := x509.MarshalECPrivateKey(key)
+	if err := ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(keyFile, future, future)
+
+	newToken := generateJWT("admin", nil, time.Now().Add(time.Hour))
+	if jwtKeys.current.kid == oldKid {
+		t.Fatalf("Expected key %s to be rotated", oldKid)
+	}
+	for _, tokenString := range []string{oldToken, newToken} {
+		if _, err := jwtKeys.parse(tokenString); err != nil {
+			t.Errorf("Expected token valid after rotation: %v", err)
+		}
+	}
+
+	// Retired keys are only kept until their tokens expire
+	retired := jwtKeys.retired[oldKid]
+	retired.until = time.Now().Add(-time.Second)
+	jwtKeys.retired[oldKid] = retired
+	if _, err := jwtKeys.parse(oldToken); err == nil || !strings.Contains(err.Error(), "unknown signing key") {
+		t.Errorf("Expected token of expired key to be rejected, got %v", err)
+	}
+
+	// An invalid key file keeps the current key
+	ioutil.WriteFile(keyFile, []byte("not a key"), 0600)
+	future = future.Add(time.Minute)
+	os.Chtimes(keyFile, future, future)
+	if _, err := jwtKeys.parse(newToken); err != nil {
+		t.Errorf("Expected current key kept, got %v", err)
+	}
+}
+
+func TestRotateJwtKey(t *testing.T) {
+	saved := JwtValidInt
+	JwtValidInt = time.Hour
+	defer func() { JwtValidInt = saved }()
+
+	keyFile := filepath.Join(t.TempDir(), "key.pem")
+	useJwtConfig(t, JwtConfig{Algorithm: "ES256", KeyFile: keyFile})
+	oldToken := generateJWT("admin", nil, time.Now().Add(time.Hour))
+	oldKid := jwtKeys.current.kid
+	oldKey, _ := ioutil.ReadFile(keyFile)
+
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der, _ := x509.MarshalECPrivateKey(key)
+	newKey := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+	prev, err := RotateJwtKey(newKey)
+	if err != nil {
+		t.Fatalf("RotateJwtKey failed: %v", err)
+	}
+	if string(prev) != string(oldKey) {
+		t.Errorf("Expected previous key to be returned")
+	}
+	if data, _ := ioutil.ReadFile(keyFile); string(data) != string(newKey) {
+		t.Errorf("Expected key file to be replaced")
+	}
+	newToken := generateJWT("admin", nil, time.Now().Add(time.Hour))
+	if jwtKeys.current.kid == oldKid {
+		t.Fatalf("Expected key %s to be rotated", oldKid)
+	}
+	for _, tokenString := range []string{oldToken, newToken} {
+		if _, err := jwtKeys.parse(tokenString); err != nil {
+			t.Errorf("Expected token valid after rotation: %v", err)
+		}
+	}
+
+	// A key of another algorithm is rejected and the current key kept
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	rsaPem := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)})
+	if _, err := RotateJwtKey(rsaPem); err == nil || !strings.Contains(err.Error(), "is not an ES256 key") {
+		t.Errorf("Expected RSA key to be rejected for ES256, got %v", err)
+	}
+	if _, err := RotateJwtKey([]byte("not a key")); err == nil {
+		t.Errorf("Expected invalid key to be rejected")
+	}
+	if data, _ := ioutil.ReadFile(keyFile); string(data) != string(newKey) {
+		t.Errorf("Expected key file to be restored")
+	}
+	if _, err := jwtKeys.parse(newToken); err != nil {
+		t.Errorf("Expected current key kept, got %v", err)
+	}
+
+	// The HS256 secret is not rotated
+	useJwtConfig(t, JwtConfig{Algorithm: "HS256"})
+	if _, err := RotateJwtKey(newKey); err == nil {
+		t.Errorf("Expected HS256 rotation to be rejected")
+	}
+}
+
+func TestJwtRevocation(t *testing.T) {
+	revocationFile := filepath.Join(t.TempDir(), "revoked.json")
+	useJwtConfig(t, JwtConfig{RevocationFile: revocationFile})
+
+	expiresAt := time.Now().Add(time.Hour)
+	tokenString := generateJWT("admin", nil, expiresAt)
+	claims, err := jwtKeys.parse(tokenString)
+	if err != nil {
+		t.Fatalf("Failed to validate token: %v", err)
+	}
+
+	if err := RevokeJwt(claims.Id, expiresAt); err != nil {
+		t.Fatalf("RevokeJwt failed: %v", err)
+	}
+	if _, err := jwtKeys.parse(tokenString); err == nil || !strings.Contains(err.Error(), "revoked") {
+		t.Errorf("Expected revoked token to be rejected, got %v", err)
+	}
+
+	// The revocation list is persisted
+	saved := jwtKeys
+	if err := ConfigureJwt(JwtConfig{RevocationFile: revocationFile}); err != nil {
+		t.Fatalf("ConfigureJwt failed: %v", err)
+	}
+	if !jwtKeys.isRevoked(claims.Id) {
+		t.Errorf("Expected %s revoked after restart", claims.Id)
+	}
+	jwtKeys = saved
+
+	// Tokens can be revoked by editing the file
+	other := generateJWT("admin", nil, expiresAt)
+	otherClaims, _ := jwtKeys.parse(other)
+	content := `{"` + otherClaims.Id + `": ` + "9999999999" + `}`
+	ioutil.WriteFile(revocationFile, []byte(content), 0600)
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(revocationFile, future, future)
+	if _, err := jwtKeys.parse(other); err == nil {
+		t.Errorf("Expected token revoked through the file to be rejected")
+	}
+
+	// Expired entries are pruned
+	if err := RevokeJwt("expired", time.Now().Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := RevokeJwt("other", expiresAt); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := jwtKeys.revoked["expired"]; ok {
+		t.Errorf("Expected expired revocation pruned")
+	}
+}
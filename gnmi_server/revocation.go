@@ -0,0 +1,540 @@
+package gnmi
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/redis/go-redis/v9"
+	"github.com/sonic-net/sonic-gnmi/common_utils"
+	"golang.org/x/crypto/ocsp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Revocation checking keeps the CRLs of the client certificate distribution
+// points, the certz CRL bundle and OCSP responses cached, and refreshes them
+// in the background before they expire. Connections only wait, for a bounded
+// time, on a revocation server the first time its CRL or response is needed.
+
+const DEFAULT_REVOCATION_REFRESH_INTERVAL time.Duration = time.Minute
+
+const (
+	// Validity of OCSP responses without a next update
+	ocspDefaultValidity = time.Hour
+	// OCSP responses unused for this long are no longer refreshed
+	ocspIdleDuration = 24 * time.Hour
+	// Largest CRL or OCSP response accepted from a revocation server
+	maxRevocationResponseSize = 16 * 1024 * 1024
+	// STATE_DB table exposing the state of the cached CRLs and OCSP responses
+	revocationStatusTable = "TELEMETRY_REVOCATION"
+)
+
+type ocspEntry struct {
+	cert       *x509.Certificate
+	issuer     *x509.Certificate
+	server     string
+	status     int // ocsp.Good, ocsp.Revoked or ocsp.Unknown
+	raw        []byte
+	thisUpdate time.Time
+	nextUpdate time.Time
+	fetched    time.Time
+	lastUsed   time.Time
+}
+
+// validUntil returns when the response expires.
+func (e *ocspEntry) validUntil() time.Time {
+	if !e.nextUpdate.IsZero() {
+		return e.nextUpdate
+	}
+	return e.fetched.Add(ocspDefaultValidity)
+}
+
+var (
+	revocationRefreshInterval = DEFAULT_REVOCATION_REFRESH_INTERVAL
+	// Longest a connection waits on a revocation server
+	revocationFetchWait = 3 * time.Second
+	// Allow DI for testing
+	revocationHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+	ocspEnabled bool
+	// OCSP responses of client and server certificates, by issuer key and serial
+	ocspMu    sync.RWMutex
+	ocspCache = map[string]*ocspEntry{}
+
+	// Running fetches and the last fetch error of each CRL URL or OCSP key
+	fetchMu          sync.Mutex
+	fetches          = map[string]chan struct{}{}
+	revocationErrors = map[string]string{}
+
+	// Allow DI for testing
+	fetchOcspResponse = func(server string, request []byte) ([]byte, error) {
+		resp, err := revocationHTTPClient.Post(server, "application/ocsp-request", bytes.NewReader(request))
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("HTTP status %s", resp.Status)
+		}
+		return io.ReadAll(io.LimitReader(resp.Body, maxRevocationResponseSize))
+	}
+	getRevocationStateDbClient = func() (*redis.Client, error) { return newDbClient("STATE_DB") }
+)
+
+func SetOcspEnabled(enabled bool) {
+	ocspEnabled = enabled
+}
+
+func GetOcspEnabled() bool {
+	return ocspEnabled
+}
+
+func SetRevocationRefreshInterval(interval time.Duration) {
+	revocationRefreshInterval = interval
+}
+
+// fetchOnce runs fetch in the background unless a fetch for key is already
+// running, and returns a channel closed when that fetch completes.
+func fetchOnce(key string, fetch func()) <-chan struct{} {
+	fetchMu.Lock()
+	defer fetchMu.Unlock()
+	if done, ok := fetches[key]; ok {
+		return done
+	}
+	done := make(chan struct{})
+	fetches[key] = done
+	go func() {
+		defer func() {
+			fetchMu.Lock()
+			delete(fetches, key)
+			fetchMu.Unlock()
+			close(done)
+		}()
+		fetch()
+	}()
+	return done
+}
+
+// waitFetches waits for all fetches to complete, for up to the fetch wait.
+func waitFetches(dones []<-chan struct{}) {
+	timer := time.NewTimer(revocationFetchWait)
+	defer timer.Stop()
+	for _, done := range dones {
+		select {
+		case <-done:
+		case <-timer.C:
+			return
+		}
+	}
+}
+
+func setRevocationError(key string, err error) {
+	fetchMu.Lock()
+	defer fetchMu.Unlock()
+	if err == nil {
+		delete(revocationErrors, key)
+	} else {
+		revocationErrors[key] = err.Error()
+	}
+}
+
+func getRevocationError(key string) string {
+	fetchMu.Lock()
+	defer fetchMu.Unlock()
+	return revocationErrors[key]
+}
+
+// refreshCrl downloads the CRL at url again in the background.
+func refreshCrl(url string) <-chan struct{} {
+	return fetchOnce(url, func() { TryDownload(url) })
+}
+
+// Returns whether refreshing crl is due, once three quarters of its validity
+// have passed, or every refresh interval if it has no future next update.
+func crlRefreshDue(crl *Crl, now time.Time) bool {
+	if crl.nextUpdate.After(crl.thisUpdate) {
+		return !now.Before(crl.thisUpdate.Add(crl.nextUpdate.Sub(crl.thisUpdate) * 3 / 4))
+	}
+	return now.Sub(crl.thisUpdate) >= revocationRefreshInterval
+}
+
+// crlBundleStatus returns whether the certz CRL bundle has a CRL of the issuer
+// of cert, and whether one of them is current, i.e. not past its next update.
+// The revocation status of a cert whose bundle CRLs are all past their next
+// update is undetermined.
+func crlBundleStatus(cert *x509.Certificate, now time.Time) (found bool, current bool) {
+	issuer := cert.Issuer.ToRDNSequence().String()
+	crlMu.RLock()
+	defer crlMu.RUnlock()
+	for _, crl := range CrlCache {
+		if !crl.bundle || crl.issuer != issuer {
+			continue
+		}
+		found = true
+		if !crl.nextUpdate.After(crl.thisUpdate) || !now.After(crl.nextUpdate) {
+			return true, true
+		}
+	}
+	return found, false
+}
+
+// loadCrlBundle replaces the bundle CRLs of the cache with the CRLs in dir,
+// as rotated by certz. Files which are not CRLs are skipped.
+func loadCrlBundle(dir string) {
+	files, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		glog.Errorf("Failed to read CRL bundle '%s': %v", dir, err)
+		return
+	}
+	loaded := map[string]*Crl{}
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, file.Name())
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			glog.Warningf("Failed to read CRL '%s': %v", path, err)
+			continue
+		}
+		list, err := parseCrl(raw)
+		if err != nil {
+			glog.Warningf("Skipping '%s', not a CRL: %v", path, err)
+			continue
+		}
+		// The validity comes from the CRL, not from when it was loaded
+		crl := &Crl{
+			thisUpdate: list.ThisUpdate,
+			nextUpdate: list.ThisUpdate,
+			crl:        raw,
+			issuer:     list.Issuer.ToRDNSequence().String(),
+			bundle:     true,
+		}
+		if list.NextUpdate.After(list.ThisUpdate) {
+			crl.nextUpdate = list.NextUpdate
+		}
+		loaded[path] = crl
+	}
+
+	InitCrlCache()
+	crlMu.Lock()
+	defer crlMu.Unlock()
+	for key, crl := range CrlCache {
+		if crl.bundle {
+			delete(CrlCache, key)
+		}
+	}
+	for key, crl := range loaded {
+		CrlCache[key] = crl
+	}
+}
+
+// Returns the OCSP cache key of cert issued by issuer.
+func ocspKey(cert, issuer *x509.Certificate) string {
+	sum := sha256.Sum256(issuer.RawSubjectPublicKeyInfo)
+	return hex.EncodeToString(sum[:8]) + "/" + cert.SerialNumber.String()
+}
+
+// refreshOcsp queries the OCSP responder of cert and caches the response.
+// The certificate is cached even if the query fails, for the refresher to
+// query again.
+func refreshOcsp(key string, cert, issuer *x509.Certificate) error {
+	common_utils.IncCounter(common_utils.OCSP_QUERY)
+	ocspMu.Lock()
+	entry, ok := ocspCache[key]
+	if !ok {
+		entry = &ocspEntry{cert: cert, issuer: issuer, lastUsed: time.Now()}
+		ocspCache[key] = entry
+	}
+	ocspMu.Unlock()
+
+	err := func() error {
+		if len(cert.OCSPServer) == 0 {
+			return fmt.Errorf("no OCSP responder")
+		}
+		request, err := ocsp.CreateRequest(cert, issuer, nil)
+		if err != nil {
+			return err
+		}
+		raw, err := fetchOcspResponse(cert.OCSPServer[0], request)
+		if err != nil {
+			return err
+		}
+		resp, err := ocsp.ParseResponseForCert(raw, cert, issuer)
+		if err != nil {
+			return err
+		}
+
+		ocspMu.Lock()
+		defer ocspMu.Unlock()
+		entry.server = cert.OCSPServer[0]
+		entry.status = resp.Status
+		entry.raw = raw
+		entry.thisUpdate = resp.ThisUpdate
+		entry.nextUpdate = resp.NextUpdate
+		entry.fetched = time.Now()
+		return nil
+	}()
+	if err != nil {
+		glog.Infof("OCSP query for certificate %s failed: %v", cert.SerialNumber, err)
+		common_utils.IncCounter(common_utils.OCSP_QUERY_FAIL)
+	}
+	setRevocationError(key, err)
+	return err
+}
+
+// Returns the unexpired cached OCSP response for key, marking it used.
+func lookupOcsp(key string) *ocspEntry {
+	ocspMu.Lock()
+	defer ocspMu.Unlock()
+	entry, ok := ocspCache[key]
+	if !ok || entry.raw == nil || time.Now().After(entry.validUntil()) {
+		return nil
+	}
+	entry.lastUsed = time.Now()
+	return entry
+}
+
+// VerifyCertOcsp checks the verified client certificate against the OCSP
+// responder of its issuer. Certificates without a responder are accepted,
+// while certificates without a response are rejected, as with CRLs.
+func VerifyCertOcsp(tlsConnState tls.ConnectionState) error {
+	if len(tlsConnState.VerifiedChains) == 0 || len(tlsConnState.VerifiedChains[0]) < 2 {
+		return nil
+	}
+	cert, issuer := tlsConnState.VerifiedChains[0][0], tlsConnState.VerifiedChains[0][1]
+	if len(cert.OCSPServer) == 0 {
+		glog.V(2).Infof("Cert does not contain an OCSP responder")
+		return nil
+	}
+	common_utils.IncCounter(common_utils.REVOCATION_CHECK)
+
+	key := ocspKey(cert, issuer)
+	entry := lookupOcsp(key)
+	if entry == nil {
+		waitFetches([]<-chan struct{}{fetchOnce(key, func() { refreshOcsp(key, cert, issuer) })})
+		entry = lookupOcsp(key)
+	}
+	if entry == nil {
+		common_utils.IncCounter(common_utils.REVOCATION_UNDETERMINED)
+		return status.Errorf(codes.Unauthenticated, "Can't get OCSP response and verify cert")
+	}
+
+	switch entry.status {
+	case ocsp.Good:
+		return nil
+	case ocsp.Revoked:
+		glog.Infof("VerifyCertOcsp peer certificate %s revoked", cert.SerialNumber)
+		common_utils.IncCounter(common_utils.REVOCATION_REVOKED)
+		return status.Error(codes.Unauthenticated, "Peer certificate revoked")
+	default:
+		common_utils.IncCounter(common_utils.REVOCATION_UNDETERMINED)
+		return status.Error(codes.Unauthenticated, "Peer certificate unknown to OCSP responder")
+	}
+}
+
+// StapleOCSP returns a GetCertificate function serving cert with the cached
+// OCSP response of its leaf stapled, once the response has been fetched.
+// Needs cert to carry its issuer as the second certificate of the chain.
+func StapleOCSP(cert tls.Certificate) func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	serve := func(*tls.ClientHelloInfo) (*tls.Certificate, error) { return &cert, nil }
+	if len(cert.Certificate) < 2 {
+		glog.Warningf("Server certificate chain has no issuer, not stapling OCSP responses")
+		return serve
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err == nil && len(leaf.OCSPServer) == 0 {
+		err = fmt.Errorf("no OCSP responder")
+	}
+	var issuer *x509.Certificate
+	if err == nil {
+		issuer, err = x509.ParseCertificate(cert.Certificate[1])
+	}
+	if err != nil {
+		glog.Warningf("Not stapling OCSP responses for the server certificate: %v", err)
+		return serve
+	}
+
+	key := ocspKey(leaf, issuer)
+	fetchOnce(key, func() { refreshOcsp(key, leaf, issuer) })
+	return func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+		entry := lookupOcsp(key)
+		if entry == nil || entry.status != ocsp.Good {
+			return &cert, nil
+		}
+		stapled := cert
+		stapled.OCSPStaple = entry.raw
+		return &stapled, nil
+	}
+}
+
+// refreshRevocation reloads the CRL bundle in bundleDir, if any, and refreshes
+// the CRLs and OCSP responses due.
+func refreshRevocation(bundleDir string) {
+	now := time.Now()
+	if bundleDir != "" {
+		loadCrlBundle(bundleDir)
+	}
+
+	RemoveExpiredCrl()
+	crlMu.RLock()
+	var urls []string
+	for url, crl := range CrlCache {
+		if !crl.bundle && crlRefreshDue(crl, now) {
+			urls = append(urls, url)
+		}
+	}
+	crlMu.RUnlock()
+	for _, url := range urls {
+		refreshCrl(url)
+	}
+
+	ocspMu.Lock()
+	due := map[string]*ocspEntry{}
+	for key, entry := range ocspCache {
+		if now.Sub(entry.lastUsed) > ocspIdleDuration {
+			delete(ocspCache, key)
+			continue
+		}
+		if entry.raw == nil || !now.Before(entry.fetched.Add(entry.validUntil().Sub(entry.fetched)*3/4)) {
+			due[key] = entry
+		}
+	}
+	ocspMu.Unlock()
+	for key, entry := range due {
+		key, cert, issuer := key, entry.cert, entry.issuer
+		fetchOnce(key, func() { refreshOcsp(key, cert, issuer) })
+	}
+}
+
+// revocationStatus returns the state of the cached CRLs and OCSP responses,
+// by CRL URL or bundle path and by OCSP key.
+func revocationStatus() map[string]map[string]string {
+	statuses := map[string]map[string]string{}
+	crlMu.RLock()
+	for key, crl := range CrlCache {
+		fields := map[string]string{
+			"type":        "crl",
+			"issuer":      crl.issuer,
+			"cached":      crl.thisUpdate.UTC().Format(time.RFC3339),
+			"next_update": crl.nextUpdate.UTC().Format(time.RFC3339),
+		}
+		if crl.bundle {
+			fields["type"] = "crl_bundle"
+			delete(fields, "cached")
+			fields["this_update"] = crl.thisUpdate.UTC().Format(time.RFC3339)
+		}
+		statuses[key] = fields
+	}
+	crlMu.RUnlock()
+
+	ocspMu.RLock()
+	for key, entry := range ocspCache {
+		fields := map[string]string{
+			"type":    "ocsp",
+			"subject": entry.cert.Subject.String(),
+			"server":  entry.server,
+			"status":  "pending",
+		}
+		if entry.raw != nil {
+			fields["status"] = map[int]string{ocsp.Good: "good", ocsp.Revoked: "revoked"}[entry.status]
+			if fields["status"] == "" {
+				fields["status"] = "unknown"
+			}
+			fields["cached"] = entry.fetched.UTC().Format(time.RFC3339)
+			fields["next_update"] = entry.validUntil().UTC().Format(time.RFC3339)
+		}
+		statuses[key] = fields
+	}
+	ocspMu.RUnlock()
+
+	for key, fields := range statuses {
+		if err := getRevocationError(key); err != "" {
+			fields["error"] = err
+		}
+	}
+	// CRLs which failed to download are not cached
+	fetchMu.Lock()
+	for key, err := range revocationErrors {
+		if _, ok := statuses[key]; !ok {
+			statuses[key] = map[string]string{"type": "crl", "error": err}
+		}
+	}
+	fetchMu.Unlock()
+	return statuses
+}
+
+// publishRevocationStatus mirrors the revocation status in STATE_DB.
+func publishRevocationStatus() error {
+	client, err := getRevocationStateDbClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	keys, err := client.Keys(ctx, revocationStatusTable+"|*").Result()
+	if err != nil {
+		return err
+	}
+	statuses := revocationStatus()
+	pipe := client.TxPipeline()
+	for _, key := range keys {
+		pipe.Del(ctx, key)
+	}
+	for source, fields := range statuses {
+		pipe.HSet(ctx, revocationStatusTable+"|"+source, fields)
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// StartRevocationRefresher refreshes the cached CRLs and OCSP responses, and
+// the CRL bundle in bundleDir if not empty, every refresh interval until the
+// returned function is called.
+func StartRevocationRefresher(bundleDir string) (stop func()) {
+	refresh := func() {
+		refreshRevocation(bundleDir)
+		if err := publishRevocationStatus(); err != nil {
+			glog.V(1).Infof("Failed to publish revocation status: %v", err)
+		}
+	}
+	refresh()
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(revocationRefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				refresh()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			wg.Wait()
+		})
+	}
+}
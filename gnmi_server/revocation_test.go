@@ -0,0 +1,337 @@
+package gnmi
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/crypto/ocsp"
+)
+
+func chainState(chain ...*x509.Certificate) tls.ConnectionState {
+	return tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{chain}}
+}
+
+// resetRevocation clears the revocation caches and restores the DI vars at
+// the end of the test.
+func resetRevocation(t *testing.T) {
+	originalWait, originalFetch := revocationFetchWait, fetchOcspResponse
+	originalClient, originalExpire := getRevocationStateDbClient, GetCrlExpireDuration()
+	SetCrlExpireDuration(DEFAULT_CRL_EXPIRE_DURATION)
+	clear := func() {
+		ReleaseCrlCache()
+		ocspMu.Lock()
+		ocspCache = map[string]*ocspEntry{}
+		ocspMu.Unlock()
+		fetchMu.Lock()
+		revocationErrors = map[string]string{}
+		fetchMu.Unlock()
+	}
+	clear()
+	InitCrlCache()
+	t.Cleanup(func() {
+		clear()
+		revocationFetchWait, fetchOcspResponse = originalWait, originalFetch
+		getRevocationStateDbClient = originalClient
+		SetCrlExpireDuration(originalExpire)
+	})
+}
+
+func TestCrlBundle(t *testing.T) {
+	resetRevocation(t)
+	ca := newTestCA(t, "revocation test CA")
+	revoked, unrevoked := ca.issueRevocable(t, 10, "", ""), ca.issueRevocable(t, 11, "", "")
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "ca.crl"), ca.crl(t, time.Now().Add(time.Hour), 10), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "README"), []byte("not a CRL"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	loadCrlBundle(dir)
+
+	if found, current := crlBundleStatus(revoked, time.Now()); !found || !current {
+		t.Fatalf("Expected the bundle to have a current CRL of the issuer")
+	}
+	if err := VerifyCertCrl(chainState(revoked, ca.cert)); err == nil {
+		t.Errorf("Expected the revoked cert without distribution points to be rejected by the bundle")
+	}
+	if err := VerifyCertCrl(chainState(unrevoked, ca.cert)); err != nil {
+		t.Errorf("Expected the unrevoked cert to be accepted, got %v", err)
+	}
+
+	os.Remove(filepath.Join(dir, "ca.crl"))
+	loadCrlBundle(dir)
+	if found, _ := crlBundleStatus(revoked, time.Now()); found {
+		t.Errorf("Expected the removed CRL to be dropped")
+	}
+	if err := VerifyCertCrl(chainState(revoked, ca.cert)); err != nil {
+		t.Errorf("Expected a cert without CRL to be accepted, got %v", err)
+	}
+}
+
+func TestCrlBundleValidity(t *testing.T) {
+	resetRevocation(t)
+	ca := newTestCA(t, "revocation test CA")
+	unrevoked := ca.issueRevocable(t, 11, "", "")
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "ca.crl")
+	if err := os.WriteFile(path, ca.crl(t, time.Now().Add(time.Hour)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	loadCrlBundle(dir)
+
+	// The validity is taken from the CRL, and kept on reload
+	crlMu.RLock()
+	crl := CrlCache[path]
+	crlMu.RUnlock()
+	list, _ := parseCrl(crl.crl)
+	if !crl.thisUpdate.Equal(list.ThisUpdate) || !crl.nextUpdate.Equal(list.NextUpdate) {
+		t.Errorf("Expected validity %v-%v of the CRL, got %v-%v", list.ThisUpdate, list.NextUpdate, crl.thisUpdate, crl.nextUpdate)
+	}
+	loadCrlBundle(dir)
+	crlMu.RLock()
+	reloaded := CrlCache[path]
+	crlMu.RUnlock()
+	if !reloaded.thisUpdate.Equal(crl.thisUpdate) {
+		t.Errorf("Expected this update %v after reload, got %v", crl.thisUpdate, reloaded.thisUpdate)
+	}
+
+	// Bundle CRLs do not expire from the cache
+	SetCrlExpireDuration(0)
+	if CrlExpired(reloaded) {
+		t.Errorf("Expected the bundle CRL not to expire")
+	}
+	if err := VerifyCertCrl(chainState(unrevoked, ca.cert)); err != nil {
+		t.Errorf("Expected the unrevoked cert to be accepted, got %v", err)
+	}
+
+	// Past its next update, the revocation status is undetermined
+	if found, current := crlBundleStatus(unrevoked, time.Now().Add(2*time.Hour)); !found || current {
+		t.Errorf("Expected the bundle CRL to be past its next update")
+	}
+	if err := os.WriteFile(path, ca.crl(t, time.Now().Add(-time.Second)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	loadCrlBundle(dir)
+	if err := VerifyCertCrl(chainState(unrevoked, ca.cert)); err == nil {
+		t.Errorf("Expected the cert to be rejected with a stale bundle CRL")
+	}
+}
+
+func TestDownloadNotCachedCrlDoesNotBlock(t *testing.T) {
+	resetRevocation(t)
+	revocationFetchWait = 50 * time.Millisecond
+	ca := newTestCA(t, "revocation test CA")
+	rawCRL := ca.crl(t, time.Now().Add(time.Hour), 10)
+
+	var requests int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		<-release
+		w.Write(rawCRL)
+	}))
+	defer srv.Close()
+
+	start := time.Now()
+	if DownloadNotCachedCrl([]string{srv.URL}) {
+		t.Errorf("Expected the CRL to be unavailable while the server is slow")
+	}
+	if DownloadNotCachedCrl([]string{srv.URL}) {
+		t.Errorf("Expected the CRL to be unavailable while the server is slow")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected bounded waits, took %v", elapsed)
+	}
+
+	done := refreshCrl(srv.URL)
+	close(release)
+	<-done
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Errorf("Expected a single download, got %d", n)
+	}
+	if !DownloadNotCachedCrl([]string{srv.URL}) {
+		t.Errorf("Expected the CRL downloaded in the background to be available")
+	}
+	if err := VerifyCertCrl(chainState(ca.issueRevocable(t, 10, srv.URL, ""), ca.cert)); err == nil {
+		t.Errorf("Expected the revoked cert to be rejected")
+	}
+}
+
+func TestSearchCrlCacheRefreshesInBackground(t *testing.T) {
+	resetRevocation(t)
+	ca := newTestCA(t, "revocation test CA")
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Write(ca.crl(t, time.Now().Add(time.Hour)))
+	}))
+	defer srv.Close()
+
+	stale := newCrl(ca.crl(t, time.Now().Add(-30*time.Second)))
+	stale.thisUpdate = time.Now().Add(-2 * revocationRefreshInterval)
+	crlMu.Lock()
+	CrlCache[srv.URL] = stale
+	crlMu.Unlock()
+
+	exist, crl := SearchCrlCache(srv.URL)
+	if !exist || crl != stale {
+		t.Fatalf("Expected the stale CRL to be used while refreshing")
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for crl == stale && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		_, crl = SearchCrlCache(srv.URL)
+	}
+	if crl == stale || atomic.LoadInt32(&requests) != 1 {
+		t.Errorf("Expected the CRL to be refreshed once, got %d downloads", atomic.LoadInt32(&requests))
+	}
+}
+
+func TestCrlRefreshDue(t *testing.T) {
+	now := time.Now()
+	crl := &Crl{thisUpdate: now, nextUpdate: now.Add(4 * time.Hour)}
+	if crlRefreshDue(crl, now.Add(2*time.Hour)) || !crlRefreshDue(crl, now.Add(3*time.Hour)) {
+		t.Errorf("Expected a refresh after three quarters of the validity")
+	}
+	crl = &Crl{thisUpdate: now, nextUpdate: now}
+	if crlRefreshDue(crl, now) || !crlRefreshDue(crl, now.Add(revocationRefreshInterval)) {
+		t.Errorf("Expected a refresh every refresh interval without a next update")
+	}
+}
+
+func TestVerifyCertOcsp(t *testing.T) {
+	resetRevocation(t)
+	ca := newTestCA(t, "revocation test CA")
+	good, revoked := ca.issueRevocable(t, 20, "", "http://ocsp.example"), ca.issueRevocable(t, 21, "", "http://ocsp.example")
+
+	queries := 0
+	fetchOcspResponse = func(server string, request []byte) ([]byte, error) {
+		queries++
+		req, err := ocsp.ParseRequest(request)
+		if err != nil || server != "http://ocsp.example" {
+			t.Errorf("Unexpected request to %s: %v", server, err)
+		}
+		if req.SerialNumber.Cmp(revoked.SerialNumber) == 0 {
+			return ca.ocspResponse(t, revoked, ocsp.Revoked), nil
+		}
+		return ca.ocspResponse(t, good, ocsp.Good), nil
+	}
+
+	if err := VerifyCertOcsp(chainState(good, ca.cert)); err != nil {
+		t.Errorf("Expected the good cert to be accepted, got %v", err)
+	}
+	if err := VerifyCertOcsp(chainState(good, ca.cert)); err != nil || queries != 1 {
+		t.Errorf("Expected the cached response to be used, got %v after %d queries", err, queries)
+	}
+	if err := VerifyCertOcsp(chainState(revoked, ca.cert)); err == nil {
+		t.Errorf("Expected the revoked cert to be rejected")
+	}
+	if err := VerifyCertOcsp(chainState(ca.issueRevocable(t, 22, "", ""), ca.cert)); err != nil {
+		t.Errorf("Expected a cert without responder to be accepted, got %v", err)
+	}
+
+	fetchOcspResponse = func(string, []byte) ([]byte, error) {
+		return nil, os.ErrDeadlineExceeded
+	}
+	unavailable := ca.issueRevocable(t, 23, "", "http://ocsp.example")
+	if err := VerifyCertOcsp(chainState(unavailable, ca.cert)); err == nil {
+		t.Errorf("Expected a cert without response to be rejected")
+	}
+	if getRevocationError(ocspKey(unavailable, ca.cert)) == "" {
+		t.Errorf("Expected the query error to be recorded")
+	}
+}
+
+func TestStapleOCSP(t *testing.T) {
+	resetRevocation(t)
+	ca := newTestCA(t, "revocation test CA")
+	leaf := ca.issueRevocable(t, 30, "", "http://ocsp.example")
+	response := ca.ocspResponse(t, leaf, ocsp.Good)
+	fetchOcspResponse = func(string, []byte) ([]byte, error) {
+		return response, nil
+	}
+
+	cert := tls.Certificate{Certificate: [][]byte{leaf.Raw, ca.cert.Raw}}
+	getCertificate := StapleOCSP(cert)
+	<-fetchOnce(ocspKey(leaf, ca.cert), func() {})
+	served, err := getCertificate(nil)
+	if err != nil || string(served.OCSPStaple) != string(response) {
+		t.Errorf("Expected the OCSP response to be stapled, err %v", err)
+	}
+
+	// Without the issuer in the chain nothing is stapled
+	served, _ = StapleOCSP(tls.Certificate{Certificate: [][]byte{leaf.Raw}})(nil)
+	if served.OCSPStaple != nil {
+		t.Errorf("Expected no staple without the issuer")
+	}
+}
+
+func TestRefreshRevocationPublishesStatus(t *testing.T) {
+	resetRevocation(t)
+	mr := miniredis.RunT(t)
+	addr := mr.Addr()
+	getRevocationStateDbClient = func() (*redis.Client, error) {
+		return redis.NewClient(&redis.Options{Addr: addr}), nil
+	}
+	mr.HSet(revocationStatusTable+"|gone", "type", "crl")
+
+	ca := newTestCA(t, "revocation test CA")
+	dir := t.TempDir()
+	bundlePath := filepath.Join(dir, "ca.crl")
+	os.WriteFile(bundlePath, ca.crl(t, time.Now().Add(time.Hour)), 0644)
+
+	leaf := ca.issueRevocable(t, 40, "", "http://ocsp.example")
+	key := ocspKey(leaf, ca.cert)
+	queries := int32(0)
+	fetchOcspResponse = func(string, []byte) ([]byte, error) {
+		atomic.AddInt32(&queries, 1)
+		return ca.ocspResponse(t, leaf, ocsp.Good), nil
+	}
+	if err := refreshOcsp(key, leaf, ca.cert); err != nil {
+		t.Fatalf("Expected success, got error: %v", err)
+	}
+	// Due for refresh
+	ocspMu.Lock()
+	ocspCache[key].fetched = time.Now().Add(-2 * time.Hour)
+	ocspCache[key].nextUpdate = time.Now().Add(time.Minute)
+	ocspMu.Unlock()
+
+	refreshRevocation(dir)
+	<-fetchOnce(key, func() {})
+	if atomic.LoadInt32(&queries) != 2 {
+		t.Errorf("Expected the due OCSP response to be refreshed, got %d queries", queries)
+	}
+	if err := publishRevocationStatus(); err != nil {
+		t.Fatalf("Expected success, got error: %v", err)
+	}
+	if mr.Exists(revocationStatusTable + "|gone") {
+		t.Errorf("Expected stale status to be removed")
+	}
+	if got := mr.HGet(revocationStatusTable+"|"+bundlePath, "type"); got != "crl_bundle" {
+		t.Errorf("Expected bundle CRL status, got %q", got)
+	}
+	if got := mr.HGet(revocationStatusTable+"|"+key, "status"); got != "good" {
+		t.Errorf("Expected good OCSP status, got %q", got)
+	}
+
+	// Unused responses are dropped
+	ocspMu.Lock()
+	ocspCache[key].lastUsed = time.Now().Add(-2 * ocspIdleDuration)
+	ocspMu.Unlock()
+	refreshRevocation("")
+	if lookupOcsp(key) != nil {
+		t.Errorf("Expected the idle OCSP response to be dropped")
+	}
+}
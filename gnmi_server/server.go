@@ -19,7 +19,9 @@ import (
 	"github.com/Azure/sonic-mgmt-common/translib"
 	gnsi_pathz_pb "github.com/openconfig/gnsi/pathz"
 	"github.com/sonic-net/sonic-gnmi/common_utils"
+	"github.com/sonic-net/sonic-gnmi/pkg/audit"
 	"github.com/sonic-net/sonic-gnmi/pkg/bypass"
+	"github.com/sonic-net/sonic-gnmi/pkg/commitconfirm"
 	operationalhandler "github.com/sonic-net/sonic-gnmi/pkg/server/operational-handler"
 	spb "github.com/sonic-net/sonic-gnmi/proto"
 	spb_gnoi "github.com/sonic-net/sonic-gnmi/proto/gnoi"
@@ -42,7 +44,6 @@ import (
 	gnoi_os_pb "github.com/openconfig/gnoi/os"
 	gnsi_authz_pb "github.com/openconfig/gnsi/authz"
 	gnsi_certz_pb "github.com/openconfig/gnsi/certz"
-	gnoi_debug "github.com/sonic-net/sonic-gnmi/pkg/gnoi/debug"
 	gnoi_debug_pb "github.com/sonic-net/sonic-gnmi/proto/gnoi/debug"
 	testcert "github.com/sonic-net/sonic-gnmi/testdata/tls"
 	"google.golang.org/grpc"
@@ -97,6 +98,8 @@ type Server struct {
 	gnsiAuthz         *GNSIAuthzServer
 	gnsiPathz         *GNSIPathzServer
 	ConnectionManager *ConnectionManager
+	// commitConfirm tracks the pending commit-confirmed Set request.
+	commitConfirm *commitconfirm.Manager
 }
 
 // handleOperationalGet handles OPERATIONAL target requests directly with standard gNMI types
@@ -113,6 +116,11 @@ func (s *Server) handleOperationalGet(ctx context.Context, req *gnmipb.GetReques
 		return nil, err
 	}
 
+	if err := checkGetDataType(req.GetType(), "OPERATIONAL"); err != nil {
+		common_utils.IncCounter(common_utils.GNMI_GET_FAIL)
+		return nil, err
+	}
+
 	// Create operational handler
 	operationalHandler, err := operationalhandler.NewOperationalHandler(paths, prefix)
 	if err != nil {
@@ -179,9 +187,8 @@ type ContainerzServer struct {
 // DebugServer is the server API for Debug service.
 type DebugServer struct {
 	*Server
-	readWhitelist  []string
-	writeWhitelist []string
 	gnoi_debug_pb.UnimplementedDebugServer
+	spb_gnoi.UnimplementedDebugShellServer
 }
 
 // HealthzServer is the server API for System Health service.
@@ -233,6 +240,8 @@ type Config struct {
 	PathzPolicy     bool   // Enable gNMI pathz policy.
 	PathzPolicyFile string // Path to gNMI pathz policy file.
 	PathzMetaFile   string // Path to JSON file with pathz metadata.
+	// Keep recent events in memory for EVENTS Get, ONCE and POLL requests.
+	EnableEventsCache bool
 }
 
 // DBusOSBackend is a concrete implementation of OSBackend
@@ -336,6 +345,7 @@ func registerAllServices(s *grpc.Server, srv *Server, fileSrv *FileServer,
 		gnoi_os_pb.RegisterOSServer(s, osSrv)
 		gnoi_containerz_pb.RegisterContainerzServer(s, containerzSrv)
 		gnoi_debug_pb.RegisterDebugServer(s, debugSrv)
+		spb_gnoi.RegisterDebugShellServer(s, debugSrv)
 		gnoi_healthz_pb.RegisterHealthzServer(s, healthzSrv)
 	}
 	if srv.config.EnableTranslibWrite {
@@ -425,7 +435,11 @@ func SrvAdvConfig(cfg *Config) ([]grpc.ServerOption, []certprovider.Provider, er
 
 	serverOption := &advancedtls.Options{
 		IdentityOptions: identityOptions,
+		// Check the client against the certz authentication policy for every new connection.
 		AdditionalPeerVerification: func(params *advancedtls.HandshakeVerificationInfo) (*advancedtls.PostHandshakeVerificationResults, error) {
+			if err := verifyAuthPolicy(cfg.FedPolicyFile, params.VerifiedChains); err != nil {
+				return nil, err
+			}
 			return &advancedtls.PostHandshakeVerificationResults{}, nil
 		},
 		RequireClientCert: false,
@@ -500,9 +514,9 @@ func NewServer(config *Config, tlsOpts []grpc.ServerOption, commonOpts []grpc.Se
 			return nil, err
 		} else {
 			commonOpts = append(commonOpts, grpc.ChainStreamInterceptor(
-				authzWatcher.StreamInterceptor))
+				certIdentityStreamInterceptor(authzWatcher.StreamInterceptor)))
 			commonOpts = append(commonOpts, grpc.ChainUnaryInterceptor(
-				authzWatcher.UnaryInterceptor))
+				certIdentityUnaryInterceptor(authzWatcher.UnaryInterceptor)))
 		}
 	}
 
@@ -519,6 +533,7 @@ func NewServer(config *Config, tlsOpts []grpc.ServerOption, commonOpts []grpc.Se
 		ReqFromMaster: ReqFromMasterDisabledMA,
 		masterEID:     uint128{High: 0, Low: 0},
 		authzWatcher:  authzWatcher,
+		commitConfirm: getCommitManager(),
 	}
 
 	// Create service servers (shared between TCP and UDS)
@@ -535,12 +550,7 @@ func NewServer(config *Config, tlsOpts []grpc.ServerOption, commonOpts []grpc.Se
 	srv.gnsiAuthz = authzSrv
 	pathzSrv := NewGNSIPathzServer(srv)
 	srv.gnsiPathz = pathzSrv
-	readWhitelist, writeWhitelist := gnoi_debug.ConstructWhitelists()
-	debugSrv := &DebugServer{
-		Server:         srv,
-		readWhitelist:  readWhitelist,
-		writeWhitelist: writeWhitelist,
-	}
+	debugSrv := &DebugServer{Server: srv}
 	certzSrv := NewGNSICertzServer(srv)
 	srv.gnsiCertz = certzSrv
 
@@ -701,11 +711,18 @@ func authenticate(config *Config, ctx context.Context, target string, writeAcces
 	success := false
 	rc, ctx := common_utils.GetContext(ctx)
 
+	// Record the caller in the audit log of the RPC
+	authMethod := ""
+	defer func() {
+		audit.SetAuth(ctx, rc.Auth.User, rc.Auth.Roles, authMethod)
+	}()
+
 	// Skip authentication for UDS (Unix Domain Socket) connections.
 	// UDS security is enforced at the file-system level via socket permissions.
 	if pr, ok := peer.FromContext(ctx); ok && pr.Addr != nil {
 		if _, isUnix := pr.Addr.(*net.UnixAddr); isUnix {
 			rc.Auth.AuthEnabled = false
+			authMethod = "uds"
 			return ctx, nil
 		}
 	}
@@ -713,6 +730,7 @@ func authenticate(config *Config, ctx context.Context, target string, writeAcces
 	if !config.UserAuth.Any() {
 		//No Auth enabled
 		rc.Auth.AuthEnabled = false
+		authMethod = "none"
 		return ctx, nil
 	}
 
@@ -721,18 +739,21 @@ func authenticate(config *Config, ctx context.Context, target string, writeAcces
 		ctx, err = BasicAuthenAndAuthor(ctx)
 		if err == nil {
 			success = true
+			authMethod = "password"
 		}
 	}
 	if !success && config.UserAuth.Enabled("jwt") {
 		_, ctx, err = JwtAuthenAndAuthor(ctx)
 		if err == nil {
 			success = true
+			authMethod = "jwt"
 		}
 	}
 	if !success && config.UserAuth.Enabled("cert") {
 		ctx, err = ClientCertAuthenAndAuthor(ctx, config.ConfigTableName, config.EnableCrl)
 		if err == nil {
 			success = true
+			authMethod = "cert"
 		}
 		// role must be readwrite to support write access
 		if success && config.ConfigTableName != "" {
@@ -864,9 +885,10 @@ func IsNativeOrigin(origin string) bool {
 func (s *Server) Get(ctx context.Context, req *gnmipb.GetRequest) (*gnmipb.GetResponse, error) {
 	common_utils.IncCounter(common_utils.GNMI_GET)
 
-	if req.GetType() != gnmipb.GetRequest_ALL {
+	dataType := req.GetType()
+	if _, ok := gnmipb.GetRequest_DataType_name[int32(dataType)]; !ok {
 		common_utils.IncCounter(common_utils.GNMI_GET_FAIL)
-		return nil, status.Errorf(codes.Unimplemented, "unsupported request type: %s", gnmipb.GetRequest_DataType_name[int32(req.GetType())])
+		return nil, status.Errorf(codes.InvalidArgument, "unsupported request type: %d", dataType)
 	}
 	// gNMI path based authorization
 	if s.config.PathzPolicy && len(req.GetPath()) != 0 {
@@ -911,6 +933,8 @@ func (s *Server) Get(ctx context.Context, req *gnmipb.GetRequest) (*gnmipb.GetRe
 		return s.handleOperationalGet(ctx, req, paths, prefix)
 	}
 
+	// Native targets only hold data of some types; translib filters by itself
+	dataTypeTarget := target
 	authTarget := "gnmi"
 	if target == "OTHERS" {
 		dc, err = sdc.NewNonDbClient(paths, prefix)
@@ -918,9 +942,13 @@ func (s *Server) Get(ctx context.Context, req *gnmipb.GetRequest) (*gnmipb.GetRe
 	} else if target == "SHOW" {
 		dc, err = sdc.NewShowClient(paths, prefix)
 		authTarget = "gnmi_show"
+	} else if target == "EVENTS" {
+		dc, err = sdc.NewEventCacheClient(paths, prefix)
+		authTarget = "gnmi_events"
 	} else if targetDbName, ok, _, _ := sdc.IsTargetDb(target); ok {
 		dc, err = sdc.NewDbClient(paths, prefix)
 		authTarget = "gnmi_" + targetDbName
+		dataTypeTarget = targetDbName
 	} else {
 		if origin == "" {
 			origin, err = ParseOrigin(paths)
@@ -932,8 +960,10 @@ func (s *Server) Get(ctx context.Context, req *gnmipb.GetRequest) (*gnmipb.GetRe
 			var targetDbName string
 			dc, err = sdc.NewMixedDbClient(paths, prefix, origin, encoding, s.config.ZmqPort, s.config.Vrf, &targetDbName)
 			authTarget = "gnmi_" + targetDbName
+			dataTypeTarget = targetDbName
 		} else {
-			dc, err = sdc.NewTranslClient(prefix, paths, ctx, extensions)
+			dc, err = sdc.NewTranslClient(prefix, paths, ctx, extensions, sdc.TranslContentOption(translContent(dataType)))
+			dataTypeTarget = ""
 		}
 	}
 
@@ -957,6 +987,13 @@ func (s *Server) Get(ctx context.Context, req *gnmipb.GetRequest) (*gnmipb.GetRe
 		return nil, err
 	}
 
+	if dataTypeTarget != "" {
+		if err := checkGetDataType(dataType, dataTypeTarget); err != nil {
+			common_utils.IncCounter(common_utils.GNMI_GET_FAIL)
+			return nil, err
+		}
+	}
+
 	spbValues, err := dc.Get(nil)
 	if err != nil {
 		if target == "SHOW" {
@@ -1022,6 +1059,11 @@ func (s *Server) Set(ctx context.Context, req *gnmipb.SetRequest) (*gnmipb.SetRe
 		common_utils.IncCounter(common_utils.GNMI_SET_FAIL)
 		return nil, grpc.Errorf(codes.Unimplemented, "GNMI is in read-only mode")
 	}
+	unionReplace, err := getUnionReplace(req)
+	if err != nil {
+		common_utils.IncCounter(common_utils.GNMI_SET_FAIL)
+		return nil, status.Errorf(codes.InvalidArgument, "invalid union_replace: %v", err)
+	}
 	// gNMI path based authorization
 	if s.config.PathzPolicy {
 		user, err := getUsername(ctx)
@@ -1039,10 +1081,51 @@ func (s *Server) Set(ctx context.Context, req *gnmipb.SetRequest) (*gnmipb.SetRe
 		for _, update := range req.GetUpdate() {
 			s.gnsiPathz.pathzProcessor.AuthorizeWithPrefix(user, req.GetPrefix(), update.GetPath(), gnsi_pathz_pb.Mode_MODE_WRITE)
 		}
+		for _, update := range unionReplace {
+			s.gnsiPathz.pathzProcessor.AuthorizeWithPrefix(user, req.GetPrefix(), update.GetPath(), gnsi_pathz_pb.Mode_MODE_WRITE)
+		}
 		if !permitted {
 			return nil, status.Error(codes.PermissionDenied, "Unauthorized request. Rejected by pathz policy.")
 		}
 	}
+	commit, err := getCommitExt(req.GetExtension())
+	if err != nil {
+		common_utils.IncCounter(common_utils.GNMI_SET_FAIL)
+		return nil, status.Errorf(codes.InvalidArgument, "invalid commit extension: %v", err)
+	}
+	if commit != nil {
+		audit.SetAttr(ctx, "commit_id", commit.id)
+	}
+	if commit != nil && commit.action != commitActionCommit {
+		resp, err := s.commitControl(ctx, req, commit)
+		if err != nil {
+			common_utils.IncCounter(common_utils.GNMI_SET_FAIL)
+		}
+		return resp, err
+	}
+	dryRun := isDryRun(req.GetExtension())
+	if dryRun {
+		audit.SetAttr(ctx, "mode", "dry_run")
+	}
+	if commit != nil && (dryRun || len(unionReplace) != 0) {
+		common_utils.IncCounter(common_utils.GNMI_SET_FAIL)
+		return nil, status.Error(codes.Unimplemented, "commit confirmed does not support dry-run or union_replace")
+	}
+	if len(unionReplace) != 0 {
+		if dryRun {
+			common_utils.IncCounter(common_utils.GNMI_SET_FAIL)
+			return nil, status.Error(codes.Unimplemented, "Set dry-run does not support union_replace")
+		}
+		audit.SetAttr(ctx, "mode", "union_replace")
+		for _, update := range unionReplace {
+			audit.AddTargets(ctx, "union_replace "+audit.PathString(req.GetPrefix(), update.GetPath()))
+		}
+		resp, err := s.setUnionReplace(ctx, req, unionReplace)
+		if err != nil {
+			common_utils.IncCounter(common_utils.GNMI_SET_FAIL)
+		}
+		return resp, err
+	}
 	var results []*gnmipb.UpdateResult
 
 	/* Fetch the prefix. */
@@ -1055,7 +1138,6 @@ func (s *Server) Set(ctx context.Context, req *gnmipb.SetRequest) (*gnmipb.SetRe
 	encoding := gnmipb.Encoding_JSON_IETF
 
 	var dc sdc.Client
-	var err error
 	paths := req.GetDelete()
 	for _, path := range req.GetReplace() {
 		paths = append(paths, path.GetPath())
@@ -1077,24 +1159,41 @@ func (s *Server) Set(ctx context.Context, req *gnmipb.SetRequest) (*gnmipb.SetRe
 		}
 
 		// Fast path: bypass validation for allowed tables/SKUs
-		allUpdates := append(req.GetReplace(), req.GetUpdate()...)
-		if resp, used, err := bypass.TrySet(ctx, prefix, req.GetDelete(), allUpdates); used {
-			if err != nil {
-				common_utils.IncCounter(common_utils.GNMI_SET_FAIL)
-				return nil, status.Error(codes.Internal, err.Error())
+		// A dry-run needs the validation, so it never takes the fast path,
+		// and neither does a commit, which needs its checkpoint
+		if !dryRun && commit == nil {
+			allUpdates := append(req.GetReplace(), req.GetUpdate()...)
+			if resp, used, err := bypass.TrySet(ctx, prefix, req.GetDelete(), allUpdates); used {
+				audit.SetAttr(ctx, "mode", "bypass")
+				if err != nil {
+					common_utils.IncCounter(common_utils.GNMI_SET_FAIL)
+					if errors.Is(err, bypass.ErrInvalidValue) {
+						return nil, status.Error(codes.InvalidArgument, err.Error())
+					}
+					return nil, status.Error(codes.Internal, err.Error())
+				}
+				common_utils.IncCounter(common_utils.GNMI_SET_BYPASS)
+				return resp, nil
 			}
-			common_utils.IncCounter(common_utils.GNMI_SET_BYPASS)
-			return resp, nil
 		}
 
 		var targetDbName string
 		dc, err = sdc.NewMixedDbClient(paths, prefix, origin, encoding, s.config.ZmqPort, s.config.Vrf, &targetDbName)
 		authTarget = "gnmi_" + targetDbName
+		if err == nil && commit != nil && targetDbName != "CONFIG_DB" {
+			dc.Close()
+			common_utils.IncCounter(common_utils.GNMI_SET_FAIL)
+			return nil, status.Errorf(codes.Unimplemented, "commit confirmed does not support %s", targetDbName)
+		}
 	} else {
 		if s.config.EnableTranslibWrite == false {
 			common_utils.IncCounter(common_utils.GNMI_SET_FAIL)
 			return nil, grpc.Errorf(codes.Unimplemented, "Translib write is disabled")
 		}
+		if dryRun {
+			common_utils.IncCounter(common_utils.GNMI_SET_FAIL)
+			return nil, status.Error(codes.Unimplemented, "Set dry-run is only supported for sonic-db origin")
+		}
 		/* Create Transl client. */
 		dc, err = sdc.NewTranslClient(prefix, nil, ctx, extensions)
 	}
@@ -1146,9 +1245,20 @@ func (s *Server) Set(ctx context.Context, req *gnmipb.SetRequest) (*gnmipb.SetRe
 		/* Add to Set response results. */
 		results = append(results, &res)
 	}
-	err = dc.Set(req.GetDelete(), req.GetReplace(), req.GetUpdate())
-	if err != nil {
+	if dryRun {
+		return s.dryRunSet(dc, req, results)
+	}
+	if commit != nil {
+		// The configuration is saved once the commit is confirmed
+		err = s.commitConfirm.Commit(commit.id, commit.rollback, func() error {
+			return dc.Set(req.GetDelete(), req.GetReplace(), req.GetUpdate())
+		})
+		if err != nil {
+			common_utils.IncCounter(common_utils.GNMI_SET_FAIL)
+		}
+	} else if err = dc.Set(req.GetDelete(), req.GetReplace(), req.GetUpdate()); err != nil {
 		common_utils.IncCounter(common_utils.GNMI_SET_FAIL)
+		err = setOpErrorStatus(err, req, results)
 	} else {
 		s.SaveStartupConfig()
 	}
@@ -1197,13 +1307,22 @@ func (s *Server) Capabilities(ctx context.Context, req *gnmipb.CapabilityRequest
 			Msg: sup_msg}}
 	exts := []*gnmi_extpb.Extension{&ext}
 
+	if wantsSchema(extensions) {
+		schemaExt, err := schemaExtension()
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		exts = append(exts, schemaExt)
+	}
+
 	return &gnmipb.CapabilityResponse{SupportedModels: suppModels,
 		SupportedEncodings: supportedEncodings,
 		GNMIVersion:        "0.7.0",
 		Extension:          exts}, nil
 }
 
-// Obtain the user name as the last element of the SPIFFE ID.
+// Obtain the user name as the last element of the SPIFFE ID, or the client
+// cert identity when identity sources are configured.
 func getUsername(ctx context.Context) (string, error) {
 	pr, ok := peer.FromContext(ctx)
 	if !ok {
@@ -1213,6 +1332,18 @@ func getUsername(ctx context.Context) (string, error) {
 	if !ok {
 		return "", grpc.Errorf(codes.Unauthenticated, "no tls info was found")
 	}
+	// Use the same identity as client cert authentication when configured
+	if sources := GetCertIdentitySources(); len(sources) > 0 {
+		cert := verifiedLeaf(tlsInfo.State)
+		if cert == nil {
+			return "", grpc.Errorf(codes.Unauthenticated, "failed to get verified client certificate")
+		}
+		username, _ := certIdentity(cert, sources)
+		if username == "" {
+			return "", status.Errorf(codes.Unauthenticated, "no %s identity in client certificate", strings.Join(sources, ", "))
+		}
+		return username, nil
+	}
 	spiffe := tlsInfo.SPIFFEID
 	if spiffe == nil {
 		return "", grpc.Errorf(codes.Unauthenticated, "failed to get SPIFFE ID")
@@ -28,6 +28,7 @@ import (
 	"unsafe"
 
 	"github.com/sonic-net/sonic-gnmi/common_utils"
+	"github.com/sonic-net/sonic-gnmi/pkg/audit"
 	spb "github.com/sonic-net/sonic-gnmi/proto"
 	sgpb "github.com/sonic-net/sonic-gnmi/proto/gnoi"
 	spb_jwt "github.com/sonic-net/sonic-gnmi/proto/gnoi/jwt"
@@ -6385,6 +6386,17 @@ func TestAuthenticate(t *testing.T) {
 		t.Errorf("authenticate with readwrite role should pass: %v", err)
 	}
 
+	// Writing STATE_DB requires the gnmi_state_db_readwrite role
+	_, err = authenticate(cfg, ctx, "gnmi_STATE_DB", true)
+	if err == nil {
+		t.Errorf("authenticate STATE_DB write with readwrite role should fail: %v", err)
+	}
+	gnmiTable.Hset("certname1", "role@", "sonic_linux,gnmi_state_db_readwrite,linux_sonic")
+	_, err = authenticate(cfg, ctx, "gnmi_STATE_DB", true)
+	if err != nil {
+		t.Errorf("authenticate STATE_DB write with state_db readwrite role should pass: %v", err)
+	}
+
 	gnmiTable.Hset("certname1", "role@", "sonic_linux,linux_sonic")
 	// Call authenticate to verify the user's role. This should faile if the role is empty.
 	_, err = authenticate(cfg, ctx, "gnmi", true)
@@ -6424,6 +6436,51 @@ func TestAuthenticateUDS(t *testing.T) {
 	}
 }
 
+func TestAuthenticateAudit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	logger, err := audit.NewLogger(audit.Config{File: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	audit.SetDefaultLogger(logger)
+	defer func() {
+		audit.SetDefaultLogger(nil)
+		logger.Close()
+	}()
+
+	interceptor := audit.NewInterceptor().UnaryInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/gnoi.system.System/Reboot"}
+	authenticated := func(ctx context.Context, cfg *Config) {
+		interceptor(ctx, &gnoi_system_pb.RebootRequest{}, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return authenticate(cfg, ctx, "gnoi", true)
+		})
+	}
+	ctx, cancel := createUDSCtx()
+	defer cancel()
+	authenticated(ctx, &Config{UserAuth: AuthTypes{"password": false, "cert": true, "jwt": false}})
+	ctx = peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 1234}})
+	authenticated(ctx, &Config{UserAuth: AuthTypes{"password": false, "cert": false, "jwt": false}})
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var methods []string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var r audit.Record
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatalf("Invalid audit record %s: %v", line, err)
+		}
+		if r.RPC != info.FullMethod || r.Code != "OK" {
+			t.Errorf("Unexpected audit record %s", line)
+		}
+		methods = append(methods, r.AuthMethod)
+	}
+	if !reflect.DeepEqual(methods, []string{"uds", "none"}) {
+		t.Errorf("Unexpected authentication methods %v", methods)
+	}
+}
+
 type MockServerStream struct {
 	grpc.ServerStream
 }
@@ -0,0 +1,74 @@
+package gnmi
+
+import (
+	"encoding/json"
+
+	log "github.com/golang/glog"
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+	gnmi_extpb "github.com/openconfig/gnmi/proto/gnmi_ext"
+	"github.com/sonic-net/sonic-gnmi/common_utils"
+	spb "github.com/sonic-net/sonic-gnmi/proto"
+	sdc "github.com/sonic-net/sonic-gnmi/sonic_data_client"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Set dry-run
+//
+// A SetRequest carrying the DRY_RUN_EXT registered extension is translated and
+// validated but not applied. The SetResponse carries a DRY_RUN_EXT extension
+// whose message is the JSON encoded sdc.DryRunResult: the JSON patch GCU would
+// apply and the validation errors of the resulting configuration, if any.
+
+// isDryRun checks if the Set extensions request a dry-run.
+func isDryRun(extensions []*gnmi_extpb.Extension) bool {
+	for _, e := range extensions {
+		if v, ok := e.Ext.(*gnmi_extpb.Extension_RegisteredExt); ok && v.RegisteredExt.GetId() == spb.DRY_RUN_EXT {
+			return true
+		}
+	}
+	return false
+}
+
+// dryRunExtension encodes a dry-run result as a SetResponse extension.
+func dryRunExtension(result *sdc.DryRunResult) (*gnmi_extpb.Extension, error) {
+	msg, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	return &gnmi_extpb.Extension{
+		Ext: &gnmi_extpb.Extension_RegisteredExt{
+			RegisteredExt: &gnmi_extpb.RegisteredExtension{
+				Id:  spb.DRY_RUN_EXT,
+				Msg: msg,
+			},
+		},
+	}, nil
+}
+
+// dryRunSet validates a Set request without applying it.
+func (s *Server) dryRunSet(dc sdc.Client, req *gnmipb.SetRequest, results []*gnmipb.UpdateResult) (*gnmipb.SetResponse, error) {
+	dr, ok := dc.(sdc.DryRunClient)
+	if !ok {
+		common_utils.IncCounter(common_utils.GNMI_SET_FAIL)
+		return nil, status.Error(codes.Unimplemented, "Set dry-run is not supported for this target")
+	}
+
+	result, err := dr.DryRunSet(req.GetDelete(), req.GetReplace(), req.GetUpdate())
+	if err != nil {
+		common_utils.IncCounter(common_utils.GNMI_SET_FAIL)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	log.V(2).Infof("Set dry-run: patch %s, errors %v", result.Patch, result.Errors)
+
+	ext, err := dryRunExtension(result)
+	if err != nil {
+		common_utils.IncCounter(common_utils.GNMI_SET_FAIL)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &gnmipb.SetResponse{
+		Prefix:    req.GetPrefix(),
+		Response:  results,
+		Extension: []*gnmi_extpb.Extension{ext},
+	}, nil
+}
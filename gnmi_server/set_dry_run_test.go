@@ -0,0 +1,54 @@
+package gnmi
+
+import (
+	"encoding/json"
+	"testing"
+
+	gnmi_extpb "github.com/openconfig/gnmi/proto/gnmi_ext"
+	spb "github.com/sonic-net/sonic-gnmi/proto"
+	sdc "github.com/sonic-net/sonic-gnmi/sonic_data_client"
+)
+
+func registeredExt(id gnmi_extpb.ExtensionID) *gnmi_extpb.Extension {
+	return &gnmi_extpb.Extension{
+		Ext: &gnmi_extpb.Extension_RegisteredExt{
+			RegisteredExt: &gnmi_extpb.RegisteredExtension{Id: id},
+		},
+	}
+}
+
+func TestIsDryRun(t *testing.T) {
+	if isDryRun(nil) {
+		t.Error("Expected no dry-run without extensions")
+	}
+	if isDryRun([]*gnmi_extpb.Extension{registeredExt(spb.BUNDLE_VERSION_EXT)}) {
+		t.Error("Expected no dry-run with bundle version extension")
+	}
+	if !isDryRun([]*gnmi_extpb.Extension{registeredExt(spb.BUNDLE_VERSION_EXT), registeredExt(spb.DRY_RUN_EXT)}) {
+		t.Error("Expected dry-run with dry-run extension")
+	}
+}
+
+func TestDryRunExtension(t *testing.T) {
+	result := &sdc.DryRunResult{
+		Patch:  json.RawMessage(`[{"op":"add","path":"/VLAN/Vlan10","value":{"vlanid":"10"}}]`),
+		Errors: []string{"Yang validation failed"},
+	}
+
+	ext, err := dryRunExtension(result)
+	if err != nil {
+		t.Fatalf("dryRunExtension failed: %v", err)
+	}
+	reg := ext.GetRegisteredExt()
+	if reg.GetId() != spb.DRY_RUN_EXT {
+		t.Errorf("Expected extension id %d, got %d", spb.DRY_RUN_EXT, reg.GetId())
+	}
+
+	var got sdc.DryRunResult
+	if err := json.Unmarshal(reg.GetMsg(), &got); err != nil {
+		t.Fatalf("Failed to decode dry-run result: %v", err)
+	}
+	if string(got.Patch) != string(result.Patch) || len(got.Errors) != 1 {
+		t.Errorf("Expected %+v, got %+v", result, got)
+	}
+}
@@ -0,0 +1,52 @@
+package gnmi
+
+import (
+	"errors"
+
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+	sdc "github.com/sonic-net/sonic-gnmi/sonic_data_client"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// setOpErrorStatus converts the failure of a Set request that identifies the
+// failed operation into a gRPC status. The status details carry a SetResponse
+// whose results report the status of each operation: the failed one, the ones
+// before it, which were rolled back, and the ones after it, which were not applied.
+func setOpErrorStatus(err error, req *gnmipb.SetRequest, results []*gnmipb.UpdateResult) error {
+	var opErr *sdc.SetOpError
+	if !errors.As(err, &opErr) {
+		return err
+	}
+
+	failed := opErr.Index
+	switch opErr.Op {
+	case gnmipb.UpdateResult_REPLACE:
+		failed += len(req.GetDelete())
+	case gnmipb.UpdateResult_UPDATE:
+		failed += len(req.GetDelete()) + len(req.GetReplace())
+	}
+
+	code := opErr.Code
+	if opErr.RollbackErr != nil {
+		code = codes.Internal
+	}
+	for i, res := range results {
+		switch {
+		case i == failed:
+			res.Message = &gnmipb.Error{Code: uint32(opErr.Code), Message: opErr.Err.Error()}
+		case i < failed && opErr.RollbackErr != nil:
+			res.Message = &gnmipb.Error{Code: uint32(codes.Internal), Message: "rollback failed: " + opErr.RollbackErr.Error()}
+		case i < failed:
+			res.Message = &gnmipb.Error{Code: uint32(codes.Aborted), Message: "rolled back"}
+		default:
+			res.Message = &gnmipb.Error{Code: uint32(codes.Aborted), Message: "not applied"}
+		}
+	}
+
+	st := status.New(code, opErr.Error())
+	if withDetails, err := st.WithDetails(&gnmipb.SetResponse{Prefix: req.GetPrefix(), Response: results}); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
@@ -0,0 +1,95 @@
+package gnmi
+
+import (
+	"fmt"
+	"testing"
+
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+	sdc "github.com/sonic-net/sonic-gnmi/sonic_data_client"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestSetOpErrorStatus(t *testing.T) {
+	path := func(name string) *gnmipb.Path {
+		return &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "DASH_QOS"}, {Name: name}}}
+	}
+	req := &gnmipb.SetRequest{
+		Delete:  []*gnmipb.Path{path("qos_01")},
+		Replace: []*gnmipb.Update{{Path: path("qos_02")}},
+		Update:  []*gnmipb.Update{{Path: path("qos_03")}, {Path: path("qos_04")}},
+	}
+	newResults := func() []*gnmipb.UpdateResult {
+		return []*gnmipb.UpdateResult{
+			{Path: path("qos_01"), Op: gnmipb.UpdateResult_DELETE},
+			{Path: path("qos_02"), Op: gnmipb.UpdateResult_REPLACE},
+			{Path: path("qos_03"), Op: gnmipb.UpdateResult_UPDATE},
+			{Path: path("qos_04"), Op: gnmipb.UpdateResult_UPDATE},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		opErr     *sdc.SetOpError
+		wantCode  codes.Code
+		wantCodes []codes.Code
+	}{
+		{
+			name: "update failed and rolled back",
+			opErr: &sdc.SetOpError{
+				Op: gnmipb.UpdateResult_UPDATE, Index: 0, Path: path("qos_03"),
+				Code: codes.Internal, Err: fmt.Errorf("write failed"),
+			},
+			wantCode:  codes.Internal,
+			wantCodes: []codes.Code{codes.Aborted, codes.Aborted, codes.Internal, codes.Aborted},
+		},
+		{
+			name: "replace could not be staged",
+			opErr: &sdc.SetOpError{
+				Op: gnmipb.UpdateResult_REPLACE, Index: 0, Path: path("qos_02"),
+				Code: codes.InvalidArgument, Err: fmt.Errorf("Unsupported value"),
+			},
+			wantCode:  codes.InvalidArgument,
+			wantCodes: []codes.Code{codes.Aborted, codes.InvalidArgument, codes.Aborted, codes.Aborted},
+		},
+		{
+			name: "rollback failed",
+			opErr: &sdc.SetOpError{
+				Op: gnmipb.UpdateResult_UPDATE, Index: 1, Path: path("qos_04"),
+				Code: codes.Internal, Err: fmt.Errorf("write failed"), RollbackErr: fmt.Errorf("delete failed"),
+			},
+			wantCode:  codes.Internal,
+			wantCodes: []codes.Code{codes.Internal, codes.Internal, codes.Internal, codes.Internal},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := newResults()
+			st, _ := status.FromError(setOpErrorStatus(tt.opErr, req, results))
+			if st.Code() != tt.wantCode {
+				t.Errorf("Expected code %v, got %v", tt.wantCode, st.Code())
+			}
+			for i, res := range results {
+				if got := codes.Code(res.GetMessage().GetCode()); got != tt.wantCodes[i] {
+					t.Errorf("Result %d: expected code %v, got %v", i, tt.wantCodes[i], got)
+				}
+			}
+
+			details := st.Details()
+			if len(details) != 1 {
+				t.Fatalf("Expected SetResponse detail, got %v", details)
+			}
+			resp, ok := details[0].(*gnmipb.SetResponse)
+			if !ok || len(resp.GetResponse()) != len(results) {
+				t.Errorf("Unexpected detail %v", details[0])
+			}
+		})
+	}
+
+	// Other errors are returned as is
+	err := fmt.Errorf("Set RPC does not support ASIC_DB")
+	if got := setOpErrorStatus(err, req, newResults()); got != err {
+		t.Errorf("Expected error unchanged, got %v", got)
+	}
+}
@@ -0,0 +1,478 @@
+package gnmi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/golang/glog"
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/sonic-net/sonic-gnmi/pkg/commitconfirm"
+	sdc "github.com/sonic-net/sonic-gnmi/sonic_data_client"
+	ssc "github.com/sonic-net/sonic-gnmi/sonic_service_client"
+	transutil "github.com/sonic-net/sonic-gnmi/transl_utils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+// union_replace
+//
+// A union_replace (gNMI 0.10) replaces the whole configuration of the device
+// with the union of the configuration given for each origin:
+//   - "sonic-db": CONFIG_DB tables, entries or fields, e.g. /CONFIG_DB/localhost/PORT
+//   - "cli": a complete CONFIG_DB document (config_db.json), as ascii or JSON IETF value
+//   - "openconfig" or no origin: OpenConfig subtrees, applied through translib
+//
+// Like the OpenConfig subtrees, each native part replaces only what its path
+// names: a "cli" part, or a "sonic-db" part at /CONFIG_DB/<instance>, replaces
+// the whole CONFIG_DB, while /CONFIG_DB/localhost/PORT replaces the PORT table
+// and leaves the other tables untouched. The native parts are merged and
+// applied together, with a config replace when the whole CONFIG_DB is replaced
+// and one incremental patch otherwise. The OpenConfig subtrees are then
+// replaced in one translib bulk request, on top of the native configuration. Every part is
+// authorized before anything is written, and when there are both, CONFIG_DB is
+// checkpointed before the config replace and restored if translib fails.
+
+const (
+	// unionReplaceFieldNumber is the field number of union_replace in SetRequest
+	unionReplaceFieldNumber protowire.Number = 6
+
+	// UpdateResult_UNION_REPLACE is the UNION_REPLACE UpdateResult operation
+	UpdateResult_UNION_REPLACE gnmipb.UpdateResult_Operation = 4
+
+	// CliOrigin is the origin of union_replace updates carrying a CONFIG_DB document
+	CliOrigin = "cli"
+)
+
+// getUnionReplace returns the union_replace updates of a SetRequest. The gNMI
+// protos the server is built with predate union_replace, so the field is
+// decoded from the unknown fields of the request.
+func getUnionReplace(req *gnmipb.SetRequest) ([]*gnmipb.Update, error) {
+	var updates []*gnmipb.Update
+	b := req.ProtoReflect().GetUnknown()
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		b = b[n:]
+		if num == unionReplaceFieldNumber && typ == protowire.BytesType {
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return nil, protowire.ParseError(n)
+			}
+			update := &gnmipb.Update{}
+			if err := proto.Unmarshal(v, update); err != nil {
+				return nil, err
+			}
+			updates = append(updates, update)
+			b = b[n:]
+			continue
+		}
+		n = protowire.ConsumeFieldValue(num, typ, b)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		b = b[n:]
+	}
+	return updates, nil
+}
+
+// unionOrigin returns the origin of a union_replace update.
+func unionOrigin(prefix *gnmipb.Path, update *gnmipb.Update) string {
+	if origin := update.GetPath().GetOrigin(); origin != "" {
+		return origin
+	}
+	return prefix.GetOrigin()
+}
+
+// unionElems returns the element names of the full path of a union_replace
+// update. The prefix elements apply to the updates of the prefix origin only.
+func unionElems(prefix *gnmipb.Path, update *gnmipb.Update) []string {
+	var elems []string
+	if unionOrigin(prefix, update) == prefix.GetOrigin() {
+		for _, elem := range prefix.GetElem() {
+			elems = append(elems, elem.GetName())
+		}
+	}
+	for _, elem := range update.GetPath().GetElem() {
+		elems = append(elems, elem.GetName())
+	}
+	return elems
+}
+
+// unionValue decodes the JSON value of a union_replace update.
+func unionValue(update *gnmipb.Update) (interface{}, error) {
+	t := update.GetVal()
+	var data []byte
+	switch {
+	case len(t.GetJsonIetfVal()) != 0:
+		data = t.GetJsonIetfVal()
+	case len(t.GetJsonVal()) != 0:
+		data = t.GetJsonVal()
+	case t.GetAsciiVal() != "":
+		data = []byte(t.GetAsciiVal())
+	default:
+		return nil, fmt.Errorf("value of %v is not JSON", update.GetPath())
+	}
+
+	var val interface{}
+	if err := json.Unmarshal(data, &val); err != nil {
+		return nil, fmt.Errorf("invalid JSON value of %v: %v", update.GetPath(), err)
+	}
+	return val, nil
+}
+
+// mergeUnionConfig merges val into config at path. Overlapping updates must
+// agree on the value of every leaf they both set.
+func mergeUnionConfig(config map[string]interface{}, path []string, val interface{}) error {
+	if len(path) == 0 {
+		src, ok := val.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("CONFIG_DB document must be a JSON object")
+		}
+		for k, v := range src {
+			if err := mergeUnionConfig(config, []string{k}, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	node := config
+	for i, name := range path[:len(path)-1] {
+		next, ok := node[name]
+		if !ok {
+			next = map[string]interface{}{}
+			node[name] = next
+		}
+		m, ok := next.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("conflicting values for /%s", strings.Join(path[:i+1], "/"))
+		}
+		node = m
+	}
+
+	name := path[len(path)-1]
+	existing, ok := node[name]
+	if !ok {
+		node[name] = val
+		return nil
+	}
+	dst, dstIsMap := existing.(map[string]interface{})
+	src, srcIsMap := val.(map[string]interface{})
+	if dstIsMap && srcIsMap {
+		for k, v := range src {
+			if err := mergeUnionConfig(dst, []string{k}, v); err != nil {
+				return fmt.Errorf("%v under /%s", err, strings.Join(path, "/"))
+			}
+		}
+		return nil
+	}
+	if !reflect.DeepEqual(existing, val) {
+		return fmt.Errorf("conflicting values for /%s", strings.Join(path, "/"))
+	}
+	return nil
+}
+
+// checkOverlappingPaths rejects OpenConfig subtrees that contain one another,
+// since each of them replaces its whole subtree.
+func checkOverlappingPaths(prefix *gnmipb.Path, updates []*gnmipb.Update) error {
+	paths := make([]string, len(updates))
+	for i, update := range updates {
+		full := transutil.GnmiTranslFullPath(prefix, update.GetPath())
+		paths[i] = "/" + strings.Join(pathElemStrings(full), "/")
+	}
+	for i := range paths {
+		for j := range paths {
+			if i == j {
+				continue
+			}
+			if paths[i] == paths[j] || strings.HasPrefix(paths[j], strings.TrimSuffix(paths[i], "/")+"/") {
+				return fmt.Errorf("overlapping union_replace paths %s and %s", paths[i], paths[j])
+			}
+		}
+	}
+	return nil
+}
+
+// pathElemStrings returns the elements of a path with their keys, sorted by key name.
+func pathElemStrings(path *gnmipb.Path) []string {
+	var elems []string
+	for _, elem := range path.GetElem() {
+		s := elem.GetName()
+		keys := make([]string, 0, len(elem.GetKey()))
+		for k := range elem.GetKey() {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			s += "[" + k + "=" + elem.GetKey()[k] + "]"
+		}
+		elems = append(elems, s)
+	}
+	return elems
+}
+
+// unionReplacePlan is a union_replace split by how it is applied.
+type unionReplacePlan struct {
+	// native is the merged CONFIG_DB document, nil if there are no native updates
+	native   map[string]interface{}
+	instance string // CONFIG_DB instance the native document applies to
+	// nativePaths are the paths in CONFIG_DB the native updates replace, an
+	// empty path being the whole CONFIG_DB
+	nativePaths [][]string
+
+	// openconfig are the OpenConfig subtrees to replace
+	openconfig []*gnmipb.Update
+}
+
+// planUnionReplace validates a union_replace and merges its native updates.
+func planUnionReplace(prefix *gnmipb.Path, updates []*gnmipb.Update) (*unionReplacePlan, error) {
+	plan := &unionReplacePlan{}
+	for _, update := range updates {
+		switch origin := unionOrigin(prefix, update); origin {
+		case "sonic-db", CliOrigin:
+			elems := unionElems(prefix, update)
+			instance := sdc.HOSTNAME
+			if origin == CliOrigin {
+				if len(elems) != 0 {
+					return nil, fmt.Errorf("cli origin only supports the root path, got /%s", strings.Join(elems, "/"))
+				}
+			} else {
+				if len(elems) < 2 || elems[0] != "CONFIG_DB" {
+					return nil, fmt.Errorf("sonic-db union_replace only supports CONFIG_DB, got /%s", strings.Join(elems, "/"))
+				}
+				instance = elems[1]
+				elems = elems[2:]
+			}
+			if plan.instance != "" && plan.instance != instance {
+				return nil, fmt.Errorf("union_replace targets both %s and %s", plan.instance, instance)
+			}
+			plan.instance = instance
+
+			val, err := unionValue(update)
+			if err != nil {
+				return nil, err
+			}
+			if plan.native == nil {
+				plan.native = map[string]interface{}{}
+			}
+			if err := mergeUnionConfig(plan.native, elems, val); err != nil {
+				return nil, err
+			}
+			plan.nativePaths = append(plan.nativePaths, elems)
+		case "", "openconfig":
+			plan.openconfig = append(plan.openconfig, update)
+		default:
+			return nil, fmt.Errorf("unsupported union_replace origin %q", origin)
+		}
+	}
+
+	if err := checkOverlappingPaths(prefix, plan.openconfig); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// isPathPrefix checks whether path is within prefix.
+func isPathPrefix(prefix, path []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// nativeReplaces returns the replaces applying the native updates, relative to
+// the CONFIG_DB instance: the whole merged document if any update replaces the
+// whole CONFIG_DB, or else the merged value of each path the updates name.
+func (plan *unionReplacePlan) nativeReplaces() ([]*gnmipb.Update, error) {
+	var paths [][]string
+	for _, path := range plan.nativePaths {
+		covered := false
+		for _, other := range plan.nativePaths {
+			if len(other) < len(path) && isPathPrefix(other, path) {
+				covered = true
+				break
+			}
+		}
+		for _, added := range paths {
+			covered = covered || (len(added) == len(path) && isPathPrefix(added, path))
+		}
+		if !covered {
+			paths = append(paths, path)
+		}
+	}
+
+	var replaces []*gnmipb.Update
+	for _, path := range paths {
+		var val interface{} = plan.native
+		replacePath := &gnmipb.Path{Elem: []*gnmipb.PathElem{}}
+		for _, name := range path {
+			val = val.(map[string]interface{})[name]
+			replacePath.Elem = append(replacePath.Elem, &gnmipb.PathElem{Name: name})
+		}
+		data, err := json.Marshal(val)
+		if err != nil {
+			return nil, err
+		}
+		replaces = append(replaces, &gnmipb.Update{
+			Path: replacePath,
+			Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_JsonIetfVal{JsonIetfVal: data}},
+		})
+	}
+	return replaces, nil
+}
+
+// newUnionReplaceService connects to the host service checkpointing CONFIG_DB.
+// Allow DI for testing
+var newUnionReplaceService = func() (commitconfirm.Service, error) {
+	return ssc.NewDbusClient()
+}
+
+// unionReplaceCheckpointDir is where the host service stores the union_replace checkpoints.
+var unionReplaceCheckpointDir = sdc.CHECK_POINT_PATH
+
+// applyUnionReplace applies the native part, then the OpenConfig part of a
+// union_replace; either may be nil. When there are both, CONFIG_DB is
+// checkpointed first and restored if the OpenConfig part fails, so that the
+// union is applied entirely or not at all.
+func applyUnionReplace(applyNative, applyOpenconfig func() error) error {
+	if applyNative == nil || applyOpenconfig == nil {
+		for _, apply := range []func() error{applyNative, applyOpenconfig} {
+			if apply != nil {
+				return apply()
+			}
+		}
+		return nil
+	}
+
+	sc, err := newUnionReplaceService()
+	if err != nil {
+		return status.Errorf(codes.Unavailable, "failed to connect to host service: %v", err)
+	}
+	defer sc.Close()
+	checkpoint := fmt.Sprintf("%s/union-replace-%d", unionReplaceCheckpointDir, time.Now().UnixNano())
+	if err := sc.CreateCheckPoint(checkpoint); err != nil {
+		return status.Errorf(codes.Internal, "failed to checkpoint CONFIG_DB: %v", err)
+	}
+	defer func() {
+		if err := sc.DeleteCheckPoint(checkpoint); err != nil {
+			log.V(1).Infof("Failed to delete union_replace checkpoint: %v", err)
+		}
+	}()
+
+	if err := applyNative(); err != nil {
+		return err
+	}
+	applyErr := applyOpenconfig()
+	if applyErr == nil {
+		return nil
+	}
+
+	log.Errorf("union_replace OpenConfig part failed, restoring CONFIG_DB: %v", applyErr)
+	config, err := ioutil.ReadFile(checkpoint + ".cp.json")
+	if err == nil {
+		err = sc.ConfigReplace(string(config))
+	}
+	if err != nil {
+		return status.Errorf(codes.Internal, "%v; restoring CONFIG_DB failed: %v", applyErr, err)
+	}
+	return applyErr
+}
+
+// setUnionReplace applies the union_replace of a SetRequest.
+func (s *Server) setUnionReplace(ctx context.Context, req *gnmipb.SetRequest, updates []*gnmipb.Update) (*gnmipb.SetResponse, error) {
+	if len(req.GetDelete())+len(req.GetReplace())+len(req.GetUpdate()) != 0 {
+		return nil, status.Error(codes.InvalidArgument, "union_replace cannot be combined with delete, replace or update")
+	}
+
+	prefix := req.GetPrefix()
+	plan, err := planUnionReplace(prefix, updates)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if plan.native != nil && !s.config.EnableNativeWrite {
+		return nil, status.Error(codes.Unimplemented, "GNMI native write is disabled")
+	}
+	if len(plan.openconfig) != 0 && !s.config.EnableTranslibWrite {
+		return nil, status.Error(codes.Unimplemented, "Translib write is disabled")
+	}
+
+	// Every part is authorized before anything is written
+	var applyNative, applyOpenconfig func() error
+	if plan.native != nil {
+		replace, err := plan.nativeReplaces()
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		var paths []*gnmipb.Path
+		for _, update := range replace {
+			log.V(2).Infof("union_replace CONFIG_DB %v: %s", update.GetPath(), update.GetVal().GetJsonIetfVal())
+			paths = append(paths, update.GetPath())
+		}
+
+		// A replace of the CONFIG_DB root is a config replace, other
+		// replaces are applied as one patch
+		nativePrefix := &gnmipb.Path{
+			Origin: "sonic-db",
+			Elem:   []*gnmipb.PathElem{{Name: "CONFIG_DB"}, {Name: plan.instance}},
+		}
+		var targetDbName string
+		dc, err := sdc.NewMixedDbClient(paths, nativePrefix, "sonic-db", gnmipb.Encoding_JSON_IETF,
+			s.config.ZmqPort, s.config.Vrf, &targetDbName)
+		if err != nil {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		defer dc.Close()
+
+		if ctx, err = authenticate(s.config, ctx, "gnmi_"+targetDbName, true); err != nil {
+			return nil, err
+		}
+		applyNative = func() error { return dc.Set(nil, replace, nil) }
+	}
+
+	if len(plan.openconfig) != 0 {
+		if ctx, err = authenticate(s.config, ctx, "gnmi", true); err != nil {
+			return nil, err
+		}
+		dc, err := sdc.NewTranslClient(prefix, nil, ctx, req.GetExtension())
+		if err != nil {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		defer dc.Close()
+
+		applyOpenconfig = func() error { return dc.Set(nil, plan.openconfig, nil) }
+	}
+
+	if err := applyUnionReplace(applyNative, applyOpenconfig); err != nil {
+		return nil, err
+	}
+
+	var results []*gnmipb.UpdateResult
+	for _, update := range updates {
+		log.V(2).Infof("Union replace path: %v", update.GetPath())
+		results = append(results, &gnmipb.UpdateResult{
+			Path: update.GetPath(),
+			Op:   UpdateResult_UNION_REPLACE,
+		})
+	}
+	s.SaveStartupConfig()
+
+	return &gnmipb.SetResponse{
+		Prefix:   prefix,
+		Response: results,
+	}, nil
+}
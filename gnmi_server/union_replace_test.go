@@ -0,0 +1,329 @@
+package gnmi
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/sonic-net/sonic-gnmi/pkg/commitconfirm"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+// unionReplaceRequest builds a SetRequest carrying union_replace updates, as
+// sent by a client built with gNMI 0.10 protos.
+func unionReplaceRequest(t *testing.T, prefix *gnmipb.Path, updates ...*gnmipb.Update) *gnmipb.SetRequest {
+	b, err := proto.Marshal(&gnmipb.SetRequest{Prefix: prefix})
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	for _, update := range updates {
+		u, err := proto.Marshal(update)
+		if err != nil {
+			t.Fatalf("Marshal failed: %v", err)
+		}
+		b = protowire.AppendTag(b, unionReplaceFieldNumber, protowire.BytesType)
+		b = protowire.AppendBytes(b, u)
+	}
+
+	req := &gnmipb.SetRequest{}
+	if err := proto.Unmarshal(b, req); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	return req
+}
+
+func jsonUpdate(origin string, val string, elems ...string) *gnmipb.Update {
+	path := &gnmipb.Path{Origin: origin}
+	for _, name := range elems {
+		path.Elem = append(path.Elem, &gnmipb.PathElem{Name: name})
+	}
+	return &gnmipb.Update{
+		Path: path,
+		Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_JsonIetfVal{JsonIetfVal: []byte(val)}},
+	}
+}
+
+func TestGetUnionReplace(t *testing.T) {
+	want := []*gnmipb.Update{
+		jsonUpdate("sonic-db", `{"Ethernet0":{"mtu":"9100"}}`, "CONFIG_DB", "localhost", "PORT"),
+		jsonUpdate("openconfig", `{"config":{"hostname":"sonic"}}`, "system"),
+	}
+	req := unionReplaceRequest(t, &gnmipb.Path{Target: "CONFIG"}, want...)
+
+	got, err := getUnionReplace(req)
+	if err != nil {
+		t.Fatalf("getUnionReplace failed: %v", err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d updates, got %d", len(want), len(got))
+	}
+	for i := range want {
+		if !proto.Equal(got[i], want[i]) {
+			t.Errorf("Update %d: expected %v, got %v", i, want[i], got[i])
+		}
+	}
+
+	// Requests without union_replace
+	got, err = getUnionReplace(&gnmipb.SetRequest{Update: want})
+	if err != nil || len(got) != 0 {
+		t.Errorf("Expected no union_replace, got %v, %v", got, err)
+	}
+}
+
+func TestPlanUnionReplace(t *testing.T) {
+	updates := []*gnmipb.Update{
+		jsonUpdate("sonic-db", `{"Ethernet0":{"mtu":"9100"}}`, "CONFIG_DB", "localhost", "PORT"),
+		jsonUpdate("sonic-db", `"up"`, "CONFIG_DB", "localhost", "PORT", "Ethernet0", "admin_status"),
+		{
+			Path: &gnmipb.Path{Origin: CliOrigin},
+			Val: &gnmipb.TypedValue{Value: &gnmipb.TypedValue_AsciiVal{
+				AsciiVal: `{"PORT":{"Ethernet0":{"mtu":"9100"}},"DEVICE_METADATA":{"localhost":{"hostname":"sonic"}}}`,
+			}},
+		},
+		jsonUpdate("openconfig", `{"config":{"name":"Ethernet4"}}`, "interfaces", "interface"),
+		jsonUpdate("", `{"config":{"hostname":"sonic"}}`, "system"),
+	}
+
+	plan, err := planUnionReplace(nil, updates)
+	if err != nil {
+		t.Fatalf("planUnionReplace failed: %v", err)
+	}
+
+	var want map[string]interface{}
+	json.Unmarshal([]byte(`{
+		"PORT": {"Ethernet0": {"mtu": "9100", "admin_status": "up"}},
+		"DEVICE_METADATA": {"localhost": {"hostname": "sonic"}}
+	}`), &want)
+	if !reflect.DeepEqual(plan.native, want) {
+		t.Errorf("Expected CONFIG_DB %v, got %v", want, plan.native)
+	}
+	if plan.instance != "localhost" {
+		t.Errorf("Expected instance localhost, got %s", plan.instance)
+	}
+	if len(plan.openconfig) != 2 {
+		t.Errorf("Expected 2 OpenConfig updates, got %d", len(plan.openconfig))
+	}
+}
+
+func TestPlanUnionReplace_PrefixOrigin(t *testing.T) {
+	prefix := &gnmipb.Path{
+		Origin: "sonic-db",
+		Elem:   []*gnmipb.PathElem{{Name: "CONFIG_DB"}, {Name: "localhost"}},
+	}
+	updates := []*gnmipb.Update{
+		jsonUpdate("", `{"Vlan10":{"vlanid":"10"}}`, "VLAN"),
+		jsonUpdate("openconfig", `{"config":{"hostname":"sonic"}}`, "system"),
+	}
+
+	plan, err := planUnionReplace(prefix, updates)
+	if err != nil {
+		t.Fatalf("planUnionReplace failed: %v", err)
+	}
+	if _, ok := plan.native["VLAN"]; !ok {
+		t.Errorf("Expected VLAN table in CONFIG_DB, got %v", plan.native)
+	}
+	if len(plan.openconfig) != 1 {
+		t.Errorf("Expected 1 OpenConfig update, got %d", len(plan.openconfig))
+	}
+}
+
+func TestUnionReplaceNativeReplaces(t *testing.T) {
+	// Partial parts replace only the tables and entries they name
+	plan, err := planUnionReplace(nil, []*gnmipb.Update{
+		jsonUpdate("sonic-db", `{"Ethernet0":{"mtu":"9100"}}`, "CONFIG_DB", "localhost", "PORT"),
+		jsonUpdate("sonic-db", `"up"`, "CONFIG_DB", "localhost", "PORT", "Ethernet0", "admin_status"),
+		jsonUpdate("sonic-db", `{"vlanid":"10"}`, "CONFIG_DB", "localhost", "VLAN", "Vlan10"),
+	})
+	if err != nil {
+		t.Fatalf("planUnionReplace failed: %v", err)
+	}
+	replaces, err := plan.nativeReplaces()
+	if err != nil {
+		t.Fatalf("nativeReplaces failed: %v", err)
+	}
+
+	var config map[string]interface{}
+	json.Unmarshal([]byte(`{
+		"PORT": {"Ethernet0": {"mtu": "1500", "speed": "100000"}, "Ethernet4": {"mtu": "1500"}},
+		"VLAN": {"Vlan10": {"vlanid": "10", "mtu": "1500"}, "Vlan20": {"vlanid": "20"}},
+		"DEVICE_METADATA": {"localhost": {"hostname": "sonic"}}
+	}`), &config)
+	for _, replace := range replaces {
+		var val interface{}
+		json.Unmarshal(replace.GetVal().GetJsonIetfVal(), &val)
+		node := config
+		elems := pathElemStrings(replace.GetPath())
+		if len(elems) == 0 {
+			t.Fatalf("Expected partial replaces, got a replace of CONFIG_DB")
+		}
+		for _, name := range elems[:len(elems)-1] {
+			node = node[name].(map[string]interface{})
+		}
+		node[elems[len(elems)-1]] = val
+	}
+	var want map[string]interface{}
+	json.Unmarshal([]byte(`{
+		"PORT": {"Ethernet0": {"mtu": "9100", "admin_status": "up"}},
+		"VLAN": {"Vlan10": {"vlanid": "10"}, "Vlan20": {"vlanid": "20"}},
+		"DEVICE_METADATA": {"localhost": {"hostname": "sonic"}}
+	}`), &want)
+	if len(replaces) != 2 || !reflect.DeepEqual(config, want) {
+		t.Errorf("Expected CONFIG_DB %v, got %v with replaces %v", want, config, replaces)
+	}
+
+	// A part at the root replaces the whole CONFIG_DB
+	plan, err = planUnionReplace(nil, []*gnmipb.Update{
+		jsonUpdate("sonic-db", `{"Ethernet0":{"mtu":"9100"}}`, "CONFIG_DB", "localhost", "PORT"),
+		jsonUpdate("cli", `{"DEVICE_METADATA":{"localhost":{"hostname":"sonic"}}}`),
+	})
+	if err != nil {
+		t.Fatalf("planUnionReplace failed: %v", err)
+	}
+	replaces, err = plan.nativeReplaces()
+	if err != nil || len(replaces) != 1 || len(replaces[0].GetPath().GetElem()) != 0 {
+		t.Fatalf("Expected one replace of CONFIG_DB, got %v, %v", replaces, err)
+	}
+	var doc map[string]interface{}
+	json.Unmarshal(replaces[0].GetVal().GetJsonIetfVal(), &doc)
+	if !reflect.DeepEqual(doc, plan.native) {
+		t.Errorf("Expected CONFIG_DB %v, got %v", plan.native, doc)
+	}
+}
+
+func TestPlanUnionReplace_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		updates []*gnmipb.Update
+		wantErr string
+	}{
+		{
+			name: "conflicting native leaves",
+			updates: []*gnmipb.Update{
+				jsonUpdate("sonic-db", `{"Ethernet0":{"mtu":"9100"}}`, "CONFIG_DB", "localhost", "PORT"),
+				jsonUpdate("cli", `{"PORT":{"Ethernet0":{"mtu":"1500"}}}`),
+			},
+			wantErr: "conflicting values",
+		},
+		{
+			name: "leaf overlapping a table",
+			updates: []*gnmipb.Update{
+				jsonUpdate("sonic-db", `"x"`, "CONFIG_DB", "localhost", "PORT"),
+				jsonUpdate("sonic-db", `"9100"`, "CONFIG_DB", "localhost", "PORT", "Ethernet0", "mtu"),
+			},
+			wantErr: "conflicting values",
+		},
+		{
+			name: "overlapping OpenConfig subtrees",
+			updates: []*gnmipb.Update{
+				jsonUpdate("openconfig", `{}`, "interfaces"),
+				jsonUpdate("openconfig", `{}`, "interfaces", "interface"),
+			},
+			wantErr: "overlapping",
+		},
+		{
+			name: "native database other than CONFIG_DB",
+			updates: []*gnmipb.Update{
+				jsonUpdate("sonic-db", `{}`, "APPL_DB", "localhost", "ROUTE_TABLE"),
+			},
+			wantErr: "only supports CONFIG_DB",
+		},
+		{
+			name: "different CONFIG_DB instances",
+			updates: []*gnmipb.Update{
+				jsonUpdate("sonic-db", `{}`, "CONFIG_DB", "asic0", "PORT"),
+				jsonUpdate("sonic-db", `{}`, "CONFIG_DB", "asic1", "PORT"),
+			},
+			wantErr: "targets both",
+		},
+		{
+			name: "cli origin below the root",
+			updates: []*gnmipb.Update{
+				jsonUpdate("cli", `{}`, "PORT"),
+			},
+			wantErr: "root path",
+		},
+		{
+			name: "cli document not an object",
+			updates: []*gnmipb.Update{
+				jsonUpdate("cli", `["PORT"]`),
+			},
+			wantErr: "JSON object",
+		},
+		{
+			name: "unsupported origin",
+			updates: []*gnmipb.Update{
+				jsonUpdate("vendor", `{}`, "system"),
+			},
+			wantErr: "unsupported union_replace origin",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := planUnionReplace(nil, tt.updates)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// unionReplaceService checkpoints and replaces an in-memory CONFIG_DB.
+type unionReplaceService struct {
+	config      string
+	checkpoints int
+	deleted     int
+}
+
+func (f *unionReplaceService) Close() error { return nil }
+
+func (f *unionReplaceService) CreateCheckPoint(cpName string) error {
+	f.checkpoints++
+	return ioutil.WriteFile(cpName+".cp.json", []byte(f.config), 0644)
+}
+
+func (f *unionReplaceService) DeleteCheckPoint(cpName string) error {
+	f.deleted++
+	return nil
+}
+
+func (f *unionReplaceService) ConfigReplace(config string) error {
+	f.config = config
+	return nil
+}
+
+func TestApplyUnionReplace(t *testing.T) {
+	svc := &unionReplaceService{config: "old"}
+	savedService, savedDir := newUnionReplaceService, unionReplaceCheckpointDir
+	newUnionReplaceService = func() (commitconfirm.Service, error) { return svc, nil }
+	unionReplaceCheckpointDir = t.TempDir()
+	defer func() { newUnionReplaceService, unionReplaceCheckpointDir = savedService, savedDir }()
+
+	applyNative := func() error { svc.config = "native"; return nil }
+	failOpenconfig := func() error { return errors.New("translib failed") }
+
+	// A single part is applied without checkpoint
+	if err := applyUnionReplace(applyNative, nil); err != nil || svc.config != "native" || svc.checkpoints != 0 {
+		t.Errorf("Native only: config %q, checkpoints %d, err %v", svc.config, svc.checkpoints, err)
+	}
+
+	// The native part is rolled back when the OpenConfig part fails
+	svc.config = "old"
+	err := applyUnionReplace(applyNative, failOpenconfig)
+	if err == nil || !strings.Contains(err.Error(), "translib failed") {
+		t.Errorf("Expected the translib error, got %v", err)
+	}
+	if svc.config != "old" || svc.checkpoints != 1 || svc.deleted != 1 {
+		t.Errorf("Expected CONFIG_DB restored: config %q, checkpoints %d, deleted %d", svc.config, svc.checkpoints, svc.deleted)
+	}
+
+	// Both parts applied
+	if err := applyUnionReplace(applyNative, func() error { return nil }); err != nil || svc.config != "native" {
+		t.Errorf("Both parts: config %q, err %v", svc.config, err)
+	}
+}
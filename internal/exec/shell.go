@@ -0,0 +1,225 @@
+package exec
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+const DEFAULT_TERM = "xterm"
+
+var (
+	// systemd-run allocates a pseudo terminal for the command on the host and
+	// forwards the input, output and window size of the local one.
+	SHELL_SYSTEMD_RUN_ARGS = []string{
+		"systemd-run",
+		"-p",
+		"ProtectSystem=strict",
+		"-p",
+		"PrivateDevices=true",
+		"-tq",
+	}
+
+	// Allow DI for mocking
+	execShellCommand = func(name string, args ...string) *exec.Cmd {
+		// Below is passed through AST validation + whitelist sanitisation, and is as safe as possible
+		// nosemgrep:dangerous-exec-command
+		return exec.Command(name, args...)
+	}
+)
+
+// Shell is a command running on the host in a pseudo terminal.
+type Shell struct {
+	pty      *os.File
+	cmd      *exec.Cmd
+	done     chan struct{}
+	exitCode int
+	err      error
+}
+
+// Starts a command on the host device in a pseudo terminal of the given size.
+//
+// Optionally runs command as the specified user (default is 'admin') and with the
+// specified terminal type (default is 'xterm').
+//
+// Returns the running shell, which must be closed by the caller.
+func StartShell(roleAccount string, cmd string, term string, rows uint16, cols uint16) (*Shell, error) {
+	ptm, pts, err := openPTY()
+	if err != nil {
+		return nil, err
+	}
+	defer pts.Close()
+
+	if rows > 0 && cols > 0 {
+		if err := setWindowSize(ptm, rows, cols); err != nil {
+			ptm.Close()
+			return nil, err
+		}
+	}
+
+	account := roleAccount
+	if account == "" {
+		account = DEFAULT_ACC
+	}
+	if term == "" {
+		term = DEFAULT_TERM
+	}
+
+	fullArgs := make([]string, 0, len(NSENTER_ARGS)+len(SHELL_SYSTEMD_RUN_ARGS)+len(SHELL_ARGS)+USER_AND_CMD)
+	fullArgs = append(fullArgs, NSENTER_ARGS...)
+	fullArgs = append(fullArgs, SHELL_SYSTEMD_RUN_ARGS...)
+	fullArgs = append(fullArgs, fmt.Sprintf("--uid=%s", account))
+	fullArgs = append(fullArgs, SHELL_ARGS...)
+	fullArgs = append(fullArgs, cmd)
+
+	command := execShellCommand(NSENTER_CMD, fullArgs...)
+	command.Env = append(os.Environ(), "TERM="+term)
+	command.Stdin = pts
+	command.Stdout = pts
+	command.Stderr = pts
+	// New session with the pseudo terminal (stdin) as controlling terminal
+	command.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true}
+
+	if err := command.Start(); err != nil {
+		ptm.Close()
+		return nil, err
+	}
+
+	s := &Shell{
+		pty:  ptm,
+		cmd:  command,
+		done: make(chan struct{}),
+	}
+	go s.wait()
+	return s, nil
+}
+
+func (s *Shell) wait() {
+	defer close(s.done)
+	err := s.cmd.Wait()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			// Killed by a signal is reported as -1
+			s.exitCode = exitErr.ExitCode()
+			return
+		}
+		s.exitCode = FAILED_TO_RUN
+		s.err = err
+	}
+}
+
+// Read reads the terminal output. Returns io.EOF once the command exited and
+// all its output was read.
+func (s *Shell) Read(p []byte) (int, error) {
+	n, err := s.pty.Read(p)
+	if err != nil && errors.Is(err, syscall.EIO) {
+		// Linux reports the hang up of the terminal as EIO
+		err = io.EOF
+	}
+	return n, err
+}
+
+// Write writes to the terminal input.
+func (s *Shell) Write(p []byte) (int, error) {
+	return s.pty.Write(p)
+}
+
+// Resize changes the window size of the terminal, which notifies the command with SIGWINCH.
+func (s *Shell) Resize(rows uint16, cols uint16) error {
+	return setWindowSize(s.pty, rows, cols)
+}
+
+// Signal sends a signal to the command.
+//
+// Interrupt and quit are typed as control characters, so that they reach the
+// foreground process of the terminal, like Ctrl-C and Ctrl-\ would.
+// Other signals are sent to the process group of the command.
+func (s *Shell) Signal(sig syscall.Signal) error {
+	switch sig {
+	case syscall.SIGINT:
+		_, err := s.pty.Write([]byte{0x03})
+		return err
+	case syscall.SIGQUIT:
+		_, err := s.pty.Write([]byte{0x1c})
+		return err
+	}
+	return syscall.Kill(-s.cmd.Process.Pid, sig)
+}
+
+// Done is closed when the command exited.
+func (s *Shell) Done() <-chan struct{} {
+	return s.done
+}
+
+// Wait waits for the command to exit.
+//
+// Returns exit code of the command, with optional error.
+func (s *Shell) Wait() (int, error) {
+	<-s.done
+	return s.exitCode, s.err
+}
+
+// Close kills the command if still running and releases the terminal.
+func (s *Shell) Close() error {
+	select {
+	case <-s.done:
+	default:
+		syscall.Kill(-s.cmd.Process.Pid, syscall.SIGKILL)
+		<-s.done
+	}
+	return s.pty.Close()
+}
+
+// Opens a new pseudo terminal, returning its master and slave sides.
+func openPTY() (*os.File, *os.File, error) {
+	ptm, err := os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var index int
+	err = control(ptm, func(fd int) error {
+		if err := unix.IoctlSetPointerInt(fd, unix.TIOCSPTLCK, 0); err != nil {
+			return err
+		}
+		index, err = unix.IoctlGetInt(fd, unix.TIOCGPTN)
+		return err
+	})
+	if err != nil {
+		ptm.Close()
+		return nil, nil, fmt.Errorf("failed to unlock pseudo terminal: %v", err)
+	}
+
+	pts, err := os.OpenFile(fmt.Sprintf("/dev/pts/%d", index), os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		ptm.Close()
+		return nil, nil, err
+	}
+	return ptm, pts, nil
+}
+
+func setWindowSize(pty *os.File, rows uint16, cols uint16) error {
+	return control(pty, func(fd int) error {
+		return unix.IoctlSetWinsize(fd, unix.TIOCSWINSZ, &unix.Winsize{Row: rows, Col: cols})
+	})
+}
+
+// Runs fn on the file descriptor, without switching the file to blocking mode
+// as Fd() does, so that a pending Read is interrupted by Close.
+func control(f *os.File, fn func(fd int) error) error {
+	conn, err := f.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var fnErr error
+	if err := conn.Control(func(fd uintptr) { fnErr = fn(int(fd)) }); err != nil {
+		return err
+	}
+	return fnErr
+}
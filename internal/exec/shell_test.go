@@ -0,0 +1,206 @@
+package exec
+
+import (
+	"bytes"
+	"io"
+	"os/exec"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// mockShellCommand runs the shell command locally, without nsenter and systemd-run.
+func mockShellCommand(t *testing.T) {
+	original := execShellCommand
+	t.Cleanup(func() { execShellCommand = original })
+	execShellCommand = func(name string, args ...string) *exec.Cmd {
+		return exec.Command("sh", "-c", args[len(args)-1])
+	}
+}
+
+// readAll reads the terminal output until EOF, failing on timeout.
+func readAll(t *testing.T, s *Shell) string {
+	out := make(chan string)
+	go func() {
+		var buf bytes.Buffer
+		io.Copy(&buf, s)
+		out <- buf.String()
+	}()
+	select {
+	case data := <-out:
+		return data
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for shell output")
+	}
+	return ""
+}
+
+func TestStartShell(t *testing.T) {
+	mockShellCommand(t)
+
+	testCases := []struct {
+		name         string
+		cmd          string
+		term         string
+		rows, cols   uint16
+		expectedOut  string
+		expectedCode int
+	}{
+		{
+			name:         "Output and exit code",
+			cmd:          "echo hello; exit 3",
+			expectedOut:  "hello",
+			expectedCode: 3,
+		},
+		{
+			name:        "Runs in a terminal",
+			cmd:         "test -t 0 && test -t 1 && echo tty",
+			expectedOut: "tty",
+		},
+		{
+			name:        "Window size",
+			cmd:         "stty size",
+			rows:        30,
+			cols:        100,
+			expectedOut: "30 100",
+		},
+		{
+			name:        "Default terminal type",
+			cmd:         "echo $TERM",
+			expectedOut: DEFAULT_TERM,
+		},
+		{
+			name:        "Terminal type",
+			cmd:         "echo $TERM",
+			term:        "vt100",
+			expectedOut: "vt100",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := StartShell("", tc.cmd, tc.term, tc.rows, tc.cols)
+			if err != nil {
+				t.Fatalf("StartShell failed: %v", err)
+			}
+			defer s.Close()
+
+			if out := readAll(t, s); !strings.Contains(out, tc.expectedOut) {
+				t.Errorf("Expected output %q, got %q", tc.expectedOut, out)
+			}
+			code, err := s.Wait()
+			if err != nil || code != tc.expectedCode {
+				t.Errorf("Expected exit code %d, got %d (err %v)", tc.expectedCode, code, err)
+			}
+		})
+	}
+}
+
+func TestShellInput(t *testing.T) {
+	mockShellCommand(t)
+
+	s, err := StartShell("", "read line; echo got $line", "", 0, 0)
+	if err != nil {
+		t.Fatalf("StartShell failed: %v", err)
+	}
+	defer s.Close()
+
+	if _, err := s.Write([]byte("abc\n")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if out := readAll(t, s); !strings.Contains(out, "got abc") {
+		t.Errorf("Expected echoed input, got %q", out)
+	}
+}
+
+func TestShellResize(t *testing.T) {
+	mockShellCommand(t)
+
+	s, err := StartShell("", "read line; stty size", "", 24, 80)
+	if err != nil {
+		t.Fatalf("StartShell failed: %v", err)
+	}
+	defer s.Close()
+
+	if err := s.Resize(50, 120); err != nil {
+		t.Fatalf("Resize failed: %v", err)
+	}
+	s.Write([]byte("\n"))
+	if out := readAll(t, s); !strings.Contains(out, "50 120") {
+		t.Errorf("Expected resized window, got %q", out)
+	}
+}
+
+func TestShellSignal(t *testing.T) {
+	mockShellCommand(t)
+
+	for _, sig := range []syscall.Signal{syscall.SIGINT, syscall.SIGTERM} {
+		t.Run(sig.String(), func(t *testing.T) {
+			s, err := StartShell("", "echo started; exec sleep 10", "", 0, 0)
+			if err != nil {
+				t.Fatalf("StartShell failed: %v", err)
+			}
+			defer s.Close()
+
+			buf := make([]byte, 64)
+			if n, _ := s.Read(buf); !strings.Contains(string(buf[:n]), "started") {
+				t.Fatalf("Expected command to start, got %q", buf[:n])
+			}
+			if err := s.Signal(sig); err != nil {
+				t.Fatalf("Signal failed: %v", err)
+			}
+			select {
+			case <-s.Done():
+			case <-time.After(5 * time.Second):
+				t.Fatalf("Expected command to exit on %v", sig)
+			}
+			if code, _ := s.Wait(); code != -1 {
+				t.Errorf("Expected exit code -1 of a killed command, got %d", code)
+			}
+		})
+	}
+}
+
+func TestShellClose(t *testing.T) {
+	mockShellCommand(t)
+
+	s, err := StartShell("", "sleep 10", "", 0, 0)
+	if err != nil {
+		t.Fatalf("StartShell failed: %v", err)
+	}
+
+	closed := make(chan error)
+	go func() { closed <- s.Close() }()
+	select {
+	case err := <-closed:
+		if err != nil {
+			t.Errorf("Close failed: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected Close to kill the command")
+	}
+}
+
+func TestStartShellArgs(t *testing.T) {
+	var gotName string
+	var gotArgs []string
+	original := execShellCommand
+	defer func() { execShellCommand = original }()
+	execShellCommand = func(name string, args ...string) *exec.Cmd {
+		gotName, gotArgs = name, args
+		return exec.Command("true")
+	}
+
+	s, err := StartShell("", "vtysh", "", 0, 0)
+	if err != nil {
+		t.Fatalf("StartShell failed: %v", err)
+	}
+	s.Wait()
+	s.Close()
+
+	want := append(append(append(append([]string{}, NSENTER_ARGS...), SHELL_SYSTEMD_RUN_ARGS...), "--uid=admin"), "sh", "-c", "vtysh")
+	if gotName != NSENTER_CMD || strings.Join(gotArgs, " ") != strings.Join(want, " ") {
+		t.Errorf("Expected %s %v, got %s %v", NSENTER_CMD, want, gotName, gotArgs)
+	}
+}
@@ -0,0 +1,251 @@
+// Package audit records the mutating gNMI, gNOI and gNSI operations.
+//
+// An interceptor records each RPC which is not known to be read-only, so that
+// new RPCs are audited without change. A record holds the user, roles, peer
+// address and authentication method of the caller, the RPC, the paths and
+// targets it acts on, its result code and its duration. Records are written as
+// JSON to syslog, and optionally to a local file rotated by size.
+//
+// The authentication of an RPC, done by its handler, is attached to its record
+// with SetAuth, and handlers can add details of the operation with SetAttr.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/syslog"
+	"sync"
+	"time"
+
+	log "github.com/golang/glog"
+)
+
+// Record is an audit record of an RPC.
+type Record struct {
+	Time       string            `json:"time"`
+	RPC        string            `json:"rpc"`
+	User       string            `json:"user,omitempty"`
+	Roles      []string          `json:"roles,omitempty"`
+	Peer       string            `json:"peer,omitempty"`
+	AuthMethod string            `json:"auth_method,omitempty"`
+	Paths      []string          `json:"paths,omitempty"`
+	Attrs      map[string]string `json:"attrs,omitempty"`
+	Code       string            `json:"code"`
+	Error      string            `json:"error,omitempty"`
+	DurationMs int64             `json:"duration_ms"`
+}
+
+// maxPaths bounds the paths and targets recorded for an RPC
+const maxPaths = 64
+
+// entry collects the record of an RPC while it runs.
+type entry struct {
+	mu         sync.Mutex
+	user       string
+	roles      []string
+	authMethod string
+	paths      []string
+	attrs      map[string]string
+}
+
+type entryKey struct{}
+
+func newContext(ctx context.Context) (context.Context, *entry) {
+	e := &entry{}
+	return context.WithValue(ctx, entryKey{}, e), e
+}
+
+func fromContext(ctx context.Context) *entry {
+	if ctx == nil {
+		return nil
+	}
+	e, _ := ctx.Value(entryKey{}).(*entry)
+	return e
+}
+
+// SetAuth records the authenticated user and roles of an RPC, and the
+// authentication method. It does nothing if the RPC is not audited.
+func SetAuth(ctx context.Context, user string, roles []string, method string) {
+	e := fromContext(ctx)
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.user = user
+	e.roles = append([]string(nil), roles...)
+	e.authMethod = method
+}
+
+// SetAttr records a detail of the operation of an RPC. It does nothing if the
+// RPC is not audited.
+func SetAttr(ctx context.Context, key string, value string) {
+	e := fromContext(ctx)
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.attrs == nil {
+		e.attrs = map[string]string{}
+	}
+	e.attrs[key] = value
+}
+
+// AddTargets records paths and targets of an RPC which are not in its
+// requests. It does nothing if the RPC is not audited.
+func AddTargets(ctx context.Context, targets ...string) {
+	if e := fromContext(ctx); e != nil {
+		e.addPaths(targets)
+	}
+}
+
+// addPaths records the paths and targets an RPC acts on, once each.
+func (e *entry) addPaths(paths []string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, p := range paths {
+		if len(e.paths) >= maxPaths {
+			return
+		}
+		seen := false
+		for _, q := range e.paths {
+			if q == p {
+				seen = true
+				break
+			}
+		}
+		if !seen {
+			e.paths = append(e.paths, p)
+		}
+	}
+}
+
+// Config configures a Logger.
+type Config struct {
+	// File is the local audit file, none if empty
+	File string
+
+	// MaxSize is the size in bytes from which the file is rotated
+	MaxSize int64
+
+	// MaxBackups is the number of rotated files kept
+	MaxBackups int
+}
+
+// Logger writes audit records to syslog and to the audit file.
+type Logger struct {
+	mu     sync.Mutex
+	syslog io.Writer
+	file   *rotatingFile
+}
+
+var (
+	// Allow DI for testing
+	openSyslog = func() (io.Writer, error) {
+		return syslog.New(syslog.LOG_AUTHPRIV|syslog.LOG_INFO, "gnmi-audit")
+	}
+
+	defaultMu     sync.RWMutex
+	defaultLogger *Logger
+)
+
+// NewLogger creates a Logger. Records are still written to the file if
+// syslog is not available.
+func NewLogger(cfg Config) (*Logger, error) {
+	l := &Logger{}
+	if cfg.File != "" {
+		f, err := openRotatingFile(cfg.File, cfg.MaxSize, cfg.MaxBackups)
+		if err != nil {
+			return nil, err
+		}
+		l.file = f
+	}
+	w, err := openSyslog()
+	if err != nil {
+		log.Errorf("Failed to open syslog for the audit log: %v", err)
+	} else {
+		l.syslog = w
+	}
+	return l, nil
+}
+
+// Log writes a record.
+func (l *Logger) Log(r *Record) {
+	data, err := json.Marshal(r)
+	if err != nil {
+		log.V(1).Infof("Failed to marshal audit record: %v", err)
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.syslog != nil {
+		if _, err := l.syslog.Write(data); err != nil {
+			log.V(1).Infof("Failed to write audit record to syslog: %v", err)
+		}
+	}
+	if l.file != nil {
+		if _, err := l.file.Write(append(data, '\n')); err != nil {
+			log.V(1).Infof("Failed to write audit record to %s: %v", l.file.path, err)
+		}
+	}
+}
+
+// Close closes syslog and the audit file.
+func (l *Logger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var firstErr error
+	if c, ok := l.syslog.(io.Closer); ok {
+		firstErr = c.Close()
+	}
+	l.syslog = nil
+	if l.file != nil {
+		if err := l.file.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		l.file = nil
+	}
+	return firstErr
+}
+
+// SetDefaultLogger registers the Logger of the server. nil disables auditing.
+func SetDefaultLogger(l *Logger) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultLogger = l
+}
+
+func getDefaultLogger() *Logger {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultLogger
+}
+
+// newRecord returns the record of an RPC completed with err.
+func (e *entry) newRecord(rpc string, peer string, start time.Time, code string, err error) *Record {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	r := &Record{
+		Time:       start.UTC().Format(time.RFC3339Nano),
+		RPC:        rpc,
+		User:       e.user,
+		Roles:      e.roles,
+		Peer:       peer,
+		AuthMethod: e.authMethod,
+		Paths:      e.paths,
+		Code:       code,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	if len(e.attrs) > 0 {
+		r.Attrs = map[string]string{}
+		for k, v := range e.attrs {
+			r.Attrs[k] = v
+		}
+	}
+	if err != nil {
+		r.Error = err.Error()
+	}
+	return r
+}
@@ -0,0 +1,159 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSyslog records the messages written to syslog.
+type fakeSyslog struct {
+	messages []string
+}
+
+func (s *fakeSyslog) Write(p []byte) (int, error) {
+	s.messages = append(s.messages, string(p))
+	return len(p), nil
+}
+
+func useFakeSyslog(t *testing.T) *fakeSyslog {
+	s := &fakeSyslog{}
+	original := openSyslog
+	t.Cleanup(func() { openSyslog = original })
+	openSyslog = func() (io.Writer, error) { return s, nil }
+	return s
+}
+
+func TestLoggerLog(t *testing.T) {
+	s := useFakeSyslog(t)
+	path := filepath.Join(t.TempDir(), "audit", "audit.log")
+	l, err := NewLogger(Config{File: path})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	r := &Record{Time: "2026-01-31T12:00:00Z", RPC: "/gnmi.gNMI/Set", User: "admin", Roles: []string{"admin"},
+		AuthMethod: "cert", Paths: []string{"update /a/b"}, Code: "OK", DurationMs: 3}
+	l.Log(r)
+	l.Log(r)
+	if err := l.Close(); err != nil {
+		t.Errorf("Failed to close logger: %v", err)
+	}
+
+	if len(s.messages) != 2 {
+		t.Fatalf("Expected 2 syslog messages, got %v", s.messages)
+	}
+	var got Record
+	if err := json.Unmarshal([]byte(s.messages[0]), &got); err != nil || got.User != "admin" || got.Paths[0] != "update /a/b" {
+		t.Errorf("Unexpected syslog record %s, err %v", s.messages[0], err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != 2 || lines[0] != s.messages[0] {
+		t.Errorf("Unexpected audit file %q", data)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("Expected the audit file to be private, got %v", info.Mode())
+	}
+}
+
+func TestLoggerWithoutSyslog(t *testing.T) {
+	original := openSyslog
+	t.Cleanup(func() { openSyslog = original })
+	openSyslog = func() (io.Writer, error) { return nil, fmt.Errorf("no syslog") }
+
+	path := filepath.Join(t.TempDir(), "audit.log")
+	l, err := NewLogger(Config{File: path})
+	if err != nil {
+		t.Fatalf("Expected the file to be used without syslog, got %v", err)
+	}
+	l.Log(&Record{RPC: "/gnoi.system.System/Reboot", Code: "OK"})
+	l.Close()
+	if data, _ := os.ReadFile(path); !bytes.Contains(data, []byte(`"rpc":"/gnoi.system.System/Reboot"`)) {
+		t.Errorf("Unexpected audit file %q", data)
+	}
+
+	if _, err := NewLogger(Config{File: filepath.Join(path, "audit.log")}); err == nil {
+		t.Errorf("Expected an error for an invalid file")
+	}
+}
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	f, err := openRotatingFile(path, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatalf("Failed to write: %v", err)
+		}
+	}
+	// A record larger than the file is not split
+	if _, err := f.Write([]byte("a long fifth\n")); err != nil {
+		t.Fatalf("Failed to write: %v", err)
+	}
+	f.Close()
+
+	for name, expected := range map[string]string{
+		"audit.log":   "a long fifth\n",
+		"audit.log.1": "fourth\n",
+		"audit.log.2": "third\n",
+	} {
+		if data, _ := os.ReadFile(filepath.Join(filepath.Dir(path), name)); string(data) != expected {
+			t.Errorf("Expected %s to hold %q, got %q", name, expected, data)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("Expected 2 rotated files only")
+	}
+
+	// The size of an existing file counts
+	f, _ = openRotatingFile(path, 20, 0)
+	f.Write([]byte("sixth\n"))
+	f.Write([]byte("seventh\n"))
+	f.Close()
+	if data, _ := os.ReadFile(path); string(data) != "seventh\n" {
+		t.Errorf("Expected the file to be truncated without backups, got %q", data)
+	}
+}
+
+func TestEntry(t *testing.T) {
+	// Not audited
+	SetAuth(context.Background(), "admin", nil, "cert")
+	SetAttr(context.Background(), "mode", "bypass")
+
+	ctx, e := newContext(context.Background())
+	roles := []string{"admin"}
+	SetAuth(ctx, "admin", roles, "password")
+	roles[0] = "changed"
+	SetAttr(ctx, "dry_run", "true")
+	e.addPaths([]string{"update /a", "update /a"})
+	AddTargets(ctx, "update /b")
+	for i := 0; i < maxPaths; i++ {
+		e.addPaths([]string{fmt.Sprintf("update /%d", i)})
+	}
+
+	start := time.Now().Add(-time.Second)
+	r := e.newRecord("/gnmi.gNMI/Set", "10.0.0.1:1234", start, "Unknown", fmt.Errorf("failed"))
+	if r.User != "admin" || r.Roles[0] != "admin" || r.AuthMethod != "password" || r.Peer != "10.0.0.1:1234" ||
+		r.Attrs["dry_run"] != "true" || r.Code != "Unknown" || r.Error != "failed" || r.DurationMs < 1000 {
+		t.Errorf("Unexpected record %+v", r)
+	}
+	if len(r.Paths) != maxPaths || r.Paths[0] != "update /a" || r.Paths[1] != "update /b" {
+		t.Errorf("Unexpected paths %v", r.Paths)
+	}
+	if r.Time != start.UTC().Format(time.RFC3339Nano) {
+		t.Errorf("Unexpected time %s", r.Time)
+	}
+}
@@ -0,0 +1,253 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+	gnoi_types "github.com/openconfig/gnoi/types"
+	"github.com/openconfig/ygot/ygot"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// readOnlyMethods are the RPCs which do not change the state of the switch.
+// Every other RPC is audited, so that new RPCs are audited by default.
+var readOnlyMethods = map[string]bool{
+	"/gnmi.gNMI/Capabilities":                                        true,
+	"/gnmi.gNMI/Get":                                                 true,
+	"/gnmi.gNMI/Subscribe":                                           true,
+	"/gnoi.system.System/Ping":                                       true,
+	"/gnoi.system.System/Traceroute":                                 true,
+	"/gnoi.system.System/Time":                                       true,
+	"/gnoi.system.System/RebootStatus":                               true,
+	"/gnoi.file.File/Get":                                            true,
+	"/gnoi.file.File/Stat":                                           true,
+	"/gnoi.healthz.Healthz/Get":                                      true,
+	"/gnoi.healthz.Healthz/List":                                     true,
+	"/gnoi.healthz.Healthz/Artifact":                                 true,
+	"/gnoi.os.OS/Verify":                                             true,
+	"/gnoi.containerz.Containerz/ListContainer":                      true,
+	"/gnoi.containerz.Containerz/ListImage":                          true,
+	"/gnoi.containerz.Containerz/ListVolume":                         true,
+	"/gnoi.containerz.Containerz/Log":                                true,
+	"/gnsi.certz.v1.Certz/GetProfileList":                            true,
+	"/gnsi.certz.v1.Certz/CanGenerateCSR":                            true,
+	"/gnsi.authz.v1.Authz/Get":                                       true,
+	"/gnsi.authz.v1.Authz/Probe":                                     true,
+	"/gnsi.pathz.v1.Pathz/Get":                                       true,
+	"/gnsi.pathz.v1.Pathz/Probe":                                     true,
+	"/gnoi.sonic.Debug/GetSubscribePreferences":                      true,
+	"/grpc.health.v1.Health/Check":                                   true,
+	"/grpc.health.v1.Health/Watch":                                   true,
+	"/grpc.reflection.v1.ServerReflection/ServerReflectionInfo":      true,
+	"/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo": true,
+}
+
+// targetFields are the request fields naming what an RPC acts on
+var targetFields = map[protoreflect.Name]bool{
+	"remote_file":    true,
+	"local_path":     true,
+	"path":           true,
+	"name":           true,
+	"instance_name":  true,
+	"image_name":     true,
+	"pid":            true,
+	"method":         true,
+	"version":        true,
+	"ssl_profile_id": true,
+	"protocol":       true,
+	"command":        true,
+	"role_account":   true,
+	"source":         true,
+	"destination":    true,
+	"imagename":      true,
+	"ifname":         true,
+	"ip":             true,
+	"signal":         true,
+}
+
+// maxDepth bounds the nesting of the request messages searched for targets
+const maxDepth = 6
+
+// IsAudited reports whether the RPC fullMethod is audited.
+func IsAudited(fullMethod string) bool {
+	return !readOnlyMethods[fullMethod]
+}
+
+// Interceptor audits the RPCs to the default Logger.
+type Interceptor struct{}
+
+// NewInterceptor creates an Interceptor.
+func NewInterceptor() *Interceptor {
+	return &Interceptor{}
+}
+
+func peerAddr(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return p.Addr.String()
+	}
+	return ""
+}
+
+// UnaryInterceptor returns a grpc.UnaryServerInterceptor auditing unary RPCs.
+func (i *Interceptor) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		logger := getDefaultLogger()
+		if logger == nil || !IsAudited(info.FullMethod) {
+			return handler(ctx, req)
+		}
+		start := time.Now()
+		ctx, e := newContext(ctx)
+		e.addPaths(requestTargets(req))
+		resp, err := handler(ctx, req)
+		logger.Log(e.newRecord(info.FullMethod, peerAddr(ctx), start, status.Code(err).String(), err))
+		return resp, err
+	}
+}
+
+// auditStream records the targets of the requests received on a stream.
+type auditStream struct {
+	grpc.ServerStream
+	ctx context.Context
+	e   *entry
+}
+
+func (s *auditStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *auditStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		s.e.addPaths(requestTargets(m))
+	}
+	return err
+}
+
+// StreamInterceptor returns a grpc.StreamServerInterceptor auditing streaming
+// RPCs. A streaming RPC is recorded once it completes.
+func (i *Interceptor) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		logger := getDefaultLogger()
+		if logger == nil || !IsAudited(info.FullMethod) {
+			return handler(srv, ss)
+		}
+		start := time.Now()
+		ctx, e := newContext(ss.Context())
+		err := handler(srv, &auditStream{ServerStream: ss, ctx: ctx, e: e})
+		logger.Log(e.newRecord(info.FullMethod, peerAddr(ctx), start, status.Code(err).String(), err))
+		return err
+	}
+}
+
+// PathString returns path joined to prefix as a string, preceded by the
+// target of prefix.
+func PathString(prefix *gnmipb.Path, path *gnmipb.Path) string {
+	full := &gnmipb.Path{}
+	if prefix != nil {
+		full.Target = prefix.GetTarget()
+		full.Elem = append(full.Elem, prefix.GetElem()...)
+	}
+	full.Elem = append(full.Elem, path.GetElem()...)
+	s, err := ygot.PathToString(full)
+	if err != nil {
+		s = path.String()
+	}
+	if full.Target != "" {
+		s = full.Target + ":" + s
+	}
+	return s
+}
+
+// requestTargets returns the paths and targets of a request. The paths of a
+// SetRequest are qualified by their operation. Other requests are searched
+// for gNMI paths and targetFields.
+func requestTargets(req interface{}) []string {
+	if set, ok := req.(*gnmipb.SetRequest); ok {
+		var targets []string
+		for _, path := range set.GetDelete() {
+			targets = append(targets, "delete "+PathString(set.GetPrefix(), path))
+		}
+		for _, update := range set.GetReplace() {
+			targets = append(targets, "replace "+PathString(set.GetPrefix(), update.GetPath()))
+		}
+		for _, update := range set.GetUpdate() {
+			targets = append(targets, "update "+PathString(set.GetPrefix(), update.GetPath()))
+		}
+		return targets
+	}
+	var m protoreflect.ProtoMessage
+	switch msg := req.(type) {
+	case protoreflect.ProtoMessage:
+		m = msg
+	case protoadapt.MessageV1:
+		// The SONiC gNOI messages are generated by gogo protobuf
+		m = protoadapt.MessageV2Of(msg)
+	default:
+		return nil
+	}
+	var targets []string
+	messageTargets(m.ProtoReflect(), 0, &targets)
+	return targets
+}
+
+func messageTargets(m protoreflect.Message, depth int, targets *[]string) {
+	if depth > maxDepth || len(*targets) >= maxPaths {
+		return
+	}
+	switch path := m.Interface().(type) {
+	case *gnmipb.Path:
+		*targets = append(*targets, PathString(nil, path))
+		return
+	case *gnoi_types.Path:
+		gnmiPath := &gnmipb.Path{}
+		for _, elem := range path.GetElem() {
+			gnmiPath.Elem = append(gnmiPath.Elem, &gnmipb.PathElem{Name: elem.GetName(), Key: elem.GetKey()})
+		}
+		*targets = append(*targets, PathString(nil, gnmiPath))
+		return
+	}
+	// Fields are walked in declaration order, so that records are stable
+	fields := m.Descriptor().Fields()
+	for i := 0; i < fields.Len() && len(*targets) < maxPaths; i++ {
+		fd := fields.Get(i)
+		if !m.Has(fd) {
+			continue
+		}
+		v := m.Get(fd)
+		switch {
+		case fd.IsMap():
+		case fd.IsList():
+			if fd.Kind() == protoreflect.MessageKind {
+				list := v.List()
+				for j := 0; j < list.Len(); j++ {
+					messageTargets(list.Get(j).Message(), depth+1, targets)
+				}
+			}
+		case fd.Kind() == protoreflect.MessageKind:
+			messageTargets(v.Message(), depth+1, targets)
+		case targetFields[fd.Name()]:
+			if value := fieldString(fd, v); value != "" {
+				*targets = append(*targets, fmt.Sprintf("%s=%s", fd.Name(), value))
+			}
+		}
+	}
+}
+
+// fieldString returns the value of a scalar field, empty for bytes.
+func fieldString(fd protoreflect.FieldDescriptor, v protoreflect.Value) string {
+	switch fd.Kind() {
+	case protoreflect.BytesKind:
+		return ""
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+			return string(ev.Name())
+		}
+	}
+	return v.String()
+}
@@ -0,0 +1,207 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"testing"
+
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+	gnoi_file_pb "github.com/openconfig/gnoi/file"
+	gnoi_system_pb "github.com/openconfig/gnoi/system"
+	gnoi_types "github.com/openconfig/gnoi/types"
+	sgpb "github.com/sonic-net/sonic-gnmi/proto/gnoi"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// useFakeLogger registers a default Logger writing to a fake syslog.
+func useFakeLogger(t *testing.T) *fakeSyslog {
+	s := useFakeSyslog(t)
+	l, err := NewLogger(Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetDefaultLogger(l)
+	t.Cleanup(func() { SetDefaultLogger(nil) })
+	return s
+}
+
+func records(t *testing.T, s *fakeSyslog) []Record {
+	t.Helper()
+	var rs []Record
+	for _, m := range s.messages {
+		var r Record
+		if err := json.Unmarshal([]byte(m), &r); err != nil {
+			t.Fatalf("Invalid record %s: %v", m, err)
+		}
+		rs = append(rs, r)
+	}
+	return rs
+}
+
+func TestUnaryInterceptor(t *testing.T) {
+	s := useFakeLogger(t)
+	interceptor := NewInterceptor().UnaryInterceptor()
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 1234}})
+
+	req := &gnmipb.SetRequest{
+		Prefix: &gnmipb.Path{Target: "APPL_DB", Elem: []*gnmipb.PathElem{{Name: "DASH_ROUTE_TABLE"}}},
+		Delete: []*gnmipb.Path{{Elem: []*gnmipb.PathElem{{Name: "eni0"}}}},
+		Update: []*gnmipb.Update{{Path: &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "eni1", Key: map[string]string{"id": "1"}}}}}},
+	}
+	_, err := interceptor(ctx, req, &grpc.UnaryServerInfo{FullMethod: "/gnmi.gNMI/Set"},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			SetAuth(ctx, "admin", []string{"admin"}, "cert")
+			SetAttr(ctx, "mode", "bypass")
+			return nil, status.Error(codes.PermissionDenied, "denied")
+		})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected the handler error, got %v", err)
+	}
+
+	// Read-only RPCs are not audited
+	interceptor(ctx, &gnmipb.GetRequest{}, &grpc.UnaryServerInfo{FullMethod: "/gnmi.gNMI/Get"},
+		func(ctx context.Context, req interface{}) (interface{}, error) { return nil, nil })
+
+	// New RPCs are audited
+	interceptor(ctx, &sgpb.ImageInstallRequest{Input: &sgpb.ImageInstallRequest_Input{Imagename: "sonic.bin"}},
+		&grpc.UnaryServerInfo{FullMethod: "/gnoi.sonic.SonicService/ImageInstall"},
+		func(ctx context.Context, req interface{}) (interface{}, error) { return nil, nil })
+
+	rs := records(t, s)
+	if len(rs) != 2 {
+		t.Fatalf("Expected 2 records, got %v", s.messages)
+	}
+	set := rs[0]
+	if set.RPC != "/gnmi.gNMI/Set" || set.User != "admin" || set.Roles[0] != "admin" || set.AuthMethod != "cert" ||
+		set.Peer != "10.0.0.1:1234" || set.Attrs["mode"] != "bypass" || set.Code != "PermissionDenied" ||
+		set.Error != "rpc error: code = PermissionDenied desc = denied" {
+		t.Errorf("Unexpected record %+v", set)
+	}
+	if len(set.Paths) != 2 || set.Paths[0] != "delete APPL_DB:/DASH_ROUTE_TABLE/eni0" ||
+		set.Paths[1] != "update APPL_DB:/DASH_ROUTE_TABLE/eni1[id=1]" {
+		t.Errorf("Unexpected paths %v", set.Paths)
+	}
+	if image := rs[1]; image.Code != "OK" || len(image.Paths) != 1 || image.Paths[0] != "imagename=sonic.bin" {
+		t.Errorf("Unexpected record %+v", image)
+	}
+}
+
+func TestInterceptorWithoutLogger(t *testing.T) {
+	SetDefaultLogger(nil)
+	called := false
+	NewInterceptor().UnaryInterceptor()(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/gnmi.gNMI/Set"},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			called = true
+			SetAuth(ctx, "admin", nil, "cert")
+			return nil, nil
+		})
+	if !called {
+		t.Errorf("Expected the handler to be called")
+	}
+}
+
+// fakeStream is a server stream receiving requests.
+type fakeStream struct {
+	grpc.ServerStream
+	ctx      context.Context
+	requests []*gnoi_file_pb.PutRequest
+}
+
+func (s *fakeStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *fakeStream) RecvMsg(m interface{}) error {
+	if len(s.requests) == 0 {
+		return io.EOF
+	}
+	proto.Merge(m.(*gnoi_file_pb.PutRequest), s.requests[0])
+	s.requests = s.requests[1:]
+	return nil
+}
+
+func TestStreamInterceptor(t *testing.T) {
+	s := useFakeLogger(t)
+	ss := &fakeStream{
+		ctx: context.Background(),
+		requests: []*gnoi_file_pb.PutRequest{
+			{Request: &gnoi_file_pb.PutRequest_Open{Open: &gnoi_file_pb.PutRequest_Details{RemoteFile: "/tmp/image.bin", Permissions: 0644}}},
+			{Request: &gnoi_file_pb.PutRequest_Contents{Contents: []byte("contents")}},
+			{Request: &gnoi_file_pb.PutRequest_Hash{Hash: &gnoi_types.HashType{Method: gnoi_types.HashType_MD5}}},
+		},
+	}
+	err := NewInterceptor().StreamInterceptor()(nil, ss, &grpc.StreamServerInfo{FullMethod: "/gnoi.file.File/Put"},
+		func(srv interface{}, stream grpc.ServerStream) error {
+			SetAuth(stream.Context(), "admin", []string{"admin"}, "jwt")
+			for {
+				req := &gnoi_file_pb.PutRequest{}
+				if err := stream.RecvMsg(req); err != nil {
+					return nil
+				}
+			}
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rs := records(t, s)
+	if len(rs) != 1 {
+		t.Fatalf("Expected 1 record, got %v", s.messages)
+	}
+	put := rs[0]
+	if put.RPC != "/gnoi.file.File/Put" || put.User != "admin" || put.AuthMethod != "jwt" || put.Code != "OK" ||
+		len(put.Paths) != 2 || put.Paths[0] != "remote_file=/tmp/image.bin" || put.Paths[1] != "method=MD5" {
+		t.Errorf("Unexpected record %+v", put)
+	}
+}
+
+func TestRequestTargets(t *testing.T) {
+	targets := requestTargets(&gnoi_system_pb.RebootRequest{
+		Method:        gnoi_system_pb.RebootMethod_COLD,
+		Subcomponents: []*gnoi_types.Path{{Elem: []*gnoi_types.PathElem{{Name: "components"}}}},
+	})
+	if len(targets) != 2 || targets[0] != "method=COLD" || targets[1] != "/components" {
+		t.Errorf("Unexpected reboot targets %v", targets)
+	}
+	// Targets follow the field declaration order
+	for i := 0; i < 20; i++ {
+		targets = requestTargets(&gnoi_system_pb.KillProcessRequest{Pid: 10, Name: "bgpd", Signal: gnoi_system_pb.KillProcessRequest_SIGNAL_KILL})
+		if len(targets) != 3 || targets[0] != "pid=10" || targets[1] != "name=bgpd" || targets[2] != "signal=SIGNAL_KILL" {
+			t.Fatalf("Unexpected kill process targets %v", targets)
+		}
+	}
+	targets = requestTargets(&gnmipb.SubscribeRequest{Request: &gnmipb.SubscribeRequest_Subscribe{Subscribe: &gnmipb.SubscriptionList{
+		Subscription: []*gnmipb.Subscription{{Path: &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "interfaces"}}}}},
+	}}})
+	if len(targets) != 1 || targets[0] != "/interfaces" {
+		t.Errorf("Unexpected gNMI path targets %v", targets)
+	}
+	if targets := requestTargets("not a message"); targets != nil {
+		t.Errorf("Unexpected targets %v", targets)
+	}
+}
+
+func TestIsAudited(t *testing.T) {
+	for method, audited := range map[string]bool{
+		"/gnmi.gNMI/Set":                      true,
+		"/gnmi.gNMI/Get":                      false,
+		"/gnoi.system.System/Reboot":          true,
+		"/gnoi.system.System/Time":            false,
+		"/gnsi.certz.v1.Certz/Rotate":         true,
+		"/gnoi.sonic.DebugShell/Shell":        true,
+		"/gnoi.sonic.SonicService/CopyConfig": true,
+		"/gnoi.file.File/Remove":              true,
+		"/gnsi.authz.v1.Authz/Probe":          false,
+		"/grpc.health.v1.Health/Check":        false,
+	} {
+		if IsAudited(method) != audited {
+			t.Errorf("Expected %s audited %v", method, audited)
+		}
+	}
+}
@@ -20,7 +20,8 @@ const (
 	MetadataKeyBypassValidation = "x-sonic-ss-bypass-validation"
 )
 
-// AllowedTables lists ConfigDB tables that can bypass validation (exact match)
+// AllowedTables lists ConfigDB tables that can bypass validation (exact match),
+// unless overridden in ConfigTable
 var AllowedTables = map[string]bool{
 	"VNET":               true,
 	"VNET_ROUTE_TUNNEL":  true,
@@ -29,7 +30,8 @@ var AllowedTables = map[string]bool{
 	"BGP_PEER_RANGE":     true,
 }
 
-// AllowedSKUPrefixes lists HwSku prefixes that can use bypass validation
+// AllowedSKUPrefixes lists HwSku prefixes that can use bypass validation,
+// unless overridden in ConfigTable
 var AllowedSKUPrefixes = []string{
 	"Cisco-8102",
 	"Cisco-8101",
@@ -121,7 +123,7 @@ func checkAllowedDeletePaths(prefix *gnmipb.Path, deletes []*gnmipb.Path) bool {
 			glog.V(2).Infof("Bypass: could not extract table from delete path")
 			return false
 		}
-		if !AllowedTables[table] {
+		if !allowedTable(table) {
 			glog.V(2).Infof("Bypass: table %s not in allowlist for delete", table)
 			return false
 		}
@@ -144,8 +146,17 @@ func hasBypassHeader(ctx context.Context) bool {
 	return false
 }
 
-// checkSKU verifies device SKU matches one of the allowed prefixes
+// checkSKU verifies device SKU matches one of the allowed prefixes.
+// The SKU is cached while the config watcher runs.
 func checkSKU() bool {
+	if cfg := currentConfig(); cfg != nil {
+		if !matchSKU(cfg.hwsku, cfg.skuPrefixes) {
+			glog.V(2).Infof("Bypass: SKU %s does not match any allowed prefix", cfg.hwsku)
+			return false
+		}
+		return true
+	}
+
 	rclient, err := getConfigDbClientFunc()
 	if err != nil {
 		glog.V(2).Infof("Bypass: failed to get CONFIG_DB client: %v", err)
@@ -159,10 +170,8 @@ func checkSKU() bool {
 		return false
 	}
 
-	for _, prefix := range AllowedSKUPrefixes {
-		if strings.HasPrefix(hwsku, prefix) {
-			return true
-		}
+	if matchSKU(hwsku, AllowedSKUPrefixes) {
+		return true
 	}
 	glog.V(2).Infof("Bypass: SKU %s does not match any allowed prefix", hwsku)
 	return false
@@ -176,7 +185,7 @@ func checkAllowedTables(prefix *gnmipb.Path, updates []*gnmipb.Update) bool {
 			glog.V(2).Infof("Bypass: could not extract table from path")
 			return false
 		}
-		if !AllowedTables[table] {
+		if !allowedTable(table) {
 			glog.V(2).Infof("Bypass: table %s not in allowlist", table)
 			return false
 		}
@@ -209,19 +218,20 @@ func extractTable(prefix *gnmipb.Path, path *gnmipb.Path) string {
 	return ""
 }
 
-// Apply executes the bypass write directly to ConfigDB
-// Returns nil on success, error on failure
-func Apply(ctx context.Context, prefix *gnmipb.Path, updates []*gnmipb.Update) error {
-	rclient, err := getConfigDbClientFunc()
-	if err != nil {
-		return fmt.Errorf("bypass: failed to get CONFIG_DB client: %v", err)
-	}
-	defer rclient.Close()
+// writeOp is a single ConfigDB write of a bypass Set
+type writeOp struct {
+	table  string
+	key    string                 // Redis key, TABLE|KEY
+	fields map[string]interface{} // nil deletes the key
+}
 
+// stageUpdates converts updates into ConfigDB writes
+func stageUpdates(prefix *gnmipb.Path, updates []*gnmipb.Update) ([]writeOp, error) {
+	var ops []writeOp
 	for _, update := range updates {
 		table, key, field := parsePath(prefix, update.GetPath())
 		if table == "" {
-			return fmt.Errorf("bypass: invalid path, cannot extract table")
+			return nil, fmt.Errorf("bypass: invalid path, cannot extract table")
 		}
 
 		val := update.GetVal()
@@ -232,23 +242,19 @@ func Apply(ctx context.Context, prefix *gnmipb.Path, updates []*gnmipb.Update) e
 		// JSON: {"entryKey1": {"field": "value"}, "entryKey2": {...}}
 		if key == "" {
 			if len(jsonVal) == 0 {
-				return fmt.Errorf("bypass: bulk update requires JSON value")
+				return nil, fmt.Errorf("bypass: bulk update requires JSON value")
 			}
 			var bulkData map[string]map[string]interface{}
 			if err := json.Unmarshal(jsonVal, &bulkData); err != nil {
-				return fmt.Errorf("bypass: failed to unmarshal bulk JSON: %v", err)
+				return nil, fmt.Errorf("bypass: failed to unmarshal bulk JSON: %v", err)
 			}
 			for entryKey, entryFields := range bulkData {
-				redisKey := table + "|" + entryKey
 				fields := convertToRedisFields(entryFields)
 				// For empty entry, use NULL placeholder (SONiC convention)
 				if len(fields) == 0 {
 					fields["NULL"] = "NULL"
 				}
-				if _, err := rclient.HSet(context.Background(), redisKey, fields).Result(); err != nil {
-					return fmt.Errorf("bypass: HSet failed for %s: %v", redisKey, err)
-				}
-				glog.V(2).Infof("Bypass: wrote %s with %d fields", redisKey, len(fields))
+				ops = append(ops, writeOp{table: table, key: table + "|" + entryKey, fields: fields})
 			}
 			continue
 		}
@@ -260,7 +266,7 @@ func Apply(ctx context.Context, prefix *gnmipb.Path, updates []*gnmipb.Update) e
 		if len(jsonVal) > 0 {
 			var data map[string]interface{}
 			if err := json.Unmarshal(jsonVal, &data); err != nil {
-				return fmt.Errorf("bypass: failed to unmarshal JSON: %v", err)
+				return nil, fmt.Errorf("bypass: failed to unmarshal JSON: %v", err)
 			}
 
 			fields := convertToRedisFields(data)
@@ -268,10 +274,7 @@ func Apply(ctx context.Context, prefix *gnmipb.Path, updates []*gnmipb.Update) e
 			if len(fields) == 0 {
 				fields["NULL"] = "NULL"
 			}
-			if _, err := rclient.HSet(context.Background(), redisKey, fields).Result(); err != nil {
-				return fmt.Errorf("bypass: HSet failed for %s: %v", redisKey, err)
-			}
-			glog.V(2).Infof("Bypass: wrote %s with %d fields", redisKey, len(fields))
+			ops = append(ops, writeOp{table: table, key: redisKey, fields: fields})
 			continue
 		}
 
@@ -285,41 +288,81 @@ func Apply(ctx context.Context, prefix *gnmipb.Path, updates []*gnmipb.Update) e
 			} else if v := val.GetUintVal(); v != 0 {
 				strVal = fmt.Sprintf("%d", v)
 			}
-			if _, err := rclient.HSet(context.Background(), redisKey, field, strVal).Result(); err != nil {
-				return fmt.Errorf("bypass: HSet failed for %s.%s: %v", redisKey, field, err)
-			}
-			glog.V(2).Infof("Bypass: wrote %s.%s = %s", redisKey, field, strVal)
+			ops = append(ops, writeOp{table: table, key: redisKey, fields: map[string]interface{}{field: strVal}})
 		}
 	}
+	return ops, nil
+}
 
-	return nil
+// stageDeletes converts delete paths into ConfigDB writes
+func stageDeletes(prefix *gnmipb.Path, deletes []*gnmipb.Path) ([]writeOp, error) {
+	var ops []writeOp
+	for _, path := range deletes {
+		table, key, _ := parsePath(prefix, path)
+		if table == "" || key == "" {
+			return nil, fmt.Errorf("bypass: invalid delete path, cannot extract table/key")
+		}
+		ops = append(ops, writeOp{table: table, key: table + "|" + key})
+	}
+	return ops, nil
 }
 
-// Delete executes bypass delete directly to ConfigDB
-// Returns nil on success, error on failure
-func Delete(ctx context.Context, prefix *gnmipb.Path, deletes []*gnmipb.Path) error {
+// write executes ConfigDB writes in a single MULTI/EXEC transaction, so that
+// either all or none of them are applied
+func write(ctx context.Context, ops []writeOp) error {
+	if len(ops) == 0 {
+		return nil
+	}
 	rclient, err := getConfigDbClientFunc()
 	if err != nil {
 		return fmt.Errorf("bypass: failed to get CONFIG_DB client: %v", err)
 	}
 	defer rclient.Close()
 
-	for _, path := range deletes {
-		table, key, _ := parsePath(prefix, path)
-		if table == "" || key == "" {
-			return fmt.Errorf("bypass: invalid delete path, cannot extract table/key")
+	if validationEnabled() {
+		if err := validateWrites(ctx, rclient, ops); err != nil {
+			return err
 		}
+	}
 
-		redisKey := table + "|" + key
-		if _, err := rclient.Del(context.Background(), redisKey).Result(); err != nil {
-			return fmt.Errorf("bypass: Del failed for %s: %v", redisKey, err)
+	_, err = rclient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, op := range ops {
+			if op.fields == nil {
+				pipe.Del(ctx, op.key)
+				glog.V(2).Infof("Bypass: deleting %s", op.key)
+				continue
+			}
+			pipe.HSet(ctx, op.key, op.fields)
+			glog.V(2).Infof("Bypass: writing %s with %d fields", op.key, len(op.fields))
 		}
-		glog.V(2).Infof("Bypass: deleted %s", redisKey)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("bypass: transaction failed: %v", err)
 	}
-
 	return nil
 }
 
+// Apply executes the bypass write directly to ConfigDB in a single transaction
+// Returns nil on success, error on failure
+func Apply(ctx context.Context, prefix *gnmipb.Path, updates []*gnmipb.Update) error {
+	ops, err := stageUpdates(prefix, updates)
+	if err != nil {
+		return err
+	}
+	return write(ctx, ops)
+}
+
+// Delete executes bypass delete directly to ConfigDB in a single transaction
+// Returns nil on success, error on failure
+func Delete(ctx context.Context, prefix *gnmipb.Path, deletes []*gnmipb.Path) error {
+	ops, err := stageDeletes(prefix, deletes)
+	if err != nil {
+		return err
+	}
+	return write(ctx, ops)
+}
+
 // parsePath extracts table, key, and optional field from gNMI path
 func parsePath(prefix *gnmipb.Path, path *gnmipb.Path) (table, key, field string) {
 	var elems []*gnmipb.PathElem
@@ -401,32 +444,32 @@ func TrySet(ctx context.Context, prefix *gnmipb.Path, deletes []*gnmipb.Path, up
 	}
 
 	glog.V(2).Infof("Bypass fast path: direct ConfigDB operations")
-	var results []*gnmipb.UpdateResult
 
-	// Execute deletes first (per gNMI spec order)
-	if len(deletes) > 0 {
-		if err := Delete(ctx, prefix, deletes); err != nil {
-			return nil, true, err
-		}
-		for _, d := range deletes {
-			results = append(results, &gnmipb.UpdateResult{
-				Path: d,
-				Op:   gnmipb.UpdateResult_DELETE,
-			})
-		}
+	// Deletes first (per gNMI spec order), then updates, in one transaction
+	ops, err := stageDeletes(prefix, deletes)
+	if err != nil {
+		return nil, true, err
+	}
+	updateOps, err := stageUpdates(prefix, updates)
+	if err != nil {
+		return nil, true, err
+	}
+	if err := write(ctx, append(ops, updateOps...)); err != nil {
+		return nil, true, err
 	}
 
-	// Then execute updates
-	if len(updates) > 0 {
-		if err := Apply(ctx, prefix, updates); err != nil {
-			return nil, true, err
-		}
-		for _, u := range updates {
-			results = append(results, &gnmipb.UpdateResult{
-				Path: u.GetPath(),
-				Op:   gnmipb.UpdateResult_UPDATE,
-			})
-		}
+	var results []*gnmipb.UpdateResult
+	for _, d := range deletes {
+		results = append(results, &gnmipb.UpdateResult{
+			Path: d,
+			Op:   gnmipb.UpdateResult_DELETE,
+		})
+	}
+	for _, u := range updates {
+		results = append(results, &gnmipb.UpdateResult{
+			Path: u.GetPath(),
+			Op:   gnmipb.UpdateResult_UPDATE,
+		})
 	}
 
 	return &gnmipb.SetResponse{
@@ -0,0 +1,206 @@
+package bypass
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/redis/go-redis/v9"
+)
+
+// ConfigTable is the CONFIG_DB table configuring the bypass fast path. Each
+// field of its global entry overrides the corresponding default:
+//
+//	GNMI_BYPASS_VALIDATION|global
+//	    tables@        tables that can bypass validation (AllowedTables)
+//	    sku_prefixes@  HwSku prefixes that can use bypass validation (AllowedSKUPrefixes)
+//	    validate       "true" to check the written values against the YANG model
+//	                   of their table
+const ConfigTable = "GNMI_BYPASS_VALIDATION"
+
+const configKey = ConfigTable + "|global"
+
+// DefaultConfigInterval is the interval at which the config watcher reloads
+// the bypass configuration in case a keyspace notification was missed.
+const DefaultConfigInterval = 5 * time.Minute
+
+// config is the bypass configuration loaded from CONFIG_DB.
+type config struct {
+	tables      map[string]bool
+	skuPrefixes []string
+	validate    bool
+	hwsku       string // device HwSku, read once
+}
+
+var (
+	configMu sync.RWMutex
+	// watched is the configuration kept up to date by the config watcher,
+	// nil while the watcher is not running
+	watched *config
+)
+
+// currentConfig returns the configuration kept by the config watcher, or nil.
+func currentConfig() *config {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return watched
+}
+
+func setConfig(cfg *config) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	watched = cfg
+}
+
+// allowedTable checks if a table can bypass validation.
+func allowedTable(table string) bool {
+	if cfg := currentConfig(); cfg != nil {
+		return cfg.tables[table]
+	}
+	return AllowedTables[table]
+}
+
+// validationEnabled checks if bypass writes are validated against the YANG models.
+func validationEnabled() bool {
+	cfg := currentConfig()
+	return cfg != nil && cfg.validate
+}
+
+// matchSKU checks if a HwSku matches one of the prefixes.
+func matchSKU(hwsku string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(hwsku, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitList splits a comma separated CONFIG_DB list field.
+func splitList(val string) []string {
+	var items []string
+	for _, item := range strings.Split(val, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// loadConfig reads the bypass configuration from CONFIG_DB. The HwSku of prev,
+// if any, is reused since it does not change at runtime.
+func loadConfig(prev *config) (*config, error) {
+	rclient, err := getConfigDbClientFunc()
+	if err != nil {
+		return nil, err
+	}
+	defer rclient.Close()
+
+	ctx := context.Background()
+	fields, err := rclient.HGetAll(ctx, configKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &config{
+		tables:      AllowedTables,
+		skuPrefixes: AllowedSKUPrefixes,
+		validate:    fields["validate"] == "true",
+	}
+	if val, ok := fields["tables@"]; ok {
+		cfg.tables = map[string]bool{}
+		for _, table := range splitList(val) {
+			cfg.tables[table] = true
+		}
+	}
+	if val, ok := fields["sku_prefixes@"]; ok {
+		cfg.skuPrefixes = splitList(val)
+	}
+
+	if prev != nil && prev.hwsku != "" {
+		cfg.hwsku = prev.hwsku
+	} else if cfg.hwsku, err = rclient.HGet(ctx, "DEVICE_METADATA|localhost", "hwsku").Result(); err != nil {
+		// The SKU check fails until the HwSku can be read
+		glog.V(2).Infof("Bypass: failed to read SKU: %v", err)
+		cfg.hwsku = ""
+	}
+	return cfg, nil
+}
+
+// reloadConfig reloads the watched configuration, keeping the previous one if
+// CONFIG_DB cannot be read.
+func reloadConfig() {
+	prev := currentConfig()
+	cfg, err := loadConfig(prev)
+	if err != nil {
+		glog.V(2).Infof("Bypass: failed to load %s: %v", configKey, err)
+		return
+	}
+	setConfig(cfg)
+}
+
+// StartConfigWatcher loads the bypass configuration from CONFIG_DB and reloads
+// it on each keyspace notification of its entry, until the returned function is
+// called. The configuration is also reloaded every interval, in case
+// notifications are disabled or were missed. While the watcher runs, the
+// allowlists and the SKU check use the loaded configuration instead of reading
+// DEVICE_METADATA on every request.
+func StartConfigWatcher(interval time.Duration) (stop func()) {
+	if interval <= 0 {
+		interval = DefaultConfigInterval
+	}
+	setConfig(nil)
+
+	// Subscribe before the first load so that no change is missed
+	var notifications <-chan *redis.Message
+	var pubsub *redis.PubSub
+	rclient, err := getConfigDbClientFunc()
+	if err != nil {
+		glog.Warningf("Bypass: failed to watch %s, polling every %v: %v", configKey, interval, err)
+	} else {
+		pattern := fmt.Sprintf("__keyspace@%d__:%s", configDbId, configKey)
+		pubsub = rclient.PSubscribe(context.Background(), pattern)
+		notifications = pubsub.Channel()
+	}
+	reloadConfig()
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case msg, ok := <-notifications:
+				if !ok {
+					notifications = nil
+					continue
+				}
+				glog.V(2).Infof("Bypass: %s changed: %s", configKey, msg.Payload)
+				reloadConfig()
+			case <-ticker.C:
+				reloadConfig()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			wg.Wait()
+			if pubsub != nil {
+				pubsub.Close()
+				rclient.Close()
+			}
+			setConfig(nil)
+		})
+	}
+}
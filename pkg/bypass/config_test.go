@@ -0,0 +1,188 @@
+//go:build !gnmi_memcheck
+// +build !gnmi_memcheck
+
+package bypass
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc/metadata"
+)
+
+// useMiniredis serves CONFIG_DB from miniredis for the duration of a test.
+func useMiniredis(t *testing.T) *miniredis.Miniredis {
+	mr := miniredis.RunT(t)
+	addr := mr.Addr()
+	originalFunc := getConfigDbClientFunc
+	t.Cleanup(func() { getConfigDbClientFunc = originalFunc })
+
+	getConfigDbClientFunc = func() (*redis.Client, error) {
+		return redis.NewClient(&redis.Options{Addr: addr}), nil
+	}
+	return mr
+}
+
+func TestLoadConfig(t *testing.T) {
+	mr := useMiniredis(t)
+	mr.HSet("DEVICE_METADATA|localhost", "hwsku", "Cisco-8102-test")
+
+	cfg, err := loadConfig(nil)
+	if err != nil {
+		t.Fatalf("loadConfig failed: %v", err)
+	}
+	if !reflect.DeepEqual(cfg.tables, AllowedTables) || !reflect.DeepEqual(cfg.skuPrefixes, AllowedSKUPrefixes) || cfg.validate {
+		t.Errorf("Expected default configuration, got %+v", cfg)
+	}
+	if cfg.hwsku != "Cisco-8102-test" {
+		t.Errorf("Expected hwsku Cisco-8102-test, got %q", cfg.hwsku)
+	}
+
+	mr.HSet(configKey, "tables@", "VNET, PORT", "sku_prefixes@", "Arista-", "validate", "true")
+	mr.HSet("DEVICE_METADATA|localhost", "hwsku", "Arista-7050")
+	cfg, err = loadConfig(cfg)
+	if err != nil {
+		t.Fatalf("loadConfig failed: %v", err)
+	}
+	want := &config{
+		tables:      map[string]bool{"VNET": true, "PORT": true},
+		skuPrefixes: []string{"Arista-"},
+		validate:    true,
+		hwsku:       "Cisco-8102-test", // read once
+	}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("Expected %+v, got %+v", want, cfg)
+	}
+
+	// An empty allowlist disables the fast path
+	mr.HSet(configKey, "tables@", "")
+	cfg, _ = loadConfig(cfg)
+	if len(cfg.tables) != 0 {
+		t.Errorf("Expected no allowed table, got %v", cfg.tables)
+	}
+}
+
+func TestConfigWatcher(t *testing.T) {
+	mr := useMiniredis(t)
+	mr.HSet("DEVICE_METADATA|localhost", "hwsku", "Cisco-8102-test")
+	mr.HSet(configKey, "tables@", "PORT")
+
+	stop := StartConfigWatcher(time.Hour)
+	defer stop()
+
+	if !checkSKU() {
+		t.Errorf("Expected SKU Cisco-8102-test to be allowed")
+	}
+	if !allowedTable("PORT") || allowedTable("VNET") {
+		t.Errorf("Expected tables from %s, got %v", configKey, currentConfig().tables)
+	}
+
+	// The SKU check is cached
+	mr.HSet("DEVICE_METADATA|localhost", "hwsku", "Force10-Z9100-C32")
+	if !checkSKU() {
+		t.Errorf("Expected cached SKU check")
+	}
+
+	// Changes are picked up on reload
+	mr.HSet(configKey, "sku_prefixes@", "Force10-")
+	reloadConfig()
+	if checkSKU() {
+		t.Errorf("Expected cached SKU Cisco-8102-test not to match Force10-")
+	}
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(MetadataKeyBypassValidation, "true"))
+	updates := []*gnmipb.Update{{
+		Path: &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "PORT"}, {Name: "Ethernet0"}}},
+		Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_JsonIetfVal{JsonIetfVal: []byte(`{"mtu": "9100"}`)}},
+	}}
+	mr.HSet(configKey, "sku_prefixes@", "Cisco-")
+	reloadConfig()
+	if _, used, err := TrySet(ctx, nil, nil, updates); !used || err != nil {
+		t.Errorf("Expected PORT write to bypass validation, got used=%v err=%v", used, err)
+	}
+
+	// The previous configuration is kept if CONFIG_DB cannot be read
+	mr.Close()
+	reloadConfig()
+	if currentConfig() == nil || !allowedTable("PORT") {
+		t.Errorf("Expected previous configuration to be kept")
+	}
+
+	stop()
+	if currentConfig() != nil {
+		t.Errorf("Expected no configuration after stop")
+	}
+	if allowedTable("PORT") || !allowedTable("VNET") {
+		t.Errorf("Expected default tables after stop")
+	}
+}
+
+func TestConfigWatcherNotification(t *testing.T) {
+	mr := useMiniredis(t)
+	mr.HSet("DEVICE_METADATA|localhost", "hwsku", "Cisco-8102-test")
+	mr.HSet(configKey, "tables@", "PORT")
+
+	// Polling is disabled, changes are picked up from keyspace notifications
+	stop := StartConfigWatcher(time.Hour)
+	defer stop()
+
+	if !allowedTable("PORT") {
+		t.Fatalf("Expected tables from %s, got %v", configKey, currentConfig().tables)
+	}
+
+	mr.HSet(configKey, "tables@", "VNET")
+	channel := fmt.Sprintf("__keyspace@%d__:%s", configDbId, configKey)
+	deadline := time.Now().Add(5 * time.Second)
+	for !allowedTable("VNET") {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected tables to be reloaded on notification, got %v", currentConfig().tables)
+		}
+		// Publish until the watcher has subscribed
+		mr.Publish(channel, "hset")
+		time.Sleep(10 * time.Millisecond)
+	}
+	if allowedTable("PORT") {
+		t.Errorf("Expected PORT not to be allowed after reload")
+	}
+}
+
+func TestTrySetTransaction(t *testing.T) {
+	mr := useMiniredis(t)
+	mr.HSet("DEVICE_METADATA|localhost", "hwsku", "Cisco-8102-test")
+	mr.HSet("VNET|vnet0", "vni", "100")
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(MetadataKeyBypassValidation, "true"))
+	deletes := []*gnmipb.Path{{Elem: []*gnmipb.PathElem{{Name: "VNET"}, {Name: "vnet0"}}}}
+	updates := []*gnmipb.Update{
+		{
+			Path: &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "VNET"}, {Name: "vnet1"}}},
+			Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_JsonIetfVal{JsonIetfVal: []byte(`{"vni": "1000"}`)}},
+		},
+		{
+			Path: &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "VNET"}, {Name: "vnet2"}}},
+			Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_JsonIetfVal{JsonIetfVal: []byte(`not valid json`)}},
+		},
+	}
+
+	// Nothing is written if any operation fails
+	if _, used, err := TrySet(ctx, nil, deletes, updates); !used || err == nil {
+		t.Fatalf("Expected bypass failure, got used=%v err=%v", used, err)
+	}
+	if !mr.Exists("VNET|vnet0") || mr.Exists("VNET|vnet1") {
+		t.Errorf("Expected no write for a failed bypass Set")
+	}
+
+	resp, used, err := TrySet(ctx, nil, deletes, updates[:1])
+	if !used || err != nil || len(resp.GetResponse()) != 2 {
+		t.Fatalf("Expected bypass success, got %v, used=%v err=%v", resp, used, err)
+	}
+	if mr.Exists("VNET|vnet0") || mr.HGet("VNET|vnet1", "vni") != "1000" {
+		t.Errorf("Expected VNET|vnet0 deleted and VNET|vnet1 written")
+	}
+}
@@ -0,0 +1,223 @@
+package bypass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/golang/glog"
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrInvalidValue is wrapped by the errors of bypass writes rejected by the
+// YANG validation.
+var ErrInvalidValue = errors.New("bypass: invalid value")
+
+// yangModelsDir is the directory of the SONiC YANG models
+var yangModelsDir = "/usr/local/yang-models"
+
+var (
+	schemaMu sync.Mutex
+	// tableLists maps each CONFIG_DB table to the lists of its YANG model,
+	// nil until the models are loaded
+	tableLists map[string][]*yang.Entry
+)
+
+// loadSchema returns the YANG lists of every CONFIG_DB table. The models are
+// only loaded once.
+func loadSchema() (map[string][]*yang.Entry, error) {
+	schemaMu.Lock()
+	defer schemaMu.Unlock()
+	if tableLists != nil {
+		return tableLists, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(yangModelsDir, "*.yang"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no YANG model in %s", yangModelsDir)
+	}
+
+	ms := yang.NewModules()
+	yang.AddPath(yangModelsDir)
+	for _, file := range files {
+		if err := ms.Read(file); err != nil {
+			glog.V(2).Infof("Bypass: failed to read %s: %v", file, err)
+		}
+	}
+	for _, err := range ms.Process() {
+		glog.V(2).Infof("Bypass: YANG error: %v", err)
+	}
+
+	// SONiC models are module/sonic-xxx container/TABLE container/TABLE_LIST lists
+	lists := map[string][]*yang.Entry{}
+	for _, mod := range ms.Modules {
+		for _, top := range yang.ToEntry(mod).Dir {
+			for table, tableEntry := range top.Dir {
+				for _, list := range tableEntry.Dir {
+					if list.IsList() {
+						lists[table] = append(lists[table], list)
+					}
+				}
+			}
+		}
+	}
+	tableLists = lists
+	return tableLists, nil
+}
+
+// validateWrites checks the fields written by bypass operations against the
+// YANG model of their table: every field must be a leaf of the table, have a
+// value of its type and reference existing entries. Only integer, boolean,
+// enumeration and leafref types are checked.
+func validateWrites(ctx context.Context, rclient *redis.Client, ops []writeOp) error {
+	schema, err := loadSchema()
+	if err != nil {
+		return fmt.Errorf("bypass: failed to load YANG models: %v", err)
+	}
+
+	// Entries written or deleted by the operations, for leafref checks
+	pending := map[string]bool{}
+	for _, op := range ops {
+		pending[op.key] = op.fields != nil
+	}
+	exists := func(key string) (bool, error) {
+		if ok, found := pending[key]; found {
+			return ok, nil
+		}
+		n, err := rclient.Exists(ctx, key).Result()
+		return n > 0, err
+	}
+
+	for _, op := range ops {
+		if op.fields == nil {
+			continue
+		}
+		lists, ok := schema[op.table]
+		if !ok {
+			return fmt.Errorf("%w: no YANG model for table %s", ErrInvalidValue, op.table)
+		}
+		list := selectList(lists, op.key[len(op.table)+1:])
+		for field, value := range op.fields {
+			if field == "NULL" {
+				continue
+			}
+			name := strings.TrimSuffix(field, "@")
+			leaf, ok := list.Dir[name]
+			if !ok || !(leaf.IsLeaf() || leaf.IsLeafList()) {
+				return fmt.Errorf("%w: %s: unknown field %s", ErrInvalidValue, op.key, field)
+			}
+			values := []string{fmt.Sprintf("%v", value)}
+			if leaf.IsLeafList() {
+				values = splitList(values[0])
+			}
+			for _, v := range values {
+				if err := checkValue(schema, leaf.Type, v, exists); err != nil {
+					return fmt.Errorf("%w: %s: field %s: %v", ErrInvalidValue, op.key, field, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// selectList returns the list of a table matching the number of components
+// of a key, e.g. ACL_RULE_LIST for "ACL_TABLE|RULE".
+func selectList(lists []*yang.Entry, key string) *yang.Entry {
+	parts := len(strings.Split(key, "|"))
+	for _, list := range lists {
+		if len(strings.Fields(list.Key)) == parts {
+			return list
+		}
+	}
+	return lists[0]
+}
+
+// checkValue checks a value against a YANG type.
+func checkValue(schema map[string][]*yang.Entry, typ *yang.YangType, value string, exists func(string) (bool, error)) error {
+	if typ == nil {
+		return nil
+	}
+	switch typ.Kind {
+	case yang.Yint8, yang.Yint16, yang.Yint32, yang.Yint64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", value)
+		}
+		return checkRange(typ, value, yang.FromInt(n))
+	case yang.Yuint8, yang.Yuint16, yang.Yuint32, yang.Yuint64:
+		n, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not an unsigned integer", value)
+		}
+		return checkRange(typ, value, yang.FromUint(n))
+	case yang.Ybool:
+		if value != "true" && value != "false" {
+			return fmt.Errorf("%q is not a boolean", value)
+		}
+	case yang.Yenum:
+		if typ.Enum != nil && !typ.Enum.IsDefined(value) {
+			return fmt.Errorf("%q is not one of %v", value, typ.Enum.Names())
+		}
+	case yang.Yunion:
+		for _, member := range typ.Type {
+			if checkValue(schema, member, value, exists) == nil {
+				return nil
+			}
+		}
+		return fmt.Errorf("%q matches no type of %s", value, typ.Name)
+	case yang.Yleafref:
+		return checkLeafref(schema, typ.Path, value, exists)
+	}
+	return nil
+}
+
+// checkRange checks an integer against the range of its type.
+func checkRange(typ *yang.YangType, value string, n yang.Number) error {
+	if len(typ.Range) == 0 {
+		return nil
+	}
+	for _, r := range typ.Range {
+		if !n.Less(r.Min) && !r.Max.Less(n) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s is out of range %v", value, typ.Range)
+}
+
+// checkLeafref checks that a value references an existing entry. Only absolute
+// references to the key of a single key list, e.g.
+// /vxlan:sonic-vxlan/vxlan:VXLAN_TUNNEL/vxlan:VXLAN_TUNNEL_LIST/vxlan:name,
+// are checked.
+func checkLeafref(schema map[string][]*yang.Entry, path string, value string, exists func(string) (bool, error)) error {
+	elems := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	if !strings.HasPrefix(path, "/") || len(elems) != 4 {
+		return nil
+	}
+	for i, elem := range elems {
+		if j := strings.Index(elem, ":"); j >= 0 {
+			elems[i] = elem[j+1:]
+		}
+	}
+	table, listName, leaf := elems[1], elems[2], elems[3]
+	for _, list := range schema[table] {
+		if list.Name != listName || list.Key != leaf {
+			continue
+		}
+		found, err := exists(table + "|" + value)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return fmt.Errorf("%s|%s does not exist", table, value)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+//go:build !gnmi_memcheck
+// +build !gnmi_memcheck
+
+package bypass
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+	"google.golang.org/grpc/metadata"
+)
+
+const testVnetYang = `
+module sonic-vnet {
+	namespace "http://github.com/sonic-net/sonic-vnet";
+	prefix vnet;
+
+	container sonic-vnet {
+		container VXLAN_TUNNEL {
+			list VXLAN_TUNNEL_LIST {
+				key "name";
+				leaf name { type string; }
+			}
+		}
+		container VNET {
+			list VNET_LIST {
+				key "name";
+				leaf name { type string; }
+				leaf vxlan_tunnel {
+					type leafref {
+						path "/vnet:sonic-vnet/vnet:VXLAN_TUNNEL/vnet:VXLAN_TUNNEL_LIST/vnet:name";
+					}
+				}
+				leaf vni { type uint32 { range "1..16777215"; } }
+				leaf scope { type enumeration { enum default; } }
+				leaf advertise_prefix { type boolean; }
+				leaf-list peer_list {
+					type leafref {
+						path "/vnet:sonic-vnet/vnet:VNET/vnet:VNET_LIST/vnet:name";
+					}
+				}
+			}
+		}
+	}
+}
+`
+
+// useTestSchema loads the YANG models from a directory holding testVnetYang
+// and enables the validation of bypass writes.
+func useTestSchema(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "sonic-vnet.yang"), []byte(testVnetYang), 0644); err != nil {
+		t.Fatal(err)
+	}
+	originalDir := yangModelsDir
+	yangModelsDir = dir
+	tableLists = nil
+	setConfig(&config{tables: AllowedTables, skuPrefixes: AllowedSKUPrefixes, validate: true, hwsku: "Cisco-8102-test"})
+	t.Cleanup(func() {
+		yangModelsDir = originalDir
+		tableLists = nil
+		setConfig(nil)
+	})
+}
+
+func TestTrySetValidation(t *testing.T) {
+	mr := useMiniredis(t)
+	useTestSchema(t)
+	mr.HSet("VXLAN_TUNNEL|tunnel0", "src_ip", "10.1.0.32")
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(MetadataKeyBypassValidation, "true"))
+	vnetUpdate := func(key string, value string) *gnmipb.Update {
+		return &gnmipb.Update{
+			Path: &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "VNET"}, {Name: key}}},
+			Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_JsonIetfVal{JsonIetfVal: []byte(value)}},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		updates []*gnmipb.Update
+		valid   bool
+	}{
+		{
+			name:    "valid entry",
+			updates: []*gnmipb.Update{vnetUpdate("vnet1", `{"vxlan_tunnel": "tunnel0", "vni": "1000", "scope": "default", "advertise_prefix": "true"}`)},
+			valid:   true,
+		},
+		{
+			name:    "empty entry",
+			updates: []*gnmipb.Update{vnetUpdate("vnet2", `{}`)},
+			valid:   true,
+		},
+		{
+			name: "leafref to an entry of the same request",
+			updates: []*gnmipb.Update{
+				vnetUpdate("vnet3", `{"peer_list": ["vnet4"]}`),
+				vnetUpdate("vnet4", `{"vni": "2000"}`),
+			},
+			valid: true,
+		},
+		{
+			name:    "unknown field",
+			updates: []*gnmipb.Update{vnetUpdate("vnet5", `{"foo": "bar"}`)},
+		},
+		{
+			name:    "integer out of range",
+			updates: []*gnmipb.Update{vnetUpdate("vnet5", `{"vni": "16777216"}`)},
+		},
+		{
+			name:    "not an integer",
+			updates: []*gnmipb.Update{vnetUpdate("vnet5", `{"vni": "abc"}`)},
+		},
+		{
+			name:    "invalid enumeration",
+			updates: []*gnmipb.Update{vnetUpdate("vnet5", `{"scope": "global"}`)},
+		},
+		{
+			name:    "invalid boolean",
+			updates: []*gnmipb.Update{vnetUpdate("vnet5", `{"advertise_prefix": "yes"}`)},
+		},
+		{
+			name:    "missing leafref target",
+			updates: []*gnmipb.Update{vnetUpdate("vnet5", `{"vxlan_tunnel": "tunnel1"}`)},
+		},
+		{
+			name:    "missing leaf-list leafref target",
+			updates: []*gnmipb.Update{vnetUpdate("vnet5", `{"peer_list": ["vnet1", "vnet9"]}`)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, used, err := TrySet(ctx, nil, nil, tt.updates)
+			if !used {
+				t.Fatalf("Expected bypass to be used")
+			}
+			if tt.valid && err != nil {
+				t.Errorf("Expected valid write, got %v", err)
+			}
+			if !tt.valid {
+				if !errors.Is(err, ErrInvalidValue) {
+					t.Errorf("Expected ErrInvalidValue, got %v", err)
+				}
+				if mr.Exists("VNET|vnet5") {
+					t.Errorf("Expected no write for an invalid value")
+				}
+			}
+		})
+	}
+
+	// Tables without YANG model are rejected
+	aclUpdate := &gnmipb.Update{
+		Path: &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "ACL_RULE"}, {Name: "ACL1|RULE1"}}},
+		Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_JsonIetfVal{JsonIetfVal: []byte(`{"PRIORITY": "10"}`)}},
+	}
+	if _, _, err := TrySet(ctx, nil, nil, []*gnmipb.Update{aclUpdate}); !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("Expected ErrInvalidValue for ACL_RULE, got %v", err)
+	}
+
+	// Deletes are not validated
+	deletes := []*gnmipb.Path{{Elem: []*gnmipb.PathElem{{Name: "VNET"}, {Name: "vnet1"}}}}
+	if _, _, err := TrySet(ctx, nil, deletes, nil); err != nil || mr.Exists("VNET|vnet1") {
+		t.Errorf("Expected VNET|vnet1 deleted, got %v", err)
+	}
+}
+
+func TestLoadSchemaNoModels(t *testing.T) {
+	originalDir := yangModelsDir
+	yangModelsDir = t.TempDir()
+	tableLists = nil
+	defer func() { yangModelsDir = originalDir }()
+
+	if _, err := loadSchema(); err == nil {
+		t.Errorf("Expected error without YANG models")
+	}
+}
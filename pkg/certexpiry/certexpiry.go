@@ -0,0 +1,221 @@
+// Package certexpiry monitors the expiry of the certificates and CRLs used by
+// the server.
+//
+// A Monitor checks the certificates it collects and computes the days left
+// before each expires. Alert thresholds are given in days: an alert is raised
+// once when a certificate gets within a threshold, and once when it expires,
+// so that a certificate about to expire is reported without flooding. The
+// alerts of a certificate restart when its content changes.
+package certexpiry
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultThresholds are the default alert thresholds, in days.
+var DefaultThresholds = []int{30, 7, 1}
+
+// Kind is the kind of a checked certificate.
+type Kind string
+
+const (
+	KindCertificate Kind = "certificate"
+	KindTrustBundle Kind = "trust-bundle"
+	KindCrl         Kind = "crl"
+)
+
+// Cert is a certificate, trust bundle or CRL checked for expiry.
+type Cert struct {
+	// Name identifies the certificate across checks
+	Name string
+
+	// Kind is the kind of the certificate
+	Kind Kind
+
+	// Path is the file or URL the certificate is read from
+	Path string
+
+	// Subject is the subject of the certificate expiring first, or the
+	// issuer of the CRL
+	Subject string
+
+	// Fingerprint identifies the content. Alerts restart when it changes
+	Fingerprint string
+
+	// Expiry is when the certificate expiring first expires, or when the
+	// CRL has to be updated
+	Expiry time.Time
+
+	// Error is why the certificate could not be read. Expiry is unset then
+	Error string
+}
+
+// Status is the expiry status of a Cert.
+type Status struct {
+	Cert
+
+	// DaysRemaining is the number of full days before the expiry, negative
+	// once expired
+	DaysRemaining int
+
+	// Threshold is the smallest alert threshold reached, 0 if none
+	Threshold int
+}
+
+// alertState is the last alert level raised for a certificate.
+type alertState struct {
+	fingerprint string
+	level       int
+}
+
+// Monitor checks the expiry of the certificates returned by collect, and
+// calls alert each time one reaches a new alert level.
+type Monitor struct {
+	collect    func() []Cert
+	alert      func(Status) error
+	thresholds []int
+
+	// checkMu serializes checks, mu guards the status
+	checkMu sync.Mutex
+	alerted map[string]alertState
+
+	mu     sync.RWMutex
+	status []Status
+}
+
+// NewMonitor creates a Monitor alerting at thresholds, in days.
+func NewMonitor(collect func() []Cert, alert func(Status) error, thresholds []int) *Monitor {
+	sorted := append([]int(nil), thresholds...)
+	sort.Sort(sort.Reverse(sort.IntSlice(sorted)))
+	return &Monitor{
+		collect:    collect,
+		alert:      alert,
+		thresholds: sorted,
+		alerted:    map[string]alertState{},
+	}
+}
+
+var (
+	defaultMu      sync.RWMutex
+	defaultMonitor *Monitor
+)
+
+// SetDefaultMonitor registers the Monitor of the server.
+func SetDefaultMonitor(m *Monitor) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultMonitor = m
+}
+
+// GetStatus returns the status of the last check of the registered Monitor.
+func GetStatus() ([]Status, error) {
+	defaultMu.RLock()
+	m := defaultMonitor
+	defaultMu.RUnlock()
+	if m == nil {
+		return nil, fmt.Errorf("certificate expiry monitoring not initialized")
+	}
+	return m.Status(), nil
+}
+
+// ParseThresholds parses a comma separated list of alert thresholds in days.
+func ParseThresholds(s string) ([]int, error) {
+	var thresholds []int
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		days, err := strconv.Atoi(field)
+		if err != nil || days <= 0 {
+			return nil, fmt.Errorf("invalid threshold %q, must be a positive number of days", field)
+		}
+		thresholds = append(thresholds, days)
+	}
+	if len(thresholds) == 0 {
+		return nil, fmt.Errorf("no threshold")
+	}
+	return thresholds, nil
+}
+
+// DaysRemaining returns the number of full days from now to expiry, negative
+// once expired.
+func DaysRemaining(expiry, now time.Time) int {
+	return int(math.Floor(expiry.Sub(now).Hours() / 24))
+}
+
+// level returns the number of thresholds reached by days, plus one once
+// expired, and the smallest threshold reached.
+func (m *Monitor) level(days int) (level int, threshold int) {
+	for _, t := range m.thresholds {
+		if days < t {
+			level++
+			threshold = t
+		}
+	}
+	if days < 0 {
+		level++
+	}
+	return level, threshold
+}
+
+// Check checks the certificates at now, raises the alerts due and returns
+// their status, sorted by name.
+func (m *Monitor) Check(now time.Time) []Status {
+	m.checkMu.Lock()
+	defer m.checkMu.Unlock()
+
+	certs := m.collect()
+	statuses := make([]Status, 0, len(certs))
+	seen := map[string]bool{}
+	for _, cert := range certs {
+		seen[cert.Name] = true
+		st := Status{Cert: cert}
+		if cert.Error != "" || cert.Expiry.IsZero() {
+			statuses = append(statuses, st)
+			continue
+		}
+		st.DaysRemaining = DaysRemaining(cert.Expiry, now)
+		level, threshold := m.level(st.DaysRemaining)
+		st.Threshold = threshold
+		statuses = append(statuses, st)
+
+		last, ok := m.alerted[cert.Name]
+		if ok && last.fingerprint != cert.Fingerprint {
+			last = alertState{}
+		}
+		if level <= last.level {
+			m.alerted[cert.Name] = alertState{fingerprint: cert.Fingerprint, level: level}
+			continue
+		}
+		if err := m.alert(st); err != nil {
+			// Retried at the next check
+			continue
+		}
+		m.alerted[cert.Name] = alertState{fingerprint: cert.Fingerprint, level: level}
+	}
+	for name := range m.alerted {
+		if !seen[name] {
+			delete(m.alerted, name)
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+
+	m.mu.Lock()
+	m.status = statuses
+	m.mu.Unlock()
+	return statuses
+}
+
+// Status returns the status of the last check.
+func (m *Monitor) Status() []Status {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]Status(nil), m.status...)
+}
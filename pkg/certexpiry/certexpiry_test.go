@@ -0,0 +1,118 @@
+package certexpiry
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseThresholds(t *testing.T) {
+	thresholds, err := ParseThresholds(" 7, 30,1 ")
+	if err != nil || len(thresholds) != 3 || thresholds[0] != 7 || thresholds[1] != 30 || thresholds[2] != 1 {
+		t.Errorf("Unexpected thresholds %v, err %v", thresholds, err)
+	}
+	for _, s := range []string{"", ",", "0", "-1", "7,a"} {
+		if _, err := ParseThresholds(s); err == nil {
+			t.Errorf("Expected an error for %q", s)
+		}
+	}
+}
+
+func TestDaysRemaining(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		expiry time.Time
+		days   int
+	}{
+		{now.Add(30 * 24 * time.Hour), 30},
+		{now.Add(30*24*time.Hour - time.Second), 29},
+		{now.Add(time.Hour), 0},
+		{now, 0},
+		{now.Add(-time.Hour), -1},
+	}
+	for _, tc := range testCases {
+		if days := DaysRemaining(tc.expiry, now); days != tc.days {
+			t.Errorf("Expected %d days to %v, got %d", tc.days, tc.expiry, days)
+		}
+	}
+}
+
+func TestMonitorCheck(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	server := Cert{Name: "server", Kind: KindCertificate, Fingerprint: "a", Expiry: now.Add(40 * 24 * time.Hour)}
+	ca := Cert{Name: "ca", Kind: KindTrustBundle, Error: "no such file"}
+	var alerts []Status
+	var alertErr error
+	m := NewMonitor(func() []Cert { return []Cert{server, ca} }, func(st Status) error {
+		if alertErr != nil {
+			return alertErr
+		}
+		alerts = append(alerts, st)
+		return nil
+	}, []int{7, 30})
+
+	check := func(at time.Time, expected ...int) {
+		t.Helper()
+		alerts = nil
+		m.Check(at)
+		if len(alerts) != len(expected) {
+			t.Fatalf("Expected alerts %v at %v, got %+v", expected, at, alerts)
+		}
+		for i, days := range expected {
+			if alerts[i].Name != "server" || alerts[i].DaysRemaining != days {
+				t.Errorf("Expected an alert at %d days, got %+v", days, alerts[i])
+			}
+		}
+	}
+
+	check(now)
+	statuses := m.Status()
+	if len(statuses) != 2 || statuses[0].Name != "ca" || statuses[0].Error == "" ||
+		statuses[1].DaysRemaining != 40 || statuses[1].Threshold != 0 {
+		t.Errorf("Unexpected status %+v", statuses)
+	}
+
+	// Each threshold and the expiry alert once
+	check(now.Add(11*24*time.Hour), 29)
+	check(now.Add(12 * 24 * time.Hour))
+	if st := m.Status()[1]; st.Threshold != 30 {
+		t.Errorf("Expected the 30 days threshold, got %+v", st)
+	}
+	check(now.Add(35*24*time.Hour), 5)
+	check(now.Add(36 * 24 * time.Hour))
+	check(now.Add(41*24*time.Hour), -1)
+	check(now.Add(42 * 24 * time.Hour))
+
+	// A failed alert is raised again at the next check
+	server.Fingerprint = "b"
+	alertErr = errors.New("events unavailable")
+	check(now.Add(42 * 24 * time.Hour))
+	alertErr = nil
+	check(now.Add(42*24*time.Hour), -2)
+
+	// The alerts restart once the certificate is rotated
+	server = Cert{Name: "server", Kind: KindCertificate, Fingerprint: "c", Expiry: now.Add(100 * 24 * time.Hour)}
+	check(now.Add(42 * 24 * time.Hour))
+	check(now.Add(75*24*time.Hour), 25)
+}
+
+func TestGetStatus(t *testing.T) {
+	SetDefaultMonitor(nil)
+	if _, err := GetStatus(); err == nil {
+		t.Errorf("Expected an error without a monitor")
+	}
+
+	now := time.Now()
+	m := NewMonitor(func() []Cert {
+		return []Cert{{Name: "server", Fingerprint: "a", Expiry: now.Add(12 * time.Hour)}}
+	}, func(Status) error { return nil }, DefaultThresholds)
+	SetDefaultMonitor(m)
+	defer SetDefaultMonitor(nil)
+	if statuses, err := GetStatus(); err != nil || len(statuses) != 0 {
+		t.Errorf("Expected no status before the first check, got %v, err %v", statuses, err)
+	}
+	m.Check(now)
+	if statuses, err := GetStatus(); err != nil || len(statuses) != 1 || statuses[0].Threshold != 1 {
+		t.Errorf("Unexpected status %+v, err %v", statuses, err)
+	}
+}
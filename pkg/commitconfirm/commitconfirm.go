@@ -0,0 +1,370 @@
+// Package commitconfirm implements commit-confirmed configuration changes.
+//
+// A commit snapshots CONFIG_DB into a checkpoint before the change is applied.
+// Unless the commit is confirmed within its rollback duration, the checkpoint
+// is restored with a config replace, so that a change cutting off management
+// access reverts by itself. Only one commit can be pending at a time.
+//
+// The pending commit is saved next to its checkpoint, so that a commit left
+// pending when the process stops is rolled back, or its timer re-armed, by
+// Recover when the process starts again.
+package commitconfirm
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// DefaultRollbackDuration is used when a commit does not specify one
+	DefaultRollbackDuration = 10 * time.Minute
+
+	// MaxRollbackDuration is the longest a commit can stay unconfirmed
+	MaxRollbackDuration = 24 * time.Hour
+
+	// CheckpointName is the name of the GCU checkpoint of the pending commit
+	CheckpointName = "commit-confirmed"
+)
+
+// State is the state of a commit.
+type State string
+
+const (
+	StatePending    State = "pending"
+	StateConfirmed  State = "confirmed"
+	StateCancelled  State = "cancelled"
+	StateRolledBack State = "rolled-back"
+	StateFailed     State = "failed"
+)
+
+// Service is the subset of the SONiC host service used to checkpoint and
+// restore CONFIG_DB.
+type Service interface {
+	Close() error
+	CreateCheckPoint(cpName string) error
+	DeleteCheckPoint(cpName string) error
+	ConfigReplace(config string) error
+}
+
+// Status describes the current or last commit.
+type Status struct {
+	// ID is the client provided commit ID
+	ID string
+
+	// State is the state of the commit
+	State State
+
+	// Started is when the commit was applied
+	Started time.Time
+
+	// Deadline is when a pending commit is rolled back
+	Deadline time.Time
+
+	// Error is the error of a failed commit or rollback
+	Error string
+}
+
+// Manager tracks the pending commit.
+type Manager struct {
+	mu sync.Mutex
+
+	// newService connects to the host service for each operation
+	newService func() (Service, error)
+
+	// checkpointDir is where the host service stores checkpoints
+	checkpointDir string
+
+	status Status
+	timer  *time.Timer
+}
+
+// NewManager creates a Manager storing its checkpoint in checkpointDir.
+func NewManager(newService func() (Service, error), checkpointDir string) *Manager {
+	return &Manager{
+		newService:    newService,
+		checkpointDir: checkpointDir,
+	}
+}
+
+var defaultManager *Manager
+
+// SetDefaultManager registers the Manager of the server.
+func SetDefaultManager(m *Manager) { defaultManager = m }
+
+// GetStatus returns the status of the current or last commit of the registered Manager.
+func GetStatus() (Status, error) {
+	if defaultManager == nil {
+		return Status{}, fmt.Errorf("commit-confirmed not initialized")
+	}
+	return defaultManager.Status(), nil
+}
+
+func (m *Manager) checkpoint() string {
+	return m.checkpointDir + "/" + CheckpointName
+}
+
+// pendingFile is where the pending commit is saved.
+func (m *Manager) pendingFile() string {
+	return m.checkpoint() + ".pending.json"
+}
+
+// pendingCommit is the saved pending commit.
+type pendingCommit struct {
+	ID       string    `json:"id"`
+	Started  time.Time `json:"started"`
+	Deadline time.Time `json:"deadline"`
+}
+
+// savePendingLocked saves the pending commit.
+func (m *Manager) savePendingLocked() error {
+	data, err := json.Marshal(pendingCommit{ID: m.status.ID, Started: m.status.Started, Deadline: m.status.Deadline})
+	if err != nil {
+		return err
+	}
+	tmp := m.pendingFile() + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, m.pendingFile())
+}
+
+// removePendingLocked removes the saved pending commit.
+func (m *Manager) removePendingLocked() {
+	if err := os.Remove(m.pendingFile()); err != nil && !os.IsNotExist(err) {
+		glog.Warningf("[CommitConfirm] Failed to remove the pending commit: %v", err)
+	}
+}
+
+// Recover resumes the commit left pending when the process stopped: it is
+// rolled back if its deadline has passed, and its timer is re-armed otherwise.
+func (m *Manager) Recover() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	data, err := ioutil.ReadFile(m.pendingFile())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(m.checkpoint() + ".cp.json"); err != nil {
+		m.removePendingLocked()
+		return fmt.Errorf("checkpoint of the pending commit is missing: %v", err)
+	}
+	var pending pendingCommit
+	if err := json.Unmarshal(data, &pending); err != nil || pending.ID == "" {
+		// Without the deadline, the checkpoint is restored to be safe
+		pending = pendingCommit{ID: "unknown", Started: time.Now()}
+	}
+	m.status = Status{ID: pending.ID, State: StatePending, Started: pending.Started, Deadline: pending.Deadline}
+
+	remaining := time.Until(pending.Deadline)
+	if remaining <= 0 {
+		glog.Warningf("[CommitConfirm] Commit %s was not confirmed before the restart, rolling back", pending.ID)
+		return m.rollbackLocked(StateRolledBack)
+	}
+	id := pending.ID
+	m.timer = time.AfterFunc(remaining, func() { m.expire(id) })
+	glog.Infof("[CommitConfirm] Commit %s still pending, rollback in %v unless confirmed", id, remaining)
+	return nil
+}
+
+// Status returns the status of the current or last commit.
+func (m *Manager) Status() Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.status
+}
+
+// validDuration applies the default rollback duration and checks its range.
+func validDuration(d time.Duration) (time.Duration, error) {
+	if d == 0 {
+		return DefaultRollbackDuration, nil
+	}
+	if d < 0 || d > MaxRollbackDuration {
+		return 0, status.Errorf(codes.InvalidArgument, "rollback duration must be between 0 and %v, got %v", MaxRollbackDuration, d)
+	}
+	return d, nil
+}
+
+// Commit checkpoints CONFIG_DB, then applies a change. Unless Confirm is called
+// within rollback (DefaultRollbackDuration if 0), the checkpoint is restored.
+// If the change fails, the checkpoint is restored immediately.
+func (m *Manager) Commit(id string, rollback time.Duration, apply func() error) error {
+	if id == "" {
+		return status.Error(codes.InvalidArgument, "commit ID is required")
+	}
+	rollback, err := validDuration(rollback)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.status.State == StatePending {
+		return status.Errorf(codes.FailedPrecondition, "commit %s is pending", m.status.ID)
+	}
+
+	sc, err := m.newService()
+	if err != nil {
+		return status.Errorf(codes.Unavailable, "failed to connect to host service: %v", err)
+	}
+	defer sc.Close()
+	if err := sc.CreateCheckPoint(m.checkpoint()); err != nil {
+		return status.Errorf(codes.Internal, "failed to create checkpoint: %v", err)
+	}
+
+	m.status = Status{ID: id, State: StatePending, Started: time.Now()}
+	m.status.Deadline = m.status.Started.Add(rollback)
+	// Saved before the change, which may restart the process
+	if err := m.savePendingLocked(); err != nil {
+		m.status = Status{ID: id, State: StateFailed, Started: m.status.Started, Error: err.Error()}
+		if err := sc.DeleteCheckPoint(m.checkpoint()); err != nil {
+			glog.Warningf("[CommitConfirm] Failed to delete checkpoint: %v", err)
+		}
+		return status.Errorf(codes.Internal, "failed to save the pending commit: %v", err)
+	}
+	if err := apply(); err != nil {
+		glog.Errorf("[CommitConfirm] Commit %s failed, rolling back: %v", id, err)
+		m.status.Error = err.Error()
+		m.rollbackLocked(StateFailed)
+		return err
+	}
+
+	m.timer = time.AfterFunc(rollback, func() { m.expire(id) })
+	glog.Infof("[CommitConfirm] Commit %s applied, rollback in %v unless confirmed", id, rollback)
+	return nil
+}
+
+// pendingLocked returns an error unless commit id is pending.
+func (m *Manager) pendingLocked(id string) error {
+	if m.status.State != StatePending {
+		return status.Error(codes.NotFound, "no commit pending")
+	}
+	if m.status.ID != id {
+		return status.Errorf(codes.NotFound, "commit %s is not pending, commit %s is", id, m.status.ID)
+	}
+	return nil
+}
+
+// Confirm makes the pending commit permanent.
+func (m *Manager) Confirm(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.pendingLocked(id); err != nil {
+		return err
+	}
+	m.timer.Stop()
+	m.status.State = StateConfirmed
+	m.status.Deadline = time.Time{}
+	m.removePendingLocked()
+	glog.Infof("[CommitConfirm] Commit %s confirmed", id)
+
+	if sc, err := m.newService(); err == nil {
+		defer sc.Close()
+		if err := sc.DeleteCheckPoint(m.checkpoint()); err != nil {
+			glog.Warningf("[CommitConfirm] Failed to delete checkpoint: %v", err)
+		}
+	}
+	return nil
+}
+
+// Cancel rolls back the pending commit now.
+func (m *Manager) Cancel(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.pendingLocked(id); err != nil {
+		return err
+	}
+	m.timer.Stop()
+	glog.Infof("[CommitConfirm] Commit %s cancelled", id)
+	return m.rollbackLocked(StateCancelled)
+}
+
+// SetRollbackDuration restarts the rollback timer of the pending commit.
+func (m *Manager) SetRollbackDuration(id string, rollback time.Duration) error {
+	rollback, err := validDuration(rollback)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.pendingLocked(id); err != nil {
+		return err
+	}
+	m.timer.Stop()
+	m.status.Deadline = time.Now().Add(rollback)
+	if err := m.savePendingLocked(); err != nil {
+		glog.Warningf("[CommitConfirm] Failed to save the pending commit: %v", err)
+	}
+	m.timer = time.AfterFunc(rollback, func() { m.expire(id) })
+	glog.Infof("[CommitConfirm] Commit %s rollback in %v unless confirmed", id, rollback)
+	return nil
+}
+
+// expire rolls back commit id if it is still pending.
+func (m *Manager) expire(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.pendingLocked(id) != nil {
+		return
+	}
+	glog.Warningf("[CommitConfirm] Commit %s not confirmed in time, rolling back", id)
+	m.rollbackLocked(StateRolledBack)
+}
+
+// rollbackLocked restores the checkpoint and sets the state of the commit.
+func (m *Manager) rollbackLocked(state State) error {
+	m.status.State = state
+	m.status.Deadline = time.Time{}
+
+	err := m.restoreLocked()
+	if err != nil {
+		glog.Errorf("[CommitConfirm] Rollback of commit %s failed: %v", m.status.ID, err)
+		m.status.State = StateFailed
+		m.status.Error = err.Error()
+		// Without a deadline, the rollback is retried when the process restarts
+		if err := m.savePendingLocked(); err != nil {
+			glog.Warningf("[CommitConfirm] Failed to save the pending commit: %v", err)
+		}
+		return status.Errorf(codes.Internal, "rollback failed: %v", err)
+	}
+	m.removePendingLocked()
+	glog.Infof("[CommitConfirm] Commit %s rolled back", m.status.ID)
+	return nil
+}
+
+// restoreLocked replaces the configuration with the checkpoint.
+func (m *Manager) restoreLocked() error {
+	config, err := ioutil.ReadFile(m.checkpoint() + ".cp.json")
+	if err != nil {
+		return fmt.Errorf("failed to read checkpoint: %v", err)
+	}
+	sc, err := m.newService()
+	if err != nil {
+		return err
+	}
+	defer sc.Close()
+	if err := sc.ConfigReplace(string(config)); err != nil {
+		return err
+	}
+	if err := sc.DeleteCheckPoint(m.checkpoint()); err != nil {
+		glog.Warningf("[CommitConfirm] Failed to delete checkpoint: %v", err)
+	}
+	return nil
+}
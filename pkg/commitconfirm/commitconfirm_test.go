@@ -0,0 +1,257 @@
+package commitconfirm
+
+import (
+	"errors"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeService checkpoints and replaces an in-memory configuration.
+type fakeService struct {
+	mu      sync.Mutex
+	config  string
+	replace []string
+	deleted int
+}
+
+func (f *fakeService) Close() error { return nil }
+
+func (f *fakeService) CreateCheckPoint(cpName string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return ioutil.WriteFile(cpName+".cp.json", []byte(f.config), 0644)
+}
+
+func (f *fakeService) DeleteCheckPoint(cpName string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.deleted++
+	return nil
+}
+
+func (f *fakeService) ConfigReplace(config string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.config = config
+	f.replace = append(f.replace, config)
+	return nil
+}
+
+func (f *fakeService) set(config string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.config = config
+}
+
+func (f *fakeService) get() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.config
+}
+
+func newTestManager(t *testing.T) (*Manager, *fakeService) {
+	svc := &fakeService{config: "old"}
+	m := NewManager(func() (Service, error) { return svc, nil }, t.TempDir())
+	return m, svc
+}
+
+func TestCommitConfirm(t *testing.T) {
+	m, svc := newTestManager(t)
+
+	if err := m.Commit("c1", time.Minute, func() error { svc.set("new"); return nil }); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	st := m.Status()
+	if st.ID != "c1" || st.State != StatePending || st.Deadline.IsZero() {
+		t.Errorf("Unexpected status after commit: %+v", st)
+	}
+
+	if err := m.Confirm("c1"); err != nil {
+		t.Fatalf("Confirm failed: %v", err)
+	}
+	if st := m.Status(); st.State != StateConfirmed {
+		t.Errorf("Expected confirmed, got %+v", st)
+	}
+	if svc.get() != "new" || len(svc.replace) != 0 {
+		t.Errorf("Confirmed config must not be rolled back, got %q", svc.get())
+	}
+
+	// A confirmed commit cannot be confirmed again
+	if err := m.Confirm("c1"); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound, got %v", err)
+	}
+}
+
+func TestCommitRollbackOnTimeout(t *testing.T) {
+	m, svc := newTestManager(t)
+
+	if err := m.Commit("c1", 20*time.Millisecond, func() error { svc.set("new"); return nil }); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for m.Status().State == StatePending && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if st := m.Status(); st.State != StateRolledBack {
+		t.Fatalf("Expected rolled-back, got %+v", st)
+	}
+	if svc.get() != "old" {
+		t.Errorf("Expected config restored, got %q", svc.get())
+	}
+	if err := m.Confirm("c1"); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound confirming a rolled back commit, got %v", err)
+	}
+}
+
+func TestCommitCancel(t *testing.T) {
+	m, svc := newTestManager(t)
+
+	if err := m.Commit("c1", time.Minute, func() error { svc.set("new"); return nil }); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	if err := m.Cancel("c2"); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound cancelling another commit, got %v", err)
+	}
+	if err := m.Cancel("c1"); err != nil {
+		t.Fatalf("Cancel failed: %v", err)
+	}
+	if st := m.Status(); st.State != StateCancelled {
+		t.Errorf("Expected cancelled, got %+v", st)
+	}
+	if svc.get() != "old" {
+		t.Errorf("Expected config restored, got %q", svc.get())
+	}
+}
+
+func TestCommitSetRollbackDuration(t *testing.T) {
+	m, svc := newTestManager(t)
+
+	if err := m.Commit("c1", 20*time.Millisecond, func() error { svc.set("new"); return nil }); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	if err := m.SetRollbackDuration("c1", time.Minute); err != nil {
+		t.Fatalf("SetRollbackDuration failed: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	st := m.Status()
+	if st.State != StatePending {
+		t.Fatalf("Expected pending after extending the rollback duration, got %+v", st)
+	}
+	if time.Until(st.Deadline) < 30*time.Second {
+		t.Errorf("Expected deadline extended, got %v", st.Deadline)
+	}
+	if err := m.SetRollbackDuration("c1", 2*MaxRollbackDuration); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument, got %v", err)
+	}
+	m.Cancel("c1")
+}
+
+func TestCommitErrors(t *testing.T) {
+	m, svc := newTestManager(t)
+
+	if err := m.Commit("", 0, func() error { return nil }); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument without ID, got %v", err)
+	}
+
+	// A failed change is rolled back immediately
+	applyErr := errors.New("apply failed")
+	err := m.Commit("c1", 0, func() error { svc.set("partial"); return applyErr })
+	if err != applyErr {
+		t.Errorf("Expected apply error, got %v", err)
+	}
+	if st := m.Status(); st.State != StateFailed || st.Error != applyErr.Error() {
+		t.Errorf("Expected failed, got %+v", st)
+	}
+	if svc.get() != "old" {
+		t.Errorf("Expected config restored, got %q", svc.get())
+	}
+
+	// Only one commit can be pending
+	if err := m.Commit("c2", 0, func() error { return nil }); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	if st := m.Status(); time.Until(st.Deadline) < DefaultRollbackDuration-time.Minute {
+		t.Errorf("Expected default rollback duration, got deadline %v", st.Deadline)
+	}
+	if err := m.Commit("c3", 0, func() error { return nil }); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected FailedPrecondition, got %v", err)
+	}
+	m.Cancel("c2")
+
+	// The checkpoint cannot be created
+	m = NewManager(func() (Service, error) { return nil, errors.New("no dbus") }, t.TempDir())
+	if err := m.Commit("c4", 0, func() error { return nil }); status.Code(err) != codes.Unavailable {
+		t.Errorf("Expected Unavailable, got %v", err)
+	}
+}
+
+func TestGetStatus(t *testing.T) {
+	SetDefaultManager(nil)
+	if _, err := GetStatus(); err == nil {
+		t.Errorf("Expected error without manager")
+	}
+
+	m, svc := newTestManager(t)
+	SetDefaultManager(m)
+	defer SetDefaultManager(nil)
+	m.Commit("c1", time.Minute, func() error { svc.set("new"); return nil })
+	defer m.Cancel("c1")
+
+	st, err := GetStatus()
+	if err != nil || st.ID != "c1" || st.State != StatePending {
+		t.Errorf("Unexpected status %+v, %v", st, err)
+	}
+}
+
+func TestCommitRecover(t *testing.T) {
+	m, svc := newTestManager(t)
+	if err := m.Recover(); err != nil || m.Status().State != "" {
+		t.Fatalf("Expected nothing to recover, got %+v, %v", m.Status(), err)
+	}
+	if err := m.Commit("c1", time.Minute, func() error { svc.set("new"); return nil }); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	deadline := m.Status().Deadline
+
+	// The process restarts with the commit pending
+	restarted := NewManager(m.newService, m.checkpointDir)
+	if err := restarted.Recover(); err != nil {
+		t.Fatalf("Recover failed: %v", err)
+	}
+	if st := restarted.Status(); st.ID != "c1" || st.State != StatePending || !st.Deadline.Equal(deadline) {
+		t.Fatalf("Expected c1 pending until %v, got %+v", deadline, st)
+	}
+	if err := restarted.Confirm("c1"); err != nil {
+		t.Fatalf("Confirm failed: %v", err)
+	}
+	if svc.get() != "new" {
+		t.Errorf("Confirmed config must not be rolled back, got %q", svc.get())
+	}
+	if err := NewManager(m.newService, m.checkpointDir).Recover(); err != nil {
+		t.Errorf("Expected nothing to recover after the confirmation, got %v", err)
+	}
+
+	// A commit whose deadline passed while the process was down is rolled back
+	m = NewManager(m.newService, m.checkpointDir)
+	if err := m.Commit("c2", 20*time.Millisecond, func() error { svc.set("newer"); return nil }); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	m.timer.Stop()
+	time.Sleep(30 * time.Millisecond)
+	restarted = NewManager(m.newService, m.checkpointDir)
+	if err := restarted.Recover(); err != nil {
+		t.Fatalf("Recover failed: %v", err)
+	}
+	if st := restarted.Status(); st.ID != "c2" || st.State != StateRolledBack {
+		t.Errorf("Expected c2 rolled back, got %+v", st)
+	}
+	if svc.get() != "new" {
+		t.Errorf("Expected config restored, got %q", svc.get())
+	}
+}
@@ -19,8 +19,8 @@ var (
 )
 
 // HandleCommandRequest implements the logic for the Debug RPC, per the gNOI spec.
-// It validates the request, then runs the command on the host, streaming responses
-// back to the client.
+// It validates the request against the whitelist policy, then runs the command on
+// the host, streaming responses back to the client.
 //
 // Responses are streamed to the client in the following order:
 //   - Request: 1, beginning of execution
@@ -32,7 +32,7 @@ var (
 func HandleCommandRequest(
 	req *debug_pb.DebugRequest,
 	stream debug_pb.Debug_DebugServer,
-	whitelist []string,
+	policy *Policy,
 ) error {
 	ctx := stream.Context()
 
@@ -46,7 +46,7 @@ func HandleCommandRequest(
 		return status.Error(codes.InvalidArgument, "command cannot be nil")
 	}
 
-	err := ValidateCommand(string(command), whitelist)
+	err := ValidateCommandPolicy(string(command), policy)
 	if err != nil {
 		return status.Errorf(codes.PermissionDenied, "command failed validation: %v", err)
 	}
@@ -95,7 +95,8 @@ func HandleCommandRequest(
 		sendStatusInResponse(stream, exitCode)
 
 	case debug_pb.DebugRequest_MODE_SHELL:
-		return status.Error(codes.Unimplemented, "mode SHELL is currently unimplemented")
+		// Runs in a pseudo terminal, see HandleShellStream for interactive sessions
+		return handleShellRequest(ctx, req, stream)
 	case debug_pb.DebugRequest_MODE_UNSPECIFIED:
 		return status.Error(codes.InvalidArgument, "mode cannot be UNSPECIFIED")
 	}
@@ -27,6 +27,7 @@ var (
 		"sleep",
 		"any",
 	}
+	testPolicy = NewPolicy("whitelist", testWhitelist, nil)
 )
 
 type mockDebugServerStream struct {
@@ -85,13 +86,13 @@ func TestHandleCommandRequest(t *testing.T) {
 			errType:   codes.InvalidArgument,
 		},
 		{
-			name: "Error on unimplemented SHELL mode",
+			name: "Error on SHELL mode command not in whitelist",
 			req: &debug_pb.DebugRequest{
-				Command: []byte("ls"),
+				Command: []byte("rm -rf /"),
 				Mode:    debug_pb.DebugRequest_MODE_SHELL,
 			},
 			expectErr: true,
-			errType:   codes.Unimplemented,
+			errType:   codes.PermissionDenied,
 		},
 		{
 			name: "Error on UNSPECIFIED mode",
@@ -181,7 +182,7 @@ func TestHandleCommandRequest(t *testing.T) {
 			return -1, ctx.Err() // Return context error
 		}
 
-		err := HandleCommandRequest(req, stream, testWhitelist)
+		err := HandleCommandRequest(req, stream, testPolicy)
 		if err == nil {
 			t.Fatal("expected an error but got nil")
 		}
@@ -201,7 +202,7 @@ func TestHandleCommandRequest(t *testing.T) {
 			defer func() { runCommand = originalRunCommand }()
 
 			stream := &mockDebugServerStream{ctx: context.Background()}
-			err := HandleCommandRequest(tc.req, stream, testWhitelist)
+			err := HandleCommandRequest(tc.req, stream, testPolicy)
 
 			if tc.expectErr {
 				if err == nil {
@@ -0,0 +1,192 @@
+package debug
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/sonic-net/sonic-gnmi/pkg/wildcard"
+)
+
+// Rule matches a command by program name and arguments, e.g. "ip route show".
+//
+// Arguments of the rule are patterns, where '*' matches any characters and '?'
+// a single one. An allow rule matches the leading arguments of the command,
+// position by position, and any further arguments unless the rule ends with
+// "$". A deny rule matches if its arguments appear in order anywhere among the
+// arguments of the command, so that "ip route del" also denies "ip -4 route del".
+// Since commands like ip and tc accept abbreviated keywords, a deny argument
+// made of letters only also matches its abbreviations and extensions: "ip route
+// del" denies "ip ro del" and "ip route delete".
+type Rule struct {
+	Program string
+	Args    []string
+	Exact   bool
+	// Where the rule comes from, e.g. "write_whitelist" or "role netops"
+	Source string
+
+	patterns []*regexp.Regexp
+}
+
+// isKeyword checks if an argument pattern is a keyword, i.e. only letters.
+func isKeyword(arg string) bool {
+	for _, r := range arg {
+		if !unicode.IsLetter(r) {
+			return false
+		}
+	}
+	return arg != ""
+}
+
+// deniesArg checks whether the i-th argument pattern of a deny rule matches an
+// argument of the command.
+func (r Rule) deniesArg(i int, arg string) bool {
+	if r.patterns[i].MatchString(arg) {
+		return true
+	}
+	keyword := r.Args[i]
+	return isKeyword(keyword) && arg != "" && (strings.HasPrefix(keyword, arg) || strings.HasPrefix(arg, keyword))
+}
+
+// ParseRule parses the text of a rule, i.e. a program name followed by
+// argument patterns, separated by spaces.
+func ParseRule(text string, source string) (Rule, error) {
+	fields := strings.Fields(text)
+	if len(fields) > 0 && fields[len(fields)-1] == "$" {
+		fields = fields[:len(fields)-1]
+		if len(fields) == 0 {
+			return Rule{}, fmt.Errorf("rule %q has no program", text)
+		}
+		return newRule(fields, true, source), nil
+	}
+	if len(fields) == 0 {
+		return Rule{}, fmt.Errorf("empty rule")
+	}
+	return newRule(fields, false, source), nil
+}
+
+func newRule(fields []string, exact bool, source string) Rule {
+	rule := Rule{Program: fields[0], Args: fields[1:], Exact: exact, Source: source}
+	for _, arg := range rule.Args {
+		rule.patterns = append(rule.patterns, wildcard.Compile(arg))
+	}
+	return rule
+}
+
+// String returns the text of the rule.
+func (r Rule) String() string {
+	fields := append([]string{r.Program}, r.Args...)
+	if r.Exact {
+		fields = append(fields, "$")
+	}
+	return strings.Join(fields, " ")
+}
+
+// allows checks whether an allow rule matches the command.
+func (r Rule) allows(program string, args []string) bool {
+	if program != r.Program || len(args) < len(r.patterns) {
+		return false
+	}
+	if r.Exact && len(args) != len(r.patterns) {
+		return false
+	}
+	for i, pattern := range r.patterns {
+		if !pattern.MatchString(args[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// denies checks whether a deny rule matches the command.
+func (r Rule) denies(program string, args []string) bool {
+	if program != r.Program {
+		return false
+	}
+	i := 0
+	for _, arg := range args {
+		if i < len(r.patterns) && r.deniesArg(i, arg) {
+			i++
+		}
+	}
+	if i < len(r.patterns) {
+		return false
+	}
+	return !r.Exact || len(args) == len(r.patterns)
+}
+
+// Policy is the set of rules a command is validated against: it must match an
+// allow rule, and no deny rule.
+type Policy struct {
+	Allow []Rule
+	Deny  []Rule
+	// Policy the command must also pass, e.g. the read policy for users
+	// without write access
+	Within *Policy
+}
+
+// NewPolicy builds a policy from rule texts, skipping empty ones.
+func NewPolicy(source string, allow []string, deny []string) *Policy {
+	policy := &Policy{}
+	policy.add(source, allow, deny)
+	return policy
+}
+
+func (p *Policy) add(source string, allow []string, deny []string) {
+	for _, text := range allow {
+		if rule, err := ParseRule(text, source); err == nil {
+			p.Allow = append(p.Allow, rule)
+		}
+	}
+	for _, text := range deny {
+		if rule, err := ParseRule(text, source); err == nil {
+			p.Deny = append(p.Deny, rule)
+		}
+	}
+}
+
+// merge returns a policy allowing what any of the policies allows, and
+// denying what any of them denies.
+func merge(policies ...*Policy) *Policy {
+	merged := &Policy{}
+	for _, policy := range policies {
+		merged.Allow = append(merged.Allow, policy.Allow...)
+		merged.Deny = append(merged.Deny, policy.Deny...)
+	}
+	return merged
+}
+
+// check validates a single command, returning which rule denied it, if any.
+func (p *Policy) check(program string, args []string) error {
+	if err := p.checkRules(program, args); err != nil {
+		return err
+	}
+	if p.Within != nil {
+		return p.Within.check(program, args)
+	}
+	return nil
+}
+
+func (p *Policy) checkRules(program string, args []string) error {
+	for _, rule := range p.Deny {
+		if rule.denies(program, args) {
+			return fmt.Errorf("%w: command %q is denied by rule %q of %s", ErrRejected, commandLine(program, args), rule, rule.Source)
+		}
+	}
+	for _, rule := range p.Allow {
+		if rule.allows(program, args) {
+			return nil
+		}
+	}
+	for _, rule := range p.Allow {
+		if rule.Program == program {
+			return fmt.Errorf("%w: command %q is not whitelisted, arguments match no rule for %q", ErrRejected, commandLine(program, args), program)
+		}
+	}
+	return fmt.Errorf("%w: command %q is not whitelisted", ErrRejected, program)
+}
+
+func commandLine(program string, args []string) string {
+	return strings.Join(append([]string{program}, args...), " ")
+}
@@ -0,0 +1,153 @@
+package debug
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseRule(t *testing.T) {
+	testCases := []struct {
+		text      string
+		expectErr bool
+		program   string
+		args      []string
+		exact     bool
+	}{
+		{text: "show", program: "show"},
+		{text: "  ip   route show ", program: "ip", args: []string{"route", "show"}},
+		{text: "config interface * $", program: "config", args: []string{"interface", "*"}, exact: true},
+		{text: "", expectErr: true},
+		{text: "$", expectErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.text, func(t *testing.T) {
+			rule, err := ParseRule(tc.text, "test")
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("expected an error for %q", tc.text)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("did not expect an error but got: %v", err)
+			}
+			if rule.Program != tc.program || strings.Join(rule.Args, " ") != strings.Join(tc.args, " ") || rule.Exact != tc.exact {
+				t.Errorf("unexpected rule %+v", rule)
+			}
+			if rule.String() != strings.Join(strings.Fields(tc.text), " ") {
+				t.Errorf("expected text %q, got %q", tc.text, rule.String())
+			}
+		})
+	}
+}
+
+func TestValidateCommandPolicy(t *testing.T) {
+	policy := NewPolicy("role netops",
+		[]string{"show", "ip route show", "ip -? route show", "config interface * Ethernet*", "date $", "grep"},
+		[]string{"show runningconfiguration", "ip route del", "config interface shutdown Ethernet0"},
+	)
+
+	testCases := []struct {
+		input   string
+		allow   bool
+		errMsgs []string
+	}{
+		{input: "show interfaces status", allow: true},
+		{input: "show version | grep SONiC", allow: true},
+		{input: "ip route show", allow: true},
+		{input: "ip route show 10.0.0.0/8", allow: true},
+		{input: "ip -4 route show", allow: true},
+		{input: "ip 'route' \"show\"", allow: true},
+		{input: "config interface startup Ethernet4", allow: true},
+		{input: "date", allow: true},
+		{
+			input:   "date -s 2020-01-01",
+			errMsgs: []string{`arguments match no rule for "date"`},
+		},
+		{
+			input:   "ip route del 10.0.0.0/8",
+			errMsgs: []string{`denied by rule "ip route del" of role netops`},
+		},
+		{
+			input:   "ip route flush",
+			errMsgs: []string{`arguments match no rule for "ip"`},
+		},
+		{
+			input:   "ip link set Ethernet0 down",
+			errMsgs: []string{`command "ip link set Ethernet0 down" is not whitelisted`},
+		},
+		{
+			input:   "config interface startup PortChannel1",
+			errMsgs: []string{"is not whitelisted"},
+		},
+		{
+			input:   "config interface shutdown Ethernet0",
+			errMsgs: []string{`denied by rule "config interface shutdown Ethernet0" of role netops`},
+		},
+		{
+			input:   "show runningconfiguration all",
+			errMsgs: []string{`denied by rule "show runningconfiguration" of role netops`},
+		},
+		{
+			input:   "show version | grep -v x | show runningconfiguration bgp",
+			errMsgs: []string{`denied by rule "show runningconfiguration"`},
+		},
+		{
+			input:   "reboot",
+			errMsgs: []string{`command "reboot" is not whitelisted`},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			err := ValidateCommandPolicy(tc.input, policy)
+			if tc.allow {
+				if err != nil {
+					t.Fatalf("expected %q to be allowed, got: %v", tc.input, err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrRejected) {
+				t.Fatalf("expected %q to be rejected, got: %v", tc.input, err)
+			}
+			for _, msg := range tc.errMsgs {
+				if !strings.Contains(err.Error(), msg) {
+					t.Errorf("expected error to contain %q, got: %v", msg, err)
+				}
+			}
+		})
+	}
+}
+
+func TestDenyRuleMatchesArgumentsInOrder(t *testing.T) {
+	policy := NewPolicy("write_whitelist", []string{"ip"}, []string{"ip route del"})
+
+	for _, input := range []string{"ip route del 1.1.1.1", "ip -4 route del 1.1.1.1", "ip -6 -s route flush del"} {
+		if err := ValidateCommandPolicy(input, policy); err == nil {
+			t.Errorf("expected %q to be denied", input)
+		}
+	}
+	for _, input := range []string{"ip route show", "ip del route"} {
+		if err := ValidateCommandPolicy(input, policy); err != nil {
+			t.Errorf("expected %q to be allowed, got: %v", input, err)
+		}
+	}
+}
+
+func TestDenyRuleMatchesAbbreviatedKeywords(t *testing.T) {
+	policy := NewPolicy("write_whitelist", []string{"ip", "config"}, []string{"ip route del", "config interface shutdown Ethernet0"})
+
+	for _, input := range []string{"ip route delete 1.1.1.1", "ip ro del 1.1.1.1", "ip r d 1.1.1.1", "ip -4 rou dele 1.1.1.1"} {
+		if err := ValidateCommandPolicy(input, policy); err == nil {
+			t.Errorf("expected %q to be denied", input)
+		}
+	}
+	// Arguments other than keywords are matched exactly
+	for _, input := range []string{"ip route show", "ip ro add 1.1.1.1", "config interface shutdown Ethernet04", "config interface shutdown Eth"} {
+		if err := ValidateCommandPolicy(input, policy); err != nil {
+			t.Errorf("expected %q to be allowed, got: %v", input, err)
+		}
+	}
+}
@@ -0,0 +1,368 @@
+package debug
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/syslog"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	log "github.com/golang/glog"
+	exec "github.com/sonic-net/sonic-gnmi/internal/exec"
+	spb_gnoi "github.com/sonic-net/sonic-gnmi/proto/gnoi"
+	debug_pb "github.com/sonic-net/sonic-gnmi/proto/gnoi/debug"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// Size of the terminal output chunks sent to the client
+	shellReadSize = 4096
+	// Longest output or input logged in a single transcript line
+	transcriptLineSize = 1024
+)
+
+var (
+	// Session limits, unless the client asks for shorter ones
+	DefaultShellTimeout     = time.Hour
+	DefaultShellIdleTimeout = 15 * time.Minute
+
+	shellSignals = map[spb_gnoi.ShellSignal]syscall.Signal{
+		spb_gnoi.ShellSignal_SIGNAL_INT:  syscall.SIGINT,
+		spb_gnoi.ShellSignal_SIGNAL_QUIT: syscall.SIGQUIT,
+		spb_gnoi.ShellSignal_SIGNAL_TERM: syscall.SIGTERM,
+		spb_gnoi.ShellSignal_SIGNAL_HUP:  syscall.SIGHUP,
+		spb_gnoi.ShellSignal_SIGNAL_KILL: syscall.SIGKILL,
+	}
+
+	sessionCount uint64
+
+	// Allow DI for mocking
+	startShell = func(roleAccount string, cmd string, term string, rows uint16, cols uint16) (shellProcess, error) {
+		return exec.StartShell(roleAccount, cmd, term, rows, cols)
+	}
+	openTranscript = func() (io.Writer, error) {
+		return syslog.New(syslog.LOG_AUTHPRIV|syslog.LOG_INFO, "gnoi-debug-shell")
+	}
+)
+
+// shellProcess is a command running in a pseudo terminal, see exec.Shell.
+type shellProcess interface {
+	io.ReadWriteCloser
+	Resize(rows uint16, cols uint16) error
+	Signal(sig syscall.Signal) error
+	Wait() (int, error)
+}
+
+// HandleShellStream implements the interactive Shell RPC.
+// The first request must start the session, with a command validated against
+// the whitelist policy. The following ones carry the terminal input, window size
+// changes and signals for the command.
+//
+// Responses are streamed to the client in the following order:
+//   - Data ([]byte): 0 - many, terminal output during execution
+//   - Status: 1, upon completion, with the exit code
+//
+// The exit code is -1 if the session was terminated by a timeout or the client.
+//
+// Returns:
+//   - Error with appropriate gRPC status code on failure
+func HandleShellStream(stream spb_gnoi.DebugShell_ShellServer, policy *Policy, username string) error {
+	req, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	start := req.GetStart()
+	if start == nil {
+		return status.Error(codes.InvalidArgument, "first request must start the session")
+	}
+	if start.GetCommand() == "" {
+		return status.Error(codes.InvalidArgument, "command cannot be empty")
+	}
+	if err := ValidateCommandPolicy(start.GetCommand(), policy); err != nil {
+		return status.Errorf(codes.PermissionDenied, "command failed validation: %v", err)
+	}
+	if start.GetTimeout() < 0 || start.GetIdleTimeout() < 0 {
+		return status.Error(codes.InvalidArgument, "timeouts cannot be negative")
+	}
+
+	// Requests are read concurrently, until the client closes its side
+	input := make(chan *spb_gnoi.ShellRequest)
+	go func() {
+		defer close(input)
+		for {
+			req, err := stream.Recv()
+			if err != nil {
+				return
+			}
+			select {
+			case input <- req:
+			case <-stream.Context().Done():
+				return
+			}
+		}
+	}()
+
+	session := &shellSession{
+		ctx:         stream.Context(),
+		username:    username,
+		start:       start,
+		timeout:     time.Duration(start.GetTimeout()),
+		idleTimeout: time.Duration(start.GetIdleTimeout()),
+		input:       input,
+		send: func(data []byte) error {
+			return stream.Send(&spb_gnoi.ShellResponse{
+				Response: &spb_gnoi.ShellResponse_Data{Data: data},
+			})
+		},
+	}
+	code, reason, err := session.run()
+	if err != nil {
+		return status.Errorf(codes.FailedPrecondition, "Failed to run command '%s': '%v'", start.GetCommand(), err)
+	}
+	return stream.Send(&spb_gnoi.ShellResponse{
+		Response: &spb_gnoi.ShellResponse_Status{
+			Status: &spb_gnoi.ShellStatus{Code: int32(code), Message: reason},
+		},
+	})
+}
+
+// Runs a gNOI Debug request in SHELL mode: the command runs in a pseudo
+// terminal without input, its output is streamed as in CLI mode.
+func handleShellRequest(ctx context.Context, req *debug_pb.DebugRequest, stream debug_pb.Debug_DebugServer) error {
+	// 1. Send request, indicating start of execution
+	if err := sendReqInResponse(stream, req); err != nil {
+		return status.Errorf(codes.FailedPrecondition, "Failed to run command '%s': '%v'", req.GetCommand(), err)
+	}
+
+	// 2. Send terminal output, within the byte limit
+	byteLimit := req.GetByteLimit()
+	var sent int64
+	session := &shellSession{
+		ctx: ctx,
+		start: &spb_gnoi.ShellStart{
+			Command:     string(req.GetCommand()),
+			RoleAccount: req.GetRoleAccount(),
+		},
+		send: func(data []byte) error {
+			if byteLimit > 0 {
+				if sent >= byteLimit {
+					return nil
+				}
+				if sent+int64(len(data)) > byteLimit {
+					data = data[:byteLimit-sent]
+				}
+				sent += int64(len(data))
+			}
+			return sendDataInResponse(stream, string(data))
+		},
+	}
+	code, _, err := session.run()
+	if err != nil {
+		return status.Errorf(codes.FailedPrecondition, "Failed to run command '%s': '%v'", req.GetCommand(), err)
+	}
+
+	// 3. Send status (with exit code), indicating completion
+	return sendStatusInResponse(stream, code)
+}
+
+// shellSession runs a command in a pseudo terminal until it exits, the client
+// goes away or a timeout expires, writing a transcript of the session to syslog.
+type shellSession struct {
+	ctx         context.Context
+	username    string
+	start       *spb_gnoi.ShellStart
+	timeout     time.Duration
+	idleTimeout time.Duration
+	// Requests of the client, nil if the session has no input
+	input <-chan *spb_gnoi.ShellRequest
+	send  func(data []byte) error
+}
+
+// Runs the session.
+//
+// Returns exit code of the command and the reason the session was terminated,
+// if not by the command exiting, with optional error.
+func (s *shellSession) run() (int, string, error) {
+	timeout := DefaultShellTimeout
+	if s.timeout > 0 && s.timeout < timeout {
+		timeout = s.timeout
+	}
+	idleTimeout := DefaultShellIdleTimeout
+	if s.idleTimeout > 0 && s.idleTimeout < idleTimeout {
+		idleTimeout = s.idleTimeout
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, timeout)
+	defer cancel()
+
+	var rows, cols uint16
+	if size := s.start.GetSize(); size != nil {
+		rows, cols = uint16(size.GetRows()), uint16(size.GetCols())
+	}
+	proc, err := startShell(s.start.GetRoleAccount(), s.start.GetCommand(), s.start.GetTerm(), rows, cols)
+	if err != nil {
+		return exec.FAILED_TO_RUN, "", err
+	}
+	defer proc.Close()
+
+	t := newTranscript()
+	defer t.close()
+	t.logf("start: user=%q peer=%q role_account=%q command=%q", s.username, peerAddress(s.ctx), s.start.GetRoleAccount(), s.start.GetCommand())
+
+	// Terminal output is read concurrently, until the command exited
+	output := make(chan []byte)
+	go func() {
+		defer close(output)
+		for {
+			buf := make([]byte, shellReadSize)
+			n, err := proc.Read(buf)
+			if n > 0 {
+				select {
+				case output <- buf[:n]:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	idle := time.NewTimer(idleTimeout)
+	defer idle.Stop()
+	resetIdle := func() {
+		if !idle.Stop() {
+			<-idle.C
+		}
+		idle.Reset(idleTimeout)
+	}
+
+	input := s.input
+	reason := ""
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) && s.ctx.Err() == nil {
+				reason = "session timed out"
+			} else {
+				reason = "session cancelled"
+			}
+			break loop
+		case <-idle.C:
+			reason = "session idle timeout"
+			break loop
+		case data, ok := <-output:
+			if !ok {
+				break loop
+			}
+			resetIdle()
+			t.logData("output", data)
+			if err := s.send(data); err != nil {
+				reason = fmt.Sprintf("failed to send output: %v", err)
+				break loop
+			}
+		case req, ok := <-input:
+			if !ok {
+				// Client closed its side, the command keeps running
+				input = nil
+				continue
+			}
+			resetIdle()
+			if err := s.handleRequest(proc, req, t); err != nil {
+				log.V(1).Infof("Shell session request failed: %v", err)
+			}
+		}
+	}
+
+	if reason != "" {
+		proc.Close()
+	}
+	code, err := proc.Wait()
+	if reason != "" {
+		code = -1
+	}
+	t.logf("end: code=%d reason=%q", code, reason)
+	return code, reason, err
+}
+
+// Forwards a client request to the command.
+func (s *shellSession) handleRequest(proc shellProcess, req *spb_gnoi.ShellRequest, t *transcript) error {
+	switch r := req.GetRequest().(type) {
+	case *spb_gnoi.ShellRequest_Stdin:
+		t.logData("input", r.Stdin)
+		_, err := proc.Write(r.Stdin)
+		return err
+	case *spb_gnoi.ShellRequest_Resize:
+		t.logf("resize: rows=%d cols=%d", r.Resize.GetRows(), r.Resize.GetCols())
+		return proc.Resize(uint16(r.Resize.GetRows()), uint16(r.Resize.GetCols()))
+	case *spb_gnoi.ShellRequest_Signal:
+		sig, ok := shellSignals[r.Signal]
+		if !ok {
+			return fmt.Errorf("unsupported signal %v", r.Signal)
+		}
+		t.logf("signal: %v", r.Signal)
+		return proc.Signal(sig)
+	}
+	return fmt.Errorf("unexpected request %T", req.GetRequest())
+}
+
+// transcript writes the input and output of a shell session to syslog, or to
+// the log if syslog is not available.
+type transcript struct {
+	id string
+	w  io.Writer
+}
+
+func newTranscript() *transcript {
+	t := &transcript{id: fmt.Sprintf("%d-%d", time.Now().Unix(), atomic.AddUint64(&sessionCount, 1))}
+	w, err := openTranscript()
+	if err != nil {
+		log.Errorf("Could not open connection to syslog for shell transcript: %v", err)
+		return t
+	}
+	t.w = w
+	return t
+}
+
+func (t *transcript) logf(format string, args ...interface{}) {
+	msg := fmt.Sprintf("session %s %s", t.id, fmt.Sprintf(format, args...))
+	if t.w == nil {
+		log.Info(msg)
+		return
+	}
+	if _, err := t.w.Write([]byte(msg)); err != nil {
+		log.Info(msg)
+	}
+}
+
+// Logs terminal data quoted, so that control characters are visible, in lines
+// short enough for syslog.
+func (t *transcript) logData(kind string, data []byte) {
+	for len(data) > 0 {
+		n := len(data)
+		if n > transcriptLineSize {
+			n = transcriptLineSize
+		}
+		t.logf("%s: %q", kind, data[:n])
+		data = data[n:]
+	}
+}
+
+func (t *transcript) close() {
+	if c, ok := t.w.(io.Closer); ok {
+		c.Close()
+	}
+}
+
+func peerAddress(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return p.Addr.String()
+	}
+	return ""
+}
@@ -0,0 +1,388 @@
+package debug
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	spb_gnoi "github.com/sonic-net/sonic-gnmi/proto/gnoi"
+	debug_pb "github.com/sonic-net/sonic-gnmi/proto/gnoi/debug"
+)
+
+// mockShell is a command echoing its input, which exits on "exit\n" or a signal.
+type mockShell struct {
+	outR    *io.PipeReader
+	outW    *io.PipeWriter
+	mu      sync.Mutex
+	input   bytes.Buffer
+	signals []syscall.Signal
+	rows    uint16
+	cols    uint16
+	code    int
+	done    chan struct{}
+	once    sync.Once
+}
+
+func newMockShell(output string) *mockShell {
+	r, w := io.Pipe()
+	s := &mockShell{outR: r, outW: w, done: make(chan struct{})}
+	if output != "" {
+		go w.Write([]byte(output))
+	}
+	return s
+}
+
+func (s *mockShell) exit(code int) {
+	s.once.Do(func() {
+		s.mu.Lock()
+		s.code = code
+		s.mu.Unlock()
+		s.outW.Close()
+		close(s.done)
+	})
+}
+
+func (s *mockShell) Read(p []byte) (int, error) { return s.outR.Read(p) }
+
+func (s *mockShell) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	s.input.Write(p)
+	s.mu.Unlock()
+	if string(p) == "exit\n" {
+		s.exit(0)
+		return len(p), nil
+	}
+	return s.outW.Write(p)
+}
+
+func (s *mockShell) Resize(rows uint16, cols uint16) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rows, s.cols = rows, cols
+	return nil
+}
+
+func (s *mockShell) Signal(sig syscall.Signal) error {
+	s.mu.Lock()
+	s.signals = append(s.signals, sig)
+	s.mu.Unlock()
+	s.exit(-1)
+	return nil
+}
+
+func (s *mockShell) Wait() (int, error) {
+	<-s.done
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.code, nil
+}
+
+func (s *mockShell) Close() error {
+	s.exit(-1)
+	return nil
+}
+
+// mockShellStream feeds the requests to the server, then blocks until the
+// context is done.
+type mockShellStream struct {
+	ctx       context.Context
+	requests  chan *spb_gnoi.ShellRequest
+	mu        sync.Mutex
+	responses []*spb_gnoi.ShellResponse
+	grpc.ServerStream
+}
+
+func newMockShellStream(ctx context.Context, reqs ...*spb_gnoi.ShellRequest) *mockShellStream {
+	s := &mockShellStream{ctx: ctx, requests: make(chan *spb_gnoi.ShellRequest, len(reqs))}
+	for _, req := range reqs {
+		s.requests <- req
+	}
+	return s
+}
+
+func (s *mockShellStream) Context() context.Context { return s.ctx }
+
+func (s *mockShellStream) Recv() (*spb_gnoi.ShellRequest, error) {
+	select {
+	case req, ok := <-s.requests:
+		if !ok {
+			return nil, io.EOF
+		}
+		return req, nil
+	case <-s.ctx.Done():
+		return nil, s.ctx.Err()
+	}
+}
+
+func (s *mockShellStream) Send(resp *spb_gnoi.ShellResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.responses = append(s.responses, resp)
+	return nil
+}
+
+func (s *mockShellStream) output() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out string
+	for _, resp := range s.responses {
+		out += string(resp.GetData())
+	}
+	return out
+}
+
+func (s *mockShellStream) status() *spb_gnoi.ShellStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.responses) == 0 {
+		return nil
+	}
+	return s.responses[len(s.responses)-1].GetStatus()
+}
+
+// mockTranscript collects the transcript lines.
+type mockTranscript struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (m *mockTranscript) Write(p []byte) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lines = append(m.lines, string(p))
+	return len(p), nil
+}
+
+func (m *mockTranscript) String() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return strings.Join(m.lines, "\n")
+}
+
+func mockShellDeps(t *testing.T, shell *mockShell, startErr error) (*mockTranscript, *string) {
+	originalStart, originalOpen := startShell, openTranscript
+	t.Cleanup(func() { startShell, openTranscript = originalStart, originalOpen })
+
+	var started string
+	startShell = func(roleAccount string, cmd string, term string, rows uint16, cols uint16) (shellProcess, error) {
+		started = cmd
+		if startErr != nil {
+			return nil, startErr
+		}
+		shell.rows, shell.cols = rows, cols
+		return shell, nil
+	}
+	tr := &mockTranscript{}
+	openTranscript = func() (io.Writer, error) { return tr, nil }
+	return tr, &started
+}
+
+func startReq(start *spb_gnoi.ShellStart) *spb_gnoi.ShellRequest {
+	return &spb_gnoi.ShellRequest{Request: &spb_gnoi.ShellRequest_Start{Start: start}}
+}
+
+func stdinReq(data string) *spb_gnoi.ShellRequest {
+	return &spb_gnoi.ShellRequest{Request: &spb_gnoi.ShellRequest_Stdin{Stdin: []byte(data)}}
+}
+
+func TestHandleShellStreamErrors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		req      *spb_gnoi.ShellRequest
+		startErr error
+		errType  codes.Code
+	}{
+		{
+			name:    "Error on first request not starting the session",
+			req:     stdinReq("ls\n"),
+			errType: codes.InvalidArgument,
+		},
+		{
+			name:    "Error on empty command",
+			req:     startReq(&spb_gnoi.ShellStart{}),
+			errType: codes.InvalidArgument,
+		},
+		{
+			name:    "Error on command not in whitelist",
+			req:     startReq(&spb_gnoi.ShellStart{Command: "rm -rf /"}),
+			errType: codes.PermissionDenied,
+		},
+		{
+			name:    "Error on negative timeout",
+			req:     startReq(&spb_gnoi.ShellStart{Command: "show", Timeout: -1}),
+			errType: codes.InvalidArgument,
+		},
+		{
+			name:     "Error on failure to start",
+			req:      startReq(&spb_gnoi.ShellStart{Command: "show"}),
+			startErr: errors.New("no pty"),
+			errType:  codes.FailedPrecondition,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockShellDeps(t, newMockShell(""), tc.startErr)
+			stream := newMockShellStream(context.Background(), tc.req)
+
+			err := HandleShellStream(stream, testPolicy, "admin")
+			if st, _ := status.FromError(err); st.Code() != tc.errType {
+				t.Errorf("expected code %v, got: %v", tc.errType, err)
+			}
+		})
+	}
+}
+
+func TestHandleShellStreamSession(t *testing.T) {
+	shell := newMockShell("")
+	tr, started := mockShellDeps(t, shell, nil)
+	stream := newMockShellStream(context.Background(),
+		startReq(&spb_gnoi.ShellStart{Command: "show", Size: &spb_gnoi.WindowSize{Rows: 24, Cols: 80}}),
+		&spb_gnoi.ShellRequest{Request: &spb_gnoi.ShellRequest_Resize{Resize: &spb_gnoi.WindowSize{Rows: 50, Cols: 120}}},
+		stdinReq("hello\n"),
+	)
+
+	done := make(chan error)
+	go func() { done <- HandleShellStream(stream, testPolicy, "admin") }()
+
+	// Wait for the echo before exiting, so that the output is sent
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(stream.output(), "hello") && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	stream.requests <- stdinReq("exit\n")
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("did not expect an error but got: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("session did not end")
+	}
+
+	if *started != "show" {
+		t.Errorf("expected command show, got %q", *started)
+	}
+	if out := stream.output(); out != "hello\n" {
+		t.Errorf("expected output %q, got %q", "hello\n", out)
+	}
+	if st := stream.status(); st == nil || st.GetCode() != 0 || st.GetMessage() != "" {
+		t.Errorf("expected exit code 0, got %+v", st)
+	}
+	if shell.rows != 50 || shell.cols != 120 {
+		t.Errorf("expected window size 50x120, got %dx%d", shell.rows, shell.cols)
+	}
+
+	transcript := tr.String()
+	for _, want := range []string{`start: user="admin"`, `command="show"`, `resize: rows=50 cols=120`, `input: "hello\n"`, `output: "hello\n"`, `input: "exit\n"`, `end: code=0`} {
+		if !strings.Contains(transcript, want) {
+			t.Errorf("expected transcript to contain %q, got:\n%s", want, transcript)
+		}
+	}
+}
+
+func TestHandleShellStreamSignal(t *testing.T) {
+	shell := newMockShell("")
+	mockShellDeps(t, shell, nil)
+	stream := newMockShellStream(context.Background(),
+		startReq(&spb_gnoi.ShellStart{Command: "show"}),
+		&spb_gnoi.ShellRequest{Request: &spb_gnoi.ShellRequest_Signal{Signal: spb_gnoi.ShellSignal_SIGNAL_INT}},
+	)
+
+	if err := HandleShellStream(stream, testPolicy, "admin"); err != nil {
+		t.Fatalf("did not expect an error but got: %v", err)
+	}
+	if len(shell.signals) != 1 || shell.signals[0] != syscall.SIGINT {
+		t.Errorf("expected SIGINT, got %v", shell.signals)
+	}
+	if st := stream.status(); st.GetCode() != -1 {
+		t.Errorf("expected exit code -1, got %+v", st)
+	}
+}
+
+func TestHandleShellStreamTimeouts(t *testing.T) {
+	testCases := []struct {
+		name   string
+		start  *spb_gnoi.ShellStart
+		output string
+		reason string
+	}{
+		{
+			name:   "Idle timeout",
+			start:  &spb_gnoi.ShellStart{Command: "show", IdleTimeout: (50 * time.Millisecond).Nanoseconds()},
+			reason: "session idle timeout",
+		},
+		{
+			name:   "Total timeout despite output",
+			start:  &spb_gnoi.ShellStart{Command: "show", Timeout: (100 * time.Millisecond).Nanoseconds(), IdleTimeout: time.Second.Nanoseconds()},
+			output: "output",
+			reason: "session timed out",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			shell := newMockShell(tc.output)
+			tr, _ := mockShellDeps(t, shell, nil)
+			stream := newMockShellStream(context.Background(), startReq(tc.start))
+
+			if err := HandleShellStream(stream, testPolicy, "admin"); err != nil {
+				t.Fatalf("did not expect an error but got: %v", err)
+			}
+			st := stream.status()
+			if st.GetCode() != -1 || st.GetMessage() != tc.reason {
+				t.Errorf("expected exit code -1 and reason %q, got %+v", tc.reason, st)
+			}
+			if !strings.Contains(tr.String(), tc.reason) {
+				t.Errorf("expected transcript to contain %q, got:\n%s", tc.reason, tr.String())
+			}
+		})
+	}
+}
+
+func TestHandleCommandRequestShellMode(t *testing.T) {
+	shell := newMockShell("SONiC Version: 202305\r\n")
+	_, started := mockShellDeps(t, shell, nil)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		shell.exit(2)
+	}()
+
+	req := &debug_pb.DebugRequest{
+		Command:   []byte("show version"),
+		Mode:      debug_pb.DebugRequest_MODE_SHELL,
+		ByteLimit: 5,
+	}
+	stream := &mockDebugServerStream{ctx: context.Background()}
+	if err := HandleCommandRequest(req, stream, testPolicy); err != nil {
+		t.Fatalf("did not expect an error but got: %v", err)
+	}
+
+	if *started != "show version" {
+		t.Errorf("expected command %q, got %q", "show version", *started)
+	}
+	responses := stream.getResponses()
+	if len(responses) != 3 {
+		t.Fatalf("expected 3 responses, got %d", len(responses))
+	}
+	if responses[0].GetRequest() != req {
+		t.Errorf("unexpected request response, got %+v", responses[0].GetRequest())
+	}
+	if data := string(responses[1].GetData()); data != "SONiC" {
+		t.Errorf("expected output within byte limit, got %q", data)
+	}
+	if code := responses[2].GetStatus().GetCode(); code != 2 {
+		t.Errorf("expected exit code 2, got %d", code)
+	}
+}
@@ -36,6 +36,15 @@ var ErrRejected = errors.New("command rejected by policy")
 //
 // Returns nil for valid command. Otherwise returns ErrRejected.
 func ValidateCommand(input string, whitelist []string) (err error) {
+	return ValidateCommandPolicy(input, NewPolicy("whitelist", whitelist, nil))
+}
+
+// ValidateCommandPolicy validates the input shell text as ValidateCommand does,
+// checking each command and its arguments against the rules of the policy.
+//
+// Returns nil for valid command. Otherwise returns ErrRejected, naming the rule
+// which denied the command, if any.
+func ValidateCommandPolicy(input string, policy *Policy) (err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			err = fmt.Errorf("%w: recover error: %v", ErrRejected, r)
@@ -61,7 +70,7 @@ func ValidateCommand(input string, whitelist []string) (err error) {
 	}
 
 	for _, statement := range ast.Stmts {
-		err := validateStatement(statement, whitelist)
+		err := validateStatement(statement, policy)
 		if err != nil {
 			return err
 		}
@@ -70,11 +79,11 @@ func ValidateCommand(input string, whitelist []string) (err error) {
 	return nil
 }
 
-// Helper which validates a provided statement against statement-specific policies, along with the whitelist.
+// Helper which validates a provided statement against statement-specific policies, along with the whitelist policy.
 // Recurses on any found pipelines, to validate the commands on either side.
 //
 // Returns nil on success, or the specific validation error, if any.
-func validateStatement(statement *syntax.Stmt, whitelist []string) error {
+func validateStatement(statement *syntax.Stmt, policy *Policy) error {
 	// Disallow negation, background, coprocessing, semicolons, and redirects.
 	if statement.Negated {
 		return fmt.Errorf("%w: negation '!' not allowed", ErrRejected)
@@ -115,10 +124,14 @@ func validateStatement(statement *syntax.Stmt, whitelist []string) error {
 			}
 		}
 
-		// The first argv element is the command name. Look it up in whitelist.
+		// The first argv element is the command name. Check it, with its arguments, against the policy.
 		cmdName := call.Args[0].Lit()
-		if !sliceContains(whitelist, cmdName) {
-			return fmt.Errorf("%w: command %q is not whitelisted", ErrRejected, cmdName)
+		args := make([]string, 0, len(call.Args)-1)
+		for _, word := range call.Args[1:] {
+			args = append(args, wordValue(word.Parts))
+		}
+		if err := policy.check(cmdName, args); err != nil {
+			return err
 		}
 	case *syntax.BinaryCmd:
 		binCmd := statement.Cmd.(*syntax.BinaryCmd)
@@ -128,11 +141,11 @@ func validateStatement(statement *syntax.Stmt, whitelist []string) error {
 		}
 
 		// Validate statements on both sides of the operator
-		errX := validateStatement(binCmd.X, whitelist)
+		errX := validateStatement(binCmd.X, policy)
 		if errX != nil {
 			return errX
 		}
-		errY := validateStatement(binCmd.Y, whitelist)
+		errY := validateStatement(binCmd.Y, policy)
 		if errY != nil {
 			return errY
 		}
@@ -215,6 +228,23 @@ func validateWordParts(word *syntax.Word, wordParts []syntax.WordPart) error {
 	return nil
 }
 
+// Helper which returns the value of a validated word, as the command will see it after quote removal.
+func wordValue(wordParts []syntax.WordPart) string {
+	var value strings.Builder
+	for _, part := range wordParts {
+		switch part := part.(type) {
+		case *syntax.Lit:
+			value.WriteString(part.Value)
+		case *syntax.SglQuoted:
+			value.WriteString(part.Value)
+		case *syntax.DblQuoted:
+			value.WriteString(wordValue(part.Parts))
+		}
+	}
+
+	return value.String()
+}
+
 // Helper which ports the slices.Contains functionality for string slices to this version of Go.
 // Returns whether the string exists within the provided slice.
 func sliceContains(slice []string, str string) bool {
@@ -0,0 +1,112 @@
+package debug
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/golang/glog"
+)
+
+var (
+	whitelistsMu sync.RWMutex
+	// watched is the whitelists kept up to date by the whitelist watcher,
+	// nil while the watcher is not running
+	watched *Whitelists
+)
+
+// CurrentWhitelists returns the whitelists kept up to date by the whitelist
+// watcher. While the watcher is not running, loads them from the whitelist file.
+func CurrentWhitelists() *Whitelists {
+	whitelistsMu.RLock()
+	whitelists := watched
+	whitelistsMu.RUnlock()
+	if whitelists != nil {
+		return whitelists
+	}
+	return loadWhitelists(nil)
+}
+
+func setWhitelists(whitelists *Whitelists) {
+	whitelistsMu.Lock()
+	defer whitelistsMu.Unlock()
+	watched = whitelists
+}
+
+// Loads the whitelists from the file. Falls back to the defaults if the file
+// does not exist, and keeps prev, if any, if the file is invalid.
+func loadWhitelists(prev *Whitelists) *Whitelists {
+	whitelists, err := LoadWhitelists()
+	if err == nil {
+		return whitelists
+	}
+	if prev != nil && !os.IsNotExist(err) {
+		glog.Errorf("Invalid whitelist at '%s', keeping previous whitelists: %v", WHITELIST_FILE_PATH, err)
+		return prev
+	}
+	glog.Warningf("Could not load whitelist at '%s', using default whitelists: %v", WHITELIST_FILE_PATH, err)
+	return DefaultWhitelists()
+}
+
+// StartWhitelistWatcher loads the whitelists and reloads them whenever the
+// whitelist file is written, replaced or removed, until the returned function
+// is called.
+func StartWhitelistWatcher() (stop func()) {
+	setWhitelists(loadWhitelists(nil))
+
+	// The directory is watched, as editors and config management replace the file
+	path := filepath.Clean(WHITELIST_FILE_PATH)
+	watcher, err := fsnotify.NewWatcher()
+	if err == nil {
+		if err = watcher.Add(filepath.Dir(path)); err != nil {
+			watcher.Close()
+		}
+	}
+	if err != nil {
+		glog.Errorf("Cannot watch whitelist at '%s', changes need a restart: %v", path, err)
+		return func() { setWhitelists(nil) }
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) != path {
+					continue
+				}
+				if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename|fsnotify.Remove) != 0 {
+					glog.V(1).Infof("Whitelist at '%s' changed (%v), reloading", path, event.Op)
+					whitelistsMu.RLock()
+					prev := watched
+					whitelistsMu.RUnlock()
+					setWhitelists(loadWhitelists(prev))
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				glog.Errorf("Received error event when watching whitelist: %v", err)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			wg.Wait()
+			watcher.Close()
+			setWhitelists(nil)
+		})
+	}
+}
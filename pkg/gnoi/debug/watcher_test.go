@@ -0,0 +1,68 @@
+package debug
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWhitelistWatcher(t *testing.T) {
+	originalPath := WHITELIST_FILE_PATH
+	t.Cleanup(func() {
+		WHITELIST_FILE_PATH = originalPath
+	})
+	WHITELIST_FILE_PATH = filepath.Join(t.TempDir(), "whitelist.yaml")
+
+	writeFile := func(content string) {
+		// Replace the file, as config management does
+		tmp := WHITELIST_FILE_PATH + ".tmp"
+		if err := os.WriteFile(tmp, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write temp file: %v", err)
+		}
+		if err := os.Rename(tmp, WHITELIST_FILE_PATH); err != nil {
+			t.Fatalf("Failed to rename temp file: %v", err)
+		}
+	}
+	allowed := func(cmd string) bool {
+		return ValidateCommandPolicy(cmd, CurrentWhitelists().PolicyFor(nil, false)) == nil
+	}
+	waitFor := func(cmd string, want bool) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for allowed(cmd) != want {
+			if time.Now().After(deadline) {
+				t.Fatalf("expected %q allowed=%v after reload", cmd, want)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	writeFile("read_whitelist: [show]\nwrite_whitelist: []")
+	stop := StartWhitelistWatcher()
+	defer stop()
+
+	if !allowed("show version") || allowed("uptime") {
+		t.Fatal("expected the initial whitelist to be loaded")
+	}
+
+	writeFile("read_whitelist: [uptime]\nwrite_whitelist: []")
+	waitFor("uptime", true)
+	waitFor("show version", false)
+
+	// Invalid file keeps the previous whitelists
+	writeFile("read_whitelist: [show")
+	time.Sleep(100 * time.Millisecond)
+	if !allowed("uptime") {
+		t.Error("expected the previous whitelist to be kept on invalid file")
+	}
+
+	// Removed file falls back to the defaults
+	os.Remove(WHITELIST_FILE_PATH)
+	waitFor("show version", true)
+
+	stop()
+	if watched != nil {
+		t.Error("expected the watched whitelists to be cleared on stop")
+	}
+}
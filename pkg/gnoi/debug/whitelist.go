@@ -1,7 +1,9 @@
 package debug
 
 import (
+	"fmt"
 	"os"
+	"strings"
 
 	"github.com/golang/glog"
 	"gopkg.in/yaml.v3"
@@ -11,24 +13,147 @@ var (
 	WHITELIST_FILE_PATH = "/etc/sonic/command_whitelist.yaml"
 )
 
+// WhitelistFile is the format of the whitelist file. Entries are rules, i.e. a
+// program name optionally followed by argument patterns (see Rule):
+//
+//	read_whitelist:
+//	  - show
+//	  - ip route show
+//	write_whitelist:
+//	  - config interface *
+//	denylist:
+//	  - ip route del
+//	roles:
+//	  netops:
+//	    allow:
+//	      - vtysh
+//	    deny:
+//	      - config reload
 type WhitelistFile struct {
 	ReadWhitelist  []string `yaml:"read_whitelist"`
 	WriteWhitelist []string `yaml:"write_whitelist"`
+	// Denied for every user
+	Denylist []string `yaml:"denylist"`
+	// Replace the read and write whitelists for users with the role. Users
+	// without write access remain limited to the read whitelist.
+	Roles map[string]RoleWhitelist `yaml:"roles"`
+}
+
+// RoleWhitelist is the section of a role in the whitelist file.
+type RoleWhitelist struct {
+	Allow []string `yaml:"allow"`
+	Deny  []string `yaml:"deny"`
+}
+
+// Whitelists holds the policies commands are validated against.
+type Whitelists struct {
+	Read  *Policy
+	Write *Policy
+	Roles map[string]*Policy
+}
+
+// PolicyFor returns the policy of a user with the given roles and access.
+//
+// If any role of the user has a section in the whitelist file, the policies of
+// these roles apply, merged. Otherwise the read or write policy applies. Without
+// write access, the commands allowed by the roles must also be allowed by the
+// read policy, so that roles cannot grant mutating commands to read-only users.
+func (w *Whitelists) PolicyFor(roles []string, writeAccess bool) *Policy {
+	var policies []*Policy
+	for _, role := range roles {
+		if policy, ok := w.Roles[strings.TrimSpace(role)]; ok {
+			policies = append(policies, policy)
+		}
+	}
+	if len(policies) > 0 {
+		policy := merge(policies...)
+		if !writeAccess {
+			policy.Within = w.Read
+		}
+		return policy
+	}
+	if writeAccess {
+		return w.Write
+	}
+	return w.Read
+}
+
+// Reads and unmarshals the whitelist file.
+func readWhitelistFile() (*WhitelistFile, error) {
+	whitelistBytes, err := os.ReadFile(WHITELIST_FILE_PATH)
+	if err != nil {
+		return nil, err
+	}
+
+	var whitelists WhitelistFile
+	if err := yaml.Unmarshal(whitelistBytes, &whitelists); err != nil {
+		return nil, fmt.Errorf("could not unmarshal whitelist: %v", err)
+	}
+	return &whitelists, nil
+}
+
+// LoadWhitelists loads the policies from the whitelist file. Unlike
+// ConstructWhitelists, it returns an error rather than the defaults if the file
+// is missing or invalid.
+func LoadWhitelists() (*Whitelists, error) {
+	file, err := readWhitelistFile()
+	if err != nil {
+		return nil, err
+	}
+	if file.ReadWhitelist == nil || file.WriteWhitelist == nil {
+		return nil, fmt.Errorf("keys 'read_whitelist' and 'write_whitelist' are required")
+	}
+
+	rules := []string{}
+	rules = append(rules, file.ReadWhitelist...)
+	rules = append(rules, file.WriteWhitelist...)
+	rules = append(rules, file.Denylist...)
+	for role, section := range file.Roles {
+		if len(section.Allow) == 0 {
+			return nil, fmt.Errorf("role %q allows no command", role)
+		}
+		rules = append(rules, section.Allow...)
+		rules = append(rules, section.Deny...)
+	}
+	for _, text := range rules {
+		if _, err := ParseRule(text, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	read := NewPolicy("read_whitelist", file.ReadWhitelist, nil)
+	read.add("denylist", nil, file.Denylist)
+	write := NewPolicy("write_whitelist", file.WriteWhitelist, nil)
+	write.add("read_whitelist", file.ReadWhitelist, nil)
+	write.add("denylist", nil, file.Denylist)
+	whitelists := &Whitelists{Read: read, Write: write, Roles: map[string]*Policy{}}
+	for role, section := range file.Roles {
+		policy := NewPolicy("role "+role, section.Allow, section.Deny)
+		policy.add("denylist", nil, file.Denylist)
+		whitelists.Roles[role] = policy
+	}
+	return whitelists, nil
+}
+
+// DefaultWhitelists returns the policies of the default read and write whitelists.
+func DefaultWhitelists() *Whitelists {
+	read, write := defaultWhitelists()
+	return &Whitelists{
+		Read:  NewPolicy("default read whitelist", read, nil),
+		Write: NewPolicy("default write whitelist", write, nil),
+	}
 }
 
 // Function which constructs a whitelist from the YAML file present at `/etc/sonic/command_whitelist.yaml`.
 // If there is any issue reading this file, returns a default set of commands.
 func ConstructWhitelists() (read, write []string) {
-	whitelistBytes, err := os.ReadFile(WHITELIST_FILE_PATH)
-	if err != nil {
+	whitelists, err := readWhitelistFile()
+	if os.IsNotExist(err) {
 		glog.Warningf("No whitelist found at path '%s', using default whitelists: %v", WHITELIST_FILE_PATH, err)
 		return defaultWhitelists()
 	}
-
-	var whitelists WhitelistFile
-	err = yaml.Unmarshal(whitelistBytes, &whitelists)
 	if err != nil {
-		glog.Warningf("Could not unmarshal whitelist at '%s', using defaults: %v", WHITELIST_FILE_PATH, err)
+		glog.Warningf("Could not read whitelist at '%s', using defaults: %v", WHITELIST_FILE_PATH, err)
 		return defaultWhitelists()
 	}
 
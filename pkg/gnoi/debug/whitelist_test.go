@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"sort"
+	"strings"
 	"testing"
 )
 
@@ -129,3 +130,148 @@ read_whitelist:
 		})
 	}
 }
+
+func TestLoadWhitelists(t *testing.T) {
+	originalPath := WHITELIST_FILE_PATH
+	t.Cleanup(func() {
+		WHITELIST_FILE_PATH = originalPath
+	})
+
+	WHITELIST_FILE_PATH = filepath.Join(t.TempDir(), "whitelist.yaml")
+	if _, err := LoadWhitelists(); !os.IsNotExist(err) {
+		t.Errorf("expected not exist error, got: %v", err)
+	}
+
+	content := `
+read_whitelist:
+  - show
+  - ip route show
+write_whitelist:
+  - config
+denylist:
+  - config reload
+roles:
+  netops:
+    allow:
+      - vtysh
+      - config interface
+    deny:
+      - config interface shutdown
+  dev:
+    allow:
+      - redis-cli
+  viewer:
+    allow:
+      - show
+      - ip route
+`
+	if err := os.WriteFile(WHITELIST_FILE_PATH, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write temp file: %v", err)
+	}
+	whitelists, err := LoadWhitelists()
+	if err != nil {
+		t.Fatalf("did not expect an error but got: %v", err)
+	}
+
+	testCases := []struct {
+		name        string
+		roles       []string
+		writeAccess bool
+		allowed     []string
+		denied      []string
+	}{
+		{
+			name:    "Read access",
+			allowed: []string{"show version", "ip route show"},
+			denied:  []string{"ip route del 1.1.1.1", "config save -y"},
+		},
+		{
+			name:        "Write access",
+			writeAccess: true,
+			allowed:     []string{"show version", "config save -y"},
+			denied:      []string{"config reload -y", "vtysh"},
+		},
+		{
+			name:        "Role replaces access level",
+			roles:       []string{"gnoi_readwrite", " netops"},
+			writeAccess: true,
+			allowed:     []string{"vtysh", "config interface startup Ethernet0"},
+			denied:      []string{"show version", "config interface shutdown Ethernet0", "config save -y"},
+		},
+		{
+			name:        "Roles are merged",
+			roles:       []string{"netops", "dev"},
+			writeAccess: true,
+			allowed:     []string{"vtysh", "redis-cli"},
+			denied:      []string{"config interface shutdown Ethernet0"},
+		},
+		{
+			name:    "Roles of read-only users are limited to the read whitelist",
+			roles:   []string{"netops", "viewer"},
+			allowed: []string{"show version", "ip route show"},
+			denied:  []string{"vtysh", "config interface startup Ethernet0", "ip route del 1.1.1.1"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			policy := whitelists.PolicyFor(tc.roles, tc.writeAccess)
+			for _, cmd := range tc.allowed {
+				if err := ValidateCommandPolicy(cmd, policy); err != nil {
+					t.Errorf("expected %q to be allowed, got: %v", cmd, err)
+				}
+			}
+			for _, cmd := range tc.denied {
+				if err := ValidateCommandPolicy(cmd, policy); err == nil {
+					t.Errorf("expected %q to be denied", cmd)
+				}
+			}
+		})
+	}
+
+	err = ValidateCommandPolicy("config reload -y", whitelists.PolicyFor(nil, true))
+	if err == nil || !strings.Contains(err.Error(), `rule "config reload" of denylist`) {
+		t.Errorf("expected denial by the denylist, got: %v", err)
+	}
+}
+
+func TestLoadWhitelistsErrors(t *testing.T) {
+	originalPath := WHITELIST_FILE_PATH
+	t.Cleanup(func() {
+		WHITELIST_FILE_PATH = originalPath
+	})
+
+	testCases := []struct {
+		name        string
+		fileContent string
+	}{
+		{
+			name:        "Malformed YAML",
+			fileContent: "read_whitelist: [cmd1, cmd2",
+		},
+		{
+			name:        "Missing write whitelist",
+			fileContent: "read_whitelist: [show]",
+		},
+		{
+			name:        "Empty rule",
+			fileContent: "read_whitelist: [show, '']\nwrite_whitelist: []",
+		},
+		{
+			name:        "Role without allow rules",
+			fileContent: "read_whitelist: [show]\nwrite_whitelist: []\nroles:\n  netops:\n    deny: [config]",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			WHITELIST_FILE_PATH = filepath.Join(t.TempDir(), "whitelist.yaml")
+			if err := os.WriteFile(WHITELIST_FILE_PATH, []byte(tc.fileContent), 0644); err != nil {
+				t.Fatalf("Failed to write temp file: %v", err)
+			}
+			if _, err := LoadWhitelists(); err == nil {
+				t.Error("expected an error, but got nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,313 @@
+package dpuproxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/golang/glog"
+	system "github.com/openconfig/gnoi/system"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Fan-out
+//
+// A target index of "all" or a comma separated list of indices (e.g., "0,2,3")
+// sends a request to each of the DPUs in parallel, for the methods registered
+// with FanOut in defaultForwardableMethods. The outcome on every DPU, with its
+// response or error, is returned in the MetadataKeyFanOutResults trailer.
+//
+// The request succeeds only when it succeeded on all DPUs; the client then
+// receives the response of the first DPU in index order. If it failed on any
+// DPU, the client receives an error listing the failed DPUs, whose code is the
+// common code of the failures (Unknown if they differ), and finds in the
+// trailer which DPUs succeeded.
+
+const (
+	// DefaultFanOutLimit is the default number of DPUs a request is sent to concurrently
+	DefaultFanOutLimit = 4
+
+	// MaxFanOutStreamSize is the maximum size of a client stream buffered for
+	// replay to each DPU. Images should be transferred with File.Put beforehand.
+	MaxFanOutStreamSize = 16 * 1024 * 1024
+)
+
+// DPUResult is the outcome of a fanned out request on one DPU.
+type DPUResult struct {
+	// Index is the DPU number (e.g., "0")
+	Index string `json:"index"`
+
+	// Code is the gRPC status code name, "OK" on success
+	Code string `json:"code"`
+
+	// Error is the error message on failure
+	Error string `json:"error,omitempty"`
+
+	// Response is the protobuf JSON encoded response on success
+	Response json.RawMessage `json:"response,omitempty"`
+}
+
+// fanOutResult is the raw outcome of a request on one DPU.
+type fanOutResult struct {
+	index string
+	resp  interface{}
+	err   error
+}
+
+// fanOutCall sends a request to a single DPU.
+type fanOutCall func(ctx context.Context, dpuIndex string) (interface{}, error)
+
+// SetFanOutLimit sets the number of DPUs a request is sent to concurrently.
+func (p *DPUProxy) SetFanOutLimit(limit int) {
+	if limit > 0 {
+		p.fanOutLimit = limit
+	}
+}
+
+// fanOutTargets expands the target index into the list of DPU indices.
+func (p *DPUProxy) fanOutTargets(ctx context.Context, targetIndex string) ([]string, error) {
+	if targetIndex == TargetIndexAll {
+		if p.resolver == nil {
+			return nil, status.Error(codes.FailedPrecondition, "DPU resolver not available")
+		}
+		indices, err := p.resolver.ListDPUs(ctx)
+		if err != nil {
+			return nil, status.Errorf(codes.Unavailable, "failed to list DPUs: %v", err)
+		}
+		if len(indices) == 0 {
+			return nil, status.Error(codes.NotFound, "no DPUs found")
+		}
+		return indices, nil
+	}
+
+	seen := make(map[string]bool)
+	var indices []string
+	for _, index := range strings.Split(targetIndex, ",") {
+		index = strings.TrimSpace(index)
+		if index == "" {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid DPU target index list %q", targetIndex)
+		}
+		if !seen[index] {
+			seen[index] = true
+			indices = append(indices, index)
+		}
+	}
+	return indices, nil
+}
+
+// fanOut sends a request to each DPU with at most p.fanOutLimit requests in
+// flight. Results are returned in the order of indices.
+func (p *DPUProxy) fanOut(ctx context.Context, indices []string, call fanOutCall) []fanOutResult {
+	limit := p.fanOutLimit
+	if limit <= 0 {
+		limit = DefaultFanOutLimit
+	}
+
+	results := make([]fanOutResult, len(indices))
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i, index := range indices {
+		wg.Add(1)
+		go func(i int, index string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			resp, err := call(ctx, index)
+			if err != nil {
+				glog.Warningf("[DPUProxy] Fanned out request failed on DPU%s: %v", index, err)
+			}
+			results[i] = fanOutResult{index: index, resp: resp, err: err}
+		}(i, index)
+	}
+	wg.Wait()
+	return results
+}
+
+// aggregateResults returns the response of the first DPU and the per-DPU
+// results encoded as a trailer. If any DPU failed, the error lists the failure
+// of each failed DPU; its code is the common code of the failures, if any.
+func aggregateResults(results []fanOutResult) (interface{}, metadata.MD, error) {
+	var resp interface{}
+	code := codes.OK
+	var failures []string
+
+	summary := make([]DPUResult, 0, len(results))
+	for _, r := range results {
+		result := DPUResult{Index: r.index, Code: status.Code(r.err).String()}
+		if r.err != nil {
+			result.Error = status.Convert(r.err).Message()
+			failures = append(failures, fmt.Sprintf("DPU%s: %s", r.index, result.Error))
+			if code == codes.OK {
+				code = status.Code(r.err)
+			} else if code != status.Code(r.err) {
+				code = codes.Unknown
+			}
+		} else {
+			if msg, ok := r.resp.(proto.Message); ok {
+				if b, err := protojson.Marshal(msg); err == nil {
+					result.Response = b
+				}
+			}
+			if resp == nil {
+				resp = r.resp
+			}
+		}
+		summary = append(summary, result)
+	}
+
+	b, err := json.Marshal(summary)
+	if err != nil {
+		return nil, nil, status.Errorf(codes.Internal, "failed to encode DPU results: %v", err)
+	}
+	trailer := metadata.Pairs(MetadataKeyFanOutResults, string(b))
+
+	if len(failures) != 0 {
+		return nil, trailer, status.Errorf(code, "request failed on %d of %d DPUs: %s",
+			len(failures), len(results), strings.Join(failures, "; "))
+	}
+	return resp, trailer, nil
+}
+
+// fanOutUnary sends a unary request to several DPUs. Requests handled locally
+// are passed to the local handler once per DPU, with the target index of that DPU.
+func (p *DPUProxy) fanOutUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler, mode ForwardingMode, targetIndex string) (interface{}, error) {
+	if !p.supportsFanOut(info.FullMethod) {
+		return nil, status.Errorf(codes.InvalidArgument,
+			"method %s does not support multiple DPU targets", info.FullMethod)
+	}
+
+	indices, err := p.fanOutTargets(ctx, targetIndex)
+	if err != nil {
+		return nil, err
+	}
+	glog.Infof("[DPUProxy] Fanning out %s to DPUs %v", info.FullMethod, indices)
+
+	results := p.fanOut(ctx, indices, func(ctx context.Context, dpuIndex string) (interface{}, error) {
+		if mode == HandleLocally {
+			return handler(withTargetIndex(ctx, dpuIndex), req)
+		}
+		if p.resolver == nil {
+			return nil, status.Error(codes.FailedPrecondition, "DPU resolver not available")
+		}
+		conn, err := p.dpuConnection(ctx, dpuIndex)
+		if err != nil {
+			return nil, err
+		}
+		done := p.beginRPC(dpuIndex)
+		resp, err := p.forwardUnary(forwardingContext(ctx, p.tlsConfig != nil), conn, info.FullMethod, req)
+		done(err)
+		return resp, err
+	})
+
+	resp, trailer, err := aggregateResults(results)
+	if trailer != nil {
+		if terr := grpc.SetTrailer(ctx, trailer); terr != nil {
+			glog.Warningf("[DPUProxy] Failed to set DPU results trailer: %v", terr)
+		}
+	}
+	return resp, err
+}
+
+// fanOutStream sends a client streaming request to several DPUs. The client
+// stream is buffered first, then replayed to each DPU.
+func (p *DPUProxy) fanOutStream(ctx context.Context, ss grpc.ServerStream, info *grpc.StreamServerInfo, targetIndex string) error {
+	if !p.supportsFanOut(info.FullMethod) {
+		return status.Errorf(codes.InvalidArgument,
+			"method %s does not support multiple DPU targets", info.FullMethod)
+	}
+
+	// Only System.SetPackage is fanned out for now
+	if info.FullMethod != "/gnoi.system.System/SetPackage" {
+		return status.Errorf(codes.Unimplemented,
+			"stream fan-out for method %s not implemented", info.FullMethod)
+	}
+
+	indices, err := p.fanOutTargets(ctx, targetIndex)
+	if err != nil {
+		return err
+	}
+
+	// Buffer the requests from client
+	var reqs []*system.SetPackageRequest
+	size := 0
+	for {
+		req := &system.SetPackageRequest{}
+		if err := ss.RecvMsg(req); err != nil {
+			if err == io.EOF {
+				break
+			}
+			glog.Errorf("[DPUProxy] Error receiving SetPackage from client: %v", err)
+			return err
+		}
+		size += proto.Size(req)
+		if size > MaxFanOutStreamSize {
+			return status.Errorf(codes.ResourceExhausted,
+				"SetPackage stream exceeds %d bytes; transfer the image to the DPUs with File.Put first",
+				MaxFanOutStreamSize)
+		}
+		reqs = append(reqs, req)
+	}
+	glog.Infof("[DPUProxy] Fanning out stream %s (%d requests) to DPUs %v", info.FullMethod, len(reqs), indices)
+
+	results := p.fanOut(ctx, indices, func(ctx context.Context, dpuIndex string) (interface{}, error) {
+		if p.resolver == nil {
+			return nil, status.Error(codes.FailedPrecondition, "DPU resolver not available")
+		}
+		conn, err := p.dpuConnection(ctx, dpuIndex)
+		if err != nil {
+			return nil, err
+		}
+		done := p.beginRPC(dpuIndex)
+		resp, err := p.replaySetPackage(forwardingContext(ctx, p.tlsConfig != nil), conn, reqs)
+		done(err)
+		return resp, err
+	})
+
+	resp, trailer, err := aggregateResults(results)
+	if trailer != nil {
+		ss.SetTrailer(trailer)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := ss.SendMsg(resp); err != nil {
+		glog.Errorf("[DPUProxy] Error sending SetPackage response to client: %v", err)
+		return err
+	}
+	return nil
+}
+
+// replaySetPackage sends the buffered SetPackage requests to a DPU and returns its response.
+func (p *DPUProxy) replaySetPackage(ctx context.Context, conn *grpc.ClientConn, reqs []*system.SetPackageRequest) (interface{}, error) {
+	clientStream, err := system.NewSystemClient(conn).SetPackage(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, req := range reqs {
+		if err := clientStream.Send(req); err != nil {
+			if err == io.EOF {
+				// The DPU ended the stream; its status is returned by CloseAndRecv
+				break
+			}
+			return nil, err
+		}
+	}
+
+	resp, err := clientStream.CloseAndRecv()
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
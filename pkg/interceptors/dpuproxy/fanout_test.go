@@ -0,0 +1,342 @@
+package dpuproxy
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/openconfig/gnoi/healthz"
+	system "github.com/openconfig/gnoi/system"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// fakeDPUSystemServer is an in-process gNOI System and Healthz server standing in for a DPU.
+type fakeDPUSystemServer struct {
+	system.UnimplementedSystemServer
+	healthz.UnimplementedHealthzServer
+
+	time     uint64
+	packages int32 // SetPackage requests received
+}
+
+func (s *fakeDPUSystemServer) Time(ctx context.Context, req *system.TimeRequest) (*system.TimeResponse, error) {
+	return &system.TimeResponse{Time: s.time}, nil
+}
+
+func (s *fakeDPUSystemServer) SetPackage(stream system.System_SetPackageServer) error {
+	for {
+		_, err := stream.Recv()
+		if err == io.EOF {
+			return stream.SendAndClose(&system.SetPackageResponse{})
+		}
+		if err != nil {
+			return err
+		}
+		atomic.AddInt32(&s.packages, 1)
+	}
+}
+
+func (s *fakeDPUSystemServer) Get(ctx context.Context, req *healthz.GetRequest) (*healthz.GetResponse, error) {
+	return &healthz.GetResponse{}, nil
+}
+
+// npuSystemServer is the NPU side System server, handling Reboot locally.
+type npuSystemServer struct {
+	system.UnimplementedSystemServer
+
+	mu       sync.Mutex
+	rebooted []string
+}
+
+func (s *npuSystemServer) Reboot(ctx context.Context, req *system.RebootRequest) (*system.RebootResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rebooted = append(s.rebooted, ExtractTargetMetadata(ctx).TargetIndex)
+	return &system.RebootResponse{}, nil
+}
+
+// startFanOutTestServers starts fake DPU0 and DPU1, registers the unreachable
+// DPU2, and returns a System client connected to an NPU with the DPU proxy installed.
+func startFanOutTestServers(t *testing.T) (*grpc.ClientConn, []*fakeDPUSystemServer, *npuSystemServer) {
+	stateMock := &mockRedisClient{data: map[string]map[string]string{
+		"CHASSIS_MIDPLANE_TABLE|DPU2": {"ip_address": "127.0.0.1", "access": "False"},
+	}}
+	configMock := &mockRedisClient{data: map[string]map[string]string{}}
+
+	var dpus []*fakeDPUSystemServer
+	for i, index := range []string{"0", "1"} {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Failed to listen: %v", err)
+		}
+		dpu := &fakeDPUSystemServer{time: uint64(1000 + i)}
+		srv := grpc.NewServer()
+		system.RegisterSystemServer(srv, dpu)
+		healthz.RegisterHealthzServer(srv, dpu)
+		go srv.Serve(lis)
+		t.Cleanup(srv.Stop)
+		dpus = append(dpus, dpu)
+
+		_, port, _ := net.SplitHostPort(lis.Addr().String())
+		stateMock.data["CHASSIS_MIDPLANE_TABLE|DPU"+index] = map[string]string{"ip_address": "127.0.0.1", "access": "True"}
+		configMock.data["DPU|dpu"+index] = map[string]string{"gnmi_port": port}
+	}
+	proxy := NewDPUProxy(NewDPUResolver(stateMock, configMock))
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	npu := &npuSystemServer{}
+	srv := grpc.NewServer(
+		grpc.UnaryInterceptor(proxy.UnaryInterceptor()),
+		grpc.StreamInterceptor(proxy.StreamInterceptor()),
+	)
+	system.RegisterSystemServer(srv, npu)
+	healthz.RegisterHealthzServer(srv, &healthz.UnimplementedHealthzServer{})
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return conn, dpus, npu
+}
+
+func fanOutContext(index string) context.Context {
+	return metadata.NewOutgoingContext(context.Background(), metadata.New(map[string]string{
+		MetadataKeyTargetType:  "dpu",
+		MetadataKeyTargetIndex: index,
+	}))
+}
+
+func decodeDPUResults(t *testing.T, md metadata.MD) []DPUResult {
+	values := md.Get(MetadataKeyFanOutResults)
+	if len(values) != 1 {
+		t.Fatalf("Expected DPU results trailer, got: %v", md)
+	}
+	var results []DPUResult
+	if err := json.Unmarshal([]byte(values[0]), &results); err != nil {
+		t.Fatalf("Invalid DPU results trailer %q: %v", values[0], err)
+	}
+	return results
+}
+
+func TestDPUProxy_FanOut_Unary(t *testing.T) {
+	conn, _, _ := startFanOutTestServers(t)
+	client := system.NewSystemClient(conn)
+
+	var trailer metadata.MD
+	resp, err := client.Time(fanOutContext("1,0"), &system.TimeRequest{}, grpc.Trailer(&trailer))
+	if err != nil {
+		t.Fatalf("Time failed: %v", err)
+	}
+	if resp.Time != 1001 {
+		t.Errorf("Expected the response of DPU1, got: %v", resp)
+	}
+
+	results := decodeDPUResults(t, trailer)
+	if len(results) != 2 || results[0].Index != "1" || results[1].Index != "0" {
+		t.Fatalf("Expected results for DPU1 and DPU0, got: %v", results)
+	}
+	var dpu0 system.TimeResponse
+	if err := protojson.Unmarshal(results[1].Response, &dpu0); err != nil || dpu0.Time != 1000 {
+		t.Errorf("Unexpected response from DPU0: %s", results[1].Response)
+	}
+
+	// Healthz.Get is forwarded as well
+	trailer = nil
+	_, err = healthz.NewHealthzClient(conn).Get(fanOutContext("0,1"), &healthz.GetRequest{}, grpc.Trailer(&trailer))
+	if err != nil {
+		t.Fatalf("Healthz.Get failed: %v", err)
+	}
+	if results := decodeDPUResults(t, trailer); len(results) != 2 {
+		t.Errorf("Expected results for 2 DPUs, got: %v", results)
+	}
+}
+
+func TestDPUProxy_FanOut_PartialFailure(t *testing.T) {
+	conn, _, _ := startFanOutTestServers(t)
+	client := system.NewSystemClient(conn)
+
+	// DPU2 is unreachable, so the request fails even though DPU0 and DPU1 answered
+	var trailer metadata.MD
+	_, err := client.Time(fanOutContext(TargetIndexAll), &system.TimeRequest{}, grpc.Trailer(&trailer))
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("Expected Unavailable when a DPU fails, got: %v", err)
+	}
+	if msg := status.Convert(err).Message(); !strings.Contains(msg, "1 of 3 DPUs") || !strings.Contains(msg, "DPU2") {
+		t.Errorf("Expected the error to name the failed DPU, got: %s", msg)
+	}
+
+	results := decodeDPUResults(t, trailer)
+	if len(results) != 3 {
+		t.Fatalf("Expected results for 3 DPUs, got: %v", results)
+	}
+	for i, code := range []string{"OK", "OK", "Unavailable"} {
+		if results[i].Index != []string{"0", "1", "2"}[i] || results[i].Code != code {
+			t.Errorf("Unexpected result for DPU%d: %+v", i, results[i])
+		}
+	}
+	var dpu1 system.TimeResponse
+	if err := protojson.Unmarshal(results[1].Response, &dpu1); err != nil || dpu1.Time != 1001 {
+		t.Errorf("Unexpected response from DPU1: %s", results[1].Response)
+	}
+	if results[2].Error == "" {
+		t.Error("Expected error message for DPU2")
+	}
+}
+
+func TestDPUProxy_FanOut_AllFailed(t *testing.T) {
+	conn, _, _ := startFanOutTestServers(t)
+	client := system.NewSystemClient(conn)
+
+	var trailer metadata.MD
+	_, err := client.Time(fanOutContext("2,7"), &system.TimeRequest{}, grpc.Trailer(&trailer))
+	if err == nil {
+		t.Fatal("Expected error when all DPUs fail")
+	}
+	// DPU2 is unreachable and DPU7 does not exist
+	if status.Code(err) != codes.Unknown {
+		t.Errorf("Expected Unknown code for mixed failures, got: %v", err)
+	}
+	results := decodeDPUResults(t, trailer)
+	if len(results) != 2 || results[0].Code != "Unavailable" || results[1].Code != "NotFound" {
+		t.Errorf("Unexpected results: %+v", results)
+	}
+}
+
+func TestDPUProxy_FanOut_HandleLocally(t *testing.T) {
+	conn, _, npu := startFanOutTestServers(t)
+	client := system.NewSystemClient(conn)
+
+	if _, err := client.Reboot(fanOutContext("1,0"), &system.RebootRequest{}); err != nil {
+		t.Fatalf("Reboot failed: %v", err)
+	}
+
+	npu.mu.Lock()
+	defer npu.mu.Unlock()
+	if len(npu.rebooted) != 2 {
+		t.Fatalf("Expected local handler to be called for 2 DPUs, got: %v", npu.rebooted)
+	}
+	seen := map[string]bool{npu.rebooted[0]: true, npu.rebooted[1]: true}
+	if !seen["0"] || !seen["1"] {
+		t.Errorf("Expected reboot of DPU0 and DPU1, got: %v", npu.rebooted)
+	}
+}
+
+func TestDPUProxy_FanOut_SetPackage(t *testing.T) {
+	conn, dpus, _ := startFanOutTestServers(t)
+	client := system.NewSystemClient(conn)
+
+	stream, err := client.SetPackage(fanOutContext("0,1"))
+	if err != nil {
+		t.Fatalf("SetPackage failed: %v", err)
+	}
+	pkg := &system.SetPackageRequest{
+		Request: &system.SetPackageRequest_Package{
+			Package: &system.Package{Filename: "/tmp/sonic.bin", Version: "1.0"},
+		},
+	}
+	if err := stream.Send(pkg); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if _, err := stream.CloseAndRecv(); err != nil {
+		t.Fatalf("CloseAndRecv failed: %v", err)
+	}
+
+	for i, dpu := range dpus {
+		if n := atomic.LoadInt32(&dpu.packages); n != 1 {
+			t.Errorf("Expected DPU%d to receive 1 request, got: %d", i, n)
+		}
+	}
+	if results := decodeDPUResults(t, stream.Trailer()); len(results) != 2 {
+		t.Errorf("Expected results for 2 DPUs, got: %v", results)
+	}
+}
+
+func TestDPUProxy_FanOut_NotSupported(t *testing.T) {
+	proxy := NewDPUProxy(nil)
+	interceptor := proxy.UnaryInterceptor()
+
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		t.Error("Handler should not be called")
+		return nil, nil
+	}
+
+	md := metadata.New(map[string]string{
+		MetadataKeyTargetType:  "dpu",
+		MetadataKeyTargetIndex: TargetIndexAll,
+	})
+	ctx := metadata.NewIncomingContext(context.Background(), md)
+
+	_, err := interceptor(ctx, "request", &grpc.UnaryServerInfo{FullMethod: "/gnmi.gNMI/Get"}, handler)
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument, got: %v", err)
+	}
+}
+
+func TestDPUProxy_fanOutTargets(t *testing.T) {
+	proxy := NewDPUProxy(nil)
+
+	indices, err := proxy.fanOutTargets(context.Background(), "0, 2,0,3")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if !reflect.DeepEqual(indices, []string{"0", "2", "3"}) {
+		t.Errorf("Unexpected indices: %v", indices)
+	}
+
+	if _, err := proxy.fanOutTargets(context.Background(), "0,,1"); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for empty index, got: %v", err)
+	}
+
+	// Listing all DPUs requires the resolver
+	if _, err := proxy.fanOutTargets(context.Background(), TargetIndexAll); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected FailedPrecondition without resolver, got: %v", err)
+	}
+}
+
+func TestDPUProxy_fanOut_Limit(t *testing.T) {
+	proxy := NewDPUProxy(nil)
+	proxy.SetFanOutLimit(2)
+
+	var inFlight, maxInFlight int32
+	results := proxy.fanOut(context.Background(), []string{"0", "1", "2", "3", "4"},
+		func(ctx context.Context, dpuIndex string) (interface{}, error) {
+			n := atomic.AddInt32(&inFlight, 1)
+			for {
+				m := atomic.LoadInt32(&maxInFlight)
+				if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&inFlight, -1)
+			return dpuIndex, nil
+		})
+
+	if maxInFlight > 2 {
+		t.Errorf("Expected at most 2 concurrent requests, got: %d", maxInFlight)
+	}
+	for i, r := range results {
+		if r.resp != r.index || r.index != []string{"0", "1", "2", "3", "4"}[i] {
+			t.Errorf("Unexpected result %d: %+v", i, r)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package dpuproxy
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/status"
+)
+
+// Connection health
+//
+// Cached DPU connections are checked on every use: a connection whose gRPC
+// connectivity state is TRANSIENT_FAILURE or SHUTDOWN, or whose DPU moved to
+// another IP address, is closed and a new one is established. Connections are
+// also evicted when the midplane entry of the DPU in STATE_DB reports it is no
+// longer accessible, either on resolution or through keyspace notifications
+// (see WatchMidplane).
+
+// DPUStatus is the proxy's view of a DPU.
+type DPUStatus struct {
+	// Index is the DPU number (e.g., "0")
+	Index string
+
+	// Reachable is the midplane access state of the DPU in STATE_DB
+	Reachable bool
+
+	// Address is the ip:port of the cached connection, empty if there is none
+	Address string
+
+	// ConnectionState is the gRPC connectivity state of the cached connection
+	ConnectionState string
+
+	// LastError is the last connection or transport error seen for the DPU
+	LastError string
+
+	// LastErrorTime is when LastError occurred
+	LastErrorTime time.Time
+
+	// InFlight is the number of RPCs currently forwarded to the DPU
+	InFlight int64
+}
+
+// dpuHealth tracks the health of a DPU as seen by the proxy.
+type dpuHealth struct {
+	reachable     bool
+	lastError     string
+	lastErrorTime time.Time
+	inFlight      int64
+}
+
+// healthLocked returns the health entry of a DPU, creating it if needed.
+// The caller must hold connMu for writing.
+func (p *DPUProxy) healthLocked(dpuIndex string) *dpuHealth {
+	h, ok := p.health[dpuIndex]
+	if !ok {
+		h = &dpuHealth{}
+		p.health[dpuIndex] = h
+	}
+	return h
+}
+
+// setReachable records the midplane access state of a DPU.
+func (p *DPUProxy) setReachable(dpuIndex string, reachable bool) {
+	p.connMu.Lock()
+	defer p.connMu.Unlock()
+	p.healthLocked(dpuIndex).reachable = reachable
+}
+
+// recordError records a connection or transport error for a DPU.
+func (p *DPUProxy) recordError(dpuIndex string, err error) {
+	p.connMu.Lock()
+	defer p.connMu.Unlock()
+	h := p.healthLocked(dpuIndex)
+	h.lastError = err.Error()
+	h.lastErrorTime = time.Now()
+}
+
+// beginRPC counts an RPC in flight to a DPU. The returned function must be
+// called with the outcome of the RPC once it completes.
+func (p *DPUProxy) beginRPC(dpuIndex string) func(err error) {
+	p.connMu.Lock()
+	p.healthLocked(dpuIndex).inFlight++
+	p.connMu.Unlock()
+
+	return func(err error) {
+		p.connMu.Lock()
+		p.healthLocked(dpuIndex).inFlight--
+		p.connMu.Unlock()
+
+		// Errors returned by the DPU itself say nothing about its health
+		if code := status.Code(err); code == codes.Unavailable || code == codes.DeadlineExceeded {
+			p.recordError(dpuIndex, err)
+		}
+	}
+}
+
+// connHealthy checks if the gRPC connectivity state of a connection allows reusing it.
+func connHealthy(conn *grpc.ClientConn) bool {
+	state := conn.GetState()
+	return state != connectivity.TransientFailure && state != connectivity.Shutdown
+}
+
+// evictConnection closes and removes the cached connection to a DPU, if any.
+func (p *DPUProxy) evictConnection(dpuIndex, reason string) {
+	p.connMu.Lock()
+	defer p.connMu.Unlock()
+	p.evictConnectionLocked(dpuIndex, reason)
+}
+
+// evictConnectionLocked is evictConnection with connMu held for writing.
+func (p *DPUProxy) evictConnectionLocked(dpuIndex, reason string) {
+	conn, ok := p.conns[dpuIndex]
+	if !ok {
+		return
+	}
+
+	glog.Infof("[DPUProxy] Evicting connection to DPU%s: %s", dpuIndex, reason)
+	if err := conn.Close(); err != nil {
+		glog.Warningf("[DPUProxy] Error closing connection to DPU%s: %v", dpuIndex, err)
+	}
+	delete(p.conns, dpuIndex)
+	delete(p.connPorts, dpuIndex)
+	delete(p.connAddrs, dpuIndex)
+}
+
+// WatchMidplane subscribes to STATE_DB keyspace notifications of the DPU
+// midplane entries, so that cached connections are evicted as soon as a DPU
+// becomes inaccessible, changes IP address or is removed. The subscription
+// ends on Close.
+func (p *DPUProxy) WatchMidplane(client *redis.Client) {
+	pattern := fmt.Sprintf("__keyspace@%d__:%s*", StateDB, ChassisMidplaneTablePrefix)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pubsub := client.PSubscribe(ctx, pattern)
+
+	p.connMu.Lock()
+	p.stopWatch = func() {
+		cancel()
+		pubsub.Close()
+	}
+	p.connMu.Unlock()
+
+	go p.watchMidplane(ctx, pubsub.Channel())
+}
+
+// watchMidplane re-checks the midplane entry of a DPU on each keyspace notification.
+func (p *DPUProxy) watchMidplane(ctx context.Context, ch <-chan *redis.Message) {
+	for msg := range ch {
+		i := strings.Index(msg.Channel, ChassisMidplaneTablePrefix)
+		if i < 0 {
+			continue
+		}
+		dpuIndex := msg.Channel[i+len(ChassisMidplaneTablePrefix):]
+		glog.V(2).Infof("[DPUProxy] Midplane entry of DPU%s changed: %s", dpuIndex, msg.Payload)
+		p.checkMidplane(ctx, dpuIndex)
+	}
+}
+
+// checkMidplane reads the midplane entry of a DPU from STATE_DB and evicts its
+// cached connection if the DPU was removed, is no longer accessible or moved to
+// another IP address. Other updates, such as a periodic refresh of the entry,
+// keep the connection.
+func (p *DPUProxy) checkMidplane(ctx context.Context, dpuIndex string) {
+	if p.resolver == nil {
+		return
+	}
+
+	fields, err := p.resolver.stateClient.HGetAll(ctx, ChassisMidplaneTablePrefix+dpuIndex)
+	if err != nil {
+		glog.Warningf("[DPUProxy] Failed to read midplane entry of DPU%s: %v", dpuIndex, err)
+		return
+	}
+
+	p.connMu.Lock()
+	defer p.connMu.Unlock()
+
+	reachable := fields["access"] == "True"
+	p.healthLocked(dpuIndex).reachable = reachable
+
+	switch {
+	case len(fields) == 0:
+		p.evictConnectionLocked(dpuIndex, "DPU removed from StateDB")
+	case !reachable:
+		p.evictConnectionLocked(dpuIndex, "DPU not accessible")
+	case p.connAddrs[dpuIndex] != "" && p.connAddrs[dpuIndex] != fields["ip_address"]:
+		p.evictConnectionLocked(dpuIndex, "DPU IP address changed")
+	}
+}
+
+// Close stops watching STATE_DB and closes all cached DPU connections.
+// Requests forwarded after Close fail.
+func (p *DPUProxy) Close() error {
+	p.connMu.Lock()
+	defer p.connMu.Unlock()
+
+	if p.stopWatch != nil {
+		p.stopWatch()
+		p.stopWatch = nil
+	}
+
+	for dpuIndex := range p.conns {
+		p.evictConnectionLocked(dpuIndex, "proxy closed")
+	}
+	p.closed = true
+	return nil
+}
+
+// Status returns the state of the DPUs present in STATE_DB and of any other
+// DPU the proxy has been asked to reach, in index order.
+func (p *DPUProxy) Status(ctx context.Context) []DPUStatus {
+	// Refresh the reachability of the DPUs in STATE_DB
+	if p.resolver != nil {
+		indices, err := p.resolver.ListDPUs(ctx)
+		if err != nil {
+			glog.Warningf("[DPUProxy] Failed to list DPUs: %v", err)
+		}
+		for _, dpuIndex := range indices {
+			if info, err := p.resolver.GetDPUInfo(ctx, dpuIndex); err == nil {
+				p.setReachable(dpuIndex, info.Reachable)
+			} else {
+				p.setReachable(dpuIndex, false)
+			}
+		}
+	}
+
+	p.connMu.RLock()
+	defer p.connMu.RUnlock()
+
+	statuses := make([]DPUStatus, 0, len(p.health))
+	for dpuIndex, h := range p.health {
+		s := DPUStatus{
+			Index:         dpuIndex,
+			Reachable:     h.reachable,
+			LastError:     h.lastError,
+			LastErrorTime: h.lastErrorTime,
+			InFlight:      h.inFlight,
+		}
+		if conn, ok := p.conns[dpuIndex]; ok {
+			s.Address = fmt.Sprintf("%s:%s", p.connAddrs[dpuIndex], p.connPorts[dpuIndex])
+			s.ConnectionState = conn.GetState().String()
+		}
+		statuses = append(statuses, s)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		a, errA := strconv.Atoi(statuses[i].Index)
+		b, errB := strconv.Atoi(statuses[j].Index)
+		if errA != nil || errB != nil {
+			return statuses[i].Index < statuses[j].Index
+		}
+		return a < b
+	})
+	return statuses
+}
+
+// GetProxyStatus returns the status of the DPUs as seen by the registered DPU proxy.
+func GetProxyStatus(ctx context.Context) ([]DPUStatus, error) {
+	if defaultProxy == nil {
+		return nil, fmt.Errorf("DPU proxy not initialized")
+	}
+	return defaultProxy.Status(ctx), nil
+}
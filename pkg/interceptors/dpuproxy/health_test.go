@@ -0,0 +1,270 @@
+package dpuproxy
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// newIdleConn returns a client connection that is never used to send RPCs.
+func newIdleConn(t *testing.T) *grpc.ClientConn {
+	conn, err := grpc.NewClient("127.0.0.1:1", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// newHealthTestProxy returns a proxy with a cached connection to DPU0 at 10.0.0.1.
+func newHealthTestProxy(t *testing.T, midplane map[string]string) (*DPUProxy, *grpc.ClientConn) {
+	stateMock := &mockRedisClient{data: map[string]map[string]string{}}
+	if midplane != nil {
+		stateMock.data["CHASSIS_MIDPLANE_TABLE|DPU0"] = midplane
+	}
+	proxy := NewDPUProxy(NewDPUResolver(stateMock, &mockRedisClient{}))
+
+	conn := newIdleConn(t)
+	proxy.conns["0"] = conn
+	proxy.connPorts["0"] = "8080"
+	proxy.connAddrs["0"] = "10.0.0.1"
+	return proxy, conn
+}
+
+func TestDPUProxy_checkMidplane(t *testing.T) {
+	tests := []struct {
+		name      string
+		midplane  map[string]string
+		evicted   bool
+		reachable bool
+	}{
+		{
+			name:      "entry refreshed",
+			midplane:  map[string]string{"ip_address": "10.0.0.1", "access": "True"},
+			evicted:   false,
+			reachable: true,
+		},
+		{
+			name:      "DPU not accessible",
+			midplane:  map[string]string{"ip_address": "10.0.0.1", "access": "False"},
+			evicted:   true,
+			reachable: false,
+		},
+		{
+			name:      "DPU IP address changed",
+			midplane:  map[string]string{"ip_address": "10.0.0.2", "access": "True"},
+			evicted:   true,
+			reachable: true,
+		},
+		{
+			name:      "DPU removed",
+			midplane:  nil,
+			evicted:   true,
+			reachable: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proxy, conn := newHealthTestProxy(t, tt.midplane)
+
+			proxy.checkMidplane(context.Background(), "0")
+
+			_, cached := proxy.conns["0"]
+			if cached == tt.evicted {
+				t.Errorf("Expected evicted=%v, connection cached=%v", tt.evicted, cached)
+			}
+			if tt.evicted {
+				if conn.GetState() != connectivity.Shutdown {
+					t.Errorf("Expected evicted connection to be closed, got state %v", conn.GetState())
+				}
+				if _, ok := proxy.connAddrs["0"]; ok {
+					t.Error("Expected connection address to be removed")
+				}
+			}
+			if proxy.health["0"].reachable != tt.reachable {
+				t.Errorf("Expected reachable=%v, got %v", tt.reachable, proxy.health["0"].reachable)
+			}
+		})
+	}
+}
+
+func TestDPUProxy_checkMidplane_RedisError(t *testing.T) {
+	proxy, _ := newHealthTestProxy(t, nil)
+	proxy.resolver.stateClient = &mockRedisClient{err: errors.New("redis down")}
+
+	proxy.checkMidplane(context.Background(), "0")
+
+	if _, ok := proxy.conns["0"]; !ok {
+		t.Error("Connection should be kept when STATE_DB cannot be read")
+	}
+}
+
+func TestDPUProxy_watchMidplane(t *testing.T) {
+	proxy, _ := newHealthTestProxy(t, map[string]string{"ip_address": "10.0.0.1", "access": "False"})
+
+	ch := make(chan *redis.Message, 2)
+	ch <- &redis.Message{Channel: "__keyspace@6__:CHASSIS_MIDPLANE_TABLE", Payload: "hset"}
+	ch <- &redis.Message{Channel: "__keyspace@6__:CHASSIS_MIDPLANE_TABLE|DPU0", Payload: "hset"}
+	close(ch)
+
+	proxy.watchMidplane(context.Background(), ch)
+
+	if _, ok := proxy.conns["0"]; ok {
+		t.Error("Expected connection to inaccessible DPU to be evicted")
+	}
+}
+
+func TestDPUProxy_getConnection_EvictsStaleConnection(t *testing.T) {
+	tests := []struct {
+		name  string
+		stale func(proxy *DPUProxy, conn *grpc.ClientConn)
+	}{
+		{
+			name: "address changed",
+			stale: func(proxy *DPUProxy, conn *grpc.ClientConn) {
+				proxy.connAddrs["0"] = "10.0.0.1"
+			},
+		},
+		{
+			name: "connection shut down",
+			stale: func(proxy *DPUProxy, conn *grpc.ClientConn) {
+				conn.Close()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			proxy, _ := startFakeDPU(t)
+
+			conn, err := proxy.GetDPUConnection(ctx, "0")
+			if err != nil {
+				t.Fatalf("GetDPUConnection failed: %v", err)
+			}
+			tt.stale(proxy, conn)
+
+			newConn, err := proxy.GetDPUConnection(ctx, "0")
+			if err != nil {
+				t.Fatalf("GetDPUConnection failed: %v", err)
+			}
+			if newConn == conn {
+				t.Error("Expected stale connection to be replaced")
+			}
+			if conn.GetState() != connectivity.Shutdown {
+				t.Errorf("Expected stale connection to be closed, got state %v", conn.GetState())
+			}
+			if proxy.connAddrs["0"] != "127.0.0.1" {
+				t.Errorf("Expected connection address 127.0.0.1, got %q", proxy.connAddrs["0"])
+			}
+		})
+	}
+}
+
+func TestDPUProxy_beginRPC(t *testing.T) {
+	proxy := NewDPUProxy(nil)
+
+	done1 := proxy.beginRPC("0")
+	done2 := proxy.beginRPC("0")
+	if proxy.health["0"].inFlight != 2 {
+		t.Errorf("Expected 2 RPCs in flight, got %d", proxy.health["0"].inFlight)
+	}
+
+	// Errors from the DPU services are not connection errors
+	done1(status.Error(codes.NotFound, "no such path"))
+	if proxy.health["0"].inFlight != 1 || proxy.health["0"].lastError != "" {
+		t.Errorf("Unexpected health after NotFound: %+v", proxy.health["0"])
+	}
+
+	done2(status.Error(codes.Unavailable, "connection refused"))
+	h := proxy.health["0"]
+	if h.inFlight != 0 {
+		t.Errorf("Expected no RPCs in flight, got %d", h.inFlight)
+	}
+	if !strings.Contains(h.lastError, "connection refused") || h.lastErrorTime.IsZero() {
+		t.Errorf("Expected transport error to be recorded, got %+v", h)
+	}
+}
+
+func TestDPUProxy_Close(t *testing.T) {
+	proxy, conn := newHealthTestProxy(t, map[string]string{"ip_address": "10.0.0.1", "access": "True"})
+	stopped := false
+	proxy.stopWatch = func() { stopped = true }
+
+	if err := proxy.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if !stopped {
+		t.Error("Expected STATE_DB watch to be stopped")
+	}
+	if len(proxy.conns) != 0 {
+		t.Errorf("Expected no cached connections, got %d", len(proxy.conns))
+	}
+	if conn.GetState() != connectivity.Shutdown {
+		t.Errorf("Expected connection to be closed, got state %v", conn.GetState())
+	}
+
+	_, err := proxy.getConnection(context.Background(), "0", "10.0.0.1", []string{"8080"})
+	if err == nil || !strings.Contains(err.Error(), "closed") {
+		t.Errorf("Expected error after Close, got: %v", err)
+	}
+}
+
+func TestDPUProxy_Status(t *testing.T) {
+	stateMock := &mockRedisClient{data: map[string]map[string]string{
+		"CHASSIS_MIDPLANE_TABLE|DPU0":  {"ip_address": "10.0.0.1", "access": "True"},
+		"CHASSIS_MIDPLANE_TABLE|DPU2":  {"ip_address": "10.0.0.3", "access": "False"},
+		"CHASSIS_MIDPLANE_TABLE|DPU10": {"ip_address": "10.0.0.11", "access": "True"},
+	}}
+	proxy := NewDPUProxy(NewDPUResolver(stateMock, &mockRedisClient{}))
+	proxy.conns["0"] = newIdleConn(t)
+	proxy.connPorts["0"] = "8080"
+	proxy.connAddrs["0"] = "10.0.0.1"
+	proxy.beginRPC("0")
+	proxy.recordError("2", errors.New("connection refused"))
+
+	statuses := proxy.Status(context.Background())
+
+	if len(statuses) != 3 {
+		t.Fatalf("Expected 3 DPUs, got %+v", statuses)
+	}
+	for i, index := range []string{"0", "2", "10"} {
+		if statuses[i].Index != index {
+			t.Errorf("Expected DPU%s at position %d, got DPU%s", index, i, statuses[i].Index)
+		}
+	}
+
+	dpu0 := statuses[0]
+	if !dpu0.Reachable || dpu0.Address != "10.0.0.1:8080" || dpu0.InFlight != 1 || dpu0.ConnectionState == "" {
+		t.Errorf("Unexpected DPU0 status: %+v", dpu0)
+	}
+	dpu2 := statuses[1]
+	if dpu2.Reachable || dpu2.Address != "" || dpu2.LastError != "connection refused" || dpu2.LastErrorTime.IsZero() {
+		t.Errorf("Unexpected DPU2 status: %+v", dpu2)
+	}
+}
+
+func TestGetProxyStatus(t *testing.T) {
+	defer SetDefaultProxy(defaultProxy)
+
+	SetDefaultProxy(nil)
+	if _, err := GetProxyStatus(context.Background()); err == nil {
+		t.Error("Expected error when DPU proxy is not initialized")
+	}
+
+	SetDefaultProxy(NewDPUProxy(nil))
+	statuses, err := GetProxyStatus(context.Background())
+	if err != nil || len(statuses) != 0 {
+		t.Errorf("Expected empty status, got %v, %v", statuses, err)
+	}
+}
@@ -2,6 +2,7 @@ package dpuproxy
 
 import (
 	"context"
+	"strings"
 
 	"google.golang.org/grpc/metadata"
 )
@@ -15,6 +16,13 @@ const (
 
 	// TargetTypeDPU indicates the request should be routed to a DPU
 	TargetTypeDPU = "dpu"
+
+	// TargetIndexAll addresses every DPU present in STATE_DB
+	TargetIndexAll = "all"
+
+	// MetadataKeyFanOutResults is the trailer key carrying the per-DPU results
+	// of a request sent to multiple DPUs, as a JSON array of DPUResult
+	MetadataKeyFanOutResults = "x-sonic-ss-fanout-results-bin"
 )
 
 // TargetMetadata contains the extracted routing metadata from a gRPC request
@@ -65,3 +73,40 @@ func ExtractTargetMetadata(ctx context.Context) TargetMetadata {
 func (tm TargetMetadata) IsDPUTarget() bool {
 	return tm.HasMetadata && tm.TargetType == TargetTypeDPU
 }
+
+// IsFanOut returns true if the metadata addresses more than one DPU, either
+// with the "all" target index or a comma separated list of indices (e.g., "0,2,3")
+func (tm TargetMetadata) IsFanOut() bool {
+	return tm.IsDPUTarget() && (tm.TargetIndex == TargetIndexAll || strings.Contains(tm.TargetIndex, ","))
+}
+
+// withTargetIndex returns a copy of the incoming context addressing the single
+// DPU dpuIndex, for handling one leg of a fanned out request locally.
+func withTargetIndex(ctx context.Context, dpuIndex string) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	md = md.Copy()
+	md.Set(MetadataKeyTargetIndex, dpuIndex)
+	return metadata.NewIncomingContext(ctx, md)
+}
+
+// credentialMetadataKeys are the incoming metadata keys carrying the caller's
+// credentials, as read by the password and JWT authentication.
+var credentialMetadataKeys = []string{"username", "password", "access_token"}
+
+// forwardingContext returns the context used to call the DPU on behalf of an
+// incoming request. Only the caller's credentials are forwarded, and only over
+// secure connections, so that the DPU can authenticate the caller too; routing
+// headers and any other metadata stay on the NPU. Callers authenticated by a
+// client certificate are seen by the DPU as the NPU, which presents its own.
+func forwardingContext(ctx context.Context, secure bool) context.Context {
+	md, ok := metadata.FromIncomingContext(ctx)
+	out := metadata.MD{}
+	if ok && secure {
+		for _, k := range credentialMetadataKeys {
+			if v := md.Get(k); len(v) > 0 {
+				out[k] = append([]string(nil), v...)
+			}
+		}
+	}
+	return metadata.NewOutgoingContext(ctx, out)
+}
@@ -2,19 +2,24 @@ package dpuproxy
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"io"
 	"sync"
 	"time"
 
 	"github.com/golang/glog"
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
 	gnoi_file_pb "github.com/openconfig/gnoi/file"
+	"github.com/openconfig/gnoi/healthz"
 	system "github.com/openconfig/gnoi/system"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/keepalive"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
 // ForwardingMode defines how a method should be handled when DPU headers are present.
@@ -32,8 +37,17 @@ type ForwardableMethod struct {
 	FullMethod  string
 	Description string
 	Mode        ForwardingMode
+	// FanOut allows the method to target several DPUs at once (see fanout.go)
+	FanOut bool
+	// Authorize requires the NPU to authorize the request before forwarding it (see SetAuthorizer)
+	Authorize bool
 }
 
+// Authorizer authenticates the caller of a request to be forwarded to a DPU and
+// checks that the NPU grants it the access the request needs. req is the request
+// of unary methods and the first request of streaming ones.
+type Authorizer func(ctx context.Context, method string, req interface{}) error
+
 // defaultForwardableMethods is the registry of methods that can be processed when DPU headers are present.
 // Methods not in this registry will be rejected with an error when DPU headers are provided.
 var defaultForwardableMethods = []ForwardableMethod{
@@ -41,6 +55,7 @@ var defaultForwardableMethods = []ForwardableMethod{
 		FullMethod:  "/gnoi.system.System/Time",
 		Description: "Get current time from DPU (for testing)",
 		Mode:        ForwardToDPU,
+		FanOut:      true,
 	},
 	{
 		FullMethod:  "/gnoi.file.File/Put",
@@ -56,11 +71,49 @@ var defaultForwardableMethods = []ForwardableMethod{
 		FullMethod:  "/gnoi.system.System/Reboot",
 		Description: "Reboot DPU from NPU host",
 		Mode:        HandleLocally,
+		FanOut:      true,
 	},
 	{
 		FullMethod:  "/gnoi.system.System/SetPackage",
 		Description: "Install package on DPU",
 		Mode:        ForwardToDPU,
+		FanOut:      true,
+	},
+	{
+		FullMethod:  "/gnoi.system.System/RebootStatus",
+		Description: "Get reboot status of DPU",
+		Mode:        ForwardToDPU,
+		FanOut:      true,
+	},
+	{
+		FullMethod:  "/gnoi.healthz.Healthz/Get",
+		Description: "Get component health from DPU",
+		Mode:        ForwardToDPU,
+		FanOut:      true,
+	},
+	{
+		FullMethod:  "/gnmi.gNMI/Capabilities",
+		Description: "Get gNMI capabilities of DPU",
+		Mode:        ForwardToDPU,
+		Authorize:   true,
+	},
+	{
+		FullMethod:  "/gnmi.gNMI/Get",
+		Description: "Get data from DPU",
+		Mode:        ForwardToDPU,
+		Authorize:   true,
+	},
+	{
+		FullMethod:  "/gnmi.gNMI/Set",
+		Description: "Set data on DPU",
+		Mode:        ForwardToDPU,
+		Authorize:   true,
+	},
+	{
+		FullMethod:  "/gnmi.gNMI/Subscribe",
+		Description: "Subscribe to DPU data (STREAM, POLL and ONCE)",
+		Mode:        ForwardToDPU,
+		Authorize:   true,
 	},
 	// gRPC reflection methods needed for grpcurl to work with DPU headers
 	{
@@ -103,24 +156,32 @@ type DPUProxy struct {
 	connMu    sync.RWMutex
 	conns     map[string]*grpc.ClientConn // key: DPU index (e.g., "0", "1")
 	connPorts map[string]string           // key: DPU index, value: successful port
+	connAddrs map[string]string           // key: DPU index, value: IP address connected to
+
+	// Connection health (see health.go), guarded by connMu
+	health    map[string]*dpuHealth // key: DPU index
+	stopWatch func()                // stops the STATE_DB watch, if any
+	closed    bool
 
-	// TODO (Future Phase): Add connection health monitoring
-	// - Periodic health checks using grpc.ConnectivityState
-	// - Recreate connections in TRANSIENT_FAILURE state
-	// - Metrics for connection state transitions
+	// fanOutLimit caps the number of DPUs a fanned out request is sent to concurrently
+	fanOutLimit int
 
-	// TODO (Future Phase): Add graceful shutdown
-	// - Implement Close() method to drain and close all connections
-	// - Register with service lifecycle hooks
+	// authorize authorizes the methods requiring it before they are forwarded
+	authorize Authorizer
+	// tlsConfig secures the DPU connections, which are plaintext if nil
+	tlsConfig *tls.Config
 }
 
 // NewDPUProxy creates a new DPU proxy interceptor with the given resolver.
 // If resolver is nil, Redis operations will be skipped (for testing).
 func NewDPUProxy(resolver *DPUResolver) *DPUProxy {
 	return &DPUProxy{
-		resolver:  resolver,
-		conns:     make(map[string]*grpc.ClientConn),
-		connPorts: make(map[string]string),
+		resolver:    resolver,
+		conns:       make(map[string]*grpc.ClientConn),
+		connPorts:   make(map[string]string),
+		connAddrs:   make(map[string]string),
+		health:      make(map[string]*dpuHealth),
+		fanOutLimit: DefaultFanOutLimit,
 	}
 }
 
@@ -140,6 +201,27 @@ func (p *DPUProxy) GetDPUConnection(ctx context.Context, dpuIndex string) (*grpc
 	return p.getConnection(ctx, dpuInfo.Index, dpuInfo.IPAddress, dpuInfo.GNMIPortsToTry)
 }
 
+// SetAuthorizer sets the authorizer of the forwarded methods requiring
+// authorization, which are rejected while none is set. It must be called
+// before serving.
+func (p *DPUProxy) SetAuthorizer(authorize Authorizer) {
+	p.authorize = authorize
+}
+
+// SetTLSConfig makes new DPU connections use TLS with cfg. The caller's
+// credentials are only forwarded over TLS. It must be called before serving.
+func (p *DPUProxy) SetTLSConfig(cfg *tls.Config) {
+	p.tlsConfig = cfg
+}
+
+// transportCredentials returns the credentials of the DPU connections.
+func (p *DPUProxy) transportCredentials() credentials.TransportCredentials {
+	if p.tlsConfig == nil {
+		return insecure.NewCredentials()
+	}
+	return credentials.NewTLS(p.tlsConfig)
+}
+
 // getForwardingMode checks if a method is registered and returns its forwarding mode.
 // Returns the ForwardingMode and a boolean indicating if the method was found.
 func (p *DPUProxy) getForwardingMode(method string) (ForwardingMode, bool) {
@@ -151,12 +233,136 @@ func (p *DPUProxy) getForwardingMode(method string) (ForwardingMode, bool) {
 	return "", false
 }
 
+// supportsFanOut checks if a registered method may be sent to several DPUs at once.
+func (p *DPUProxy) supportsFanOut(method string) bool {
+	for _, m := range defaultForwardableMethods {
+		if m.FullMethod == method {
+			return m.FanOut
+		}
+	}
+	return false
+}
+
+// requiresAuthorization checks if a registered method must be authorized by the NPU before forwarding.
+func (p *DPUProxy) requiresAuthorization(method string) bool {
+	for _, m := range defaultForwardableMethods {
+		if m.FullMethod == method {
+			return m.Authorize
+		}
+	}
+	return false
+}
+
+// authorizeForward authorizes req on the NPU if method requires it.
+// Errors are returned as gRPC status errors ready to be sent to the client.
+func (p *DPUProxy) authorizeForward(ctx context.Context, method string, req interface{}) error {
+	if !p.requiresAuthorization(method) {
+		return nil
+	}
+	if p.authorize == nil {
+		glog.Warningf("[DPUProxy] No authorizer for %s, rejecting request", method)
+		return status.Errorf(codes.PermissionDenied, "method %s cannot be authorized for DPU routing", method)
+	}
+	if err := p.authorize(ctx, method, req); err != nil {
+		glog.Warningf("[DPUProxy] %s not authorized for DPU routing: %v", method, err)
+		if _, ok := status.FromError(err); ok {
+			return err
+		}
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	return nil
+}
+
+// newStreamRequest returns an empty first request of a streaming method requiring authorization.
+func newStreamRequest(method string) (proto.Message, bool) {
+	switch method {
+	case "/gnmi.gNMI/Subscribe":
+		return &gnmipb.SubscribeRequest{}, true
+	}
+	return nil, false
+}
+
+// authorizedStream is a server stream whose first request was already
+// received, to be authorized, and is returned again by the first RecvMsg.
+type authorizedStream struct {
+	grpc.ServerStream
+	first proto.Message
+}
+
+func (s *authorizedStream) RecvMsg(m interface{}) error {
+	if s.first == nil {
+		return s.ServerStream.RecvMsg(m)
+	}
+	msg, ok := m.(proto.Message)
+	if !ok {
+		return status.Errorf(codes.Internal, "unexpected stream message type %T", m)
+	}
+	proto.Reset(msg)
+	proto.Merge(msg, s.first)
+	s.first = nil
+	return nil
+}
+
+// authorizeStream receives the first request of ss and authorizes it on the
+// NPU if the method requires it. The returned stream replays that request.
+func (p *DPUProxy) authorizeStream(ctx context.Context, ss grpc.ServerStream, method string) (grpc.ServerStream, error) {
+	if !p.requiresAuthorization(method) {
+		return ss, nil
+	}
+	first, ok := newStreamRequest(method)
+	if !ok {
+		return nil, status.Errorf(codes.PermissionDenied, "method %s cannot be authorized for DPU routing", method)
+	}
+	if err := ss.RecvMsg(first); err != nil {
+		return nil, err
+	}
+	if err := p.authorizeForward(ctx, method, first); err != nil {
+		return nil, err
+	}
+	return &authorizedStream{ServerStream: ss, first: first}, nil
+}
+
+// dpuConnection resolves the DPU from Redis and returns a connection to it.
+// Errors are returned as gRPC status errors ready to be sent to the client.
+func (p *DPUProxy) dpuConnection(ctx context.Context, dpuIndex string) (*grpc.ClientConn, error) {
+	dpuInfo, err := p.resolver.GetDPUInfo(ctx, dpuIndex)
+	if err != nil {
+		glog.Warningf("[DPUProxy] Error resolving DPU%s: %v, returning error", dpuIndex, err)
+		return nil, status.Errorf(codes.NotFound, "DPU%s not found or unreachable: %v", dpuIndex, err)
+	}
+
+	glog.Infof("[DPUProxy] Resolved DPU%s: ip=%s, reachable=%t",
+		dpuInfo.Index, dpuInfo.IPAddress, dpuInfo.Reachable)
+	p.setReachable(dpuInfo.Index, dpuInfo.Reachable)
+
+	if !dpuInfo.Reachable {
+		glog.Warningf("[DPUProxy] DPU%s is unreachable, returning error", dpuInfo.Index)
+		p.evictConnection(dpuInfo.Index, "DPU not accessible")
+		return nil, status.Errorf(codes.Unavailable, "DPU%s is not currently reachable", dpuInfo.Index)
+	}
+
+	// Get or create connection to DPU
+	conn, err := p.getConnection(ctx, dpuInfo.Index, dpuInfo.IPAddress, dpuInfo.GNMIPortsToTry)
+	if err != nil {
+		glog.Errorf("[DPUProxy] Failed to get connection to DPU%s: %v", dpuInfo.Index, err)
+		p.recordError(dpuInfo.Index, err)
+		return nil, status.Errorf(codes.Internal, "failed to connect to DPU%s: %v", dpuInfo.Index, err)
+	}
+
+	p.connMu.RLock()
+	actualPort := p.connPorts[dpuInfo.Index]
+	p.connMu.RUnlock()
+	glog.Infof("[DPUProxy] Using DPU%s at %s:%s", dpuInfo.Index, dpuInfo.IPAddress, actualPort)
+
+	return conn, nil
+}
+
 // getConnection retrieves or creates a gRPC connection to the specified DPU.
 // Connections are cached and reused. Uses keepalive settings for long-lived connections.
 func (p *DPUProxy) getConnection(ctx context.Context, dpuIndex, ipAddress string, portsToTry []string) (*grpc.ClientConn, error) {
-	// Check if we already have a connection
+	// Check if we already have a healthy connection to the current DPU address
 	p.connMu.RLock()
-	if conn, ok := p.conns[dpuIndex]; ok {
+	if conn, ok := p.conns[dpuIndex]; ok && p.connAddrs[dpuIndex] == ipAddress && connHealthy(conn) {
 		p.connMu.RUnlock()
 		return conn, nil
 	}
@@ -166,9 +372,19 @@ func (p *DPUProxy) getConnection(ctx context.Context, dpuIndex, ipAddress string
 	p.connMu.Lock()
 	defer p.connMu.Unlock()
 
+	if p.closed {
+		return nil, fmt.Errorf("DPU proxy closed")
+	}
+
 	// Double-check after acquiring write lock (another goroutine might have created it)
 	if conn, ok := p.conns[dpuIndex]; ok {
-		return conn, nil
+		if connAddr := p.connAddrs[dpuIndex]; connAddr != ipAddress {
+			p.evictConnectionLocked(dpuIndex, fmt.Sprintf("DPU address changed from %s to %s", connAddr, ipAddress))
+		} else if !connHealthy(conn) {
+			p.evictConnectionLocked(dpuIndex, fmt.Sprintf("connection in %s state", conn.GetState()))
+		} else {
+			return conn, nil
+		}
 	}
 
 	// Try multiple ports to find the working gNMI service
@@ -180,7 +396,7 @@ func (p *DPUProxy) getConnection(ctx context.Context, dpuIndex, ipAddress string
 		// Create connection with keepalive settings for long-lived connections
 		conn, err := grpc.NewClient(
 			target,
-			grpc.WithTransportCredentials(insecure.NewCredentials()),
+			grpc.WithTransportCredentials(p.transportCredentials()),
 			grpc.WithKeepaliveParams(keepalive.ClientParameters{
 				Time:                10 * time.Second, // Send keepalive ping every 10s
 				Timeout:             3 * time.Second,  // Wait 3s for ping ack before considering connection dead
@@ -209,6 +425,7 @@ func (p *DPUProxy) getConnection(ctx context.Context, dpuIndex, ipAddress string
 			// Cache the successful connection and port for reuse
 			p.conns[dpuIndex] = conn
 			p.connPorts[dpuIndex] = port
+			p.connAddrs[dpuIndex] = ipAddress
 			glog.Infof("[DPUProxy] Successfully verified connectivity to DPU%s at %s", dpuIndex, target)
 			return conn, nil
 		}
@@ -246,6 +463,87 @@ func (p *DPUProxy) forwardTimeRequest(ctx context.Context, conn *grpc.ClientConn
 	return resp, nil
 }
 
+// forwardUnary forwards a unary request to the DPU based on method.
+func (p *DPUProxy) forwardUnary(ctx context.Context, conn *grpc.ClientConn, method string, req interface{}) (interface{}, error) {
+	switch method {
+	case "/gnoi.system.System/Time":
+		return p.forwardTimeRequest(ctx, conn, req)
+	case "/gnoi.system.System/RebootStatus":
+		return p.forwardRebootStatusRequest(ctx, conn, req)
+	case "/gnoi.healthz.Healthz/Get":
+		return p.forwardHealthzGetRequest(ctx, conn, req)
+	case "/gnmi.gNMI/Capabilities", "/gnmi.gNMI/Get", "/gnmi.gNMI/Set":
+		return p.forwardGNMIRequest(ctx, conn, method, req)
+	default:
+		// This shouldn't happen due to getForwardingMode check, but handle gracefully
+		glog.Errorf("[DPUProxy] Unknown forwardable method: %s", method)
+		return nil, status.Errorf(codes.Unimplemented,
+			"forwarding for method %s not yet implemented", method)
+	}
+}
+
+// forwardRebootStatusRequest forwards a gNOI System.RebootStatus request to the DPU.
+func (p *DPUProxy) forwardRebootStatusRequest(ctx context.Context, conn *grpc.ClientConn, req interface{}) (interface{}, error) {
+	statusReq, ok := req.(*system.RebootStatusRequest)
+	if !ok {
+		glog.Errorf("[DPUProxy] Invalid request type for RebootStatus method: %T", req)
+		return nil, status.Errorf(codes.Internal,
+			"invalid request type for RebootStatus method: expected *system.RebootStatusRequest, got %T", req)
+	}
+
+	resp, err := system.NewSystemClient(conn).RebootStatus(ctx, statusReq)
+	if err != nil {
+		glog.Errorf("[DPUProxy] Error forwarding RebootStatus request to DPU: %v", err)
+		return nil, err
+	}
+	return resp, nil
+}
+
+// forwardHealthzGetRequest forwards a gNOI Healthz.Get request to the DPU.
+func (p *DPUProxy) forwardHealthzGetRequest(ctx context.Context, conn *grpc.ClientConn, req interface{}) (interface{}, error) {
+	getReq, ok := req.(*healthz.GetRequest)
+	if !ok {
+		glog.Errorf("[DPUProxy] Invalid request type for Healthz.Get method: %T", req)
+		return nil, status.Errorf(codes.Internal,
+			"invalid request type for Healthz.Get method: expected *healthz.GetRequest, got %T", req)
+	}
+
+	resp, err := healthz.NewHealthzClient(conn).Get(ctx, getReq)
+	if err != nil {
+		glog.Errorf("[DPUProxy] Error forwarding Healthz.Get request to DPU: %v", err)
+		return nil, err
+	}
+	return resp, nil
+}
+
+// forwardGNMIRequest forwards a unary gNMI request (Capabilities, Get or Set) to the DPU.
+func (p *DPUProxy) forwardGNMIRequest(ctx context.Context, conn *grpc.ClientConn, method string, req interface{}) (interface{}, error) {
+	client := gnmipb.NewGNMIClient(conn)
+
+	var resp interface{}
+	var err error
+	switch r := req.(type) {
+	case *gnmipb.CapabilityRequest:
+		resp, err = client.Capabilities(ctx, r)
+	case *gnmipb.GetRequest:
+		resp, err = client.Get(ctx, r)
+	case *gnmipb.SetRequest:
+		resp, err = client.Set(ctx, r)
+	default:
+		glog.Errorf("[DPUProxy] Invalid request type for %s: %T", method, req)
+		return nil, status.Errorf(codes.Internal, "invalid request type for %s: %T", method, req)
+	}
+
+	if err != nil {
+		// Return the DPU status as is so that clients see the DPU's error code
+		glog.Errorf("[DPUProxy] Error forwarding %s to DPU: %v", method, err)
+		return nil, err
+	}
+
+	glog.V(2).Infof("[DPUProxy] Successfully forwarded %s to DPU", method)
+	return resp, nil
+}
+
 // forwardStream forwards a streaming RPC to the DPU.
 // This implements bidirectional streaming proxy between client and DPU.
 func (p *DPUProxy) forwardStream(ctx context.Context, conn *grpc.ClientConn, ss grpc.ServerStream, info *grpc.StreamServerInfo) error {
@@ -259,6 +557,11 @@ func (p *DPUProxy) forwardStream(ctx context.Context, conn *grpc.ClientConn, ss
 		return p.forwardSetPackageStream(ctx, conn, ss)
 	}
 
+	// For gNMI.Subscribe, relay requests and responses in both directions
+	if info.FullMethod == "/gnmi.gNMI/Subscribe" {
+		return p.forwardSubscribeStream(ctx, conn, ss)
+	}
+
 	// Add other stream methods here as needed
 	return status.Errorf(codes.Unimplemented, "stream forwarding for method %s not implemented", info.FullMethod)
 }
@@ -401,6 +704,72 @@ func (p *DPUProxy) forwardSetPackageStream(ctx context.Context, conn *grpc.Clien
 	return nil
 }
 
+// forwardSubscribeStream forwards a gNMI.Subscribe bidirectional stream to the DPU.
+// Subscription and poll requests are relayed to the DPU as they arrive, and
+// DPU responses are relayed back until the DPU ends the stream.
+func (p *DPUProxy) forwardSubscribeStream(ctx context.Context, conn *grpc.ClientConn, ss grpc.ServerStream) error {
+	// Cancelling the context tears down the DPU stream when either side fails
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Create gNMI client for DPU
+	gnmiClient := gnmipb.NewGNMIClient(conn)
+
+	// Create a bidirectional stream to the DPU
+	clientStream, err := gnmiClient.Subscribe(ctx)
+	if err != nil {
+		glog.Errorf("[DPUProxy] Failed to create Subscribe client stream to DPU: %v", err)
+		return status.Errorf(codes.Internal, "failed to create Subscribe client stream to DPU: %v", err)
+	}
+
+	// Goroutine to forward requests from client to DPU
+	go func() {
+		for {
+			// Receive request from client
+			var req gnmipb.SubscribeRequest
+			if err := ss.RecvMsg(&req); err != nil {
+				if err == io.EOF {
+					// Half close; the DPU keeps streaming responses
+					glog.V(2).Infof("[DPUProxy] Subscribe client finished sending requests")
+					if err := clientStream.CloseSend(); err != nil {
+						glog.Warningf("[DPUProxy] Error closing send on Subscribe client stream: %v", err)
+					}
+				} else {
+					glog.V(2).Infof("[DPUProxy] Error receiving Subscribe from client: %v", err)
+					cancel()
+				}
+				return
+			}
+
+			// Forward request to DPU. On failure the DPU stream is broken
+			// and the error is returned by Recv below.
+			if err := clientStream.Send(&req); err != nil {
+				glog.Errorf("[DPUProxy] Error sending Subscribe to DPU: %v", err)
+				return
+			}
+		}
+	}()
+
+	// Forward responses from DPU to client
+	for {
+		resp, err := clientStream.Recv()
+		if err == io.EOF {
+			glog.Infof("[DPUProxy] Successfully forwarded gNMI.Subscribe stream to DPU")
+			return nil
+		}
+		if err != nil {
+			// Return the DPU status as is so that clients see the DPU's error code
+			glog.V(2).Infof("[DPUProxy] Subscribe stream from DPU ended: %v", err)
+			return err
+		}
+
+		if err := ss.SendMsg(resp); err != nil {
+			glog.Errorf("[DPUProxy] Error sending Subscribe response to client: %v", err)
+			return err
+		}
+	}
+}
+
 // UnaryInterceptor returns a gRPC unary server interceptor for DPU routing.
 // It intercepts unary RPC calls and checks for routing metadata.
 func (p *DPUProxy) UnaryInterceptor() grpc.UnaryServerInterceptor {
@@ -423,6 +792,11 @@ func (p *DPUProxy) UnaryInterceptor() grpc.UnaryServerInterceptor {
 			glog.Infof("[DPUProxy] DPU routing requested: method=%s, mode=%s, target-type=%s, target-index=%s",
 				info.FullMethod, mode, targetMeta.TargetType, targetMeta.TargetIndex)
 
+			// Send to several DPUs and aggregate the results
+			if targetMeta.IsFanOut() {
+				return p.fanOutUnary(ctx, req, info, handler, mode, targetMeta.TargetIndex)
+			}
+
 			// Handle based on forwarding mode
 			switch mode {
 			case HandleLocally:
@@ -436,48 +810,25 @@ func (p *DPUProxy) UnaryInterceptor() grpc.UnaryServerInterceptor {
 				// Forward to DPU - existing logic
 				glog.Infof("[DPUProxy] ForwardToDPU mode: routing %s to DPU", info.FullMethod)
 
+				// The NPU authorizes the request before it leaves the NPU
+				if err := p.authorizeForward(ctx, info.FullMethod, req); err != nil {
+					return nil, err
+				}
+
 				// Resolve DPU information from Redis (if resolver is available)
 				if p.resolver != nil {
-					dpuInfo, err := p.resolver.GetDPUInfo(ctx, targetMeta.TargetIndex)
+					conn, err := p.dpuConnection(ctx, targetMeta.TargetIndex)
 					if err != nil {
-						glog.Warningf("[DPUProxy] Error resolving DPU%s: %v, returning error",
-							targetMeta.TargetIndex, err)
-						return nil, status.Errorf(codes.NotFound,
-							"DPU%s not found or unreachable: %v", targetMeta.TargetIndex, err)
+						return nil, err
 					}
 
-					glog.Infof("[DPUProxy] Resolved DPU%s: ip=%s, reachable=%t",
-						dpuInfo.Index, dpuInfo.IPAddress, dpuInfo.Reachable)
+					glog.Infof("[DPUProxy] Forwarding %s to DPU%s", info.FullMethod, targetMeta.TargetIndex)
+					done := p.beginRPC(targetMeta.TargetIndex)
 
-					if !dpuInfo.Reachable {
-						glog.Warningf("[DPUProxy] DPU%s is unreachable, returning error", dpuInfo.Index)
-						return nil, status.Errorf(codes.Unavailable,
-							"DPU%s is not currently reachable", dpuInfo.Index)
-					}
-
-					// Get or create connection to DPU
-					conn, err := p.getConnection(ctx, dpuInfo.Index, dpuInfo.IPAddress, dpuInfo.GNMIPortsToTry)
-					if err != nil {
-						glog.Errorf("[DPUProxy] Failed to get connection to DPU%s: %v", dpuInfo.Index, err)
-						return nil, status.Errorf(codes.Internal,
-							"failed to connect to DPU%s: %v", dpuInfo.Index, err)
-					}
-
-					// Forward the request to DPU based on method
-					actualPort := p.connPorts[dpuInfo.Index]
-					glog.Infof("[DPUProxy] Forwarding %s to DPU%s at %s:%s",
-						info.FullMethod, dpuInfo.Index, dpuInfo.IPAddress, actualPort)
-
-					// Handle method-specific forwarding
-					switch info.FullMethod {
-					case "/gnoi.system.System/Time":
-						return p.forwardTimeRequest(ctx, conn, req)
-					default:
-						// This shouldn't happen due to getForwardingMode check, but handle gracefully
-						glog.Errorf("[DPUProxy] Unknown forwardable method: %s", info.FullMethod)
-						return nil, status.Errorf(codes.Unimplemented,
-							"forwarding for method %s not yet implemented", info.FullMethod)
-					}
+					// Carry the caller's credentials over to the DPU, if the connection is secure
+					resp, err := p.forwardUnary(forwardingContext(ctx, p.tlsConfig != nil), conn, info.FullMethod, req)
+					done(err)
+					return resp, err
 				}
 
 			default:
@@ -515,6 +866,11 @@ func (p *DPUProxy) StreamInterceptor() grpc.StreamServerInterceptor {
 			glog.Infof("[DPUProxy] DPU streaming requested: method=%s, mode=%s, target-type=%s, target-index=%s",
 				info.FullMethod, mode, targetMeta.TargetType, targetMeta.TargetIndex)
 
+			// Send to several DPUs and aggregate the results
+			if targetMeta.IsFanOut() {
+				return p.fanOutStream(ctx, ss, info, targetMeta.TargetIndex)
+			}
+
 			// Handle based on forwarding mode
 			switch mode {
 			case HandleLocally:
@@ -528,39 +884,26 @@ func (p *DPUProxy) StreamInterceptor() grpc.StreamServerInterceptor {
 				// Forward to DPU - existing logic
 				glog.Infof("[DPUProxy] ForwardToDPU mode: routing stream %s to DPU", info.FullMethod)
 
+				// The NPU authorizes the first request before the stream leaves the NPU
+				var err error
+				if ss, err = p.authorizeStream(ctx, ss, info.FullMethod); err != nil {
+					return err
+				}
+
 				// Resolve DPU information from Redis (if resolver is available)
 				if p.resolver != nil {
-					dpuInfo, err := p.resolver.GetDPUInfo(ctx, targetMeta.TargetIndex)
-					if err != nil {
-						glog.Warningf("[DPUProxy] Error resolving DPU%s: %v, returning error",
-							targetMeta.TargetIndex, err)
-						return status.Errorf(codes.NotFound,
-							"DPU%s not found or unreachable: %v", targetMeta.TargetIndex, err)
-					}
-
-					glog.Infof("[DPUProxy] Resolved DPU%s: ip=%s, reachable=%t",
-						dpuInfo.Index, dpuInfo.IPAddress, dpuInfo.Reachable)
-
-					if !dpuInfo.Reachable {
-						glog.Warningf("[DPUProxy] DPU%s is unreachable, returning error", dpuInfo.Index)
-						return status.Errorf(codes.Unavailable,
-							"DPU%s is not currently reachable", dpuInfo.Index)
-					}
-
-					// Get or create connection to DPU
-					conn, err := p.getConnection(ctx, dpuInfo.Index, dpuInfo.IPAddress, dpuInfo.GNMIPortsToTry)
+					conn, err := p.dpuConnection(ctx, targetMeta.TargetIndex)
 					if err != nil {
-						glog.Errorf("[DPUProxy] Failed to get connection to DPU%s: %v", dpuInfo.Index, err)
-						return status.Errorf(codes.Internal,
-							"failed to connect to DPU%s: %v", dpuInfo.Index, err)
+						return err
 					}
 
-					// Forward the stream to DPU
-					actualPort := p.connPorts[dpuInfo.Index]
-					glog.Infof("[DPUProxy] Forwarding stream %s to DPU%s at %s:%s",
-						info.FullMethod, dpuInfo.Index, dpuInfo.IPAddress, actualPort)
+					glog.Infof("[DPUProxy] Forwarding stream %s to DPU%s", info.FullMethod, targetMeta.TargetIndex)
+					done := p.beginRPC(targetMeta.TargetIndex)
 
-					return p.forwardStream(ctx, conn, ss, info)
+					// Carry the caller's credentials over to the DPU, if the connection is secure
+					err = p.forwardStream(forwardingContext(ctx, p.tlsConfig != nil), conn, ss, info)
+					done(err)
+					return err
 				}
 
 			default:
@@ -0,0 +1,378 @@
+package dpuproxy
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"testing"
+
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+	testcert "github.com/sonic-net/sonic-gnmi/testdata/tls"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// fakeDPUServer is an in-process gNMI server standing in for a DPU.
+// It records the metadata of the last request it served.
+type fakeDPUServer struct {
+	gnmipb.UnimplementedGNMIServer
+
+	mu sync.Mutex
+	md metadata.MD
+}
+
+func (s *fakeDPUServer) saveMetadata(ctx context.Context) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	s.mu.Lock()
+	s.md = md
+	s.mu.Unlock()
+}
+
+func (s *fakeDPUServer) lastMetadata() metadata.MD {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.md
+}
+
+func (s *fakeDPUServer) Capabilities(ctx context.Context, req *gnmipb.CapabilityRequest) (*gnmipb.CapabilityResponse, error) {
+	s.saveMetadata(ctx)
+	return &gnmipb.CapabilityResponse{GNMIVersion: "dpu"}, nil
+}
+
+func (s *fakeDPUServer) Get(ctx context.Context, req *gnmipb.GetRequest) (*gnmipb.GetResponse, error) {
+	s.saveMetadata(ctx)
+	if req.GetPrefix().GetTarget() == "MISSING_DB" {
+		return nil, status.Error(codes.NotFound, "no such target")
+	}
+	return &gnmipb.GetResponse{
+		Notification: []*gnmipb.Notification{{Prefix: req.GetPrefix(), Timestamp: 1}},
+	}, nil
+}
+
+func (s *fakeDPUServer) Set(ctx context.Context, req *gnmipb.SetRequest) (*gnmipb.SetResponse, error) {
+	s.saveMetadata(ctx)
+	return &gnmipb.SetResponse{Prefix: req.GetPrefix()}, nil
+}
+
+// Subscribe answers the subscription with one update and a sync response,
+// then one update per poll request until the client closes its side.
+func (s *fakeDPUServer) Subscribe(stream gnmipb.GNMI_SubscribeServer) error {
+	s.saveMetadata(stream.Context())
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		update := &gnmipb.SubscribeResponse{
+			Response: &gnmipb.SubscribeResponse_Update{Update: &gnmipb.Notification{Timestamp: 1}},
+		}
+		if err := stream.Send(update); err != nil {
+			return err
+		}
+		if req.GetSubscribe() != nil {
+			sync := &gnmipb.SubscribeResponse{
+				Response: &gnmipb.SubscribeResponse_SyncResponse{SyncResponse: true},
+			}
+			if err := stream.Send(sync); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// npuServer is the NPU side gNMI server; it must never be reached for DPU requests.
+type npuServer struct {
+	gnmipb.UnimplementedGNMIServer
+}
+
+func (s *npuServer) Get(ctx context.Context, req *gnmipb.GetRequest) (*gnmipb.GetResponse, error) {
+	return nil, status.Error(codes.FailedPrecondition, "served by NPU")
+}
+
+// startFakeDPU starts a fake DPU0 and returns a DPU proxy resolving DPU0 to it.
+func startFakeDPU(t *testing.T, opts ...grpc.ServerOption) (*DPUProxy, *fakeDPUServer) {
+	dpuLis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	dpu := &fakeDPUServer{}
+	dpuSrv := grpc.NewServer(opts...)
+	gnmipb.RegisterGNMIServer(dpuSrv, dpu)
+	go dpuSrv.Serve(dpuLis)
+	t.Cleanup(dpuSrv.Stop)
+
+	_, port, _ := net.SplitHostPort(dpuLis.Addr().String())
+	stateMock := &mockRedisClient{
+		data: map[string]map[string]string{
+			"CHASSIS_MIDPLANE_TABLE|DPU0": {
+				"ip_address": "127.0.0.1",
+				"access":     "True",
+			},
+		},
+	}
+	configMock := &mockRedisClient{
+		data: map[string]map[string]string{
+			"DPU|dpu0": {
+				"gnmi_port": port,
+			},
+		},
+	}
+	return NewDPUProxy(NewDPUResolver(stateMock, configMock)), dpu
+}
+
+// allowAll authorizes every forwarded request.
+func allowAll(ctx context.Context, method string, req interface{}) error {
+	return nil
+}
+
+// startProxyTestServers starts a fake DPU0 and an NPU server with the DPU proxy
+// installed, and returns a gNMI client connected to the NPU.
+func startProxyTestServers(t *testing.T) (gnmipb.GNMIClient, *fakeDPUServer) {
+	proxy, dpu := startFakeDPU(t)
+	proxy.SetAuthorizer(allowAll)
+	return startNPU(t, proxy), dpu
+}
+
+// startNPU starts an NPU server with proxy installed and returns a gNMI client connected to it.
+func startNPU(t *testing.T, proxy *DPUProxy) gnmipb.GNMIClient {
+	npuLis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	npuSrv := grpc.NewServer(
+		grpc.UnaryInterceptor(proxy.UnaryInterceptor()),
+		grpc.StreamInterceptor(proxy.StreamInterceptor()),
+	)
+	gnmipb.RegisterGNMIServer(npuSrv, &npuServer{})
+	go npuSrv.Serve(npuLis)
+	t.Cleanup(npuSrv.Stop)
+
+	conn, err := grpc.NewClient(npuLis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return gnmipb.NewGNMIClient(conn)
+}
+
+func dpuContext(index string) context.Context {
+	return metadata.NewOutgoingContext(context.Background(), metadata.New(map[string]string{
+		MetadataKeyTargetType:  "dpu",
+		MetadataKeyTargetIndex: index,
+		"username":             "admin",
+		"password":             "secret",
+	}))
+}
+
+func TestDPUProxy_ForwardGNMI_Unary(t *testing.T) {
+	client, dpu := startProxyTestServers(t)
+	ctx := dpuContext("0")
+
+	capResp, err := client.Capabilities(ctx, &gnmipb.CapabilityRequest{})
+	if err != nil {
+		t.Fatalf("Capabilities failed: %v", err)
+	}
+	if capResp.GNMIVersion != "dpu" {
+		t.Errorf("Expected capabilities from DPU, got: %v", capResp)
+	}
+
+	getResp, err := client.Get(ctx, &gnmipb.GetRequest{Prefix: &gnmipb.Path{Target: "APPL_DB"}})
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if len(getResp.Notification) != 1 || getResp.Notification[0].Prefix.Target != "APPL_DB" {
+		t.Errorf("Unexpected Get response: %v", getResp)
+	}
+
+	setResp, err := client.Set(ctx, &gnmipb.SetRequest{Prefix: &gnmipb.Path{Target: "APPL_DB"}})
+	if err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if setResp.Prefix.Target != "APPL_DB" {
+		t.Errorf("Unexpected Set response: %v", setResp)
+	}
+
+	// Credentials are not sent in plaintext, routing headers are not forwarded
+	md := dpu.lastMetadata()
+	if len(md.Get("username")) != 0 || len(md.Get("password")) != 0 {
+		t.Errorf("Credentials should not be forwarded without TLS, got: %v", md)
+	}
+	if len(md.Get(MetadataKeyTargetType)) != 0 || len(md.Get(MetadataKeyTargetIndex)) != 0 {
+		t.Errorf("Routing headers should not be forwarded, got: %v", md)
+	}
+
+	// DPU errors are returned as is
+	_, err = client.Get(ctx, &gnmipb.GetRequest{Prefix: &gnmipb.Path{Target: "MISSING_DB"}})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound from DPU, got: %v", err)
+	}
+
+	// Requests without routing headers are served by the NPU
+	_, err = client.Get(context.Background(), &gnmipb.GetRequest{})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected request to be served by NPU, got: %v", err)
+	}
+}
+
+func TestDPUProxy_ForwardGNMI_UnknownDPU(t *testing.T) {
+	client, _ := startProxyTestServers(t)
+
+	_, err := client.Get(dpuContext("5"), &gnmipb.GetRequest{})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound for unknown DPU, got: %v", err)
+	}
+}
+
+func TestDPUProxy_ForwardGNMI_Subscribe(t *testing.T) {
+	client, _ := startProxyTestServers(t)
+
+	stream, err := client.Subscribe(dpuContext("0"))
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+
+	subReq := &gnmipb.SubscribeRequest{
+		Request: &gnmipb.SubscribeRequest_Subscribe{
+			Subscribe: &gnmipb.SubscriptionList{
+				Prefix: &gnmipb.Path{Target: "COUNTERS_DB"},
+				Mode:   gnmipb.SubscriptionList_POLL,
+			},
+		},
+	}
+	if err := stream.Send(subReq); err != nil {
+		t.Fatalf("Send subscription failed: %v", err)
+	}
+
+	recv := func() *gnmipb.SubscribeResponse {
+		resp, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Recv failed: %v", err)
+		}
+		return resp
+	}
+
+	if recv().GetUpdate() == nil {
+		t.Error("Expected update for subscription")
+	}
+	if !recv().GetSyncResponse() {
+		t.Error("Expected sync response")
+	}
+
+	// Poll requests are relayed to the DPU
+	pollReq := &gnmipb.SubscribeRequest{Request: &gnmipb.SubscribeRequest_Poll{Poll: &gnmipb.Poll{}}}
+	if err := stream.Send(pollReq); err != nil {
+		t.Fatalf("Send poll failed: %v", err)
+	}
+	if recv().GetUpdate() == nil {
+		t.Error("Expected update for poll")
+	}
+
+	// Closing the client side ends the stream
+	if err := stream.CloseSend(); err != nil {
+		t.Fatalf("CloseSend failed: %v", err)
+	}
+	if _, err := stream.Recv(); err != io.EOF {
+		t.Errorf("Expected EOF after close, got: %v", err)
+	}
+}
+
+func TestDPUProxy_ForwardGNMI_TLS(t *testing.T) {
+	cert, err := testcert.NewCert()
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	proxy, dpu := startFakeDPU(t, grpc.Creds(credentials.NewTLS(&tls.Config{Certificates: []tls.Certificate{cert}})))
+	proxy.SetAuthorizer(allowAll)
+	proxy.SetTLSConfig(&tls.Config{InsecureSkipVerify: true})
+	client := startNPU(t, proxy)
+
+	ctx := metadata.AppendToOutgoingContext(dpuContext("0"), "x-custom", "value")
+	if _, err := client.Get(ctx, &gnmipb.GetRequest{Prefix: &gnmipb.Path{Target: "APPL_DB"}}); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+
+	// Only the credentials are forwarded over TLS
+	md := dpu.lastMetadata()
+	if v := md.Get("username"); len(v) != 1 || v[0] != "admin" {
+		t.Errorf("Expected username to be forwarded, got: %v", md)
+	}
+	if v := md.Get("password"); len(v) != 1 || v[0] != "secret" {
+		t.Errorf("Expected password to be forwarded, got: %v", md)
+	}
+	if len(md.Get("x-custom")) != 0 || len(md.Get(MetadataKeyTargetType)) != 0 {
+		t.Errorf("Only credentials should be forwarded, got: %v", md)
+	}
+}
+
+func TestDPUProxy_ForwardGNMI_Authorization(t *testing.T) {
+	proxy, dpu := startFakeDPU(t)
+	client := startNPU(t, proxy)
+	ctx := dpuContext("0")
+
+	// Without an authorizer, gNMI requests are never forwarded
+	_, err := client.Get(ctx, &gnmipb.GetRequest{})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected PermissionDenied without authorizer, got: %v", err)
+	}
+
+	// A read-only user may read but not write
+	var mu sync.Mutex
+	var authorized []string
+	proxy.SetAuthorizer(func(ctx context.Context, method string, req interface{}) error {
+		mu.Lock()
+		authorized = append(authorized, method)
+		mu.Unlock()
+		if _, ok := req.(*gnmipb.SetRequest); ok {
+			return fmt.Errorf("admin does not have write access, target gnmi")
+		}
+		return nil
+	})
+
+	_, err = client.Set(ctx, &gnmipb.SetRequest{Prefix: &gnmipb.Path{Target: "CONFIG_DB"}})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected PermissionDenied for read-only Set, got: %v", err)
+	}
+	if dpu.lastMetadata() != nil {
+		t.Errorf("Rejected request should not reach the DPU")
+	}
+	if _, err := client.Get(ctx, &gnmipb.GetRequest{Prefix: &gnmipb.Path{Target: "APPL_DB"}}); err != nil {
+		t.Errorf("Get failed: %v", err)
+	}
+
+	// Streams are authorized on their first request, which is still forwarded
+	stream, err := client.Subscribe(ctx)
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	subReq := &gnmipb.SubscribeRequest{
+		Request: &gnmipb.SubscribeRequest_Subscribe{
+			Subscribe: &gnmipb.SubscriptionList{Mode: gnmipb.SubscriptionList_ONCE},
+		},
+	}
+	if err := stream.Send(subReq); err != nil {
+		t.Fatalf("Send subscription failed: %v", err)
+	}
+	if resp, err := stream.Recv(); err != nil || resp.GetUpdate() == nil {
+		t.Errorf("Expected update for subscription, got: %v, %v", resp, err)
+	}
+	stream.CloseSend()
+
+	expected := []string{"/gnmi.gNMI/Set", "/gnmi.gNMI/Get", "/gnmi.gNMI/Subscribe"}
+	mu.Lock()
+	defer mu.Unlock()
+	if fmt.Sprint(authorized) != fmt.Sprint(expected) {
+		t.Errorf("Expected authorized methods %v, got: %v", expected, authorized)
+	}
+}
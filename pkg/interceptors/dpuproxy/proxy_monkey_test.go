@@ -375,6 +375,11 @@ func TestDPUProxy_getConnection_CachedConnection(t *testing.T) {
 		return &mockSystemClient{}
 	})
 
+	// Mock the connectivity state check of the cached connection
+	patches.ApplyFunc(connHealthy, func(conn *grpc.ClientConn) bool {
+		return true
+	})
+
 	proxy := NewDPUProxy(nil)
 	ctx := context.Background()
 
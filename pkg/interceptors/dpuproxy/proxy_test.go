@@ -178,7 +178,7 @@ func TestDPUProxy_UnaryInterceptor_WithDPUMetadata(t *testing.T) {
 	interceptor := proxy.UnaryInterceptor()
 
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		t.Error("Handler should not be called for non-forwardable gNMI method with DPU metadata")
+		t.Error("Handler should not be called for non-forwardable method with DPU metadata")
 		return nil, nil
 	}
 
@@ -188,11 +188,11 @@ func TestDPUProxy_UnaryInterceptor_WithDPUMetadata(t *testing.T) {
 	})
 	ctx := metadata.NewIncomingContext(context.Background(), md)
 
-	// /gnmi.gNMI/Get is not in the forwardable registry, should be rejected
-	resp, err := interceptor(ctx, "request", &grpc.UnaryServerInfo{FullMethod: "/gnmi.gNMI/Get"}, handler)
+	// /gnoi.system.System/KillProcess is not in the forwardable registry, should be rejected
+	resp, err := interceptor(ctx, "request", &grpc.UnaryServerInfo{FullMethod: "/gnoi.system.System/KillProcess"}, handler)
 
 	if err == nil {
-		t.Error("Expected error for non-forwardable gNMI method with DPU metadata")
+		t.Error("Expected error for non-forwardable method with DPU metadata")
 	}
 
 	if resp != nil {
@@ -279,7 +279,7 @@ func TestDPUProxy_StreamInterceptor_WithDPUMetadata(t *testing.T) {
 	interceptor := proxy.StreamInterceptor()
 
 	handler := func(srv interface{}, ss grpc.ServerStream) error {
-		t.Error("Handler should not be called for non-forwardable gNMI method with DPU metadata")
+		t.Error("Handler should not be called for non-forwardable method with DPU metadata")
 		return nil
 	}
 
@@ -290,11 +290,11 @@ func TestDPUProxy_StreamInterceptor_WithDPUMetadata(t *testing.T) {
 	ctx := metadata.NewIncomingContext(context.Background(), md)
 	ss := &mockServerStream{ctx: ctx}
 
-	// /gnmi.gNMI/Subscribe is not in the forwardable registry, should be rejected
-	err := interceptor(nil, ss, &grpc.StreamServerInfo{FullMethod: "/gnmi.gNMI/Subscribe"}, handler)
+	// /gnoi.system.System/Traceroute is not in the forwardable registry, should be rejected
+	err := interceptor(nil, ss, &grpc.StreamServerInfo{FullMethod: "/gnoi.system.System/Traceroute"}, handler)
 
 	if err == nil {
-		t.Error("Expected error for non-forwardable gNMI method with DPU metadata")
+		t.Error("Expected error for non-forwardable method with DPU metadata")
 	}
 
 	// Check that error is Unimplemented
@@ -11,6 +11,9 @@ import (
 type RedisClient interface {
 	// HGetAll returns all fields and values in a hash stored at key.
 	HGetAll(ctx context.Context, key string) (map[string]string, error)
+
+	// Keys returns all keys matching pattern.
+	Keys(ctx context.Context, pattern string) ([]string, error)
 }
 
 // GoRedisAdapter adapts the go-redis client to our RedisClient interface.
@@ -28,6 +31,11 @@ func (a *GoRedisAdapter) HGetAll(ctx context.Context, key string) (map[string]st
 	return a.client.HGetAll(ctx, key).Result()
 }
 
+// Keys implements RedisClient interface.
+func (a *GoRedisAdapter) Keys(ctx context.Context, pattern string) ([]string, error) {
+	return a.client.Keys(ctx, pattern).Result()
+}
+
 // NewRedisClient creates a new Redis client connected to SONiC's Redis instance.
 // It connects via Unix socket to the specified database.
 func NewRedisClient(socketPath string, db int) *redis.Client {
@@ -3,6 +3,9 @@ package dpuproxy
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 const (
@@ -117,3 +120,27 @@ func (r *DPUResolver) GetDPUInfo(ctx context.Context, dpuIndex string) (*DPUInfo
 		GNMIPortsToTry: portsToTry,
 	}, nil
 }
+
+// ListDPUs returns the indices of the DPUs present in STATE_DB, in numeric order.
+func (r *DPUResolver) ListDPUs(ctx context.Context) ([]string, error) {
+	keys, err := r.stateClient.Keys(ctx, ChassisMidplaneTablePrefix+"*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list DPUs in StateDB: %w", err)
+	}
+
+	var indices []string
+	for _, key := range keys {
+		index := strings.TrimPrefix(key, ChassisMidplaneTablePrefix)
+		if _, err := strconv.Atoi(index); err != nil {
+			continue // Not a DPU<N> key
+		}
+		indices = append(indices, index)
+	}
+
+	sort.Slice(indices, func(i, j int) bool {
+		a, _ := strconv.Atoi(indices[i])
+		b, _ := strconv.Atoi(indices[j])
+		return a < b
+	})
+	return indices, nil
+}
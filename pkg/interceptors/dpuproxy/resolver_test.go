@@ -3,6 +3,8 @@ package dpuproxy
 import (
 	"context"
 	"errors"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -22,6 +24,19 @@ func (m *mockRedisClient) HGetAll(ctx context.Context, key string) (map[string]s
 	return map[string]string{}, nil // Empty map indicates key not found
 }
 
+func (m *mockRedisClient) Keys(ctx context.Context, pattern string) ([]string, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	var keys []string
+	for key := range m.data {
+		if strings.HasPrefix(key, strings.TrimSuffix(pattern, "*")) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
 func TestDPUResolver_GetDPUInfo_Success(t *testing.T) {
 	stateMock := &mockRedisClient{
 		data: map[string]map[string]string{
@@ -271,3 +286,30 @@ func TestNewDPUResolver(t *testing.T) {
 		t.Error("Expected resolver to have configClient set")
 	}
 }
+
+func TestDPUResolver_ListDPUs(t *testing.T) {
+	stateMock := &mockRedisClient{
+		data: map[string]map[string]string{
+			"CHASSIS_MIDPLANE_TABLE|DPU10": {"ip_address": "169.254.200.11"},
+			"CHASSIS_MIDPLANE_TABLE|DPU2":  {"ip_address": "169.254.200.3"},
+			"CHASSIS_MIDPLANE_TABLE|DPU0":  {"ip_address": "169.254.200.1"},
+			"CHASSIS_MIDPLANE_TABLE|DPUX":  {"ip_address": "169.254.200.99"},
+		},
+	}
+
+	resolver := NewDPUResolver(stateMock, &mockRedisClient{})
+	indices, err := resolver.ListDPUs(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	expected := []string{"0", "2", "10"}
+	if !reflect.DeepEqual(indices, expected) {
+		t.Errorf("Expected %v, got: %v", expected, indices)
+	}
+
+	stateMock.err = errors.New("connection refused")
+	if _, err := resolver.ListDPUs(context.Background()); err == nil {
+		t.Error("Expected error when Redis fails")
+	}
+}
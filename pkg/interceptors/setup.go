@@ -1,18 +1,23 @@
 package interceptors
 
 import (
+	"crypto/tls"
+
+	"github.com/sonic-net/sonic-gnmi/pkg/audit"
 	"github.com/sonic-net/sonic-gnmi/pkg/interceptors/dpuproxy"
 	"google.golang.org/grpc"
 )
 
 // ServerChain represents a configured interceptor chain with cleanup capabilities.
 type ServerChain struct {
-	chain   *Chain
-	cleanup func() error
+	chain    *Chain
+	dpuProxy *dpuproxy.DPUProxy
+	cleanup  func() error
 }
 
 // NewServerChain creates a complete interceptor chain for the gNMI server.
-// Currently includes DPU proxy interceptor with Redis-based DPU resolution.
+// Currently includes the audit interceptor, recording the mutating RPCs to the
+// default audit logger, and DPU proxy interceptor with Redis-based DPU resolution.
 // Returns the chain and a cleanup function that must be called during shutdown.
 func NewServerChain() (*ServerChain, error) {
 	// Create Redis clients for DPU info resolution from both StateDB and ConfigDB
@@ -27,13 +32,22 @@ func NewServerChain() (*ServerChain, error) {
 	dpuProxy := dpuproxy.NewDPUProxy(dpuResolver)
 	dpuproxy.SetDefaultProxy(dpuProxy)
 
-	// Create interceptor chain with DPU proxy
-	chain := NewChain(dpuProxy)
+	// Evict DPU connections as soon as the DPU midplane state changes
+	dpuProxy.WatchMidplane(stateRedisClient)
+
+	// Create interceptor chain with DPU proxy, audited first so that proxied
+	// RPCs are audited too
+	chain := NewChain(audit.NewInterceptor(), dpuProxy)
 
-	// Create cleanup function to close Redis clients
+	// Create cleanup function to close the DPU proxy and Redis clients
 	cleanup := func() error {
 		var firstErr error
 
+		// Close DPU connections and stop watching StateDB
+		if err := dpuProxy.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+
 		// Close state Redis client
 		if err := stateRedisClient.Close(); err != nil && firstErr == nil {
 			firstErr = err
@@ -44,22 +58,27 @@ func NewServerChain() (*ServerChain, error) {
 			firstErr = err
 		}
 
-		// TODO: Add DPU proxy connection cleanup when implemented
-		// if dpuProxy != nil {
-		//     if err := dpuProxy.Close(); err != nil && firstErr == nil {
-		//         firstErr = err
-		//     }
-		// }
-
 		return firstErr
 	}
 
 	return &ServerChain{
-		chain:   chain,
-		cleanup: cleanup,
+		chain:    chain,
+		dpuProxy: dpuProxy,
+		cleanup:  cleanup,
 	}, nil
 }
 
+// SetDPUAuthorizer sets the authorizer of the requests the DPU proxy forwards.
+// Requests needing authorization are not forwarded until it is set.
+func (sc *ServerChain) SetDPUAuthorizer(authorize dpuproxy.Authorizer) {
+	sc.dpuProxy.SetAuthorizer(authorize)
+}
+
+// SetDPUTLSConfig makes the DPU proxy connect to the DPUs over TLS with cfg.
+func (sc *ServerChain) SetDPUTLSConfig(cfg *tls.Config) {
+	sc.dpuProxy.SetTLSConfig(cfg)
+}
+
 // GetServerOptions returns gRPC server options with all interceptors configured.
 func (sc *ServerChain) GetServerOptions() []grpc.ServerOption {
 	return []grpc.ServerOption{
@@ -0,0 +1,81 @@
+package operationalhandler
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/sonic-net/sonic-gnmi/pkg/certexpiry"
+)
+
+// CertExpiryHandler implements PathHandler for certificate expiry queries.
+// It acts as a gNMI adapter for the certexpiry package.
+type CertExpiryHandler struct{}
+
+// CertExpiryInfo represents the expiry of a certificate, trust bundle or CRL
+// returned by gNMI queries.
+type CertExpiryInfo struct {
+	Name          string `json:"name"`
+	Type          string `json:"type"`
+	Path          string `json:"path,omitempty"`
+	Subject       string `json:"subject,omitempty"`
+	Expiry        string `json:"expiry,omitempty"`
+	DaysRemaining *int   `json:"days-remaining,omitempty"`
+	Threshold     int    `json:"threshold,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+// NewCertExpiryHandler creates a new CertExpiryHandler.
+func NewCertExpiryHandler() *CertExpiryHandler {
+	return &CertExpiryHandler{}
+}
+
+// SupportedPaths returns the list of paths this handler supports.
+func (h *CertExpiryHandler) SupportedPaths() []string {
+	return []string{
+		"certificates/expiry",
+	}
+}
+
+// DescribePaths describes the paths this handler supports.
+func (h *CertExpiryHandler) DescribePaths() []PathInfo {
+	return []PathInfo{{
+		Path:        "/sonic/system/certificates/expiry",
+		Description: "Expiry of the server certificates, trust bundles and cached CRLs at the last check",
+	}}
+}
+
+// HandleGet processes a gNMI Get request for the expiry of the certificates
+// checked by the certificate expiry monitor.
+func (h *CertExpiryHandler) HandleGet(path *gnmipb.Path) ([]byte, error) {
+	statuses, err := certexpiry.GetStatus()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get certificate expiry: %v", err)
+	}
+
+	infos := make([]CertExpiryInfo, 0, len(statuses))
+	for _, st := range statuses {
+		info := CertExpiryInfo{
+			Name:    st.Name,
+			Type:    string(st.Kind),
+			Path:    st.Path,
+			Subject: st.Subject,
+			Error:   st.Error,
+		}
+		if st.Error == "" {
+			days := st.DaysRemaining
+			info.Expiry = st.Expiry.UTC().Format(time.RFC3339)
+			info.DaysRemaining = &days
+			info.Threshold = st.Threshold
+		}
+		infos = append(infos, info)
+	}
+
+	jsonData, err := json.Marshal(infos)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal certificate expiry: %v", err)
+	}
+
+	return jsonData, nil
+}
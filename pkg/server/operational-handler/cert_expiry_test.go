@@ -0,0 +1,101 @@
+package operationalhandler
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/sonic-net/sonic-gnmi/pkg/certexpiry"
+)
+
+func setupCertExpiryMonitor(t *testing.T, certs ...certexpiry.Cert) *certexpiry.Monitor {
+	m := certexpiry.NewMonitor(func() []certexpiry.Cert { return certs },
+		func(certexpiry.Status) error { return nil }, certexpiry.DefaultThresholds)
+	certexpiry.SetDefaultMonitor(m)
+	t.Cleanup(func() { certexpiry.SetDefaultMonitor(nil) })
+	return m
+}
+
+func certExpiryPath() *gnmipb.Path {
+	return &gnmipb.Path{
+		Elem: []*gnmipb.PathElem{
+			{Name: "sonic"},
+			{Name: "system"},
+			{Name: "certificates"},
+			{Name: "expiry"},
+		},
+	}
+}
+
+func TestCertExpiryHandler_SupportedPaths(t *testing.T) {
+	paths := NewCertExpiryHandler().SupportedPaths()
+	if len(paths) != 1 || paths[0] != "certificates/expiry" {
+		t.Errorf("unexpected supported paths: %v", paths)
+	}
+}
+
+func TestCertExpiryHandler_HandleGet(t *testing.T) {
+	now := time.Now()
+	m := setupCertExpiryMonitor(t,
+		certexpiry.Cert{Name: "server", Kind: certexpiry.KindCertificate, Path: "/etc/sonic/telemetry/streamingtelemetryserver.cer",
+			Subject: "CN=switch", Fingerprint: "a", Expiry: now.Add(5*24*time.Hour + time.Hour)},
+		certexpiry.Cert{Name: "ca", Kind: certexpiry.KindTrustBundle, Path: "/etc/sonic/telemetry/dsmsroot.cer", Error: "no such file"},
+	)
+	handler := NewCertExpiryHandler()
+
+	data, err := handler.HandleGet(certExpiryPath())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(data) != "[]" {
+		t.Errorf("expected no certificate before the first check, got %s", data)
+	}
+
+	m.Check(now)
+	data, err = handler.HandleGet(certExpiryPath())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var infos []CertExpiryInfo
+	if err := json.Unmarshal(data, &infos); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if len(infos) != 2 {
+		t.Fatalf("expected 2 certificates, got %s", data)
+	}
+	if ca := infos[0]; ca.Name != "ca" || ca.Type != "trust-bundle" || ca.Error == "" || ca.DaysRemaining != nil || ca.Expiry != "" {
+		t.Errorf("unexpected CA expiry: %+v", ca)
+	}
+	server := infos[1]
+	if server.Name != "server" || server.Subject != "CN=switch" || server.DaysRemaining == nil || *server.DaysRemaining != 5 ||
+		server.Threshold != 7 || server.Expiry != now.Add(5*24*time.Hour+time.Hour).UTC().Format(time.RFC3339) {
+		t.Errorf("unexpected server expiry: %s", data)
+	}
+}
+
+func TestCertExpiryHandler_HandleGet_NotInitialized(t *testing.T) {
+	certexpiry.SetDefaultMonitor(nil)
+
+	if _, err := NewCertExpiryHandler().HandleGet(certExpiryPath()); err == nil {
+		t.Error("expected error when certificate expiry monitoring is not initialized")
+	}
+}
+
+func TestOperationalHandler_CertExpiry(t *testing.T) {
+	setupCertExpiryMonitor(t).Check(time.Now())
+
+	handler, err := NewOperationalHandler([]*gnmipb.Path{certExpiryPath()}, &gnmipb.Path{Target: "OPERATIONAL"})
+	if err != nil {
+		t.Fatalf("failed to create operational handler: %v", err)
+	}
+	defer handler.Close()
+
+	values, err := handler.Get(nil)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if len(values) != 1 || string(values[0].Value.GetJsonVal()) != "[]" {
+		t.Fatalf("unexpected values: %v", values)
+	}
+}
@@ -0,0 +1,74 @@
+package operationalhandler
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/sonic-net/sonic-gnmi/pkg/commitconfirm"
+)
+
+// CommitHandler implements PathHandler for commit-confirmed status queries.
+// It acts as a gNMI adapter for the commitconfirm package.
+type CommitHandler struct{}
+
+// CommitStatusInfo represents the commit-confirmed status returned by gNMI queries.
+type CommitStatusInfo struct {
+	ID               string `json:"id,omitempty"`
+	State            string `json:"state"`
+	Started          string `json:"started,omitempty"`
+	RollbackDeadline string `json:"rollback-deadline,omitempty"`
+	Error            string `json:"error,omitempty"`
+}
+
+// NewCommitHandler creates a new CommitHandler.
+func NewCommitHandler() *CommitHandler {
+	return &CommitHandler{}
+}
+
+// SupportedPaths returns the list of paths this handler supports.
+func (h *CommitHandler) SupportedPaths() []string {
+	return []string{
+		"commit/status",
+	}
+}
+
+// DescribePaths describes the paths this handler supports.
+func (h *CommitHandler) DescribePaths() []PathInfo {
+	return []PathInfo{{
+		Path:        "/sonic/system/commit/status",
+		Description: "State of the current or last commit-confirmed Set request",
+	}}
+}
+
+// HandleGet processes a gNMI Get request for the status of the current or last
+// commit-confirmed Set request. The state is "none" if there was no commit.
+func (h *CommitHandler) HandleGet(path *gnmipb.Path) ([]byte, error) {
+	st, err := commitconfirm.GetStatus()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit status: %v", err)
+	}
+
+	info := &CommitStatusInfo{
+		ID:    st.ID,
+		State: string(st.State),
+		Error: st.Error,
+	}
+	if info.State == "" {
+		info.State = "none"
+	}
+	if !st.Started.IsZero() {
+		info.Started = st.Started.UTC().Format(time.RFC3339)
+	}
+	if !st.Deadline.IsZero() {
+		info.RollbackDeadline = st.Deadline.UTC().Format(time.RFC3339)
+	}
+
+	jsonData, err := json.Marshal(info)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal commit status: %v", err)
+	}
+
+	return jsonData, nil
+}
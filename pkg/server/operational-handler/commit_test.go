@@ -0,0 +1,119 @@
+package operationalhandler
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/sonic-net/sonic-gnmi/pkg/commitconfirm"
+)
+
+// stubCommitService implements commitconfirm.Service with an empty checkpoint.
+type stubCommitService struct{}
+
+func (s *stubCommitService) Close() error { return nil }
+
+func (s *stubCommitService) CreateCheckPoint(cpName string) error {
+	return ioutil.WriteFile(cpName+".cp.json", []byte("{}"), 0644)
+}
+
+func (s *stubCommitService) DeleteCheckPoint(cpName string) error { return nil }
+
+func (s *stubCommitService) ConfigReplace(config string) error { return nil }
+
+func setupCommitManager(t *testing.T) *commitconfirm.Manager {
+	m := commitconfirm.NewManager(func() (commitconfirm.Service, error) {
+		return &stubCommitService{}, nil
+	}, t.TempDir())
+	commitconfirm.SetDefaultManager(m)
+	t.Cleanup(func() { commitconfirm.SetDefaultManager(nil) })
+	return m
+}
+
+func commitStatusPath() *gnmipb.Path {
+	return &gnmipb.Path{
+		Elem: []*gnmipb.PathElem{
+			{Name: "sonic"},
+			{Name: "system"},
+			{Name: "commit"},
+			{Name: "status"},
+		},
+	}
+}
+
+func TestCommitHandler_SupportedPaths(t *testing.T) {
+	paths := NewCommitHandler().SupportedPaths()
+	if len(paths) != 1 || paths[0] != "commit/status" {
+		t.Errorf("unexpected supported paths: %v", paths)
+	}
+}
+
+func TestCommitHandler_HandleGet(t *testing.T) {
+	m := setupCommitManager(t)
+	handler := NewCommitHandler()
+
+	data, err := handler.HandleGet(commitStatusPath())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var info CommitStatusInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if info.State != "none" || info.ID != "" {
+		t.Errorf("expected no commit, got %s", data)
+	}
+
+	if err := m.Commit("c1", time.Minute, func() error { return nil }); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	defer m.Cancel("c1")
+
+	data, err = handler.HandleGet(commitStatusPath())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	info = CommitStatusInfo{}
+	if err := json.Unmarshal(data, &info); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if info.ID != "c1" || info.State != "pending" || info.Started == "" || info.RollbackDeadline == "" {
+		t.Errorf("unexpected commit status: %s", data)
+	}
+}
+
+func TestCommitHandler_HandleGet_NotInitialized(t *testing.T) {
+	commitconfirm.SetDefaultManager(nil)
+
+	if _, err := NewCommitHandler().HandleGet(commitStatusPath()); err == nil {
+		t.Error("expected error when commit-confirmed is not initialized")
+	}
+}
+
+func TestOperationalHandler_CommitStatus(t *testing.T) {
+	setupCommitManager(t)
+
+	handler, err := NewOperationalHandler([]*gnmipb.Path{commitStatusPath()}, &gnmipb.Path{Target: "OPERATIONAL"})
+	if err != nil {
+		t.Fatalf("failed to create operational handler: %v", err)
+	}
+	defer handler.Close()
+
+	values, err := handler.Get(nil)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if len(values) != 1 {
+		t.Fatalf("expected 1 value, got %d", len(values))
+	}
+
+	var info CommitStatusInfo
+	if err := json.Unmarshal(values[0].Value.GetJsonVal(), &info); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if info.State != "none" {
+		t.Errorf("unexpected commit status: %+v", info)
+	}
+}
@@ -36,6 +36,15 @@ func (h *DiskSpaceHandler) SupportedPaths() []string {
 	}
 }
 
+// DescribePaths describes the paths this handler supports.
+func (h *DiskSpaceHandler) DescribePaths() []PathInfo {
+	return []PathInfo{{
+		Path:        "/sonic/system/filesystem[path=*]/disk-space",
+		Description: "Total and available disk space of a filesystem, in MB",
+		Keys:        map[string]string{"path": "Filesystem path, e.g. /host"},
+	}}
+}
+
 // HandleGet processes a gNMI Get request for disk space information.
 func (h *DiskSpaceHandler) HandleGet(path *gnmipb.Path) ([]byte, error) {
 	// Extract the filesystem path from the gNMI path
@@ -1,10 +1,14 @@
 // Package operationalhandler provides gNMI handlers for operational state queries.
 //
 // This package implements server-side gNMI path handlers for operational data
-// including disk space monitoring, package management, and system health checks.
+// including disk space monitoring, package management, DPU proxy status,
+// commit-confirmed status, certificate expiry and system health checks.
 //
 // The operational handler supports paths like:
 //   - /sonic/system/filesystem[path=*]/disk-space
+//   - /sonic/system/dpu-proxy[index=*]/status
+//   - /sonic/system/commit/status
+//   - /sonic/system/certificates/expiry
 //
 // Example usage:
 //
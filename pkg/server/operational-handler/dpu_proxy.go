@@ -0,0 +1,101 @@
+package operationalhandler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/sonic-net/sonic-gnmi/pkg/interceptors/dpuproxy"
+)
+
+// DPUProxyHandler implements PathHandler for DPU proxy status queries.
+// It acts as a gNMI adapter for the dpuproxy package.
+type DPUProxyHandler struct{}
+
+// DPUProxyStatusInfo represents the proxy status of a DPU returned by gNMI queries.
+type DPUProxyStatusInfo struct {
+	Index           string `json:"index"`
+	Reachable       bool   `json:"reachable"`
+	Address         string `json:"address,omitempty"`
+	ConnectionState string `json:"connection-state,omitempty"`
+	LastError       string `json:"last-error,omitempty"`
+	LastErrorTime   string `json:"last-error-time,omitempty"`
+	InFlightRPCs    int64  `json:"in-flight-rpcs"`
+}
+
+// NewDPUProxyHandler creates a new DPUProxyHandler.
+func NewDPUProxyHandler() *DPUProxyHandler {
+	return &DPUProxyHandler{}
+}
+
+// SupportedPaths returns the list of paths this handler supports.
+func (h *DPUProxyHandler) SupportedPaths() []string {
+	return []string{
+		"dpu-proxy/status",
+	}
+}
+
+// DescribePaths describes the paths this handler supports.
+func (h *DPUProxyHandler) DescribePaths() []PathInfo {
+	return []PathInfo{{
+		Path:        "/sonic/system/dpu-proxy[index=*]/status",
+		Description: "Connection status of the DPUs behind the gNMI proxy",
+		Keys:        map[string]string{"index": "DPU index, all DPUs if omitted or *"},
+	}}
+}
+
+// HandleGet processes a gNMI Get request for DPU proxy status.
+// Handles paths like /sonic/system/dpu-proxy[index=0]/status; without an
+// index (or with index=*) the status of all DPUs is returned.
+func (h *DPUProxyHandler) HandleGet(path *gnmipb.Path) ([]byte, error) {
+	index := h.extractIndex(path)
+
+	statuses, err := dpuproxy.GetProxyStatus(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get DPU proxy status: %v", err)
+	}
+
+	infos := make([]*DPUProxyStatusInfo, 0, len(statuses))
+	for _, s := range statuses {
+		if index != "" && s.Index != index {
+			continue
+		}
+		info := &DPUProxyStatusInfo{
+			Index:           s.Index,
+			Reachable:       s.Reachable,
+			Address:         s.Address,
+			ConnectionState: s.ConnectionState,
+			LastError:       s.LastError,
+			InFlightRPCs:    s.InFlight,
+		}
+		if !s.LastErrorTime.IsZero() {
+			info.LastErrorTime = s.LastErrorTime.UTC().Format(time.RFC3339)
+		}
+		infos = append(infos, info)
+	}
+
+	if index != "" && len(infos) == 0 {
+		return nil, fmt.Errorf("DPU%s not found", index)
+	}
+
+	jsonData, err := json.Marshal(infos)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal DPU proxy status: %v", err)
+	}
+
+	return jsonData, nil
+}
+
+// extractIndex extracts the DPU index from a gNMI path, empty for all DPUs.
+func (h *DPUProxyHandler) extractIndex(path *gnmipb.Path) string {
+	for _, elem := range path.GetElem() {
+		if elem.GetName() == "dpu-proxy" {
+			if index := elem.GetKey()["index"]; index != "*" {
+				return index
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,151 @@
+package operationalhandler
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/sonic-net/sonic-gnmi/pkg/interceptors/dpuproxy"
+)
+
+// stubRedisClient implements dpuproxy.RedisClient over an in-memory table.
+type stubRedisClient struct {
+	data map[string]map[string]string
+}
+
+func (s *stubRedisClient) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	if fields, ok := s.data[key]; ok {
+		return fields, nil
+	}
+	return map[string]string{}, nil
+}
+
+func (s *stubRedisClient) Keys(ctx context.Context, pattern string) ([]string, error) {
+	var keys []string
+	for key := range s.data {
+		if strings.HasPrefix(key, strings.TrimSuffix(pattern, "*")) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func setupDPUProxy(t *testing.T) {
+	stateClient := &stubRedisClient{data: map[string]map[string]string{
+		"CHASSIS_MIDPLANE_TABLE|DPU0": {"ip_address": "169.254.200.1", "access": "True"},
+		"CHASSIS_MIDPLANE_TABLE|DPU1": {"ip_address": "169.254.200.2", "access": "False"},
+	}}
+	configClient := &stubRedisClient{data: map[string]map[string]string{}}
+	dpuproxy.SetDefaultProxy(dpuproxy.NewDPUProxy(dpuproxy.NewDPUResolver(stateClient, configClient)))
+	t.Cleanup(func() { dpuproxy.SetDefaultProxy(nil) })
+}
+
+func dpuProxyStatusPath(index string) *gnmipb.Path {
+	elem := &gnmipb.PathElem{Name: "dpu-proxy"}
+	if index != "" {
+		elem.Key = map[string]string{"index": index}
+	}
+	return &gnmipb.Path{
+		Elem: []*gnmipb.PathElem{
+			{Name: "sonic"},
+			{Name: "system"},
+			elem,
+			{Name: "status"},
+		},
+	}
+}
+
+func TestDPUProxyHandler_SupportedPaths(t *testing.T) {
+	paths := NewDPUProxyHandler().SupportedPaths()
+	if len(paths) != 1 || paths[0] != "dpu-proxy/status" {
+		t.Errorf("unexpected supported paths: %v", paths)
+	}
+}
+
+func TestDPUProxyHandler_HandleGet(t *testing.T) {
+	setupDPUProxy(t)
+	handler := NewDPUProxyHandler()
+
+	tests := []struct {
+		name     string
+		index    string
+		expected []string
+		wantErr  bool
+	}{
+		{name: "all DPUs", index: "", expected: []string{"0", "1"}},
+		{name: "wildcard index", index: "*", expected: []string{"0", "1"}},
+		{name: "single DPU", index: "1", expected: []string{"1"}},
+		{name: "unknown DPU", index: "7", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := handler.HandleGet(dpuProxyStatusPath(tt.index))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got %s", data)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var infos []DPUProxyStatusInfo
+			if err := json.Unmarshal(data, &infos); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			if len(infos) != len(tt.expected) {
+				t.Fatalf("expected %d DPUs, got %s", len(tt.expected), data)
+			}
+			for i, info := range infos {
+				if info.Index != tt.expected[i] {
+					t.Errorf("expected DPU %s, got %s", tt.expected[i], info.Index)
+				}
+				if info.Reachable != (info.Index == "0") {
+					t.Errorf("unexpected reachable state for DPU %s: %v", info.Index, info.Reachable)
+				}
+				if info.InFlightRPCs != 0 || info.Address != "" {
+					t.Errorf("expected no connection to DPU %s, got %+v", info.Index, info)
+				}
+			}
+		})
+	}
+}
+
+func TestDPUProxyHandler_HandleGet_NotInitialized(t *testing.T) {
+	dpuproxy.SetDefaultProxy(nil)
+
+	if _, err := NewDPUProxyHandler().HandleGet(dpuProxyStatusPath("")); err == nil {
+		t.Error("expected error when DPU proxy is not initialized")
+	}
+}
+
+func TestOperationalHandler_DPUProxyStatus(t *testing.T) {
+	setupDPUProxy(t)
+
+	handler, err := NewOperationalHandler([]*gnmipb.Path{dpuProxyStatusPath("0")}, &gnmipb.Path{Target: "OPERATIONAL"})
+	if err != nil {
+		t.Fatalf("failed to create operational handler: %v", err)
+	}
+	defer handler.Close()
+
+	values, err := handler.Get(nil)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if len(values) != 1 {
+		t.Fatalf("expected 1 value, got %d", len(values))
+	}
+
+	jsonVal := values[0].Value.GetJsonVal()
+	var infos []DPUProxyStatusInfo
+	if err := json.Unmarshal(jsonVal, &infos); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if len(infos) != 1 || infos[0].Index != "0" || !infos[0].Reachable {
+		t.Errorf("unexpected DPU proxy status: %s", jsonVal)
+	}
+}
@@ -43,6 +43,31 @@ func (h *FirmwareHandler) SupportedPaths() []string {
 	}
 }
 
+// DescribePaths describes the paths this handler supports.
+func (h *FirmwareHandler) DescribePaths() []PathInfo {
+	keys := map[string]string{
+		"path":    "Directory path, e.g. /host",
+		"pattern": "Glob pattern of the file names, * if omitted",
+	}
+	return []PathInfo{
+		{
+			Path:        "/sonic/system/filesystem[path=*]/files[pattern=*]/list",
+			Description: "Files of a directory matching a pattern",
+			Keys:        keys,
+		},
+		{
+			Path:        "/sonic/system/filesystem[path=*]/files[pattern=*]/count",
+			Description: "Number of files of a directory matching a pattern",
+			Keys:        keys,
+		},
+		{
+			Path:        "/sonic/system/filesystem[path=*]/files[pattern=*]/types",
+			Description: "Files of a directory matching a pattern, grouped by type",
+			Keys:        keys,
+		},
+	}
+}
+
 // HandleGet processes a gNMI Get request for file listing information.
 func (h *FirmwareHandler) HandleGet(path *gnmipb.Path) ([]byte, error) {
 	// Extract the filesystem path, pattern, and field from the gNMI path
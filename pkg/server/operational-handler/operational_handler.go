@@ -53,25 +53,28 @@ type PathHandler interface {
 	SupportedPaths() []string
 }
 
-// NewOperationalHandler creates a new OperationalHandler for the given paths and prefix.
-// It follows the same signature as other sonic-gnmi handlers like NewNonDbClient.
-func NewOperationalHandler(paths []*gnmipb.Path, prefix *gnmipb.Path) (Handler, error) {
-	handler := &OperationalHandler{
-		prefix:       prefix,
-		paths:        paths,
-		pathHandlers: make(map[string]PathHandler),
-	}
+// registeredPathHandler is a path handler of the OPERATIONAL target.
+type registeredPathHandler struct {
+	new func() PathHandler
 
-	// Register path handlers
-	diskSpaceHandler := NewDiskSpaceHandler()
-	for _, supportedPath := range diskSpaceHandler.SupportedPaths() {
-		handler.pathHandlers[supportedPath] = diskSpaceHandler
-	}
+	// needed tells if the handler is needed for the requested path strings,
+	// the handler is always registered if nil
+	needed func(pathStrs []string) bool
+}
+
+// pathHandlerRegistry lists the path handlers of the OPERATIONAL target.
+var pathHandlerRegistry = []registeredPathHandler{
+	{new: func() PathHandler { return NewDiskSpaceHandler() }},
+	{new: func() PathHandler { return NewDPUProxyHandler() }},
+	{new: func() PathHandler { return NewCommitHandler() }},
+	{new: func() PathHandler { return NewCertExpiryHandler() }},
+	// File listing handler, still using FirmwareHandler internally
+	{new: func() PathHandler { return NewFirmwareHandler() }, needed: needsFileHandler},
+}
 
-	// Register file listing handler if filesystem/files paths are requested
-	needsFileHandler := false
-	for _, path := range paths {
-		pathStr := handler.pathToString(path)
+// needsFileHandler checks if filesystem/files paths are requested.
+func needsFileHandler(pathStrs []string) bool {
+	for _, pathStr := range pathStrs {
 		// Check for both firmware paths (legacy) and filesystem/files paths (new)
 		// Must explicitly contain "/files/" or "/files[" to avoid matching "/filesystem"
 		if strings.Contains(pathStr, "/firmware/") ||
@@ -80,21 +83,39 @@ func NewOperationalHandler(paths []*gnmipb.Path, prefix *gnmipb.Path) (Handler,
 			strings.Contains(pathStr, "/files/") ||
 			strings.Contains(pathStr, "/files[") ||
 			strings.HasSuffix(pathStr, "/files") {
-			needsFileHandler = true
-			break
+			return true
 		}
 	}
+	return false
+}
 
-	if needsFileHandler {
-		fileHandler := NewFirmwareHandler() // Still using FirmwareHandler internally
-		for _, supportedPath := range fileHandler.SupportedPaths() {
-			handler.pathHandlers[supportedPath] = fileHandler
+// NewOperationalHandler creates a new OperationalHandler for the given paths and prefix.
+// It follows the same signature as other sonic-gnmi handlers like NewNonDbClient.
+func NewOperationalHandler(paths []*gnmipb.Path, prefix *gnmipb.Path) (Handler, error) {
+	handler := &OperationalHandler{
+		prefix:       prefix,
+		paths:        paths,
+		pathHandlers: make(map[string]PathHandler),
+	}
+
+	pathStrs := make([]string, len(paths))
+	for i, path := range paths {
+		pathStrs[i] = handler.pathToString(path)
+	}
+
+	// Register path handlers
+	for _, registered := range pathHandlerRegistry {
+		if registered.needed != nil && !registered.needed(pathStrs) {
+			continue
+		}
+		pathHandler := registered.new()
+		for _, supportedPath := range pathHandler.SupportedPaths() {
+			handler.pathHandlers[supportedPath] = pathHandler
 		}
 	}
 
 	// Validate that all requested paths are supported
-	for _, path := range paths {
-		pathStr := handler.pathToString(path)
+	for _, pathStr := range pathStrs {
 		if !handler.isPathSupported(pathStr) {
 			return nil, status.Errorf(codes.Unimplemented, "unsupported path: %s", pathStr)
 		}
@@ -152,6 +173,24 @@ func (h *OperationalHandler) pathMatches(requestedPath, supportedPath string) bo
 		return false
 	}
 
+	if supportedPath == "dpu-proxy/status" {
+		// Match paths like "dpu-proxy[index=*]/status"
+		if requestedPath == "dpu-proxy/status" {
+			return true
+		}
+		return strings.Contains(requestedPath, "dpu-proxy") && strings.HasSuffix(requestedPath, "/status")
+	}
+
+	if supportedPath == "commit/status" {
+		// Match paths like "sonic/system/commit/status"
+		return requestedPath == "commit/status" || strings.HasSuffix(requestedPath, "/commit/status")
+	}
+
+	if supportedPath == "certificates/expiry" {
+		// Match paths like "sonic/system/certificates/expiry"
+		return requestedPath == "certificates/expiry" || strings.HasSuffix(requestedPath, "/certificates/expiry")
+	}
+
 	// Legacy support for firmware paths (deprecated, use filesystem/files instead)
 	if supportedPath == "firmware/files" {
 		// Match paths like "firmware[directory=*]/files", "firmware[directory=*]/files/count", etc.
@@ -171,6 +171,36 @@ func TestOperationalHandler_PathMatches(t *testing.T) {
 			supportedPath: "filesystem/disk-space",
 			expected:      false,
 		},
+		{
+			name:          "dpu proxy status with index",
+			requestedPath: "sonic/system/dpu-proxy[index=0]/status",
+			supportedPath: "dpu-proxy/status",
+			expected:      true,
+		},
+		{
+			name:          "dpu proxy status without index",
+			requestedPath: "sonic/system/dpu-proxy/status",
+			supportedPath: "dpu-proxy/status",
+			expected:      true,
+		},
+		{
+			name:          "commit status",
+			requestedPath: "sonic/system/commit/status",
+			supportedPath: "commit/status",
+			expected:      true,
+		},
+		{
+			name:          "certificate expiry",
+			requestedPath: "sonic/system/certificates/expiry",
+			supportedPath: "certificates/expiry",
+			expected:      true,
+		},
+		{
+			name:          "dpu proxy status is not commit status",
+			requestedPath: "sonic/system/dpu-proxy/status",
+			supportedPath: "commit/status",
+			expected:      false,
+		},
 		{
 			name:          "path shorter than suffix no panic",
 			requestedPath: "/disk",
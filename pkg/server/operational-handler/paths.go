@@ -0,0 +1,38 @@
+package operationalhandler
+
+import "sort"
+
+// PathInfo describes a path pattern of the OPERATIONAL target.
+type PathInfo struct {
+	// Path is the full path pattern, e.g. /sonic/system/filesystem[path=*]/disk-space
+	Path string `json:"path"`
+
+	// Description tells what the path returns
+	Description string `json:"description,omitempty"`
+
+	// Keys describes the keys of the path elements
+	Keys map[string]string `json:"keys,omitempty"`
+}
+
+// PathDescriber is implemented by path handlers describing their paths.
+type PathDescriber interface {
+	DescribePaths() []PathInfo
+}
+
+// RegisteredPaths lists the paths of every path handler of the OPERATIONAL
+// target, sorted by path.
+func RegisteredPaths() []PathInfo {
+	var paths []PathInfo
+	for _, registered := range pathHandlerRegistry {
+		handler := registered.new()
+		if describer, ok := handler.(PathDescriber); ok {
+			paths = append(paths, describer.DescribePaths()...)
+			continue
+		}
+		for _, path := range handler.SupportedPaths() {
+			paths = append(paths, PathInfo{Path: "/sonic/system/" + path})
+		}
+	}
+	sort.Slice(paths, func(i, j int) bool { return paths[i].Path < paths[j].Path })
+	return paths
+}
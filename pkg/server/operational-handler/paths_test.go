@@ -0,0 +1,46 @@
+package operationalhandler
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/ygot/ygot"
+)
+
+func TestRegisteredPaths(t *testing.T) {
+	paths := RegisteredPaths()
+	if !sort.SliceIsSorted(paths, func(i, j int) bool { return paths[i].Path < paths[j].Path }) {
+		t.Errorf("Expected paths sorted, got %v", paths)
+	}
+
+	described := map[string]bool{}
+	for _, info := range paths {
+		if !strings.HasPrefix(info.Path, "/sonic/system/") || info.Description == "" {
+			t.Errorf("Unexpected path %+v", info)
+		}
+		described[info.Path] = true
+
+		// Every described path is served by the OPERATIONAL target
+		path, err := ygot.StringToStructuredPath(strings.ReplaceAll(info.Path, "=*]", "=/host]"))
+		if err != nil {
+			t.Fatalf("Invalid path %s: %v", info.Path, err)
+		}
+		if _, err := NewOperationalHandler([]*gnmipb.Path{path}, &gnmipb.Path{Target: "OPERATIONAL"}); err != nil {
+			t.Errorf("Path %s is not supported: %v", info.Path, err)
+		}
+	}
+
+	for _, want := range []string{
+		"/sonic/system/certificates/expiry",
+		"/sonic/system/commit/status",
+		"/sonic/system/dpu-proxy[index=*]/status",
+		"/sonic/system/filesystem[path=*]/disk-space",
+		"/sonic/system/filesystem[path=*]/files[pattern=*]/list",
+	} {
+		if !described[want] {
+			t.Errorf("Expected %s in registered paths", want)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+// Package wildcard matches strings against shell-like wildcard patterns, where
+// '*' matches any sequence of characters and '?' any single character.
+package wildcard
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Compile converts a wildcard pattern to an anchored regular expression. Any
+// character other than '*' and '?' matches itself.
+func Compile(pattern string) *regexp.Regexp {
+	var expr strings.Builder
+	expr.WriteString("^")
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			expr.WriteString(".*")
+		case '?':
+			expr.WriteString(".")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	expr.WriteString("$")
+	return regexp.MustCompile(expr.String())
+}
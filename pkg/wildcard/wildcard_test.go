@@ -0,0 +1,28 @@
+package wildcard
+
+import "testing"
+
+func TestCompile(t *testing.T) {
+	testCases := []struct {
+		pattern string
+		input   string
+		match   bool
+	}{
+		{pattern: "*.example.org", input: "alice.example.org", match: true},
+		{pattern: "*.example.org", input: "alice.example.org.evil", match: false},
+		{pattern: "*.example.org", input: "aliceXexample.org", match: false},
+		{pattern: "host-?.lab", input: "host-1.lab", match: true},
+		{pattern: "host-?.lab", input: "host-10.lab", match: false},
+		{pattern: "Ethernet*", input: "Ethernet0", match: true},
+		{pattern: "[a-z]+", input: "[a-z]+", match: true},
+		{pattern: "[a-z]+", input: "abc", match: false},
+		{pattern: "*", input: "", match: true},
+		{pattern: "", input: "", match: true},
+		{pattern: "", input: "x", match: false},
+	}
+	for _, tc := range testCases {
+		if match := Compile(tc.pattern).MatchString(tc.input); match != tc.match {
+			t.Errorf("Compile(%q).MatchString(%q) = %v, expected %v", tc.pattern, tc.input, match, tc.match)
+		}
+	}
+}
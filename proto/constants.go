@@ -2,3 +2,5 @@ package gnmi_sonic
 
 const BUNDLE_VERSION_EXT = 700
 const SUPPORTED_VERSIONS_EXT = 701
+const DRY_RUN_EXT = 702
+const SCHEMA_EXT = 703
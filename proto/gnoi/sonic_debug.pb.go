@@ -1,6 +1,6 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // versions:
-// 	protoc-gen-go v1.26.0
+// 	protoc-gen-go v1.34.1
 // 	protoc        v3.6.1
 // source: sonic_debug.proto
 
@@ -25,6 +25,65 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// ShellSignal lists the signals which can be sent to a Shell command.
+type ShellSignal int32
+
+const (
+	ShellSignal_SIGNAL_UNSPECIFIED ShellSignal = 0
+	ShellSignal_SIGNAL_INT         ShellSignal = 1
+	ShellSignal_SIGNAL_QUIT        ShellSignal = 2
+	ShellSignal_SIGNAL_TERM        ShellSignal = 3
+	ShellSignal_SIGNAL_HUP         ShellSignal = 4
+	ShellSignal_SIGNAL_KILL        ShellSignal = 5
+)
+
+// Enum value maps for ShellSignal.
+var (
+	ShellSignal_name = map[int32]string{
+		0: "SIGNAL_UNSPECIFIED",
+		1: "SIGNAL_INT",
+		2: "SIGNAL_QUIT",
+		3: "SIGNAL_TERM",
+		4: "SIGNAL_HUP",
+		5: "SIGNAL_KILL",
+	}
+	ShellSignal_value = map[string]int32{
+		"SIGNAL_UNSPECIFIED": 0,
+		"SIGNAL_INT":         1,
+		"SIGNAL_QUIT":        2,
+		"SIGNAL_TERM":        3,
+		"SIGNAL_HUP":         4,
+		"SIGNAL_KILL":        5,
+	}
+)
+
+func (x ShellSignal) Enum() *ShellSignal {
+	p := new(ShellSignal)
+	*p = x
+	return p
+}
+
+func (x ShellSignal) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ShellSignal) Descriptor() protoreflect.EnumDescriptor {
+	return file_sonic_debug_proto_enumTypes[0].Descriptor()
+}
+
+func (ShellSignal) Type() protoreflect.EnumType {
+	return &file_sonic_debug_proto_enumTypes[0]
+}
+
+func (x ShellSignal) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ShellSignal.Descriptor instead.
+func (ShellSignal) EnumDescriptor() ([]byte, []int) {
+	return file_sonic_debug_proto_rawDescGZIP(), []int{0}
+}
+
 // Request message for GetSubscribePreferences RPC
 type SubscribePreferencesReq struct {
 	state         protoimpl.MessageState
@@ -144,7 +203,7 @@ func (x *SubscribePreference) GetTargetDefinedMode() gnmi.SubscriptionMode {
 	if x != nil {
 		return x.TargetDefinedMode
 	}
-	return gnmi.SubscriptionMode_TARGET_DEFINED
+	return gnmi.SubscriptionMode(0)
 }
 
 func (x *SubscribePreference) GetWildcardSupported() bool {
@@ -161,6 +220,410 @@ func (x *SubscribePreference) GetMinSampleInterval() uint64 {
 	return 0
 }
 
+// Request message for Shell RPC
+type ShellRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Request:
+	//	*ShellRequest_Start
+	//	*ShellRequest_Stdin
+	//	*ShellRequest_Resize
+	//	*ShellRequest_Signal
+	Request isShellRequest_Request `protobuf_oneof:"request"`
+}
+
+func (x *ShellRequest) Reset() {
+	*x = ShellRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sonic_debug_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ShellRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShellRequest) ProtoMessage() {}
+
+func (x *ShellRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sonic_debug_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShellRequest.ProtoReflect.Descriptor instead.
+func (*ShellRequest) Descriptor() ([]byte, []int) {
+	return file_sonic_debug_proto_rawDescGZIP(), []int{2}
+}
+
+func (m *ShellRequest) GetRequest() isShellRequest_Request {
+	if m != nil {
+		return m.Request
+	}
+	return nil
+}
+
+func (x *ShellRequest) GetStart() *ShellStart {
+	if x, ok := x.GetRequest().(*ShellRequest_Start); ok {
+		return x.Start
+	}
+	return nil
+}
+
+func (x *ShellRequest) GetStdin() []byte {
+	if x, ok := x.GetRequest().(*ShellRequest_Stdin); ok {
+		return x.Stdin
+	}
+	return nil
+}
+
+func (x *ShellRequest) GetResize() *WindowSize {
+	if x, ok := x.GetRequest().(*ShellRequest_Resize); ok {
+		return x.Resize
+	}
+	return nil
+}
+
+func (x *ShellRequest) GetSignal() ShellSignal {
+	if x, ok := x.GetRequest().(*ShellRequest_Signal); ok {
+		return x.Signal
+	}
+	return ShellSignal_SIGNAL_UNSPECIFIED
+}
+
+type isShellRequest_Request interface {
+	isShellRequest_Request()
+}
+
+type ShellRequest_Start struct {
+	// Starts the session, must be the first request.
+	Start *ShellStart `protobuf:"bytes,1,opt,name=start,proto3,oneof"`
+}
+
+type ShellRequest_Stdin struct {
+	// Bytes written to the terminal.
+	Stdin []byte `protobuf:"bytes,2,opt,name=stdin,proto3,oneof"`
+}
+
+type ShellRequest_Resize struct {
+	// New size of the terminal window.
+	Resize *WindowSize `protobuf:"bytes,3,opt,name=resize,proto3,oneof"`
+}
+
+type ShellRequest_Signal struct {
+	// Signal sent to the command.
+	Signal ShellSignal `protobuf:"varint,4,opt,name=signal,proto3,enum=gnoi.sonic.ShellSignal,oneof"`
+}
+
+func (*ShellRequest_Start) isShellRequest_Request() {}
+
+func (*ShellRequest_Stdin) isShellRequest_Request() {}
+
+func (*ShellRequest_Resize) isShellRequest_Request() {}
+
+func (*ShellRequest_Signal) isShellRequest_Request() {}
+
+// ShellStart describes the command run by a Shell session.
+type ShellStart struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Command to run, validated against the command whitelist of the user role.
+	Command string `protobuf:"bytes,1,opt,name=command,proto3" json:"command,omitempty"`
+	// Initial size of the terminal window.
+	Size *WindowSize `protobuf:"bytes,2,opt,name=size,proto3" json:"size,omitempty"`
+	// Maximum duration of the session, in nanoseconds.
+	Timeout int64 `protobuf:"varint,3,opt,name=timeout,proto3" json:"timeout,omitempty"`
+	// Duration without input or output ending the session, in nanoseconds.
+	IdleTimeout int64 `protobuf:"varint,4,opt,name=idle_timeout,json=idleTimeout,proto3" json:"idle_timeout,omitempty"`
+	// Role account to run the command as.
+	RoleAccount string `protobuf:"bytes,5,opt,name=role_account,json=roleAccount,proto3" json:"role_account,omitempty"`
+	// Terminal type, set as TERM environment variable.
+	Term string `protobuf:"bytes,6,opt,name=term,proto3" json:"term,omitempty"`
+}
+
+func (x *ShellStart) Reset() {
+	*x = ShellStart{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sonic_debug_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ShellStart) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShellStart) ProtoMessage() {}
+
+func (x *ShellStart) ProtoReflect() protoreflect.Message {
+	mi := &file_sonic_debug_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShellStart.ProtoReflect.Descriptor instead.
+func (*ShellStart) Descriptor() ([]byte, []int) {
+	return file_sonic_debug_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ShellStart) GetCommand() string {
+	if x != nil {
+		return x.Command
+	}
+	return ""
+}
+
+func (x *ShellStart) GetSize() *WindowSize {
+	if x != nil {
+		return x.Size
+	}
+	return nil
+}
+
+func (x *ShellStart) GetTimeout() int64 {
+	if x != nil {
+		return x.Timeout
+	}
+	return 0
+}
+
+func (x *ShellStart) GetIdleTimeout() int64 {
+	if x != nil {
+		return x.IdleTimeout
+	}
+	return 0
+}
+
+func (x *ShellStart) GetRoleAccount() string {
+	if x != nil {
+		return x.RoleAccount
+	}
+	return ""
+}
+
+func (x *ShellStart) GetTerm() string {
+	if x != nil {
+		return x.Term
+	}
+	return ""
+}
+
+// WindowSize is the size of a terminal window, in characters.
+type WindowSize struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Rows uint32 `protobuf:"varint,1,opt,name=rows,proto3" json:"rows,omitempty"`
+	Cols uint32 `protobuf:"varint,2,opt,name=cols,proto3" json:"cols,omitempty"`
+}
+
+func (x *WindowSize) Reset() {
+	*x = WindowSize{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sonic_debug_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WindowSize) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WindowSize) ProtoMessage() {}
+
+func (x *WindowSize) ProtoReflect() protoreflect.Message {
+	mi := &file_sonic_debug_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WindowSize.ProtoReflect.Descriptor instead.
+func (*WindowSize) Descriptor() ([]byte, []int) {
+	return file_sonic_debug_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *WindowSize) GetRows() uint32 {
+	if x != nil {
+		return x.Rows
+	}
+	return 0
+}
+
+func (x *WindowSize) GetCols() uint32 {
+	if x != nil {
+		return x.Cols
+	}
+	return 0
+}
+
+// Response message for Shell RPC
+type ShellResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Response:
+	//	*ShellResponse_Data
+	//	*ShellResponse_Status
+	Response isShellResponse_Response `protobuf_oneof:"response"`
+}
+
+func (x *ShellResponse) Reset() {
+	*x = ShellResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sonic_debug_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ShellResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShellResponse) ProtoMessage() {}
+
+func (x *ShellResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sonic_debug_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShellResponse.ProtoReflect.Descriptor instead.
+func (*ShellResponse) Descriptor() ([]byte, []int) {
+	return file_sonic_debug_proto_rawDescGZIP(), []int{5}
+}
+
+func (m *ShellResponse) GetResponse() isShellResponse_Response {
+	if m != nil {
+		return m.Response
+	}
+	return nil
+}
+
+func (x *ShellResponse) GetData() []byte {
+	if x, ok := x.GetResponse().(*ShellResponse_Data); ok {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *ShellResponse) GetStatus() *ShellStatus {
+	if x, ok := x.GetResponse().(*ShellResponse_Status); ok {
+		return x.Status
+	}
+	return nil
+}
+
+type isShellResponse_Response interface {
+	isShellResponse_Response()
+}
+
+type ShellResponse_Data struct {
+	// Bytes read from the terminal.
+	Data []byte `protobuf:"bytes,1,opt,name=data,proto3,oneof"`
+}
+
+type ShellResponse_Status struct {
+	// Exit status of the command, last response of the session.
+	Status *ShellStatus `protobuf:"bytes,2,opt,name=status,proto3,oneof"`
+}
+
+func (*ShellResponse_Data) isShellResponse_Response() {}
+
+func (*ShellResponse_Status) isShellResponse_Response() {}
+
+// ShellStatus is the exit status of a Shell command.
+type ShellStatus struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Exit code of the command, -1 if it did not complete.
+	Code int32 `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+	// Reason the session ended, if not the command exit.
+	Message string `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+}
+
+func (x *ShellStatus) Reset() {
+	*x = ShellStatus{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sonic_debug_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ShellStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShellStatus) ProtoMessage() {}
+
+func (x *ShellStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_sonic_debug_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShellStatus.ProtoReflect.Descriptor instead.
+func (*ShellStatus) Descriptor() ([]byte, []int) {
+	return file_sonic_debug_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ShellStatus) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *ShellStatus) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
 var File_sonic_debug_proto protoreflect.FileDescriptor
 
 var file_sonic_debug_proto_rawDesc = []byte{
@@ -189,15 +652,67 @@ var file_sonic_debug_proto_rawDesc = []byte{
 	0x72, 0x64, 0x53, 0x75, 0x70, 0x70, 0x6f, 0x72, 0x74, 0x65, 0x64, 0x12, 0x2e, 0x0a, 0x13, 0x6d,
 	0x69, 0x6e, 0x5f, 0x73, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x5f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x76,
 	0x61, 0x6c, 0x18, 0x05, 0x20, 0x01, 0x28, 0x04, 0x52, 0x11, 0x6d, 0x69, 0x6e, 0x53, 0x61, 0x6d,
-	0x70, 0x6c, 0x65, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x32, 0x6a, 0x0a, 0x05, 0x44,
-	0x65, 0x62, 0x75, 0x67, 0x12, 0x61, 0x0a, 0x17, 0x47, 0x65, 0x74, 0x53, 0x75, 0x62, 0x73, 0x63,
-	0x72, 0x69, 0x62, 0x65, 0x50, 0x72, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x73, 0x12,
-	0x23, 0x2e, 0x67, 0x6e, 0x6f, 0x69, 0x2e, 0x73, 0x6f, 0x6e, 0x69, 0x63, 0x2e, 0x53, 0x75, 0x62,
-	0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x50, 0x72, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65,
-	0x73, 0x52, 0x65, 0x71, 0x1a, 0x1f, 0x2e, 0x67, 0x6e, 0x6f, 0x69, 0x2e, 0x73, 0x6f, 0x6e, 0x69,
+	0x70, 0x6c, 0x65, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x22, 0xc6, 0x01, 0x0a, 0x0c,
+	0x53, 0x68, 0x65, 0x6c, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x2e, 0x0a, 0x05,
+	0x73, 0x74, 0x61, 0x72, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x67, 0x6e,
+	0x6f, 0x69, 0x2e, 0x73, 0x6f, 0x6e, 0x69, 0x63, 0x2e, 0x53, 0x68, 0x65, 0x6c, 0x6c, 0x53, 0x74,
+	0x61, 0x72, 0x74, 0x48, 0x00, 0x52, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x12, 0x16, 0x0a, 0x05,
+	0x73, 0x74, 0x64, 0x69, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x48, 0x00, 0x52, 0x05, 0x73,
+	0x74, 0x64, 0x69, 0x6e, 0x12, 0x30, 0x0a, 0x06, 0x72, 0x65, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x67, 0x6e, 0x6f, 0x69, 0x2e, 0x73, 0x6f, 0x6e, 0x69,
+	0x63, 0x2e, 0x57, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x53, 0x69, 0x7a, 0x65, 0x48, 0x00, 0x52, 0x06,
+	0x72, 0x65, 0x73, 0x69, 0x7a, 0x65, 0x12, 0x31, 0x0a, 0x06, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x6c,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x17, 0x2e, 0x67, 0x6e, 0x6f, 0x69, 0x2e, 0x73, 0x6f,
+	0x6e, 0x69, 0x63, 0x2e, 0x53, 0x68, 0x65, 0x6c, 0x6c, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x6c, 0x48,
+	0x00, 0x52, 0x06, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x6c, 0x42, 0x09, 0x0a, 0x07, 0x72, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x22, 0xc6, 0x01, 0x0a, 0x0a, 0x53, 0x68, 0x65, 0x6c, 0x6c, 0x53, 0x74,
+	0x61, 0x72, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x12, 0x2a, 0x0a,
+	0x04, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x67, 0x6e,
+	0x6f, 0x69, 0x2e, 0x73, 0x6f, 0x6e, 0x69, 0x63, 0x2e, 0x57, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x53,
+	0x69, 0x7a, 0x65, 0x52, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x74, 0x69, 0x6d,
+	0x65, 0x6f, 0x75, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x74, 0x69, 0x6d, 0x65,
+	0x6f, 0x75, 0x74, 0x12, 0x21, 0x0a, 0x0c, 0x69, 0x64, 0x6c, 0x65, 0x5f, 0x74, 0x69, 0x6d, 0x65,
+	0x6f, 0x75, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0b, 0x69, 0x64, 0x6c, 0x65, 0x54,
+	0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x12, 0x21, 0x0a, 0x0c, 0x72, 0x6f, 0x6c, 0x65, 0x5f, 0x61,
+	0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x72, 0x6f,
+	0x6c, 0x65, 0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x65, 0x72,
+	0x6d, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x65, 0x72, 0x6d, 0x22, 0x34, 0x0a,
+	0x0a, 0x57, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x53, 0x69, 0x7a, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x72,
+	0x6f, 0x77, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x04, 0x72, 0x6f, 0x77, 0x73, 0x12,
+	0x12, 0x0a, 0x04, 0x63, 0x6f, 0x6c, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x04, 0x63,
+	0x6f, 0x6c, 0x73, 0x22, 0x64, 0x0a, 0x0d, 0x53, 0x68, 0x65, 0x6c, 0x6c, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x14, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0c, 0x48, 0x00, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x12, 0x31, 0x0a, 0x06, 0x73, 0x74,
+	0x61, 0x74, 0x75, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x67, 0x6e, 0x6f,
+	0x69, 0x2e, 0x73, 0x6f, 0x6e, 0x69, 0x63, 0x2e, 0x53, 0x68, 0x65, 0x6c, 0x6c, 0x53, 0x74, 0x61,
+	0x74, 0x75, 0x73, 0x48, 0x00, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x42, 0x0a, 0x0a,
+	0x08, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x3b, 0x0a, 0x0b, 0x53, 0x68, 0x65,
+	0x6c, 0x6c, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x63, 0x6f, 0x64, 0x65,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x12, 0x18, 0x0a, 0x07,
+	0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6d,
+	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x2a, 0x78, 0x0a, 0x0b, 0x53, 0x68, 0x65, 0x6c, 0x6c, 0x53,
+	0x69, 0x67, 0x6e, 0x61, 0x6c, 0x12, 0x16, 0x0a, 0x12, 0x53, 0x49, 0x47, 0x4e, 0x41, 0x4c, 0x5f,
+	0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x0e, 0x0a,
+	0x0a, 0x53, 0x49, 0x47, 0x4e, 0x41, 0x4c, 0x5f, 0x49, 0x4e, 0x54, 0x10, 0x01, 0x12, 0x0f, 0x0a,
+	0x0b, 0x53, 0x49, 0x47, 0x4e, 0x41, 0x4c, 0x5f, 0x51, 0x55, 0x49, 0x54, 0x10, 0x02, 0x12, 0x0f,
+	0x0a, 0x0b, 0x53, 0x49, 0x47, 0x4e, 0x41, 0x4c, 0x5f, 0x54, 0x45, 0x52, 0x4d, 0x10, 0x03, 0x12,
+	0x0e, 0x0a, 0x0a, 0x53, 0x49, 0x47, 0x4e, 0x41, 0x4c, 0x5f, 0x48, 0x55, 0x50, 0x10, 0x04, 0x12,
+	0x0f, 0x0a, 0x0b, 0x53, 0x49, 0x47, 0x4e, 0x41, 0x4c, 0x5f, 0x4b, 0x49, 0x4c, 0x4c, 0x10, 0x05,
+	0x32, 0x6a, 0x0a, 0x05, 0x44, 0x65, 0x62, 0x75, 0x67, 0x12, 0x61, 0x0a, 0x17, 0x47, 0x65, 0x74,
+	0x53, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x50, 0x72, 0x65, 0x66, 0x65, 0x72, 0x65,
+	0x6e, 0x63, 0x65, 0x73, 0x12, 0x23, 0x2e, 0x67, 0x6e, 0x6f, 0x69, 0x2e, 0x73, 0x6f, 0x6e, 0x69,
 	0x63, 0x2e, 0x53, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x50, 0x72, 0x65, 0x66, 0x65,
-	0x72, 0x65, 0x6e, 0x63, 0x65, 0x30, 0x01, 0x42, 0x0f, 0x5a, 0x0d, 0x2e, 0x2f, 0x3b, 0x67, 0x6e,
-	0x6f, 0x69, 0x5f, 0x73, 0x6f, 0x6e, 0x69, 0x63, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x72, 0x65, 0x6e, 0x63, 0x65, 0x73, 0x52, 0x65, 0x71, 0x1a, 0x1f, 0x2e, 0x67, 0x6e, 0x6f, 0x69,
+	0x2e, 0x73, 0x6f, 0x6e, 0x69, 0x63, 0x2e, 0x53, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65,
+	0x50, 0x72, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x30, 0x01, 0x32, 0x4e, 0x0a, 0x0a,
+	0x44, 0x65, 0x62, 0x75, 0x67, 0x53, 0x68, 0x65, 0x6c, 0x6c, 0x12, 0x40, 0x0a, 0x05, 0x53, 0x68,
+	0x65, 0x6c, 0x6c, 0x12, 0x18, 0x2e, 0x67, 0x6e, 0x6f, 0x69, 0x2e, 0x73, 0x6f, 0x6e, 0x69, 0x63,
+	0x2e, 0x53, 0x68, 0x65, 0x6c, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19, 0x2e,
+	0x67, 0x6e, 0x6f, 0x69, 0x2e, 0x73, 0x6f, 0x6e, 0x69, 0x63, 0x2e, 0x53, 0x68, 0x65, 0x6c, 0x6c,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x28, 0x01, 0x30, 0x01, 0x42, 0x0f, 0x5a, 0x0d,
+	0x2e, 0x2f, 0x3b, 0x67, 0x6e, 0x6f, 0x69, 0x5f, 0x73, 0x6f, 0x6e, 0x69, 0x63, 0x62, 0x06, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -212,24 +727,38 @@ func file_sonic_debug_proto_rawDescGZIP() []byte {
 	return file_sonic_debug_proto_rawDescData
 }
 
-var file_sonic_debug_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_sonic_debug_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_sonic_debug_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
 var file_sonic_debug_proto_goTypes = []interface{}{
-	(*SubscribePreferencesReq)(nil), // 0: gnoi.sonic.SubscribePreferencesReq
-	(*SubscribePreference)(nil),     // 1: gnoi.sonic.SubscribePreference
-	(*gnmi.Path)(nil),               // 2: gnmi.Path
-	(gnmi.SubscriptionMode)(0),      // 3: gnmi.SubscriptionMode
+	(ShellSignal)(0),                // 0: gnoi.sonic.ShellSignal
+	(*SubscribePreferencesReq)(nil), // 1: gnoi.sonic.SubscribePreferencesReq
+	(*SubscribePreference)(nil),     // 2: gnoi.sonic.SubscribePreference
+	(*ShellRequest)(nil),            // 3: gnoi.sonic.ShellRequest
+	(*ShellStart)(nil),              // 4: gnoi.sonic.ShellStart
+	(*WindowSize)(nil),              // 5: gnoi.sonic.WindowSize
+	(*ShellResponse)(nil),           // 6: gnoi.sonic.ShellResponse
+	(*ShellStatus)(nil),             // 7: gnoi.sonic.ShellStatus
+	(*gnmi.Path)(nil),               // 8: gnmi.Path
+	(gnmi.SubscriptionMode)(0),      // 9: gnmi.SubscriptionMode
 }
 var file_sonic_debug_proto_depIdxs = []int32{
-	2, // 0: gnoi.sonic.SubscribePreferencesReq.path:type_name -> gnmi.Path
-	2, // 1: gnoi.sonic.SubscribePreference.path:type_name -> gnmi.Path
-	3, // 2: gnoi.sonic.SubscribePreference.target_defined_mode:type_name -> gnmi.SubscriptionMode
-	0, // 3: gnoi.sonic.Debug.GetSubscribePreferences:input_type -> gnoi.sonic.SubscribePreferencesReq
-	1, // 4: gnoi.sonic.Debug.GetSubscribePreferences:output_type -> gnoi.sonic.SubscribePreference
-	4, // [4:5] is the sub-list for method output_type
-	3, // [3:4] is the sub-list for method input_type
-	3, // [3:3] is the sub-list for extension type_name
-	3, // [3:3] is the sub-list for extension extendee
-	0, // [0:3] is the sub-list for field type_name
+	8,  // 0: gnoi.sonic.SubscribePreferencesReq.path:type_name -> gnmi.Path
+	8,  // 1: gnoi.sonic.SubscribePreference.path:type_name -> gnmi.Path
+	9,  // 2: gnoi.sonic.SubscribePreference.target_defined_mode:type_name -> gnmi.SubscriptionMode
+	4,  // 3: gnoi.sonic.ShellRequest.start:type_name -> gnoi.sonic.ShellStart
+	5,  // 4: gnoi.sonic.ShellRequest.resize:type_name -> gnoi.sonic.WindowSize
+	0,  // 5: gnoi.sonic.ShellRequest.signal:type_name -> gnoi.sonic.ShellSignal
+	5,  // 6: gnoi.sonic.ShellStart.size:type_name -> gnoi.sonic.WindowSize
+	7,  // 7: gnoi.sonic.ShellResponse.status:type_name -> gnoi.sonic.ShellStatus
+	1,  // 8: gnoi.sonic.Debug.GetSubscribePreferences:input_type -> gnoi.sonic.SubscribePreferencesReq
+	3,  // 9: gnoi.sonic.DebugShell.Shell:input_type -> gnoi.sonic.ShellRequest
+	2,  // 10: gnoi.sonic.Debug.GetSubscribePreferences:output_type -> gnoi.sonic.SubscribePreference
+	6,  // 11: gnoi.sonic.DebugShell.Shell:output_type -> gnoi.sonic.ShellResponse
+	10, // [10:12] is the sub-list for method output_type
+	8,  // [8:10] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
 }
 
 func init() { file_sonic_debug_proto_init() }
@@ -262,19 +791,90 @@ func file_sonic_debug_proto_init() {
 				return nil
 			}
 		}
+		file_sonic_debug_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ShellRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sonic_debug_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ShellStart); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sonic_debug_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*WindowSize); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sonic_debug_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ShellResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sonic_debug_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ShellStatus); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_sonic_debug_proto_msgTypes[2].OneofWrappers = []interface{}{
+		(*ShellRequest_Start)(nil),
+		(*ShellRequest_Stdin)(nil),
+		(*ShellRequest_Resize)(nil),
+		(*ShellRequest_Signal)(nil),
+	}
+	file_sonic_debug_proto_msgTypes[5].OneofWrappers = []interface{}{
+		(*ShellResponse_Data)(nil),
+		(*ShellResponse_Status)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_sonic_debug_proto_rawDesc,
-			NumEnums:      0,
-			NumMessages:   2,
+			NumEnums:      1,
+			NumMessages:   7,
 			NumExtensions: 0,
-			NumServices:   1,
+			NumServices:   2,
 		},
 		GoTypes:           file_sonic_debug_proto_goTypes,
 		DependencyIndexes: file_sonic_debug_proto_depIdxs,
+		EnumInfos:         file_sonic_debug_proto_enumTypes,
 		MessageInfos:      file_sonic_debug_proto_msgTypes,
 	}.Build()
 	File_sonic_debug_proto = out.File
@@ -393,3 +993,115 @@ var _Debug_serviceDesc = grpc.ServiceDesc{
 	},
 	Metadata: "sonic_debug.proto",
 }
+
+// DebugShellClient is the client API for DebugShell service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type DebugShellClient interface {
+	// Shell runs a command in a pseudo terminal on the host. The first request
+	// must carry a ShellStart, the following ones stream stdin, window size
+	// changes and signals. The terminal output is streamed back, followed by a
+	// ShellStatus with the exit code of the command.
+	Shell(ctx context.Context, opts ...grpc.CallOption) (DebugShell_ShellClient, error)
+}
+
+type debugShellClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewDebugShellClient(cc grpc.ClientConnInterface) DebugShellClient {
+	return &debugShellClient{cc}
+}
+
+func (c *debugShellClient) Shell(ctx context.Context, opts ...grpc.CallOption) (DebugShell_ShellClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_DebugShell_serviceDesc.Streams[0], "/gnoi.sonic.DebugShell/Shell", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &debugShellShellClient{stream}
+	return x, nil
+}
+
+type DebugShell_ShellClient interface {
+	Send(*ShellRequest) error
+	Recv() (*ShellResponse, error)
+	grpc.ClientStream
+}
+
+type debugShellShellClient struct {
+	grpc.ClientStream
+}
+
+func (x *debugShellShellClient) Send(m *ShellRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *debugShellShellClient) Recv() (*ShellResponse, error) {
+	m := new(ShellResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// DebugShellServer is the server API for DebugShell service.
+type DebugShellServer interface {
+	// Shell runs a command in a pseudo terminal on the host. The first request
+	// must carry a ShellStart, the following ones stream stdin, window size
+	// changes and signals. The terminal output is streamed back, followed by a
+	// ShellStatus with the exit code of the command.
+	Shell(DebugShell_ShellServer) error
+}
+
+// UnimplementedDebugShellServer can be embedded to have forward compatible implementations.
+type UnimplementedDebugShellServer struct {
+}
+
+func (*UnimplementedDebugShellServer) Shell(DebugShell_ShellServer) error {
+	return status.Errorf(codes.Unimplemented, "method Shell not implemented")
+}
+
+func RegisterDebugShellServer(s *grpc.Server, srv DebugShellServer) {
+	s.RegisterService(&_DebugShell_serviceDesc, srv)
+}
+
+func _DebugShell_Shell_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(DebugShellServer).Shell(&debugShellShellServer{stream})
+}
+
+type DebugShell_ShellServer interface {
+	Send(*ShellResponse) error
+	Recv() (*ShellRequest, error)
+	grpc.ServerStream
+}
+
+type debugShellShellServer struct {
+	grpc.ServerStream
+}
+
+func (x *debugShellShellServer) Send(m *ShellResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *debugShellShellServer) Recv() (*ShellRequest, error) {
+	m := new(ShellRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+var _DebugShell_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "gnoi.sonic.DebugShell",
+	HandlerType: (*DebugShellServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Shell",
+			Handler:       _DebugShell_Shell_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "sonic_debug.proto",
+}
@@ -0,0 +1,196 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	log "github.com/golang/glog"
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+	"google.golang.org/grpc/codes"
+)
+
+// SetOpError reports the operation of a Set request that failed. The entries
+// written by the operations before it are restored, unless RollbackErr is set.
+type SetOpError struct {
+	// Op is the type of the failed operation
+	Op gnmipb.UpdateResult_Operation
+
+	// Index is the index of the failed operation among the operations of its type
+	Index int
+
+	// Path is the path of the failed operation
+	Path *gnmipb.Path
+
+	// Code is InvalidArgument if the operation could not be staged and
+	// PermissionDenied if it targets a table that is not writable, in which
+	// cases nothing was written, and Internal if it could not be applied
+	Code codes.Code
+
+	// Err is the error of the operation
+	Err error
+
+	// RollbackErr is the error restoring the entries written before the failure
+	RollbackErr error
+}
+
+func (e *SetOpError) Error() string {
+	msg := fmt.Sprintf("%v %v failed: %v", e.Op, e.Path, e.Err)
+	if e.RollbackErr != nil {
+		msg += fmt.Sprintf(", rollback failed: %v", e.RollbackErr)
+	}
+	return msg
+}
+
+func (e *SetOpError) Unwrap() error {
+	return e.Err
+}
+
+// dbEntryOp is a write of a single entry.
+type dbEntryOp struct {
+	dbName string
+	table  string
+	key    string
+	dbkey  string            // Redis key of the entry
+	values map[string]string // nil deletes the entry
+}
+
+func newDbEntryOp(tblPath tablePath, key string, values map[string]string) dbEntryOp {
+	return dbEntryOp{
+		dbName: tblPath.dbName,
+		table:  tblPath.tableName,
+		key:    key,
+		dbkey:  tblPath.tableName + tblPath.delimitor + key,
+		values: values,
+	}
+}
+
+// dbSetOp is an operation of a Set request staged into entry writes.
+type dbSetOp struct {
+	op      gnmipb.UpdateResult_Operation
+	index   int
+	path    *gnmipb.Path
+	tables  []string // tables the operation targets
+	entries []dbEntryOp
+}
+
+// dbEntryUndo restores an entry written by a transaction.
+type dbEntryUndo struct {
+	entry dbEntryOp
+	prev  map[string]string // empty if the entry did not exist
+}
+
+// stageSetDB resolves every operation of a Set request into entry writes.
+func (c *MixedDbClient) stageSetDB(delete []*gnmipb.Path, replace []*gnmipb.Update, update []*gnmipb.Update) ([]dbSetOp, error) {
+	var ops []dbSetOp
+	stage := func(op gnmipb.UpdateResult_Operation, index int, path *gnmipb.Path, val *gnmipb.TypedValue) error {
+		tblPaths, err := c.getDbtablePath(path, val)
+		if err == nil {
+			var entries []dbEntryOp
+			if entries, err = c.stageTableData(tblPaths); err == nil {
+				setOp := dbSetOp{op: op, index: index, path: path, entries: entries}
+				for _, tblPath := range tblPaths {
+					setOp.tables = append(setOp.tables, tblPath.tableName)
+				}
+				ops = append(ops, setOp)
+				return nil
+			}
+		}
+		return &SetOpError{Op: op, Index: index, Path: path, Code: codes.InvalidArgument, Err: err}
+	}
+
+	/* DELETE */
+	for i, path := range delete {
+		if err := stage(gnmipb.UpdateResult_DELETE, i, path, nil); err != nil {
+			return nil, err
+		}
+	}
+	/* REPLACE */
+	for i, item := range replace {
+		if err := stage(gnmipb.UpdateResult_REPLACE, i, item.GetPath(), item.GetVal()); err != nil {
+			return nil, err
+		}
+	}
+	/* UPDATE */
+	for i, item := range update {
+		if err := stage(gnmipb.UpdateResult_UPDATE, i, item.GetPath(), item.GetVal()); err != nil {
+			return nil, err
+		}
+	}
+	return ops, nil
+}
+
+// applySetDB writes the entries of staged operations. If a write fails, the
+// entries written before it are restored in reverse order.
+func (c *MixedDbClient) applySetDB(ops []dbSetOp) error {
+	var undo []dbEntryUndo
+	for _, op := range ops {
+		for _, entry := range op.entries {
+			prev, err := c.getDbEntry(entry.dbName, entry.dbkey)
+			if err == nil {
+				if entry.values == nil {
+					err = c.DbDelTable(entry.table, entry.key)
+				} else {
+					err = c.DbSetTable(entry.table, entry.key, entry.values)
+				}
+			}
+			if err != nil {
+				log.V(2).Infof("swsscommon write failed for %v %v, key %s: %v", op.op, op.path, entry.dbkey, err)
+				return &SetOpError{
+					Op:          op.op,
+					Index:       op.index,
+					Path:        op.path,
+					Code:        codes.Internal,
+					Err:         err,
+					RollbackErr: c.rollbackSetDB(undo),
+				}
+			}
+			undo = append(undo, dbEntryUndo{entry: entry, prev: prev})
+		}
+	}
+	return nil
+}
+
+// rollbackSetDB restores the entries written by a failed transaction.
+func (c *MixedDbClient) rollbackSetDB(undo []dbEntryUndo) error {
+	var rollbackErr error
+	for i := len(undo) - 1; i >= 0; i-- {
+		entry, prev := undo[i].entry, undo[i].prev
+		log.V(2).Infof("Rollback %s", entry.dbkey)
+
+		// An entry is deleted before being restored if it did not exist or
+		// if the write added fields to it
+		var err error
+		if len(prev) == 0 || !fieldsSubset(entry.values, prev) {
+			err = c.DbDelTable(entry.table, entry.key)
+		}
+		if err == nil && len(prev) != 0 {
+			err = c.DbSetTable(entry.table, entry.key, prev)
+		}
+		if err != nil {
+			log.Errorf("Rollback of %s failed: %v", entry.dbkey, err)
+			if rollbackErr == nil {
+				rollbackErr = fmt.Errorf("%s: %v", entry.dbkey, err)
+			}
+		}
+	}
+	return rollbackErr
+}
+
+// fieldsSubset checks if every field of a is a field of b.
+func fieldsSubset(a map[string]string, b map[string]string) bool {
+	for field := range a {
+		if _, ok := b[field]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// getDbEntry returns the fields of an entry, empty if it does not exist.
+func (c *MixedDbClient) getDbEntry(dbName string, dbkey string) (map[string]string, error) {
+	redisDb, ok := RedisDbMap[c.mapkey+":"+dbName]
+	if !ok {
+		return nil, fmt.Errorf("Redis Client not present for dbName %v mapkey %v", dbName, c.mapkey)
+	}
+	return redisDb.HGetAll(context.Background(), dbkey).Result()
+}
@@ -0,0 +1,151 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/agiledragon/gomonkey/v2"
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+	"google.golang.org/grpc/codes"
+)
+
+// fakeApplDb records the writes of a MixedDbClient to an in-memory table.
+type fakeApplDb struct {
+	entries map[string]map[string]string
+	writes  []string
+	failSet map[string]bool
+	failDel map[string]bool
+}
+
+func newFakeApplDb(t *testing.T) *fakeApplDb {
+	db := &fakeApplDb{
+		entries: map[string]map[string]string{},
+		failSet: map[string]bool{},
+		failDel: map[string]bool{},
+	}
+	patches := gomonkey.ApplyMethod(reflect.TypeOf(&MixedDbClient{}), "DbSetTable",
+		func(_ *MixedDbClient, table string, key string, values map[string]string) error {
+			db.writes = append(db.writes, "SET "+key)
+			if db.failSet[key] {
+				return fmt.Errorf("set %s failed", key)
+			}
+			entry, ok := db.entries[key]
+			if !ok {
+				entry = map[string]string{}
+				db.entries[key] = entry
+			}
+			for k, v := range values {
+				entry[k] = v
+			}
+			return nil
+		})
+	patches.ApplyMethod(reflect.TypeOf(&MixedDbClient{}), "DbDelTable",
+		func(_ *MixedDbClient, table string, key string) error {
+			db.writes = append(db.writes, "DEL "+key)
+			if db.failDel[key] {
+				return fmt.Errorf("delete %s failed", key)
+			}
+			delete(db.entries, key)
+			return nil
+		})
+	patches.ApplyPrivateMethod(reflect.TypeOf(&MixedDbClient{}), "getDbEntry",
+		func(_ *MixedDbClient, dbName string, dbkey string) (map[string]string, error) {
+			prev := map[string]string{}
+			for k, v := range db.entries[dbkey[len("DASH_QOS:"):]] {
+				prev[k] = v
+			}
+			return prev, nil
+		})
+	t.Cleanup(patches.Reset)
+	return db
+}
+
+func qosEntry(key string, values map[string]string) dbEntryOp {
+	return dbEntryOp{dbName: "APPL_DB", table: "DASH_QOS", key: key, dbkey: "DASH_QOS:" + key, values: values}
+}
+
+func TestMixedDbClientApplySetDB(t *testing.T) {
+	db := newFakeApplDb(t)
+	client := MixedDbClient{target: "APPL_DB"}
+
+	ops := []dbSetOp{
+		{op: gnmipb.UpdateResult_DELETE, entries: []dbEntryOp{qosEntry("qos_01", nil)}},
+		{op: gnmipb.UpdateResult_UPDATE, entries: []dbEntryOp{qosEntry("qos_02", map[string]string{"bw": "1000"})}},
+	}
+	db.entries["qos_01"] = map[string]string{"bw": "500"}
+	if err := client.applySetDB(ops); err != nil {
+		t.Fatalf("applySetDB failed: %v", err)
+	}
+	want := map[string]map[string]string{"qos_02": {"bw": "1000"}}
+	if !reflect.DeepEqual(db.entries, want) {
+		t.Errorf("Expected %v, got %v", want, db.entries)
+	}
+}
+
+func TestMixedDbClientApplySetDBRollback(t *testing.T) {
+	db := newFakeApplDb(t)
+	client := MixedDbClient{target: "APPL_DB"}
+
+	db.entries["qos_01"] = map[string]string{"bw": "500"}
+	db.entries["qos_02"] = map[string]string{"bw": "600", "flows": "10"}
+	db.failSet["qos_04"] = true
+	original := map[string]map[string]string{
+		"qos_01": {"bw": "500"},
+		"qos_02": {"bw": "600", "flows": "10"},
+	}
+
+	path := &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "DASH_QOS"}, {Name: "qos_04"}}}
+	ops := []dbSetOp{
+		{op: gnmipb.UpdateResult_DELETE, entries: []dbEntryOp{qosEntry("qos_01", nil)}},
+		{op: gnmipb.UpdateResult_REPLACE, entries: []dbEntryOp{
+			qosEntry("qos_02", map[string]string{"bw": "700"}),
+			qosEntry("qos_03", map[string]string{"bw": "800"}),
+		}},
+		{op: gnmipb.UpdateResult_UPDATE, index: 1, path: path, entries: []dbEntryOp{
+			qosEntry("qos_02", map[string]string{"burst": "10"}),
+			qosEntry("qos_04", map[string]string{"bw": "900"}),
+		}},
+	}
+
+	err := client.applySetDB(ops)
+	var opErr *SetOpError
+	if !errors.As(err, &opErr) {
+		t.Fatalf("Expected SetOpError, got %v", err)
+	}
+	if opErr.Op != gnmipb.UpdateResult_UPDATE || opErr.Index != 1 || opErr.Path != path ||
+		opErr.Code != codes.Internal || opErr.RollbackErr != nil {
+		t.Errorf("Unexpected SetOpError %+v", opErr)
+	}
+	if !reflect.DeepEqual(db.entries, original) {
+		t.Errorf("Expected entries restored to %v, got %v", original, db.entries)
+	}
+
+	// Entries only updated with existing fields are restored without a delete
+	wantWrites := []string{
+		"DEL qos_01", "SET qos_02", "SET qos_03", "SET qos_02", "SET qos_04",
+		"DEL qos_02", "SET qos_02", "DEL qos_03", "SET qos_02", "SET qos_01",
+	}
+	if !reflect.DeepEqual(db.writes, wantWrites) {
+		t.Errorf("Expected writes %v, got %v", wantWrites, db.writes)
+	}
+}
+
+func TestMixedDbClientApplySetDBRollbackFailure(t *testing.T) {
+	db := newFakeApplDb(t)
+	client := MixedDbClient{target: "APPL_DB"}
+
+	db.failSet["qos_02"] = true
+	db.failDel["qos_01"] = true
+	ops := []dbSetOp{
+		{op: gnmipb.UpdateResult_UPDATE, entries: []dbEntryOp{qosEntry("qos_01", map[string]string{"bw": "500"})}},
+		{op: gnmipb.UpdateResult_UPDATE, index: 1, entries: []dbEntryOp{qosEntry("qos_02", map[string]string{"bw": "600"})}},
+	}
+
+	err := client.applySetDB(ops)
+	var opErr *SetOpError
+	if !errors.As(err, &opErr) || opErr.Index != 1 || opErr.RollbackErr == nil {
+		t.Fatalf("Expected SetOpError with rollback error, got %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	log "github.com/golang/glog"
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc/codes"
+)
+
+// WRITE_ALLOWLIST_TABLE is the CONFIG_DB table listing the tables native Set
+// can write in databases other than CONFIG_DB, APPL_DB and DPU_APPL_DB. The key
+// is the database name and the tables@ field the list of writable tables, e.g.
+//
+//	GNMI_DB_WRITE_ALLOWLIST|STATE_DB  tables@=MAINTENANCE_TABLE,FOO_TABLE
+//
+// Writing these databases requires the gnmi_<db>_readwrite role, e.g.
+// gnmi_state_db_readwrite.
+const WRITE_ALLOWLIST_TABLE string = "GNMI_DB_WRITE_ALLOWLIST"
+
+// getWritableTables returns the allowlisted tables of the target database.
+func (c *MixedDbClient) getWritableTables() (map[string]bool, error) {
+	configDb, ok := RedisDbMap[c.mapkey+":CONFIG_DB"]
+	if !ok {
+		log.V(2).Infof("No CONFIG_DB for mapkey %v, no writable tables", c.mapkey)
+		return nil, nil
+	}
+
+	key := WRITE_ALLOWLIST_TABLE + "|" + c.target
+	val, err := configDb.HGet(context.Background(), key, "tables@").Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", key, err)
+	}
+
+	tables := map[string]bool{}
+	for _, table := range strings.Split(val, ",") {
+		if table = strings.TrimSpace(table); table != "" {
+			tables[table] = true
+		}
+	}
+	return tables, nil
+}
+
+// SetAllowlistedDB applies a Set request to a database whose writable tables
+// are allowlisted in CONFIG_DB, such as STATE_DB. All entries are written in a
+// single MULTI/EXEC transaction; a replace overwrites each entry it sets.
+func (c *MixedDbClient) SetAllowlistedDB(delete []*gnmipb.Path, replace []*gnmipb.Update, update []*gnmipb.Update) error {
+	allowed, err := c.getWritableTables()
+	if err != nil {
+		return err
+	}
+	if len(allowed) == 0 {
+		return fmt.Errorf("Set RPC does not support %v", c.target)
+	}
+
+	ops, err := c.stageSetDB(delete, replace, update)
+	if err != nil {
+		return err
+	}
+	for _, op := range ops {
+		for _, table := range op.tables {
+			if !allowed[table] {
+				return &SetOpError{
+					Op:    op.op,
+					Index: op.index,
+					Path:  op.path,
+					Code:  codes.PermissionDenied,
+					Err:   fmt.Errorf("table %s of %s is not writable", table, c.target),
+				}
+			}
+		}
+	}
+	return c.writeDbTx(ops)
+}
+
+// writeDbTx writes the entries of staged operations in a single transaction.
+func (c *MixedDbClient) writeDbTx(ops []dbSetOp) error {
+	redisDb, ok := RedisDbMap[c.mapkey+":"+c.target]
+	if !ok {
+		return fmt.Errorf("Redis Client not present for dbName %v mapkey %v", c.target, c.mapkey)
+	}
+
+	ctx := context.Background()
+	var cmdOps []int // index of the operation of each queued command
+	queued := 0
+	for _, op := range ops {
+		queued += len(op.entries)
+	}
+	if queued == 0 {
+		return nil
+	}
+
+	cmds, err := redisDb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, op := range ops {
+			for _, entry := range op.entries {
+				if entry.values == nil || op.op == gnmipb.UpdateResult_REPLACE {
+					pipe.Del(ctx, entry.dbkey)
+					cmdOps = append(cmdOps, i)
+				}
+				if len(entry.values) != 0 {
+					args := make([]interface{}, 0, 2*len(entry.values))
+					for field, value := range entry.values {
+						args = append(args, field, value)
+					}
+					pipe.HSet(ctx, entry.dbkey, args...)
+					cmdOps = append(cmdOps, i)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		failed := ops[0]
+		for i, cmd := range cmds {
+			if cmd.Err() != nil && i < len(cmdOps) {
+				failed = ops[cmdOps[i]]
+				break
+			}
+		}
+		log.V(2).Infof("%s transaction failed at %v %v: %v", c.target, failed.op, failed.path, err)
+		return &SetOpError{Op: failed.op, Index: failed.index, Path: failed.path, Code: codes.Internal, Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package client
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/agiledragon/gomonkey/v2"
+	"github.com/alicebob/miniredis/v2"
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc/codes"
+)
+
+// setupAllowlistDbs serves CONFIG_DB and STATE_DB of mapkey "test:" from miniredis.
+func setupAllowlistDbs(t *testing.T) (*miniredis.Miniredis, *miniredis.Miniredis) {
+	configDb := miniredis.RunT(t)
+	stateDb := miniredis.RunT(t)
+
+	saved := RedisDbMap
+	RedisDbMap = map[string]*redis.Client{
+		"test::CONFIG_DB": redis.NewClient(&redis.Options{Addr: configDb.Addr()}),
+		"test::STATE_DB":  redis.NewClient(&redis.Options{Addr: stateDb.Addr()}),
+	}
+	t.Cleanup(func() { RedisDbMap = saved })
+	return configDb, stateDb
+}
+
+func stateEntry(table string, key string, values map[string]string) dbEntryOp {
+	return dbEntryOp{dbName: "STATE_DB", table: table, key: key, dbkey: table + "|" + key, values: values}
+}
+
+func TestMixedDbClientGetWritableTables(t *testing.T) {
+	configDb, _ := setupAllowlistDbs(t)
+	client := MixedDbClient{target: "STATE_DB", mapkey: "test:"}
+
+	tables, err := client.getWritableTables()
+	if err != nil || len(tables) != 0 {
+		t.Errorf("Expected no writable tables, got %v, %v", tables, err)
+	}
+
+	configDb.HSet(WRITE_ALLOWLIST_TABLE+"|STATE_DB", "tables@", "MAINTENANCE_TABLE, FOO_TABLE")
+	tables, err = client.getWritableTables()
+	want := map[string]bool{"MAINTENANCE_TABLE": true, "FOO_TABLE": true}
+	if err != nil || !reflect.DeepEqual(tables, want) {
+		t.Errorf("Expected %v, got %v, %v", want, tables, err)
+	}
+
+	// Allowlists are per database
+	client.target = "COUNTERS_DB"
+	if tables, _ := client.getWritableTables(); len(tables) != 0 {
+		t.Errorf("Expected no writable COUNTERS_DB tables, got %v", tables)
+	}
+}
+
+func TestMixedDbClientSetAllowlistedDB(t *testing.T) {
+	configDb, stateDb := setupAllowlistDbs(t)
+	client := MixedDbClient{target: "STATE_DB", mapkey: "test:"}
+
+	if err := client.SetAllowlistedDB(nil, nil, nil); err == nil || !strings.Contains(err.Error(), "Set RPC does not support STATE_DB") {
+		t.Errorf("Expected STATE_DB without allowlist to be rejected, got %v", err)
+	}
+	configDb.HSet(WRITE_ALLOWLIST_TABLE+"|STATE_DB", "tables@", "MAINTENANCE_TABLE")
+
+	stateDb.HSet("MAINTENANCE_TABLE|Ethernet0", "reason", "old", "owner", "ops")
+	stateDb.HSet("MAINTENANCE_TABLE|Ethernet4", "reason", "old")
+	ops := []dbSetOp{
+		{op: gnmipb.UpdateResult_DELETE, tables: []string{"MAINTENANCE_TABLE"},
+			entries: []dbEntryOp{stateEntry("MAINTENANCE_TABLE", "Ethernet4", nil)}},
+		{op: gnmipb.UpdateResult_REPLACE, tables: []string{"MAINTENANCE_TABLE"},
+			entries: []dbEntryOp{stateEntry("MAINTENANCE_TABLE", "Ethernet0", map[string]string{"reason": "upgrade"})}},
+		{op: gnmipb.UpdateResult_UPDATE, tables: []string{"MAINTENANCE_TABLE"},
+			entries: []dbEntryOp{stateEntry("MAINTENANCE_TABLE", "Ethernet8", map[string]string{"reason": "rma"})}},
+	}
+	mock := gomonkey.ApplyPrivateMethod(reflect.TypeOf(&MixedDbClient{}), "stageSetDB",
+		func(_ *MixedDbClient, delete []*gnmipb.Path, replace []*gnmipb.Update, update []*gnmipb.Update) ([]dbSetOp, error) {
+			return ops, nil
+		})
+	defer mock.Reset()
+
+	if err := client.SetAllowlistedDB(nil, nil, nil); err != nil {
+		t.Fatalf("SetAllowlistedDB failed: %v", err)
+	}
+	if stateDb.Exists("MAINTENANCE_TABLE|Ethernet4") {
+		t.Errorf("Expected MAINTENANCE_TABLE|Ethernet4 deleted")
+	}
+	if got, _ := stateDb.HKeys("MAINTENANCE_TABLE|Ethernet0"); !reflect.DeepEqual(got, []string{"reason"}) {
+		t.Errorf("Expected MAINTENANCE_TABLE|Ethernet0 replaced, got fields %v", got)
+	}
+	if got := stateDb.HGet("MAINTENANCE_TABLE|Ethernet8", "reason"); got != "rma" {
+		t.Errorf("Expected MAINTENANCE_TABLE|Ethernet8 updated, got %q", got)
+	}
+
+	// Nothing is written if a table is not allowlisted
+	ops = append(ops, dbSetOp{op: gnmipb.UpdateResult_UPDATE, index: 1, tables: []string{"PORT_TABLE"},
+		entries: []dbEntryOp{stateEntry("PORT_TABLE", "Ethernet0", map[string]string{"oper_status": "down"})}})
+	stateDb.HSet("MAINTENANCE_TABLE|Ethernet4", "reason", "old")
+	err := client.SetAllowlistedDB(nil, nil, nil)
+	var opErr *SetOpError
+	if !errors.As(err, &opErr) || opErr.Code != codes.PermissionDenied || opErr.Index != 1 {
+		t.Fatalf("Expected PermissionDenied SetOpError, got %v", err)
+	}
+	if !stateDb.Exists("MAINTENANCE_TABLE|Ethernet4") || stateDb.Exists("PORT_TABLE|Ethernet0") {
+		t.Errorf("Expected no write for a rejected request")
+	}
+}
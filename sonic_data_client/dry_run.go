@@ -0,0 +1,109 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync/atomic"
+
+	log "github.com/golang/glog"
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+	ssc "github.com/sonic-net/sonic-gnmi/sonic_service_client"
+)
+
+// DryRunResult is the outcome of a Set request validated without being applied.
+type DryRunResult struct {
+	// Patch is the JSON patch the Set request would apply
+	Patch json.RawMessage `json:"patch"`
+
+	// Errors lists the validation errors of the resulting configuration
+	Errors []string `json:"errors,omitempty"`
+}
+
+// DryRunClient is implemented by clients that can validate a Set request
+// without applying it.
+type DryRunClient interface {
+	DryRunSet(delete []*gnmipb.Path, replace []*gnmipb.Update, update []*gnmipb.Update) (*DryRunResult, error)
+}
+
+var dryRunSeq uint64
+
+// dryRunCheckpoint returns a checkpoint name unique to a dry-run, so that
+// dry-runs do not overwrite or delete the checkpoint of concurrent Set requests.
+func dryRunCheckpoint() string {
+	return fmt.Sprintf("%s/config-dryrun-%d-%d", CHECK_POINT_PATH, os.Getpid(), atomic.AddUint64(&dryRunSeq, 1))
+}
+
+// validateConfig validates a CONFIG_DB configuration against the SONiC YANG
+// models, as a full configuration Set does.
+func (c *MixedDbClient) validateConfig(config map[string]interface{}) error {
+	content, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	// Concurrent dry-runs validate their own file
+	f, err := os.CreateTemp(c.workPath, "config_db.json.dryrun-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.Write(content)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return validateYangFile(f.Name())
+}
+
+// DryRunSet translates an incremental CONFIG_DB Set request into the JSON patch
+// GCU would apply and validates the resulting configuration, without applying it.
+func (c *MixedDbClient) DryRunSet(delete []*gnmipb.Path, replace []*gnmipb.Update, update []*gnmipb.Update) (*DryRunResult, error) {
+	if c.target != "CONFIG_DB" {
+		return nil, fmt.Errorf("Set dry-run does not support %v", c.target)
+	}
+
+	// Full configuration Set requests are not translated into a patch
+	paths := append([]*gnmipb.Path{}, delete...)
+	for _, u := range replace {
+		paths = append(paths, u.GetPath())
+	}
+	for _, u := range update {
+		paths = append(paths, u.GetPath())
+	}
+	for _, path := range paths {
+		fullPath, err := c.gnmiFullPath(c.prefix, path)
+		if err != nil {
+			return nil, err
+		}
+		if len(fullPath.GetElem()) == 0 {
+			return nil, fmt.Errorf("Set dry-run does not support full configuration")
+		}
+	}
+
+	sc, err := ssc.NewDbusClient()
+	if err != nil {
+		return nil, err
+	}
+
+	// The checkpoint of real Set requests may be in use
+	patchList, err := c.buildIncrementalPatch(sc, dryRunCheckpoint(), delete, replace, update)
+	if err != nil {
+		return nil, err
+	}
+	if patchList == nil {
+		patchList = [](map[string]interface{}){}
+	}
+	text, err := json.Marshal(patchList)
+	if err != nil {
+		return nil, err
+	}
+	log.V(2).Infof("Dry-run JsonPatch: %s", text)
+
+	result := &DryRunResult{Patch: text}
+	if err := c.validateConfig(c.jClient.jsonData); err != nil {
+		result.Errors = append(result.Errors, err.Error())
+	}
+	return result, nil
+}
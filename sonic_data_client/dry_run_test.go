@@ -0,0 +1,134 @@
+package client
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/agiledragon/gomonkey/v2"
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+	sdcfg "github.com/sonic-net/sonic-gnmi/sonic_db_config"
+	ssc "github.com/sonic-net/sonic-gnmi/sonic_service_client"
+)
+
+func TestMixedDbClientDryRunSetUnsupported(t *testing.T) {
+	prefix := &gnmipb.Path{
+		Origin: "sonic-db",
+		Elem:   []*gnmipb.PathElem{{Name: "CONFIG_DB"}, {Name: "localhost"}},
+	}
+
+	client := MixedDbClient{target: "APPL_DB", prefix: prefix}
+	if _, err := client.DryRunSet(nil, nil, nil); err == nil || !strings.Contains(err.Error(), "APPL_DB") {
+		t.Errorf("Expected dry-run of APPL_DB to fail, got %v", err)
+	}
+
+	client = MixedDbClient{target: "CONFIG_DB", prefix: prefix}
+	replace := []*gnmipb.Update{{
+		Path: &gnmipb.Path{Elem: []*gnmipb.PathElem{}},
+		Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_JsonIetfVal{JsonIetfVal: []byte(`{}`)}},
+	}}
+	if _, err := client.DryRunSet(nil, replace, nil); err == nil || !strings.Contains(err.Error(), "full configuration") {
+		t.Errorf("Expected dry-run of full configuration to fail, got %v", err)
+	}
+}
+
+func TestMixedDbClientValidateConfig(t *testing.T) {
+	client := MixedDbClient{workPath: t.TempDir()}
+	config := map[string]interface{}{"VLAN": map[string]interface{}{"Vlan5000": map[string]interface{}{"vlanid": "5000"}}}
+
+	mock := gomonkey.ApplyFunc(RunPyCode, func(text string) error {
+		return nil
+	})
+	if err := client.validateConfig(config); err != nil {
+		t.Errorf("Expected valid configuration, got %v", err)
+	}
+	mock.Reset()
+
+	var files []string
+	mock = gomonkey.ApplyFunc(RunPyCode, func(text string) error {
+		fileName := regexp.MustCompile(`filename = "(.*)"`).FindStringSubmatch(text)[1]
+		files = append(files, fileName)
+		ioutil.WriteFile(fileName+".err", []byte("Vlan5000: vlanid out of range\n"), 0644)
+		return fmt.Errorf("Python failure")
+	})
+	defer mock.Reset()
+	err := client.validateConfig(config)
+	if err == nil || !strings.Contains(err.Error(), "vlanid out of range") {
+		t.Errorf("Expected validation error, got %v", err)
+	}
+
+	// Each dry-run validates its own file, removed afterwards
+	client.validateConfig(config)
+	if len(files) != 2 || files[0] == files[1] {
+		t.Errorf("Expected a file per dry-run, got %v", files)
+	}
+	if left, _ := ioutil.ReadDir(client.workPath); len(left) != 0 {
+		t.Errorf("Expected dry-run files to be removed, got %d files", len(left))
+	}
+}
+
+// checkpointService records the checkpoints of a dry-run, stored as empty
+// configurations.
+type checkpointService struct {
+	ssc.FakeClient
+	created []string
+	deleted []string
+}
+
+func (f *checkpointService) CreateCheckPoint(cpName string) error {
+	f.created = append(f.created, cpName)
+	return ioutil.WriteFile(cpName+".cp.json", []byte(`{}`), 0644)
+}
+
+func (f *checkpointService) DeleteCheckPoint(cpName string) error {
+	f.deleted = append(f.deleted, cpName)
+	return os.Remove(cpName + ".cp.json")
+}
+
+func TestDryRunCheckpoint(t *testing.T) {
+	first, second := dryRunCheckpoint(), dryRunCheckpoint()
+	if first == second || first == CHECK_POINT_PATH+"/config" || filepath.Dir(first) != CHECK_POINT_PATH {
+		t.Errorf("Expected unique dry-run checkpoints in %s, got %s and %s", CHECK_POINT_PATH, first, second)
+	}
+}
+
+func TestMixedDbClientDryRunSetCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+	svc := &checkpointService{}
+	n := 0
+	patches := gomonkey.ApplyFuncReturn(ssc.NewDbusClient, svc, nil)
+	patches.ApplyFuncReturn(sdcfg.CheckDbMultiNamespace, false, nil)
+	patches.ApplyFuncReturn(RunPyCode, nil)
+	patches.ApplyFunc(dryRunCheckpoint, func() string {
+		n++
+		return fmt.Sprintf("%s/config-dryrun-%d", dir, n)
+	})
+	defer patches.Reset()
+
+	prefix := &gnmipb.Path{
+		Origin: "sonic-db",
+		Elem:   []*gnmipb.PathElem{{Name: "CONFIG_DB"}, {Name: "localhost"}},
+	}
+	client := MixedDbClient{target: "CONFIG_DB", prefix: prefix, workPath: dir}
+	update := []*gnmipb.Update{{
+		Path: &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "VLAN"}, {Name: "Vlan10"}}},
+		Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_JsonIetfVal{JsonIetfVal: []byte(`{"vlanid":"10"}`)}},
+	}}
+	for i := 0; i < 2; i++ {
+		result, err := client.DryRunSet(nil, nil, update)
+		if err != nil || !strings.Contains(string(result.Patch), "/VLAN/Vlan10") {
+			t.Fatalf("Unexpected dry-run result %v, err %v", result, err)
+		}
+	}
+
+	// Each dry-run uses its own checkpoint, never the one of Set requests
+	want := []string{dir + "/config-dryrun-1", dir + "/config-dryrun-2"}
+	if !reflect.DeepEqual(svc.created, want) || !reflect.DeepEqual(svc.deleted, want) {
+		t.Errorf("Expected checkpoints %v, got created %v, deleted %v", want, svc.created, svc.deleted)
+	}
+}
@@ -58,6 +58,11 @@ const TEST_EVENT = "{\"sonic-host:device-test-event"
 const PARAM_HEARTBEAT = "heartbeat"
 const PARAM_QSIZE = "qsize"
 const PARAM_USE_CACHE = "usecache"
+const PARAM_SOURCE = "source"
+const PARAM_TAG = "tag"
+const PARAM_RESUME = "resume"
+const PARAM_COUNT = "count"
+const PARAM_SINCE = "since"
 
 type EventClient struct {
 	prefix *gnmipb.Path
@@ -83,6 +88,19 @@ type EventClient struct {
 	last_latency_full  bool
 
 	last_errors uint64
+
+	// Server side filter on event source and tag
+	filter eventFilter
+
+	// Replay events after this sequence number before streaming live events
+	resume_seq  uint64
+	resume      bool
+	last_replay uint64
+
+	// Recent events selection for Get, ONCE and POLL
+	count       int
+	since       time.Time
+	last_polled uint64
 }
 
 func Set_heartbeat(val int) {
@@ -141,6 +159,8 @@ func NewEventClient(paths []*gnmipb.Path, prefix *gnmipb.Path, logLevel int) (Cl
 					use_cache = false
 					log.V(7).Infof("Cache use is turned off")
 				}
+			} else if err := parse_query_param(&evtc, k, v); err != nil {
+				return nil, err
 			}
 		}
 	}
@@ -174,6 +194,64 @@ func NewEventClient(paths []*gnmipb.Path, prefix *gnmipb.Path, logLevel int) (Cl
 	return &evtc, nil
 }
 
+// NewEventCacheClient returns an EventClient serving Get, ONCE and POLL
+// requests from the recent events retained by the events journal.
+// It does not subscribe to eventd.
+func NewEventCacheClient(paths []*gnmipb.Path, prefix *gnmipb.Path) (Client, error) {
+	var evtc EventClient
+	evtc.prefix = prefix
+
+	for _, path := range paths {
+		// Only one path is expected. Take the last if many
+		evtc.path = path
+	}
+
+	for _, e := range evtc.path.GetElem() {
+		for k, v := range e.GetKey() {
+			if err := parse_query_param(&evtc, k, v); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	log.V(7).Infof("NewEventCacheClient constructed. count=%d since=%v", evtc.count, evtc.since)
+	return &evtc, nil
+}
+
+// parse_query_param handles the path keys selecting which events are sent.
+// Unknown keys are ignored.
+func parse_query_param(evtc *EventClient, k string, v string) error {
+	switch k {
+	case PARAM_SOURCE:
+		add_filter_values(&evtc.filter.sources, v)
+		log.V(7).Infof("Events filtered by source %v", v)
+	case PARAM_TAG:
+		add_filter_values(&evtc.filter.tags, v)
+		log.V(7).Infof("Events filtered by tag %v", v)
+	case PARAM_RESUME:
+		val, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid %s value %q: expecting a sequence number", PARAM_RESUME, v)
+		}
+		evtc.resume_seq = val
+		evtc.resume = true
+		log.V(7).Infof("Events resume requested after sequence %v", val)
+	case PARAM_COUNT:
+		val, err := strconv.Atoi(v)
+		if err != nil || val <= 0 {
+			return fmt.Errorf("invalid %s value %q: expecting a positive number", PARAM_COUNT, v)
+		}
+		evtc.count = val
+	case PARAM_SINCE:
+		t, err := parse_since(v)
+		if err != nil {
+			return err
+		}
+		evtc.since = t
+	}
+	return nil
+}
+
 func compute_latency(evtc *EventClient) {
 	if evtc.last_latency_full {
 		var total uint64 = 0
@@ -317,6 +395,66 @@ func C_deinit_subs(h unsafe.Pointer) {
 	C.events_deinit_subscriber_wrap(h)
 }
 
+// StartEventsCollector starts a process wide eventd subscriber that feeds the
+// events journal, so that recent events can be served to Get, ONCE and POLL
+// requests while no client is streaming. The returned function stops the
+// collector and releases its subscriber.
+func StartEventsCollector() (stop func()) {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		collect_events(done)
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			wg.Wait()
+		})
+	}
+}
+
+func collect_events(done <-chan struct{}) {
+	str_ptr := C.malloc(C.sizeof_char * C.size_t(EVENT_BUFFSZ))
+	defer C.free(unsafe.Pointer(str_ptr))
+
+	op_ptr := (*C.event_receive_op_C_t)(C.malloc(C.size_t(unsafe.Sizeof(C.event_receive_op_C_t{}))))
+	defer C.free(unsafe.Pointer(op_ptr))
+
+	op_ptr.event_str = (*C.char)(str_ptr)
+	op_ptr.event_sz = C.uint32_t(EVENT_BUFFSZ)
+
+	/* The eventd offline cache is left to streaming clients */
+	h := C_init_subs(false)
+	defer C_deinit_subs(h)
+	log.V(1).Infof("Events collector started")
+
+	for {
+		/* The receive times out after SUBSCRIBER_TIMEOUT, so a stop is noticed */
+		select {
+		case <-done:
+			log.V(1).Infof("Events collector stopped")
+			return
+		default:
+		}
+
+		rc := (int)(C.event_receive_wrap(h, op_ptr))
+		if rc != 0 {
+			continue
+		}
+		evt_str := C.GoString((*C.char)(op_ptr.event_str))
+		if strings.HasPrefix(evt_str, TEST_EVENT) || strings.HasPrefix(evt_str, EVENTD_PUBLISHER_SOURCE) {
+			continue
+		}
+		if _, err := evtJournal.record(evt_str, (int64)(op_ptr.publish_epoch_ms)); err != nil {
+			log.V(1).Infof("Events collector failed to record %v: %v", evt_str, err)
+		}
+	}
+}
+
 func get_events(evtc *EventClient) {
 	defer evtc.wg.Done()
 
@@ -346,18 +484,17 @@ func get_events(evtc *EventClient) {
 				qlen := evtc.q.Len()
 
 				if qlen < evtc.pq_max {
-					var fvp map[string]interface{}
-					json.Unmarshal([]byte(evt.Event_str), &fvp)
-
-					jv, err := json.Marshal(fvp)
+					jv, skip, err := process_event(evtc, evt)
 
 					if err == nil {
-						evtTv := &gnmipb.TypedValue{
-							Value: &gnmipb.TypedValue_JsonIetfVal{
-								JsonIetfVal: jv,
-							}}
-						if err := send_event(evtc, evtTv, evt.Publish_epoch_ms); err != nil {
-							break
+						if !skip {
+							evtTv := &gnmipb.TypedValue{
+								Value: &gnmipb.TypedValue_JsonIetfVal{
+									JsonIetfVal: jv,
+								}}
+							if err := send_event(evtc, evtTv, evt.Publish_epoch_ms); err != nil {
+								break
+							}
 						}
 					} else {
 						log.V(1).Infof("Invalid event string: %v", evt.Event_str)
@@ -388,6 +525,27 @@ func get_events(evtc *EventClient) {
 	evtc.stopMutex.RUnlock()
 }
 
+// process_event journals a received event and returns the JSON to send.
+// skip is set for events rejected by the client filter or already replayed.
+func process_event(evtc *EventClient, evt Evt_rcvd) (jv []byte, skip bool, err error) {
+	if strings.HasPrefix(evt.Event_str, EVENTD_PUBLISHER_SOURCE) {
+		// Heartbeats are neither filtered nor journaled
+		var fvp map[string]interface{}
+		json.Unmarshal([]byte(evt.Event_str), &fvp)
+		jv, err = json.Marshal(fvp)
+		return jv, false, err
+	}
+
+	entry, err := evtJournal.record(evt.Event_str, evt.Publish_epoch_ms)
+	if err != nil {
+		return nil, false, err
+	}
+	if entry.seq <= evtc.last_replay || !evtc.filter.match(entry.source, entry.tag) {
+		return nil, true, nil
+	}
+	return entry.json, false, nil
+}
+
 func send_event(evtc *EventClient, tv *gnmipb.TypedValue,
 	timestamp int64) error {
 	spbv := &spb.Value{
@@ -404,6 +562,30 @@ func send_event(evtc *EventClient, tv *gnmipb.TypedValue,
 	return nil
 }
 
+// replay_events sends the journaled events published after the sequence
+// number requested by the client, before any live event is streamed.
+func replay_events(evtc *EventClient) error {
+	entries, missed := evtJournal.since(evtc.resume_seq)
+	if missed {
+		log.V(1).Infof("%v resume after sequence %v: some events are no longer available for replay", evtc, evtc.resume_seq)
+	}
+	for _, entry := range entries {
+		evtc.last_replay = entry.seq
+		if !evtc.filter.match(entry.source, entry.tag) {
+			continue
+		}
+		evtTv := &gnmipb.TypedValue{
+			Value: &gnmipb.TypedValue_JsonIetfVal{
+				JsonIetfVal: entry.json,
+			}}
+		if err := send_event(evtc, evtTv, entry.timestamp); err != nil {
+			return err
+		}
+	}
+	log.V(3).Infof("%v replayed events up to sequence %v", evtc, evtc.last_replay)
+	return nil
+}
+
 func (evtc *EventClient) StreamRun(q *queue.PriorityQueue, stop chan struct{}, wg *sync.WaitGroup, subscribe *gnmipb.SubscriptionList) {
 
 	evtc.wg = wg
@@ -412,6 +594,12 @@ func (evtc *EventClient) StreamRun(q *queue.PriorityQueue, stop chan struct{}, w
 	evtc.q = q
 	evtc.channel = stop
 
+	if evtc.resume {
+		if err := replay_events(evtc); err != nil {
+			log.V(1).Infof("%v failed to replay events: %v", evtc, err)
+		}
+	}
+
 	go get_events(evtc)
 	evtc.wg.Add(1)
 	go update_stats(evtc)
@@ -436,16 +624,78 @@ func (evtc *EventClient) isStopped() bool {
 	return val == 1
 }
 
+// recent_values returns the recent events selected by the client as values,
+// oldest first. Only events after sequence number after are included.
+func recent_values(evtc *EventClient, after uint64) []*spb.Value {
+	var values []*spb.Value
+	for _, entry := range evtJournal.latest(&evtc.filter, after, evtc.since, evtc.count) {
+		values = append(values, &spb.Value{
+			Prefix:    evtc.prefix,
+			Path:      evtc.path,
+			Timestamp: entry.timestamp,
+			Val: &gnmipb.TypedValue{
+				Value: &gnmipb.TypedValue_JsonIetfVal{
+					JsonIetfVal: entry.json,
+				}},
+		})
+		evtc.last_polled = entry.seq
+	}
+	return values
+}
+
+// send_recent enqueues the recent events after sequence number after,
+// followed by a sync response.
+func send_recent(evtc *EventClient, after uint64) {
+	for _, spbv := range recent_values(evtc, after) {
+		if err := evtc.q.Put(Value{spbv}); err != nil {
+			log.V(3).Infof("Queue error:  %v", err)
+			return
+		}
+	}
+	evtc.q.Put(Value{
+		&spb.Value{
+			Timestamp:    time.Now().UnixNano(),
+			SyncResponse: true,
+		},
+	})
+}
+
 func (evtc *EventClient) Get(wg *sync.WaitGroup) ([]*spb.Value, error) {
-	return nil, nil
+	return recent_values(evtc, 0), nil
 }
 
 func (evtc *EventClient) OnceRun(q *queue.PriorityQueue, once chan struct{}, wg *sync.WaitGroup, subscribe *gnmipb.SubscriptionList) {
-	return
+	evtc.wg = wg
+	defer evtc.wg.Done()
+
+	evtc.q = q
+	evtc.channel = once
+
+	_, more := <-evtc.channel
+	if !more {
+		log.V(1).Infof("%v once channel closed, exiting OnceRun routine", evtc)
+		return
+	}
+	send_recent(evtc, 0)
 }
 
+// PollRun sends the recent events on the first poll and only the events
+// received since the previous poll afterwards.
 func (evtc *EventClient) PollRun(q *queue.PriorityQueue, poll chan struct{}, wg *sync.WaitGroup, subscribe *gnmipb.SubscriptionList) {
-	return
+	evtc.wg = wg
+	defer evtc.wg.Done()
+
+	evtc.q = q
+	evtc.channel = poll
+
+	for {
+		_, more := <-evtc.channel
+		if !more {
+			log.V(1).Infof("%v poll channel closed, exiting PollRun routine", evtc)
+			return
+		}
+		send_recent(evtc, evtc.last_polled)
+	}
 }
 
 func (evtc *EventClient) AppDBPollRun(q *queue.PriorityQueue, poll chan struct{}, wg *sync.WaitGroup, subscribe *gnmipb.SubscriptionList) {
@@ -0,0 +1,257 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Events journal
+//
+// Every event received from eventd is stamped with a server wide sequence
+// number and kept in a bounded replay buffer. A client that reconnects can
+// pass the last sequence number it has seen via the "resume" path key and
+// the events published in between are replayed before live streaming resumes.
+//
+// The journal also keeps the most recent events of each source in a ring of
+// its own, so that a chatty source cannot push out the history of the others.
+// These serve Get, ONCE and POLL requests on the EVENTS target.
+//
+// Multiple EventClients may receive the same event from their own eventd
+// subscriber; such duplicates are identified by content and publish time and
+// share the sequence number of the first copy.
+
+const EVENT_REPLAY_BUFFSZ = 4096    // Max events retained for replay.
+const EVENT_RECENT_PER_SOURCE = 256 // Max recent events retained per source.
+
+// Event JSON field carrying the sequence number.
+const EVENT_SEQUENCE_FIELD = "sequence-id"
+
+type eventEntry struct {
+	seq       uint64
+	source    string // YANG module of the event, e.g. sonic-events-bgp
+	tag       string // Event tag, e.g. bgp-state
+	json      []byte // Event JSON sent to clients, sequence included
+	timestamp int64
+	received  time.Time
+	key       string
+}
+
+// eventRing is a fixed size ring buffer of events, oldest first.
+type eventRing struct {
+	entries []*eventEntry
+	head    int // Index of the oldest entry
+	count   int
+}
+
+func newEventRing(size int) *eventRing {
+	return &eventRing{entries: make([]*eventEntry, size)}
+}
+
+// push appends e and returns the entry it evicted, if any.
+func (r *eventRing) push(e *eventEntry) (evicted *eventEntry) {
+	size := len(r.entries)
+	if r.count == size {
+		evicted = r.entries[r.head]
+		r.entries[r.head] = e
+		r.head = (r.head + 1) % size
+	} else {
+		r.entries[(r.head+r.count)%size] = e
+		r.count++
+	}
+	return evicted
+}
+
+func (r *eventRing) at(i int) *eventEntry {
+	return r.entries[(r.head+i)%len(r.entries)]
+}
+
+type eventJournal struct {
+	mu         sync.Mutex
+	seq        uint64
+	replay     *eventRing
+	recent     map[string]*eventRing // Per source
+	recentSize int
+	index      map[string]*eventEntry
+}
+
+var evtJournal = newEventJournal(EVENT_REPLAY_BUFFSZ, EVENT_RECENT_PER_SOURCE)
+
+func newEventJournal(size int, recentSize int) *eventJournal {
+	return &eventJournal{
+		replay:     newEventRing(size),
+		recent:     make(map[string]*eventRing),
+		recentSize: recentSize,
+		index:      make(map[string]*eventEntry),
+	}
+}
+
+// parse_event splits an eventd event string of the form
+// {"<source>:<tag>": {<params>}} into its source, tag and parameters.
+// Strings that are not valid event JSON are returned with empty source/tag.
+func parse_event(evt_str string) (source string, tag string, fvp map[string]interface{}) {
+	json.Unmarshal([]byte(evt_str), &fvp)
+	for k := range fvp {
+		if i := strings.Index(k, ":"); i >= 0 {
+			source = k[:i]
+			tag = k[i+1:]
+		} else {
+			tag = k
+		}
+		break
+	}
+	return source, tag, fvp
+}
+
+// record assigns a sequence number to the event and appends it to the
+// journal. An event already journaled returns the existing entry.
+func (j *eventJournal) record(evt_str string, timestamp int64) (*eventEntry, error) {
+	key := fmt.Sprintf("%d|%s", timestamp, evt_str)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if e, ok := j.index[key]; ok {
+		return e, nil
+	}
+
+	source, tag, fvp := parse_event(evt_str)
+	seq := j.seq + 1
+	for _, v := range fvp {
+		if params, ok := v.(map[string]interface{}); ok {
+			params[EVENT_SEQUENCE_FIELD] = seq
+		}
+		break
+	}
+	jv, err := json.Marshal(fvp)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &eventEntry{
+		seq:       seq,
+		source:    source,
+		tag:       tag,
+		json:      jv,
+		timestamp: timestamp,
+		received:  time.Now(),
+		key:       key,
+	}
+	j.seq = seq
+
+	if evicted := j.replay.push(e); evicted != nil {
+		delete(j.index, evicted.key)
+	}
+	j.index[key] = e
+
+	ring, ok := j.recent[source]
+	if !ok {
+		ring = newEventRing(j.recentSize)
+		j.recent[source] = ring
+	}
+	ring.push(e)
+	return e, nil
+}
+
+// since returns the journaled events with sequence number greater than seq,
+// oldest first. missed is set when events after seq were already evicted, or
+// when seq is ahead of the journal, i.e. the server restarted since the
+// client last connected; all retained events are returned in that case.
+func (j *eventJournal) since(seq uint64) (entries []*eventEntry, missed bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if seq > j.seq {
+		seq = 0
+		missed = true
+	}
+
+	for i := 0; i < j.replay.count; i++ {
+		e := j.replay.at(i)
+		if e.seq > seq {
+			if len(entries) == 0 && e.seq > seq+1 {
+				missed = true
+			}
+			entries = append(entries, e)
+		}
+	}
+	return entries, missed
+}
+
+// latest returns the recent events matching filter, oldest first. Only events
+// with sequence number greater than after and received at or after notBefore
+// are returned; count limits the result to the newest count events when > 0.
+func (j *eventJournal) latest(filter *eventFilter, after uint64, notBefore time.Time, count int) []*eventEntry {
+	var entries []*eventEntry
+
+	j.mu.Lock()
+	for source, ring := range j.recent {
+		if len(filter.sources) != 0 && !filter.sources[source] {
+			continue
+		}
+		for i := 0; i < ring.count; i++ {
+			e := ring.at(i)
+			if e.seq <= after || e.received.Before(notBefore) || !filter.match(e.source, e.tag) {
+				continue
+			}
+			entries = append(entries, e)
+		}
+	}
+	j.mu.Unlock()
+
+	sort.Slice(entries, func(a, b int) bool { return entries[a].seq < entries[b].seq })
+	if count > 0 && len(entries) > count {
+		entries = entries[len(entries)-count:]
+	}
+	return entries
+}
+
+// eventFilter selects events by source (YANG module) and tag.
+// An empty set matches everything.
+type eventFilter struct {
+	sources map[string]bool
+	tags    map[string]bool
+}
+
+// add_filter_values parses a comma separated path key value into set.
+func add_filter_values(set *map[string]bool, val string) {
+	for _, v := range strings.Split(val, ",") {
+		v = strings.TrimSpace(v)
+		if len(v) == 0 {
+			continue
+		}
+		if *set == nil {
+			*set = make(map[string]bool)
+		}
+		(*set)[v] = true
+	}
+}
+
+func (f *eventFilter) match(source, tag string) bool {
+	if len(f.sources) != 0 && !f.sources[source] {
+		return false
+	}
+	if len(f.tags) != 0 && !f.tags[tag] && !f.tags[source+":"+tag] {
+		return false
+	}
+	return true
+}
+
+// parse_since parses the "since" path key. It accepts a duration relative to
+// now (e.g. 10m), an RFC3339 timestamp or seconds since the epoch.
+func parse_since(val string) (time.Time, error) {
+	if secs, err := strconv.ParseInt(val, 10, 64); err == nil {
+		return time.Unix(secs, 0), nil
+	}
+	if d, err := time.ParseDuration(val); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, val); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid %s value %q: expecting a duration, RFC3339 time or epoch seconds", PARAM_SINCE, val)
+}
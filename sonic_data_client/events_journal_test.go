@@ -0,0 +1,301 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Workiva/go-datastructures/queue"
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+func bgpEvent(ip string) string {
+	return fmt.Sprintf(`{"sonic-events-bgp:bgp-state": {"ip": "%s", "status": "down"}}`, ip)
+}
+
+func TestParseEvent(t *testing.T) {
+	source, tag, fvp := parse_event(bgpEvent("10.0.0.1"))
+	if source != "sonic-events-bgp" || tag != "bgp-state" || fvp == nil {
+		t.Errorf("Unexpected parse result: source=%q tag=%q fvp=%v", source, tag, fvp)
+	}
+
+	source, tag, fvp = parse_event("test0")
+	if source != "" || tag != "" || fvp != nil {
+		t.Errorf("Invalid event should not parse: source=%q tag=%q fvp=%v", source, tag, fvp)
+	}
+}
+
+func TestEventJournalRecord(t *testing.T) {
+	j := newEventJournal(3, 3)
+
+	e1, err := j.record(bgpEvent("10.0.0.1"), 100)
+	if err != nil || e1.seq != 1 {
+		t.Fatalf("record failed: seq=%v err=%v", e1, err)
+	}
+	var fvp map[string]map[string]interface{}
+	if err := json.Unmarshal(e1.json, &fvp); err != nil {
+		t.Fatalf("Invalid journal json %s: %v", e1.json, err)
+	}
+	if seq := fvp["sonic-events-bgp:bgp-state"][EVENT_SEQUENCE_FIELD]; seq != float64(1) {
+		t.Errorf("Sequence not injected in event: %s", e1.json)
+	}
+
+	// Same event received by another client shares the sequence number
+	dup, _ := j.record(bgpEvent("10.0.0.1"), 100)
+	if dup != e1 {
+		t.Errorf("Duplicate event got a new entry: seq=%v", dup.seq)
+	}
+
+	// Same content published later is a new event
+	e2, _ := j.record(bgpEvent("10.0.0.1"), 200)
+	if e2.seq != 2 {
+		t.Errorf("Expected seq 2, got %v", e2.seq)
+	}
+
+	// Invalid events are journaled as is
+	e3, _ := j.record("test0", 300)
+	if e3.seq != 3 || string(e3.json) != "null" {
+		t.Errorf("Unexpected entry for invalid event: seq=%v json=%s", e3.seq, e3.json)
+	}
+
+	// Journal is bounded, the oldest entry is evicted
+	j.record(bgpEvent("10.0.0.2"), 400)
+	if _, ok := j.index[e1.key]; ok {
+		t.Errorf("Evicted entry still indexed")
+	}
+	entries, missed := j.since(0)
+	if len(entries) != 3 || entries[0].seq != 2 || entries[2].seq != 4 || !missed {
+		t.Errorf("Unexpected entries after eviction: %d missed=%v", len(entries), missed)
+	}
+}
+
+func TestEventJournalSince(t *testing.T) {
+	j := newEventJournal(10, 10)
+	for i := 0; i < 5; i++ {
+		j.record(bgpEvent(fmt.Sprintf("10.0.0.%d", i)), int64(i))
+	}
+
+	tests := []struct {
+		seq     uint64
+		count   int
+		missed  bool
+		first   uint64
+		comment string
+	}{
+		{0, 5, false, 1, "from start"},
+		{3, 2, false, 4, "resume"},
+		{5, 0, false, 0, "up to date"},
+		{99, 5, true, 1, "server restarted"},
+	}
+	for _, tt := range tests {
+		entries, missed := j.since(tt.seq)
+		if len(entries) != tt.count || missed != tt.missed {
+			t.Errorf("%s: got %d entries missed=%v, expected %d missed=%v", tt.comment, len(entries), missed, tt.count, tt.missed)
+			continue
+		}
+		if tt.count > 0 && entries[0].seq != tt.first {
+			t.Errorf("%s: first seq %v, expected %v", tt.comment, entries[0].seq, tt.first)
+		}
+	}
+}
+
+func TestEventFilter(t *testing.T) {
+	var f eventFilter
+	if !f.match("sonic-events-swss", "if-state") {
+		t.Errorf("Empty filter should match all")
+	}
+
+	add_filter_values(&f.sources, "sonic-events-bgp, sonic-events-swss")
+	add_filter_values(&f.tags, "bgp-state,sonic-events-swss:if-state")
+
+	tests := []struct {
+		source string
+		tag    string
+		want   bool
+	}{
+		{"sonic-events-bgp", "bgp-state", true},
+		{"sonic-events-swss", "if-state", true},
+		{"sonic-events-bgp", "notification", false},
+		{"sonic-events-host", "bgp-state", false},
+		{"sonic-events-bgp", "if-state", false},
+	}
+	for _, tt := range tests {
+		if got := f.match(tt.source, tt.tag); got != tt.want {
+			t.Errorf("match(%q, %q) = %v, expected %v", tt.source, tt.tag, got, tt.want)
+		}
+	}
+}
+
+func TestEventReplay(t *testing.T) {
+	saved := evtJournal
+	evtJournal = newEventJournal(10, 10)
+	defer func() { evtJournal = saved }()
+
+	evtJournal.record(bgpEvent("10.0.0.1"), 1)
+	evtJournal.record(`{"sonic-events-swss:if-state": {"ifname": "Ethernet0"}}`, 2)
+	evtJournal.record(bgpEvent("10.0.0.2"), 3)
+
+	evtc := &EventClient{
+		q:          queue.NewPriorityQueue(1, false),
+		resume_seq: 1,
+		resume:     true,
+	}
+	add_filter_values(&evtc.filter.sources, "sonic-events-bgp")
+
+	if err := replay_events(evtc); err != nil {
+		t.Fatalf("replay_events failed: %v", err)
+	}
+	if evtc.q.Len() != 1 || evtc.last_replay != 3 {
+		t.Errorf("Expected 1 replayed event up to seq 3, got %d up to %v", evtc.q.Len(), evtc.last_replay)
+	}
+
+	// Live copies of replayed events are not sent twice
+	if _, skip, _ := process_event(evtc, Evt_rcvd{bgpEvent("10.0.0.2"), 0, 3}); !skip {
+		t.Errorf("Replayed event should be skipped")
+	}
+	jv, skip, err := process_event(evtc, Evt_rcvd{bgpEvent("10.0.0.3"), 0, 4})
+	if skip || err != nil || len(jv) == 0 {
+		t.Errorf("New event should be sent: skip=%v err=%v", skip, err)
+	}
+	if _, skip, _ := process_event(evtc, Evt_rcvd{`{"sonic-events-swss:if-state": {}}`, 0, 5}); !skip {
+		t.Errorf("Filtered event should be skipped")
+	}
+
+	// Heartbeats are always sent and not journaled
+	_, skip, _ = process_event(evtc, Evt_rcvd{`{"sonic-events-eventd:heartbeat": {}}`, 0, 6})
+	if skip || evtJournal.seq != 5 {
+		t.Errorf("Heartbeat should bypass journal and filter: skip=%v seq=%v", skip, evtJournal.seq)
+	}
+}
+
+func TestEventJournalLatest(t *testing.T) {
+	j := newEventJournal(10, 2)
+	j.record(bgpEvent("10.0.0.1"), 1)
+	j.record(`{"sonic-events-swss:if-state": {"ifname": "Ethernet0"}}`, 2)
+	j.record(bgpEvent("10.0.0.2"), 3)
+	j.record(bgpEvent("10.0.0.3"), 4)
+	j.record(`{"sonic-events-host:disk-usage": {"fs": "/"}}`, 5)
+
+	var all eventFilter
+	var bgp eventFilter
+	add_filter_values(&bgp.sources, "sonic-events-bgp")
+
+	tests := []struct {
+		comment   string
+		filter    *eventFilter
+		after     uint64
+		notBefore time.Time
+		count     int
+		want      []uint64
+	}{
+		// seq 1 was pushed out of the bgp ring by the newer bgp events
+		{"all sources", &all, 0, time.Time{}, 0, []uint64{2, 3, 4, 5}},
+		{"one source", &bgp, 0, time.Time{}, 0, []uint64{3, 4}},
+		{"count", &all, 0, time.Time{}, 2, []uint64{4, 5}},
+		{"after", &all, 3, time.Time{}, 0, []uint64{4, 5}},
+		{"not before", &all, 0, time.Now().Add(time.Minute), 0, nil},
+	}
+	for _, tt := range tests {
+		var got []uint64
+		for _, e := range j.latest(tt.filter, tt.after, tt.notBefore, tt.count) {
+			got = append(got, e.seq)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, expected %v", tt.comment, got, tt.want)
+		}
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	if ts, err := parse_since("1700000000"); err != nil || ts.Unix() != 1700000000 {
+		t.Errorf("Epoch seconds: got %v, %v", ts, err)
+	}
+	if ts, err := parse_since("10m"); err != nil || time.Since(ts) < 10*time.Minute {
+		t.Errorf("Duration: got %v, %v", ts, err)
+	}
+	if ts, err := parse_since("2024-01-02T03:04:05Z"); err != nil || ts.Unix() != 1704164645 {
+		t.Errorf("RFC3339: got %v, %v", ts, err)
+	}
+	if _, err := parse_since("yesterday"); err == nil {
+		t.Errorf("Invalid since should fail")
+	}
+}
+
+// eventsUntilSync counts the values queued before the sync response.
+func eventsUntilSync(q *queue.PriorityQueue) int {
+	n := 0
+	for {
+		items, err := q.Get(1)
+		if err != nil || len(items) == 0 {
+			return n
+		}
+		if v, ok := items[0].(Value); ok && v.GetSyncResponse() {
+			return n
+		}
+		n++
+	}
+}
+
+func TestEventCacheClient(t *testing.T) {
+	saved := evtJournal
+	evtJournal = newEventJournal(10, 10)
+	defer func() { evtJournal = saved }()
+
+	evtJournal.record(bgpEvent("10.0.0.1"), 1)
+	evtJournal.record(`{"sonic-events-swss:if-state": {"ifname": "Ethernet0"}}`, 2)
+	evtJournal.record(bgpEvent("10.0.0.2"), 3)
+
+	path := &gnmipb.Path{
+		Elem: []*gnmipb.PathElem{
+			{
+				Name: "all",
+				Key: map[string]string{
+					PARAM_SOURCE: "sonic-events-bgp",
+					PARAM_COUNT:  "1",
+				},
+			},
+		},
+	}
+	dc, err := NewEventCacheClient([]*gnmipb.Path{path}, nil)
+	if err != nil {
+		t.Fatalf("NewEventCacheClient failed: %v", err)
+	}
+	values, err := dc.Get(nil)
+	if err != nil || len(values) != 1 || values[0].GetTimestamp() != 3 {
+		t.Errorf("Get: expected the latest bgp event, got %v, %v", values, err)
+	}
+
+	// First poll sends recent events, later polls only the new ones
+	path.Elem[0].Key = map[string]string{PARAM_SOURCE: "sonic-events-bgp"}
+	dc, _ = NewEventCacheClient([]*gnmipb.Path{path}, nil)
+	q := queue.NewPriorityQueue(1, false)
+	poll := make(chan struct{}, 1)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go dc.PollRun(q, poll, &wg, nil)
+
+	poll <- struct{}{}
+	if n := eventsUntilSync(q); n != 2 {
+		t.Errorf("First poll: expected 2 events, got %d", n)
+	}
+	evtJournal.record(bgpEvent("10.0.0.3"), 4)
+	poll <- struct{}{}
+	if n := eventsUntilSync(q); n != 1 {
+		t.Errorf("Second poll: expected 1 event, got %d", n)
+	}
+	close(poll)
+	wg.Wait()
+
+	path.Elem[0].Key = map[string]string{PARAM_COUNT: "none"}
+	if _, err := NewEventCacheClient([]*gnmipb.Path{path}, nil); err == nil {
+		t.Errorf("Invalid count should fail")
+	}
+
+	path.Elem[0].Key = map[string]string{PARAM_RESUME: "latest"}
+	if _, err := NewEventCacheClient([]*gnmipb.Path{path}, nil); err == nil {
+		t.Errorf("Invalid resume should fail")
+	}
+}
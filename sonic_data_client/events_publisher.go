@@ -0,0 +1,63 @@
+package client
+
+/*
+#include <stdlib.h>
+#include <stdint.h>
+#include "events_wrap.h"
+*/
+import "C"
+
+import (
+	"fmt"
+	"sync"
+	"unsafe"
+
+	log "github.com/golang/glog"
+)
+
+var (
+	// Publisher handles, by event source. They are kept for the life of the
+	// process, as each one connects to the events proxy.
+	publishersMu sync.Mutex
+	publishers   = map[string]unsafe.Pointer{}
+)
+
+// PublishEvent publishes a SONiC event of source and tag, e.g.
+// sonic-events-host and cert-expiry, with params as its data.
+func PublishEvent(source string, tag string, params map[string]string) error {
+	publishersMu.Lock()
+	defer publishersMu.Unlock()
+
+	handle, ok := publishers[source]
+	if !ok {
+		csource := C.CString(source)
+		defer C.free(unsafe.Pointer(csource))
+		handle = C.events_init_publisher_wrap(csource)
+		if handle == nil {
+			return fmt.Errorf("failed to init the events publisher of %s", source)
+		}
+		publishers[source] = handle
+	}
+
+	ctag := C.CString(tag)
+	defer C.free(unsafe.Pointer(ctag))
+	cparams := make([]C.param_C_t, 0, len(params))
+	for name, val := range params {
+		cname := C.CString(name)
+		defer C.free(unsafe.Pointer(cname))
+		cval := C.CString(val)
+		defer C.free(unsafe.Pointer(cval))
+		cparams = append(cparams, C.param_C_t{name: cname, val: cval})
+	}
+	var pparams *C.param_C_t
+	if len(cparams) > 0 {
+		pparams = &cparams[0]
+	}
+
+	rc := C.event_publish_wrap(handle, ctag, pparams, C.uint32_t(len(cparams)))
+	if rc != 0 {
+		return fmt.Errorf("failed to publish event %s:%s rc=%d", source, tag, rc)
+	}
+	log.V(4).Infof("Published event %s:%s %v", source, tag, params)
+	return nil
+}
@@ -1062,17 +1062,20 @@ func ConvertDbEntry(inputData map[string]interface{}) map[string]string {
 	return outputData
 }
 
-func (c *MixedDbClient) handleTableData(tblPaths []tablePath) error {
+// stageTableData resolves the table paths of a Set operation into the entries
+// to write, without writing them.
+func (c *MixedDbClient) stageTableData(tblPaths []tablePath) ([]dbEntryOp, error) {
 	var pattern string
 	var dbkeys []string
 	var err error
 	var res interface{}
+	var entries []dbEntryOp
 
 	for _, tblPath := range tblPaths {
-		log.V(5).Infof("handleTableData: tblPath %v", tblPath)
+		log.V(5).Infof("stageTableData: tblPath %v", tblPath)
 		redisDb, ok := RedisDbMap[c.mapkey+":"+tblPath.dbName]
 		if !ok {
-			return fmt.Errorf("Redis Client not present for dbName %v mapkey %v", tblPath.dbName, c.mapkey)
+			return nil, fmt.Errorf("Redis Client not present for dbName %v mapkey %v", tblPath.dbName, c.mapkey)
 		}
 
 		if tblPath.jsonField == "" { // Not asked to include field in json value, which means not wildcard query
@@ -1081,7 +1084,7 @@ func (c *MixedDbClient) handleTableData(tblPaths []tablePath) error {
 				if len(tblPaths) != 1 {
 					log.V(2).Infof("WARNING: more than one path exists for field granularity query: %v", tblPaths)
 				}
-				return fmt.Errorf("Unsupported path %v, can't update field", tblPath)
+				return nil, fmt.Errorf("Unsupported path %v, can't update field", tblPath)
 			}
 		}
 
@@ -1098,7 +1101,7 @@ func (c *MixedDbClient) handleTableData(tblPaths []tablePath) error {
 				dbkeys, err = redisDb.Keys(context.Background(), pattern).Result()
 				if err != nil {
 					log.V(2).Infof("redis Keys failed for %v, pattern %s", tblPath, pattern)
-					return fmt.Errorf("redis Keys failed for %v, pattern %s %v", tblPath, pattern, err)
+					return nil, fmt.Errorf("redis Keys failed for %v, pattern %s %v", tblPath, pattern, err)
 				}
 			} else {
 				// both table name and key provided
@@ -1107,11 +1110,7 @@ func (c *MixedDbClient) handleTableData(tblPaths []tablePath) error {
 
 			for _, dbkey := range dbkeys {
 				tableKey := strings.TrimPrefix(dbkey, tblPath.tableName+tblPath.delimitor)
-				err = c.DbDelTable(tblPath.tableName, tableKey)
-				if err != nil {
-					log.V(2).Infof("swsscommon delete failed for  %v, dbkey %s", tblPath, dbkey)
-					return err
-				}
+				entries = append(entries, newDbEntryOp(tblPath, tableKey, nil))
 			}
 		} else if tblPath.operation == opAdd {
 			if tblPath.tableKey != "" {
@@ -1119,17 +1118,13 @@ func (c *MixedDbClient) handleTableData(tblPaths []tablePath) error {
 				if len(tblPath.jsonValue) != 0 {
 					res, err = parseJson([]byte(tblPath.jsonValue))
 					if err != nil {
-						return err
+						return nil, err
 					}
 					if vtable, ok := res.(map[string]interface{}); ok {
 						outputData := ConvertDbEntry(vtable)
-						err = c.DbSetTable(tblPath.tableName, tblPath.tableKey, outputData)
-						if err != nil {
-							log.V(2).Infof("swsscommon update failed for  %v, value %v", tblPath, outputData)
-							return err
-						}
+						entries = append(entries, newDbEntryOp(tblPath, tblPath.tableKey, outputData))
 					} else {
-						return fmt.Errorf("Key %v: Unsupported value %v type %v", tblPath.tableKey, res, reflect.TypeOf(res))
+						return nil, fmt.Errorf("Key %v: Unsupported value %v type %v", tblPath.tableKey, res, reflect.TypeOf(res))
 					}
 				} else {
 					// protobytes can be empty
@@ -1137,43 +1132,35 @@ func (c *MixedDbClient) handleTableData(tblPaths []tablePath) error {
 					vtable := make(map[string]interface{})
 					vtable["pb"] = tblPath.protoValue
 					outputData := ConvertDbEntry(vtable)
-					err = c.DbSetTable(tblPath.tableName, tblPath.tableKey, outputData)
-					if err != nil {
-						log.V(2).Infof("swsscommon update failed for  %v, value %v", tblPath, outputData)
-						return err
-					}
+					entries = append(entries, newDbEntryOp(tblPath, tblPath.tableKey, outputData))
 				}
 			} else {
 				if len(tblPath.jsonValue) == 0 {
-					return fmt.Errorf("No valid value: %v", tblPath)
+					return nil, fmt.Errorf("No valid value: %v", tblPath)
 				}
 				res, err = parseJson([]byte(tblPath.jsonValue))
 				if err != nil {
-					return err
+					return nil, err
 				}
 				if vtable, ok := res.(map[string]interface{}); ok {
 					for tableKey, tres := range vtable {
 						if vt, ret := tres.(map[string]interface{}); ret {
 							outputData := ConvertDbEntry(vt)
-							err = c.DbSetTable(tblPath.tableName, tableKey, outputData)
-							if err != nil {
-								log.V(2).Infof("swsscommon update failed for  %v, value %v", tblPath, outputData)
-								return err
-							}
+							entries = append(entries, newDbEntryOp(tblPath, tableKey, outputData))
 						} else {
-							return fmt.Errorf("Key %v: Unsupported value %v type %v", tableKey, tres, reflect.TypeOf(tres))
+							return nil, fmt.Errorf("Key %v: Unsupported value %v type %v", tableKey, tres, reflect.TypeOf(tres))
 						}
 					}
 				} else {
-					return fmt.Errorf("Unsupported value %v type %v", res, reflect.TypeOf(res))
+					return nil, fmt.Errorf("Unsupported value %v type %v", res, reflect.TypeOf(res))
 				}
 			}
 		} else {
-			return fmt.Errorf("Unsupported operation %v", tblPath.operation)
+			return nil, fmt.Errorf("Unsupported operation %v", tblPath.operation)
 		}
 
 	}
-	return nil
+	return entries, nil
 }
 
 /* Populate the JsonPatch corresponding each GNMI operation. */
@@ -1254,21 +1241,35 @@ with open(filename, 'r') as fp:
 		yang_parser.validate_data_tree()
 	except sonic_yang.SonicYangException as e:
 		print("Yang validation error: {}".format(str(e)))
+		with open(filename + ".err", 'w') as ep:
+			ep.write(str(e))
 		raise
 `
 
-func (c *MixedDbClient) SetIncrementalConfig(delete []*gnmipb.Path, replace []*gnmipb.Update, update []*gnmipb.Update) error {
-	var err error
-
-	var sc ssc.Service
-	sc, err = ssc.NewDbusClient()
-	if err != nil {
-		return err
+// validateYangFile validates the CONFIG_DB configuration in fileName against
+// the SONiC YANG models.
+func validateYangFile(fileName string) error {
+	errFileName := fileName + ".err"
+	os.Remove(errFileName)
+	defer os.Remove(errFileName)
+	if err := RunPyCode(fmt.Sprintf(PyCodeForYang, fileName)); err != nil {
+		if msg, rerr := ioutil.ReadFile(errFileName); rerr == nil && len(msg) != 0 {
+			return fmt.Errorf("Yang validation failed: %s", strings.TrimSpace(string(msg)))
+		}
+		return fmt.Errorf("Yang validation failed!")
 	}
+	return nil
+}
+
+// buildIncrementalPatch translates a Set request into a JSON patch against the
+// checkpoint of CONFIG_DB created as checkpoint. The patch is also applied to
+// c.jClient, which then holds the configuration resulting from the Set request.
+func (c *MixedDbClient) buildIncrementalPatch(sc ssc.Service, checkpoint string, delete []*gnmipb.Path, replace []*gnmipb.Update, update []*gnmipb.Update) ([](map[string]interface{}), error) {
+	var err error
 
 	multiNs, err := sdcfg.CheckDbMultiNamespace()
 	if err != nil {
-		return err
+		return nil, err
 	}
 	namespace := ""
 	if multiNs {
@@ -1279,15 +1280,15 @@ func (c *MixedDbClient) SetIncrementalConfig(delete []*gnmipb.Path, replace []*g
 		}
 	}
 
-	err = sc.CreateCheckPoint(CHECK_POINT_PATH + "/config")
+	err = sc.CreateCheckPoint(checkpoint)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer sc.DeleteCheckPoint(CHECK_POINT_PATH + "/config")
-	fileName := CHECK_POINT_PATH + "/config.cp.json"
+	defer sc.DeleteCheckPoint(checkpoint)
+	fileName := checkpoint + ".cp.json"
 	c.jClient, err = NewJsonClient(fileName, namespace)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	var patchList [](map[string]interface{})
@@ -1295,7 +1296,7 @@ func (c *MixedDbClient) SetIncrementalConfig(delete []*gnmipb.Path, replace []*g
 	for _, path := range delete {
 		fullPath, err := c.gnmiFullPath(c.prefix, path)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		log.V(2).Infof("Path #%v", fullPath)
 
@@ -1316,7 +1317,7 @@ func (c *MixedDbClient) SetIncrementalConfig(delete []*gnmipb.Path, replace []*g
 		curr := map[string]interface{}{}
 		err = c.ConvertToJsonPatch(c.prefix, path, nil, DELETE_OPERATION, &curr)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		if multiNs {
 			curr["path"] = "/" + namespace + curr["path"].(string)
@@ -1328,7 +1329,7 @@ func (c *MixedDbClient) SetIncrementalConfig(delete []*gnmipb.Path, replace []*g
 	for _, path := range replace {
 		fullPath, err := c.gnmiFullPath(c.prefix, path.GetPath())
 		if err != nil {
-			return err
+			return nil, err
 		}
 		log.V(2).Infof("Path #%v", fullPath)
 
@@ -1351,14 +1352,14 @@ func (c *MixedDbClient) SetIncrementalConfig(delete []*gnmipb.Path, replace []*g
 				err := c.jClient.Replace(stringSlice, string(t.GetJsonIetfVal()))
 				if err != nil {
 					// Add failed
-					return err
+					return nil, err
 				}
 			}
 		}
 		curr := map[string]interface{}{}
 		err = c.ConvertToJsonPatch(c.prefix, path.GetPath(), path.GetVal(), REPLACE_OPERATION, &curr)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		if multiNs {
 			curr["path"] = "/" + namespace + curr["path"].(string)
@@ -1370,7 +1371,7 @@ func (c *MixedDbClient) SetIncrementalConfig(delete []*gnmipb.Path, replace []*g
 	for _, path := range update {
 		fullPath, err := c.gnmiFullPath(c.prefix, path.GetPath())
 		if err != nil {
-			return err
+			return nil, err
 		}
 		log.V(2).Infof("Path #%v", fullPath)
 
@@ -1384,25 +1385,41 @@ func (c *MixedDbClient) SetIncrementalConfig(delete []*gnmipb.Path, replace []*g
 			}
 			t := path.GetVal()
 			if t == nil {
-				return fmt.Errorf("Invalid update %v", path)
+				return nil, fmt.Errorf("Invalid update %v", path)
 			} else {
 				err := c.jClient.Add(stringSlice, string(t.GetJsonIetfVal()))
 				if err != nil {
 					// Add failed
-					return err
+					return nil, err
 				}
 			}
 		}
 		curr := map[string]interface{}{}
 		err = c.ConvertToJsonPatch(c.prefix, path.GetPath(), path.GetVal(), UPDATE_OPERATION, &curr)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		if multiNs {
 			curr["path"] = "/" + namespace + curr["path"].(string)
 		}
 		patchList = append(patchList, curr)
 	}
+	return patchList, nil
+}
+
+func (c *MixedDbClient) SetIncrementalConfig(delete []*gnmipb.Path, replace []*gnmipb.Update, update []*gnmipb.Update) error {
+	var err error
+
+	var sc ssc.Service
+	sc, err = ssc.NewDbusClient()
+	if err != nil {
+		return err
+	}
+
+	patchList, err := c.buildIncrementalPatch(sc, CHECK_POINT_PATH+"/config", delete, replace, update)
+	if err != nil {
+		return err
+	}
 	if len(patchList) == 0 {
 		// No need to apply patch
 		return nil
@@ -1441,13 +1458,7 @@ func (c *MixedDbClient) SetFullConfig(delete []*gnmipb.Path, replace []*gnmipb.U
 		return err
 	}
 
-	PyCodeInGo := fmt.Sprintf(PyCodeForYang, fileName)
-	err = RunPyCode(PyCodeInGo)
-	if err != nil {
-		return fmt.Errorf("Yang validation failed!")
-	}
-
-	return nil
+	return validateYangFile(fileName)
 }
 
 func (c *MixedDbClient) ReplaceFullConfig(delete []*gnmipb.Path, replace []*gnmipb.Update, update []*gnmipb.Update) error {
@@ -1472,44 +1483,15 @@ func (c *MixedDbClient) ReplaceFullConfig(delete []*gnmipb.Path, replace []*gnmi
 	return err
 }
 
+// SetDB applies a Set request to APPL_DB or DPU_APPL_DB as a transaction: all
+// operations are staged before any entry is written, and the entries written
+// before a failure are restored. A failure is reported as a *SetOpError.
 func (c *MixedDbClient) SetDB(delete []*gnmipb.Path, replace []*gnmipb.Update, update []*gnmipb.Update) error {
-	/* DELETE */
-	deletePathList, err := c.getAllDbtablePath(delete)
+	ops, err := c.stageSetDB(delete, replace, update)
 	if err != nil {
 		return err
 	}
-
-	for _, tblPaths := range deletePathList {
-		err = c.handleTableData(tblPaths)
-		if err != nil {
-			return err
-		}
-	}
-
-	/* REPLACE */
-	for _, item := range replace {
-		tblPaths, err := c.getDbtablePath(item.GetPath(), item.GetVal())
-		if err != nil {
-			return err
-		}
-		err = c.handleTableData(tblPaths)
-		if err != nil {
-			return err
-		}
-	}
-
-	/* UPDATE */
-	for _, item := range update {
-		tblPaths, err := c.getDbtablePath(item.GetPath(), item.GetVal())
-		if err != nil {
-			return err
-		}
-		err = c.handleTableData(tblPaths)
-		if err != nil {
-			return err
-		}
-	}
-	return nil
+	return c.applySetDB(ops)
 }
 
 func (c *MixedDbClient) SetConfigDB(delete []*gnmipb.Path, replace []*gnmipb.Update, update []*gnmipb.Update) error {
@@ -1552,7 +1534,8 @@ func (c *MixedDbClient) Set(delete []*gnmipb.Path, replace []*gnmipb.Update, upd
 		// Keep APPL_DB for backward compatibility
 		return c.SetDB(delete, replace, update)
 	}
-	return fmt.Errorf("Set RPC does not support %v", c.target)
+	// Other databases only allow writing allowlisted tables
+	return c.SetAllowlistedDB(delete, replace, update)
 }
 
 func (c *MixedDbClient) GetCheckPoint() ([]*spb.Value, error) {
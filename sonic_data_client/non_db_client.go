@@ -29,8 +29,9 @@ const (
 type dataGetFunc func() ([]byte, error)
 
 type path2DataFunc struct {
-	path    []string
-	getFunc dataGetFunc
+	path        []string
+	getFunc     dataGetFunc
+	description string
 }
 
 type statsRing struct {
@@ -70,36 +71,44 @@ var (
 	// for getting data at the path specified
 	path2DataFuncTbl = []path2DataFunc{
 		{ // Get cpu utilization
-			path:    []string{"OTHERS", "platform", "cpu"},
-			getFunc: dataGetFunc(getCpuUtil),
+			path:        []string{"OTHERS", "platform", "cpu"},
+			getFunc:     dataGetFunc(getCpuUtil),
+			description: "CPU utilization of all CPUs and each CPU over the last 100ms, 1s, 5s and 1min",
 		},
 		{ // Get host uptime
-			path:    []string{"OTHERS", "proc", "uptime"},
-			getFunc: dataGetFunc(getSysUptime),
+			path:        []string{"OTHERS", "proc", "uptime"},
+			getFunc:     dataGetFunc(getSysUptime),
+			description: "Content of /proc/uptime",
 		},
 		{ // Get proc meminfo
-			path:    []string{"OTHERS", "proc", "meminfo"},
-			getFunc: dataGetFunc(getProcMeminfo),
+			path:        []string{"OTHERS", "proc", "meminfo"},
+			getFunc:     dataGetFunc(getProcMeminfo),
+			description: "Content of /proc/meminfo",
 		},
 		{ // Get proc diskstats
-			path:    []string{"OTHERS", "proc", "diskstats"},
-			getFunc: dataGetFunc(getProcDiskstats),
+			path:        []string{"OTHERS", "proc", "diskstats"},
+			getFunc:     dataGetFunc(getProcDiskstats),
+			description: "Content of /proc/diskstats",
 		},
 		{ // Get proc loadavg
-			path:    []string{"OTHERS", "proc", "loadavg"},
-			getFunc: dataGetFunc(getProcLoadavg),
+			path:        []string{"OTHERS", "proc", "loadavg"},
+			getFunc:     dataGetFunc(getProcLoadavg),
+			description: "Content of /proc/loadavg",
 		},
 		{ // Get proc vmstat
-			path:    []string{"OTHERS", "proc", "vmstat"},
-			getFunc: dataGetFunc(getProcVmstat),
+			path:        []string{"OTHERS", "proc", "vmstat"},
+			getFunc:     dataGetFunc(getProcVmstat),
+			description: "Content of /proc/vmstat",
 		},
 		{ // Get proc stat
-			path:    []string{"OTHERS", "proc", "stat"},
-			getFunc: dataGetFunc(getProcStat),
+			path:        []string{"OTHERS", "proc", "stat"},
+			getFunc:     dataGetFunc(getProcStat),
+			description: "Content of /proc/stat",
 		},
 		{ // OS build version
-			path:    []string{"OTHERS", "osversion", "build"},
-			getFunc: dataGetFunc(getBuildVersion),
+			path:        []string{"OTHERS", "osversion", "build"},
+			getFunc:     dataGetFunc(getBuildVersion),
+			description: "SONiC build version from /etc/sonic/sonic_version.yml",
 		},
 	}
 )
@@ -0,0 +1,119 @@
+package client
+
+import (
+	"sort"
+	"strings"
+)
+
+// Path schema
+//
+// The paths served by the SHOW, OTHERS and EVENTS targets are not described
+// by YANG models. PathSchema describes them, with their options, for discovery
+// through Capabilities.
+
+// PathSchema describes a path pattern of a non YANG target.
+type PathSchema struct {
+	Path        string             `json:"path"`
+	Description string             `json:"description,omitempty"`
+	Options     []PathOptionSchema `json:"options,omitempty"`
+	Subcommands map[string]string  `json:"subcommands,omitempty"`
+	MinArgs     int                `json:"min_args,omitempty"`
+	MaxArgs     int                `json:"max_args,omitempty"` // -1 means any number of args
+}
+
+// PathOptionSchema describes an option, given as path element key.
+type PathOptionSchema struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Type        string   `json:"type"`
+	Values      []string `json:"values,omitempty"`
+	Required    bool     `json:"required,omitempty"`
+}
+
+var valueTypeNames = map[ValueType]string{
+	StringValue:      "string",
+	StringSliceValue: "string-list",
+	BoolValue:        "bool",
+	IntValue:         "int",
+	EnumValue:        "enum",
+}
+
+// ShowPathSchemas lists the paths registered with RegisterCliPath, sorted by
+// path. Hidden and unimplemented options are not listed.
+func ShowPathSchemas() []PathSchema {
+	var schemas []PathSchema
+	var walk func(n *Node, path []string)
+	walk = func(n *Node, path []string) {
+		for val, child := range n.Children() {
+			if val != "" {
+				walk(child, append(path[:len(path):len(path)], val))
+				continue
+			}
+			config, ok := child.Meta().(ShowPathConfig)
+			if !ok || !child.term {
+				continue
+			}
+			schemas = append(schemas, showPathSchema(path, config))
+		}
+	}
+	walk(showTrie.Root(), nil)
+	sort.Slice(schemas, func(i, j int) bool { return schemas[i].Path < schemas[j].Path })
+	return schemas
+}
+
+func showPathSchema(path []string, config ShowPathConfig) PathSchema {
+	schema := PathSchema{
+		// The first element is the SHOW target
+		Path:        "/" + strings.Join(path[1:], "/"),
+		Description: config.description["usage"]["desc"],
+		Subcommands: config.description["subcommands"],
+		MinArgs:     config.minArgs,
+		MaxArgs:     config.maxArgs,
+	}
+	for _, option := range config.options {
+		if option.hidden || option.optType == Unimplemented {
+			continue
+		}
+		schema.Options = append(schema.Options, PathOptionSchema{
+			Name:        option.optName,
+			Description: option.description,
+			Type:        valueTypeNames[option.valueType],
+			Values:      option.enumValues,
+			Required:    option.optType == Required,
+		})
+	}
+	sort.Slice(schema.Options, func(i, j int) bool { return schema.Options[i].Name < schema.Options[j].Name })
+	return schema
+}
+
+// OthersPathSchemas lists the paths of the OTHERS target, sorted by path.
+func OthersPathSchemas() []PathSchema {
+	var schemas []PathSchema
+	for _, pt := range path2DataFuncTbl {
+		schemas = append(schemas, PathSchema{
+			// The first element is the OTHERS target
+			Path:        "/" + strings.Join(pt.path[1:], "/"),
+			Description: pt.description,
+		})
+	}
+	sort.Slice(schemas, func(i, j int) bool { return schemas[i].Path < schemas[j].Path })
+	return schemas
+}
+
+// EventsPathSchemas lists the paths of the EVENTS target.
+func EventsPathSchemas() []PathSchema {
+	return []PathSchema{{
+		Path:        "/all",
+		Description: "Events published by the SONiC components",
+		Options: []PathOptionSchema{
+			{Name: PARAM_COUNT, Description: "Get, ONCE and POLL only: number of most recent events returned", Type: "int"},
+			{Name: PARAM_HEARTBEAT, Description: "Heartbeat interval in seconds, at most 600", Type: "int"},
+			{Name: PARAM_QSIZE, Description: "Size of the queue of pending events", Type: "int"},
+			{Name: PARAM_RESUME, Description: "Replays the retained events after this sequence number", Type: "int"},
+			{Name: PARAM_SINCE, Description: "Get, ONCE and POLL only: events after a duration ago, RFC3339 time or epoch seconds", Type: "string"},
+			{Name: PARAM_SOURCE, Description: "Comma separated list of event sources", Type: "string-list"},
+			{Name: PARAM_TAG, Description: "Comma separated list of event tags", Type: "string-list"},
+			{Name: PARAM_USE_CACHE, Description: "Sends the events cached by eventd before the subscription", Type: "bool"},
+		},
+	}}
+}
@@ -0,0 +1,60 @@
+package client
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestShowPathSchemas(t *testing.T) {
+	saved := showTrie
+	showTrie = NewTrie()
+	defer func() { showTrie = saved }()
+
+	getter := func(args CmdArgs, options OptionMap) ([]byte, error) { return nil, nil }
+	RegisterCliPath([]string{"SHOW", "interfaces", "status"}, getter, "SHOW/interfaces/status[OPTIONS]: Show interface status", 0, 1, nil,
+		RequiredOption(NewShowCmdOption("display", "Display mode", EnumValue, "all", "frontend")),
+		UnimplementedOption(NewShowCmdOption("namespace", "Namespace", StringValue)),
+	)
+	RegisterCliPath([]string{"SHOW", "clock"}, getter, "SHOW/clock[OPTIONS]: Show date and time", 0, 0,
+		map[string]string{"timezones": "show/clock/timezones: List of available timezones"},
+	)
+
+	want := []PathSchema{
+		{
+			Path:        "/clock",
+			Description: "SHOW/clock[OPTIONS]: Show date and time",
+			Options:     []PathOptionSchema{{Name: "help", Description: showCmdOptionHelpDesc, Type: "bool"}},
+			Subcommands: map[string]string{"timezones": "show/clock/timezones: List of available timezones"},
+		},
+		{
+			Path:        "/interfaces/status",
+			Description: "SHOW/interfaces/status[OPTIONS]: Show interface status",
+			Options: []PathOptionSchema{
+				{Name: "display", Description: "Display mode", Type: "enum", Values: []string{"all", "frontend"}, Required: true},
+				{Name: "help", Description: showCmdOptionHelpDesc, Type: "bool"},
+			},
+			MaxArgs: 1,
+		},
+	}
+	if got := ShowPathSchemas(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+}
+
+func TestOthersPathSchemas(t *testing.T) {
+	schemas := OthersPathSchemas()
+	if len(schemas) != len(path2DataFuncTbl) {
+		t.Fatalf("Expected %d paths, got %d", len(path2DataFuncTbl), len(schemas))
+	}
+	for i, schema := range schemas {
+		if schema.Description == "" {
+			t.Errorf("Expected description for %s", schema.Path)
+		}
+		if i > 0 && schemas[i-1].Path >= schema.Path {
+			t.Errorf("Expected paths sorted, got %s before %s", schemas[i-1].Path, schema.Path)
+		}
+	}
+	if schemas[0].Path != "/osversion/build" {
+		t.Errorf("Expected /osversion/build first, got %s", schemas[0].Path)
+	}
+}
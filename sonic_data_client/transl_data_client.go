@@ -44,6 +44,7 @@ type TranslClient struct {
 
 	version  *translib.Version // Client version; populated by parseVersion()
 	encoding gnmipb.Encoding
+	content  string // translib content filter for Get; empty for all data
 }
 
 func NewTranslClient(prefix *gnmipb.Path, getpaths []*gnmipb.Path, ctx context.Context, extensions []*gnmi_extpb.Extension, opts ...TranslClientOption) (Client, error) {
@@ -52,6 +53,11 @@ func NewTranslClient(prefix *gnmipb.Path, getpaths []*gnmipb.Path, ctx context.C
 	client.ctx = ctx
 	client.prefix = prefix
 	client.extensions = extensions
+	for _, o := range opts {
+		if c, ok := o.(TranslContentOption); ok {
+			client.content = string(c)
+		}
+	}
 
 	if getpaths != nil {
 		var addWildcardKeys bool
@@ -86,7 +92,7 @@ func (c *TranslClient) Get(w *sync.WaitGroup) ([]*spb.Value, error) {
 	/* Iterate through all GNMI paths. */
 	for gnmiPath, URIPath := range c.path2URI {
 		/* Fill values for each GNMI path. */
-		val, err := transutil.TranslProcessGet(URIPath, nil, c.ctx)
+		val, err := transutil.TranslProcessGet(URIPath, nil, c.ctx, c.content)
 
 		if err != nil {
 			return nil, err
@@ -508,3 +514,9 @@ type TranslClientOption interface {
 type TranslWildcardOption struct{}
 
 func (t TranslWildcardOption) IsTranslClientOption() {}
+
+// TranslContentOption restricts Get to the config ("config"), state ("nonconfig")
+// or operational ("operational") data of the paths. Empty means all data.
+type TranslContentOption string
+
+func (t TranslContentOption) IsTranslClientOption() {}
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"math"
 	"os"
 	"os/signal"
 	"path/filepath"
@@ -19,7 +20,12 @@ import (
 	"time"
 
 	gnmi "github.com/sonic-net/sonic-gnmi/gnmi_server"
+	"github.com/sonic-net/sonic-gnmi/pkg/audit"
+	"github.com/sonic-net/sonic-gnmi/pkg/bypass"
+	"github.com/sonic-net/sonic-gnmi/pkg/certexpiry"
+	gnoi_debug "github.com/sonic-net/sonic-gnmi/pkg/gnoi/debug"
 	"github.com/sonic-net/sonic-gnmi/pkg/interceptors"
+	sdc "github.com/sonic-net/sonic-gnmi/sonic_data_client"
 	testcert "github.com/sonic-net/sonic-gnmi/testdata/tls"
 
 	"github.com/fsnotify/fsnotify"
@@ -54,6 +60,11 @@ type TelemetryConfig struct {
 	AllowNoClientCert     *bool
 	JwtRefInt             *uint64
 	JwtValInt             *uint64
+	JwtSigningAlg         *string
+	JwtKeyFile            *string
+	JwtRevocationFile     *string
+	JwtIssuer             *string
+	JwtAudience           *string
 	GnmiTranslibWrite     *bool
 	GnmiNativeWrite       *bool
 	Threshold             *int
@@ -62,19 +73,40 @@ type TelemetryConfig struct {
 	WithMasterArbitration *bool
 	WithSaveOnSet         *bool
 	IdleConnDuration      *int
+	KeepaliveTime         *int
+	KeepaliveTimeout      *int
+	KeepaliveMinTime      *int
+	KeepaliveNoStream     *bool
+	MaxConnAge            *int
+	MaxConnAgeGrace       *int
+	MaxConcurrentStreams  *int
+	MaxRecvMsgSize        *int
+	MaxSendMsgSize        *int
 	Vrf                   *string
 	EnableCrl             *bool
 	CrlExpireDuration     *int
+	ClientCertIdentity    *string
+	EnableOcsp            *bool
+	RevocationRefreshInt  *int
+	CertExpiryCheckInt    *int
+	CertExpiryAlertDays   *string
+	EnableAuditLog        *bool
+	AuditLogFile          *string
+	AuditLogMaxSize       *int
+	AuditLogMaxBackups    *int
 	CaCertLnk             *string
 	ServerCertLnk         *string
 	ServerKeyLnk          *string
 	CertCRLConfig         *string
 	IntManFile            *string
 	CertzMetaFile         *string
+	CertzAuthPolicyFile   *string
 	ImgDirPath            *string
 	AuthzMetaFile         *string
 	AuthPolicyEnabled     *bool
 	AuthzPolicyFile       *string
+	EventsCache           *bool
+	DpuTLS                *bool
 }
 
 func main() {
@@ -178,15 +210,38 @@ func setupFlags(fs *flag.FlagSet) (*TelemetryConfig, *gnmi.Config, error) {
 		AllowNoClientCert:     fs.Bool("allow_no_client_auth", false, "When set, telemetry server will request but not require a client certificate."),
 		JwtRefInt:             fs.Uint64("jwt_refresh_int", 900, "Seconds before JWT expiry the token can be refreshed."),
 		JwtValInt:             fs.Uint64("jwt_valid_int", 3600, "Seconds that JWT token is valid for."),
+		JwtSigningAlg:         fs.String("jwt_signing_alg", "HS256", "JWT signing algorithm: HS256 (in-memory secret), RS256 or ES256."),
+		JwtKeyFile:            fs.String("jwt_key_file", "/etc/sonic/telemetry/jwt_signing_key.pem", "RS256/ES256 JWT signing key, generated if missing. The public key is published in the same path with a .pub suffix."),
+		JwtRevocationFile:     fs.String("jwt_revocation_file", "/etc/sonic/telemetry/jwt_revoked.json", "JSON file of revoked JWT token IDs."),
+		JwtIssuer:             fs.String("jwt_issuer", "", "JWT issuer claim, required on validation when set."),
+		JwtAudience:           fs.String("jwt_audience", "", "JWT audience claim, required on validation when set."),
 		GnmiTranslibWrite:     fs.Bool("gnmi_translib_write", gnmi.ENABLE_TRANSLIB_WRITE, "Enable gNMI translib write for management framework"),
 		GnmiNativeWrite:       fs.Bool("gnmi_native_write", gnmi.ENABLE_NATIVE_WRITE, "Enable gNMI native write"),
 		Threshold:             fs.Int("threshold", 100, "max number of client connections"),
 		WithMasterArbitration: fs.Bool("with-master-arbitration", false, "Enables master arbitration policy."),
 		WithSaveOnSet:         fs.Bool("with-save-on-set", false, "Enables save-on-set."),
 		IdleConnDuration:      fs.Int("idle_conn_duration", 5, "Seconds before server closes idle connections"),
+		KeepaliveTime:         fs.Int("keepalive_time", 7200, "Seconds without activity before server pings the client"),
+		KeepaliveTimeout:      fs.Int("keepalive_timeout", 20, "Seconds server waits for a ping ack before closing the connection"),
+		KeepaliveMinTime:      fs.Int("keepalive_min_time", 300, "Minimum seconds between client pings, connections of clients pinging more often are closed"),
+		KeepaliveNoStream:     fs.Bool("keepalive_permit_without_stream", false, "Allow client pings on connections without active stream"),
+		MaxConnAge:            fs.Int("max_connection_age", 0, "Seconds before server gracefully closes a connection, 0 meaning inf"),
+		MaxConnAgeGrace:       fs.Int("max_connection_age_grace", 0, "Seconds given to pending RPCs after max_connection_age before the connection is forcibly closed, 0 meaning inf"),
+		MaxConcurrentStreams:  fs.Int("max_concurrent_streams", 0, "Max concurrent streams per connection, 0 meaning no limit"),
+		MaxRecvMsgSize:        fs.Int("max_recv_msg_size", 4*1024*1024, "Max size in bytes of a received message"),
+		MaxSendMsgSize:        fs.Int("max_send_msg_size", math.MaxInt32, "Max size in bytes of a sent message"),
 		Vrf:                   fs.String("vrf", "", "VRF name, when zmq_address belong on a VRF, need VRF name to bind ZMQ."),
 		EnableCrl:             fs.Bool("enable_crl", false, "Enable certificate revocation list"),
 		CrlExpireDuration:     fs.Int("crl_expire_duration", 86400, "Certificate revocation list cache expire duration"),
+		EnableOcsp:            fs.Bool("enable_ocsp", false, "Check client certificates with the OCSP responder of their issuer, and staple OCSP responses of the server certificate"),
+		RevocationRefreshInt:  fs.Int("revocation_refresh_interval", 60, "Seconds between background refreshes of the cached CRLs, CRL bundle and OCSP responses"),
+		CertExpiryCheckInt:    fs.Int("cert_expiry_check_interval", 3600, "Seconds between expiry checks of the server certificates, trust bundles and cached CRLs"),
+		CertExpiryAlertDays:   fs.String("cert_expiry_alert_days", "30,7,1", "Comma separated numbers of days before a certificate or CRL expires at which an expiry event is raised"),
+		EnableAuditLog:        fs.Bool("enable_audit_log", true, "Record the mutating gNMI, gNOI and gNSI RPCs as JSON to syslog"),
+		AuditLogFile:          fs.String("audit_log_file", "", "Local file the audit records are also written to, none if empty"),
+		AuditLogMaxSize:       fs.Int("audit_log_max_size", 10, "Size in MB from which the audit log file is rotated"),
+		AuditLogMaxBackups:    fs.Int("audit_log_max_backups", 5, "Number of rotated audit log files kept"),
+		ClientCertIdentity:    fs.String("client_cert_identity", "", "Comma separated client certificate identity sources in order of preference - cn,dns,uri,spiffe,email. Used by cert authentication, authz and pathz; empty for CN authentication and SPIFFE pathz users."),
 		ImgDirPath:            fs.String("img_dir", "/tmp/host_tmp", "Directory path where image will be transferred."),
 		CaCert:                fs.String("ca_crt", "", "CA certificate for client certificate validation. Optional."),
 		ServerCert:            fs.String("server_crt", "", "TLS server certificate"),
@@ -197,9 +252,12 @@ func setupFlags(fs *flag.FlagSet) (*TelemetryConfig, *gnmi.Config, error) {
 		IntManFile:            fs.String("integrity_manifest_file", "", "Full path name of integrity manifest file."),
 		CertCRLConfig:         fs.String("cert_crl_dir", "/mtls/crl", "Directory for CRL files"),
 		CertzMetaFile:         fs.String("grpc_meta", "/keys/grpc-version.json", "gRPC credentials metadata JSON file"),
+		CertzAuthPolicyFile:   fs.String("certz_auth_policy_file", "/keys/authentication_policy.json", "Full path name of the certz authentication policy file mapping CAs to allowed client identities."),
 		AuthzMetaFile:         fs.String("authz_meta", "/keys/authz-version.json", "authz policy metadata JSON file"),
 		AuthPolicyEnabled:     fs.Bool("authz_policy_enabled", false, "Enable authz policy. Require insecure flag to be false."),
 		AuthzPolicyFile:       fs.String("authorization_policy_file", "/keys/authorization_policy.json", "Full path name of the JSON authorization policy file."),
+		EventsCache:           fs.Bool("events_cache", true, "Keep recent events in memory to serve EVENTS Get, ONCE and POLL requests."),
+		DpuTLS:                fs.Bool("dpu_tls", false, "Connect to DPUs over TLS with the server certificate, verifying them against ca_crt. Requires TLS. Without it, requests are forwarded to DPUs in plaintext and caller credentials are not forwarded."),
 	}
 
 	fs.Var(&telemetryCfg.UserAuth, "client_auth", "Client auth mode(s) - none,cert,password")
@@ -233,6 +291,36 @@ func setupFlags(fs *flag.FlagSet) (*TelemetryConfig, *gnmi.Config, error) {
 	switch {
 	case *telemetryCfg.IdleConnDuration < 0:
 		return nil, nil, fmt.Errorf("idle_conn_duration must be >= 0, 0 meaning inf")
+	case *telemetryCfg.KeepaliveTime <= 0:
+		return nil, nil, fmt.Errorf("keepalive_time must be > 0.")
+	case *telemetryCfg.KeepaliveTimeout <= 0:
+		return nil, nil, fmt.Errorf("keepalive_timeout must be > 0.")
+	case *telemetryCfg.KeepaliveMinTime < 0:
+		return nil, nil, fmt.Errorf("keepalive_min_time must be >= 0.")
+	case *telemetryCfg.MaxConnAge < 0:
+		return nil, nil, fmt.Errorf("max_connection_age must be >= 0, 0 meaning inf")
+	case *telemetryCfg.MaxConnAgeGrace < 0:
+		return nil, nil, fmt.Errorf("max_connection_age_grace must be >= 0, 0 meaning inf")
+	case *telemetryCfg.MaxConcurrentStreams < 0:
+		return nil, nil, fmt.Errorf("max_concurrent_streams must be >= 0, 0 meaning no limit")
+	case *telemetryCfg.MaxRecvMsgSize <= 0:
+		return nil, nil, fmt.Errorf("max_recv_msg_size must be > 0.")
+	case *telemetryCfg.MaxSendMsgSize <= 0:
+		return nil, nil, fmt.Errorf("max_send_msg_size must be > 0.")
+	case *telemetryCfg.RevocationRefreshInt <= 0:
+		return nil, nil, fmt.Errorf("revocation_refresh_interval must be > 0.")
+	case *telemetryCfg.CertExpiryCheckInt <= 0:
+		return nil, nil, fmt.Errorf("cert_expiry_check_interval must be > 0.")
+	case *telemetryCfg.AuditLogMaxSize <= 0:
+		return nil, nil, fmt.Errorf("audit_log_max_size must be > 0.")
+	case *telemetryCfg.AuditLogMaxBackups < 0:
+		return nil, nil, fmt.Errorf("audit_log_max_backups must be >= 0.")
+	}
+
+	switch *telemetryCfg.JwtSigningAlg {
+	case "HS256", "RS256", "ES256":
+	default:
+		return nil, nil, fmt.Errorf("jwt_signing_alg must be HS256, RS256 or ES256.")
 	}
 
 	switch {
@@ -241,6 +329,10 @@ func setupFlags(fs *flag.FlagSet) (*TelemetryConfig, *gnmi.Config, error) {
 		log.Infof("Log level must be greater than 0, setting to default value of 2")
 	}
 
+	if *telemetryCfg.DpuTLS && *telemetryCfg.NoTLS {
+		return nil, nil, fmt.Errorf("dpu_tls requires TLS, it cannot be set with noTLS.")
+	}
+
 	if !*telemetryCfg.NoTLS && !*telemetryCfg.Insecure {
 		switch {
 		case *telemetryCfg.ServerCert == "":
@@ -274,8 +366,24 @@ func setupFlags(fs *flag.FlagSet) (*TelemetryConfig, *gnmi.Config, error) {
 	cfg.CertCRLConfig = *telemetryCfg.CertCRLConfig
 	cfg.IntManFile = *telemetryCfg.IntManFile
 	cfg.CertzMetaFile = *telemetryCfg.CertzMetaFile
+	cfg.FedPolicyFile = *telemetryCfg.CertzAuthPolicyFile
 
 	gnmi.SetCrlExpireDuration(time.Duration(*telemetryCfg.CrlExpireDuration) * time.Second)
+	gnmi.SetOcspEnabled(*telemetryCfg.EnableOcsp)
+	gnmi.SetRevocationRefreshInterval(time.Duration(*telemetryCfg.RevocationRefreshInt) * time.Second)
+	gnmi.SetCertExpiryCheckInterval(time.Duration(*telemetryCfg.CertExpiryCheckInt) * time.Second)
+
+	expiryThresholds, err := certexpiry.ParseThresholds(*telemetryCfg.CertExpiryAlertDays)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cert_expiry_alert_days: %v", err)
+	}
+	gnmi.SetCertExpiryThresholds(expiryThresholds)
+
+	identitySources, err := gnmi.ParseCertIdentitySources(*telemetryCfg.ClientCertIdentity)
+	if err != nil {
+		return nil, nil, fmt.Errorf("client_cert_identity: %v", err)
+	}
+	gnmi.SetCertIdentitySources(identitySources)
 
 	// TODO: After other dependent projects are migrated to ZmqPort, remove ZmqAddress
 	zmqAddress := *telemetryCfg.ZmqAddress
@@ -314,9 +422,34 @@ func setupFlags(fs *flag.FlagSet) (*TelemetryConfig, *gnmi.Config, error) {
 	cfg.AuthzMetaFile = string(*telemetryCfg.AuthzMetaFile)
 	cfg.AuthzPolicy = *telemetryCfg.AuthPolicyEnabled && !*telemetryCfg.Insecure
 	cfg.AuthzPolicyFile = string(*telemetryCfg.AuthzPolicyFile)
+	cfg.EnableEventsCache = *telemetryCfg.EventsCache
 	return telemetryCfg, cfg, nil
 }
 
+// grpcServerOptions returns the connection management options applied to
+// both the TCP and UDS listeners.
+func grpcServerOptions(telemetryCfg *TelemetryConfig) []grpc.ServerOption {
+	opts := []grpc.ServerOption{
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			MaxConnectionIdle:     time.Duration(*telemetryCfg.IdleConnDuration) * time.Second, // 0 is inf
+			MaxConnectionAge:      time.Duration(*telemetryCfg.MaxConnAge) * time.Second,       // 0 is inf
+			MaxConnectionAgeGrace: time.Duration(*telemetryCfg.MaxConnAgeGrace) * time.Second,  // 0 is inf
+			Time:                  time.Duration(*telemetryCfg.KeepaliveTime) * time.Second,
+			Timeout:               time.Duration(*telemetryCfg.KeepaliveTimeout) * time.Second,
+		}),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             time.Duration(*telemetryCfg.KeepaliveMinTime) * time.Second,
+			PermitWithoutStream: *telemetryCfg.KeepaliveNoStream,
+		}),
+		grpc.MaxRecvMsgSize(*telemetryCfg.MaxRecvMsgSize),
+		grpc.MaxSendMsgSize(*telemetryCfg.MaxSendMsgSize),
+	}
+	if *telemetryCfg.MaxConcurrentStreams > 0 {
+		opts = append(opts, grpc.MaxConcurrentStreams(uint32(*telemetryCfg.MaxConcurrentStreams)))
+	}
+	return opts
+}
+
 func isFlagPassed(fs *flag.FlagSet, name string) bool {
 	found := false
 	fs.Visit(func(f *flag.Flag) {
@@ -409,6 +542,59 @@ func signalHandler(serverControlSignal chan<- ServerControlValue, sigchannel <-c
 func startGNMIServer(telemetryCfg *TelemetryConfig, cfg *gnmi.Config, serverControlSignal chan ServerControlValue, stopSignalHandler chan<- bool, wg *sync.WaitGroup) {
 	defer wg.Done()
 
+	// The bypass configuration is reloaded from CONFIG_DB while the server runs
+	stopBypassWatcher := bypass.StartConfigWatcher(bypass.DefaultConfigInterval)
+	defer stopBypassWatcher()
+
+	// The gNOI Debug command whitelists are reloaded when their file changes
+	stopWhitelistWatcher := gnoi_debug.StartWhitelistWatcher()
+	defer stopWhitelistWatcher()
+
+	if cfg.EnableCrl || gnmi.GetOcspEnabled() {
+		// CRLs rotated by certz for the default profile
+		bundleDir := ""
+		if cfg.EnableCrl && cfg.CertCRLConfig != "" {
+			bundleDir = filepath.Join(cfg.CertCRLConfig, "crl")
+		}
+		stopRevocationRefresher := gnmi.StartRevocationRefresher(bundleDir)
+		defer stopRevocationRefresher()
+	}
+
+	// Recent events are collected for EVENTS Get, ONCE and POLL
+	if cfg.EnableEventsCache {
+		stopEventsCollector := sdc.StartEventsCollector()
+		defer stopEventsCollector()
+	}
+
+	// The expiry of the credentials is checked while the server runs
+	stopCertExpiryMonitor := gnmi.StartCertExpiryMonitor(cfg)
+	defer stopCertExpiryMonitor()
+
+	// The cached wildcard and regex entries of the client cert role table are
+	// reloaded when the table changes
+	if cfg.ConfigTableName != "" {
+		stopCertRoleRulesWatcher := gnmi.StartCertRoleRulesWatcher(cfg.ConfigTableName)
+		defer stopCertRoleRulesWatcher()
+	}
+
+	// The mutating RPCs are audited by the interceptor chain
+	if *telemetryCfg.EnableAuditLog {
+		auditLogger, err := audit.NewLogger(audit.Config{
+			File:       *telemetryCfg.AuditLogFile,
+			MaxSize:    int64(*telemetryCfg.AuditLogMaxSize) * 1024 * 1024,
+			MaxBackups: *telemetryCfg.AuditLogMaxBackups,
+		})
+		if err != nil {
+			log.Errorf("Failed to open the audit log: %v", err)
+		} else {
+			audit.SetDefaultLogger(auditLogger)
+			defer func() {
+				audit.SetDefaultLogger(nil)
+				auditLogger.Close()
+			}()
+		}
+	}
+
 	var currentServerChain *interceptors.ServerChain
 	defer func() {
 		// Cleanup on function exit (ServerStop)
@@ -427,6 +613,7 @@ func startGNMIServer(telemetryCfg *TelemetryConfig, cfg *gnmi.Config, serverCont
 		var tlsOpts []grpc.ServerOption
 		var commonOpts []grpc.ServerOption
 		var certLoaded int32
+		var certReloader *gnmi.ServerCertReloader
 		atomic.StoreInt32(&certLoaded, 0) // Not loaded
 
 		if !*telemetryCfg.NoTLS {
@@ -439,17 +626,12 @@ func startGNMIServer(telemetryCfg *TelemetryConfig, cfg *gnmi.Config, serverCont
 					return
 				}
 			} else {
-				watcher, err := fsnotify.NewWatcher()
-				if err != nil {
-					log.Errorf("Received error when creating fsnotify watcher %v", err)
-				}
-				if watcher != nil {
-					go iNotifyCertMonitoring(watcher, telemetryCfg, serverControlSignal, nil, &certLoaded)
-				}
-				certificate, err = tls.LoadX509KeyPair(*telemetryCfg.ServerCert, *telemetryCfg.ServerKey)
+				startCertMonitoring(telemetryCfg, serverControlSignal, &certLoaded)
+				certFile, keyFile := serverCertFiles(telemetryCfg, cfg)
+				certificate, err = tls.LoadX509KeyPair(certFile, keyFile)
 				if err != nil {
-					computeSHA512Checksum(*telemetryCfg.ServerCert)
-					computeSHA512Checksum(*telemetryCfg.ServerKey)
+					computeSHA512Checksum(certFile)
+					computeSHA512Checksum(keyFile)
 					log.Errorf("could not load server key pair: %s", err)
 					for {
 						serverControlValue := <-serverControlSignal
@@ -468,7 +650,6 @@ func startGNMIServer(telemetryCfg *TelemetryConfig, cfg *gnmi.Config, serverCont
 
 			tlsCfg := &tls.Config{
 				ClientAuth:               tls.RequireAndVerifyClientCert,
-				Certificates:             []tls.Certificate{certificate},
 				MinVersion:               tls.VersionTLS12,
 				CurvePreferences:         []tls.CurveID{tls.CurveP521, tls.CurveP384, tls.CurveP256},
 				PreferServerCipherSuites: true,
@@ -489,14 +670,15 @@ func startGNMIServer(telemetryCfg *TelemetryConfig, cfg *gnmi.Config, serverCont
 				tlsCfg.ClientAuth = tls.RequestClientCert
 			}
 
+			var certPool *x509.CertPool
 			if *telemetryCfg.CaCert != "" {
 				caCertLoaded := true
-				ca, err := ioutil.ReadFile(*telemetryCfg.CaCert)
+				ca, err := ioutil.ReadFile(caCertFile(telemetryCfg, cfg))
 				if err != nil {
 					log.Errorf("could not read CA certificate: %s", err)
 					caCertLoaded = false
 				}
-				certPool := x509.NewCertPool()
+				certPool = x509.NewCertPool()
 				if ok := certPool.AppendCertsFromPEM(ca); !ok {
 					log.Errorf("failed to append CA certificate")
 					caCertLoaded = false
@@ -513,7 +695,6 @@ func startGNMIServer(telemetryCfg *TelemetryConfig, cfg *gnmi.Config, serverCont
 					}
 					continue
 				}
-				tlsCfg.ClientCAs = certPool
 			} else {
 				if telemetryCfg.UserAuth.Enabled("cert") {
 					telemetryCfg.UserAuth.Unset("cert")
@@ -523,19 +704,35 @@ func startGNMIServer(telemetryCfg *TelemetryConfig, cfg *gnmi.Config, serverCont
 
 			atomic.StoreInt32(&certLoaded, 1) // Certs have loaded
 
-			keep_alive_params := keepalive.ServerParameters{
-				MaxConnectionIdle: time.Duration(*telemetryCfg.IdleConnDuration) * time.Second, // duration in which idle connection will be closed, default is inf
+			// The certificate and the client CAs are selected for every new
+			// connection, so that rotations apply without restarting the server
+			certReloader = gnmi.NewServerCertReloader(func() (tls.Certificate, *x509.CertPool, error) {
+				return loadServerCerts(telemetryCfg, cfg)
+			})
+			certReloader.Set(certificate, certPool)
+			certReloader.SetAuthPolicyFile(cfg.FedPolicyFile)
+			tlsCfg.GetCertificate = certReloader.GetCertificate
+			tlsCfg.GetConfigForClient = certReloader.GetConfigForClient(tlsCfg)
+			if !*telemetryCfg.Insecure {
+				gnmi.SetServerCertReloader(certReloader)
 			}
 
 			tlsOpts = []grpc.ServerOption{grpc.Creds(credentials.NewTLS(tlsCfg))}
 
-			if *telemetryCfg.IdleConnDuration > 0 { // non inf case
-				commonOpts = append(commonOpts, grpc.KeepaliveParams(keep_alive_params))
-			}
-
 			cfg.UserAuth = telemetryCfg.UserAuth
 
-			gnmi.GenerateJwtSecretKey()
+			jwtCfg := gnmi.JwtConfig{
+				Algorithm:      *telemetryCfg.JwtSigningAlg,
+				KeyFile:        *telemetryCfg.JwtKeyFile,
+				RevocationFile: *telemetryCfg.JwtRevocationFile,
+				Issuer:         *telemetryCfg.JwtIssuer,
+				Audience:       *telemetryCfg.JwtAudience,
+			}
+			if err := gnmi.ConfigureJwt(jwtCfg); err != nil {
+				// Keep serving, tokens are then signed with a random HS256 secret
+				log.Errorf("Failed to configure JWT signing, falling back to HS256: %v", err)
+				gnmi.GenerateJwtSecretKey()
+			}
 		}
 
 		// Setup interceptor chain (includes DPU proxy with Redis-based routing)
@@ -546,6 +743,12 @@ func startGNMIServer(telemetryCfg *TelemetryConfig, cfg *gnmi.Config, serverCont
 			return
 		}
 
+		// setupFlags rejects dpu_tls without TLS, so the reloader is set
+		if *telemetryCfg.DpuTLS {
+			currentServerChain.SetDPUTLSConfig(certReloader.ClientConfig())
+		}
+
+		commonOpts = append(commonOpts, grpcServerOptions(telemetryCfg)...)
 		commonOpts = append(commonOpts, currentServerChain.GetServerOptions()...)
 
 		s, err := gnmi.NewServer(cfg, tlsOpts, commonOpts)
@@ -553,6 +756,8 @@ func startGNMIServer(telemetryCfg *TelemetryConfig, cfg *gnmi.Config, serverCont
 			log.Errorf("Failed to create gNMI server: %v", err)
 			return
 		}
+		// Requests forwarded to DPUs are authorized as if the NPU served them
+		currentServerChain.SetDPUAuthorizer(s.AuthorizeDPUForward)
 		if *telemetryCfg.WithSaveOnSet {
 			s.SaveStartupConfig = gnmi.SaveOnSetEnabled
 		}
@@ -571,20 +776,100 @@ func startGNMIServer(telemetryCfg *TelemetryConfig, cfg *gnmi.Config, serverCont
 			}
 		}()
 
-		serverControlValue := <-serverControlSignal
-		log.V(1).Infof("Received signal for gnmi server to close")
-		if serverControlValue == ServerStop {
-			s.ForceStop() // No graceful stop
-			stopSignalHandler <- true
-			log.Flush()
-			return
+		for {
+			serverControlValue := <-serverControlSignal
+			if serverControlValue == ServerStop {
+				log.V(1).Infof("Received signal for gnmi server to close")
+				gnmi.SetServerCertReloader(nil)
+				s.ForceStop() // No graceful stop
+				stopSignalHandler <- true
+				log.Flush()
+				return
+			}
+			if certReloader == nil || *telemetryCfg.Insecure {
+				break
+			}
+			// Rotated certificates apply to new connections, existing ones are kept
+			if serverControlValue == ServerStart {
+				if err := certReloader.Reload(); err != nil {
+					log.Errorf("Failed to reload server certificates, serving the previous ones: %v", err)
+				}
+			} else {
+				log.V(1).Infof("Server certificate removed, serving the loaded one until it is replaced")
+			}
+			// Cert monitoring ends once it signals a change
+			startCertMonitoring(telemetryCfg, serverControlSignal, &certLoaded)
 		}
+		log.V(1).Infof("Received signal for gnmi server to restart")
 		s.Stop() // Graceful stop
-		// Both ServerStart and ServerRestart will loop and restart server
-		// We use different value to distinguish between write/create and remove/rename
 	}
 }
 
+// startCertMonitoring watches the server certificate directory until the
+// certificate changes.
+func startCertMonitoring(telemetryCfg *TelemetryConfig, serverControlSignal chan<- ServerControlValue, certLoaded *int32) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Errorf("Received error when creating fsnotify watcher %v", err)
+	}
+	if watcher != nil {
+		go iNotifyCertMonitoring(watcher, telemetryCfg, serverControlSignal, nil, certLoaded)
+	}
+}
+
+// isCertzLink returns true if path is a symlink to an existing file, as set by
+// certz rotations.
+func isCertzLink(path string) bool {
+	if path == "" {
+		return false
+	}
+	if fi, err := os.Lstat(path); err != nil || fi.Mode()&os.ModeSymlink == 0 {
+		return false
+	}
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// serverCertFiles returns the server certificate and key files, the certz
+// symlinks once certz rotated them.
+func serverCertFiles(telemetryCfg *TelemetryConfig, cfg *gnmi.Config) (string, string) {
+	if isCertzLink(cfg.SrvCertLnk) && isCertzLink(cfg.SrvKeyLnk) {
+		return cfg.SrvCertLnk, cfg.SrvKeyLnk
+	}
+	return *telemetryCfg.ServerCert, *telemetryCfg.ServerKey
+}
+
+// caCertFile returns the client CA file, the certz symlink once certz rotated
+// the trust bundle.
+func caCertFile(telemetryCfg *TelemetryConfig, cfg *gnmi.Config) string {
+	if isCertzLink(cfg.CaCertLnk) {
+		return cfg.CaCertLnk
+	}
+	return *telemetryCfg.CaCert
+}
+
+// loadServerCerts loads the server certificate and the client CAs, nil if no
+// CA is configured.
+func loadServerCerts(telemetryCfg *TelemetryConfig, cfg *gnmi.Config) (tls.Certificate, *x509.CertPool, error) {
+	certFile, keyFile := serverCertFiles(telemetryCfg, cfg)
+	certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return tls.Certificate{}, nil, fmt.Errorf("could not load server key pair: %s", err)
+	}
+	if *telemetryCfg.CaCert == "" {
+		return certificate, nil, nil
+	}
+	ca, err := ioutil.ReadFile(caCertFile(telemetryCfg, cfg))
+	if err != nil {
+		return tls.Certificate{}, nil, fmt.Errorf("could not read CA certificate: %s", err)
+	}
+	certPool := x509.NewCertPool()
+	if ok := certPool.AppendCertsFromPEM(ca); !ok {
+		return tls.Certificate{}, nil, fmt.Errorf("failed to append CA certificate")
+	}
+	return certificate, certPool, nil
+}
+
 func computeSHA512Checksum(file string) {
 	currentTime := time.Now().UTC()
 	f, err := os.Open(file)
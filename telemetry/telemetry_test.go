@@ -214,7 +214,7 @@ func TestStartGNMIServer(t *testing.T) {
 	}
 }
 
-func TestStartGNMIServerGracefulStop(t *testing.T) {
+func TestStartGNMIServerCertRotation(t *testing.T) {
 	testServerCert := "../testdata/certs/testserver.cert"
 	testServerKey := "../testdata/certs/testserver.key"
 	timeoutInterval := 15
@@ -237,7 +237,9 @@ func TestStartGNMIServerGracefulStop(t *testing.T) {
 		t.Errorf("Expected err to be nil, got err %v", err)
 	}
 
+	var loads int32
 	patches := gomonkey.ApplyFunc(tls.LoadX509KeyPair, func(certFile, keyFile string) (tls.Certificate, error) {
+		atomic.AddInt32(&loads, 1)
 		return tls.Certificate{}, nil
 	})
 	patches.ApplyFunc(gnmi.NewServer, func(cfg *gnmi.Config, tlsOpts []grpc.ServerOption, commonOpts []grpc.ServerOption) (*gnmi.Server, error) {
@@ -260,11 +262,13 @@ func TestStartGNMIServerGracefulStop(t *testing.T) {
 	wg := &sync.WaitGroup{}
 
 	counter := 0
-	exitCalled := false
+	stopCalled := false
+	forceStopCalled := false
 	patches.ApplyMethod(reflect.TypeOf(&gnmi.Server{}), "Stop", func(_ *gnmi.Server) {
-		exitCalled = true
+		stopCalled = true
 	})
 	patches.ApplyMethod(reflect.TypeOf(&gnmi.Server{}), "ForceStop", func(_ *gnmi.Server) {
+		forceStopCalled = true
 	})
 
 	defer patches.Reset()
@@ -276,9 +280,12 @@ func TestStartGNMIServerGracefulStop(t *testing.T) {
 	for {
 		select {
 		case <-tick.C:
-			if counter == 0 { // simulate cert rotation first
+			switch counter {
+			case 0: // simulate cert removal first
 				sendSignal(serverControlSignal, ServerRestart)
-			} else { // simulate sigterm second
+			case 1: // then the new cert
+				sendSignal(serverControlSignal, ServerStart)
+			default: // simulate sigterm last
 				sendSignal(serverControlSignal, ServerStop)
 			}
 			counter += 1
@@ -286,15 +293,22 @@ func TestStartGNMIServerGracefulStop(t *testing.T) {
 			t.Errorf("Failed to send shutdown signal")
 			return
 		}
-		if counter > 1 { // both signals have been sent
+		if counter > 2 { // all signals have been sent
 			break
 		}
 	}
 
 	wg.Wait()
 
-	if !exitCalled {
-		t.Errorf("s.Stop should be called if gnmi server is called to shutdown")
+	if stopCalled {
+		t.Errorf("s.Stop should not be called when certs are rotated")
+	}
+	if !forceStopCalled {
+		t.Errorf("s.ForceStop should be called if gnmi server is called to shutdown")
+	}
+	// Loaded on start, and reloaded for the new cert only
+	if n := atomic.LoadInt32(&loads); n != 2 {
+		t.Errorf("Expected the certs to be loaded twice, got %d", n)
 	}
 }
 
@@ -1416,6 +1430,92 @@ func TestFlagsNoPortNoUnixSocket(t *testing.T) {
 	}
 }
 
+func TestGrpcServerOptionFlags(t *testing.T) {
+	originalArgs := os.Args
+	defer func() {
+		os.Args = originalArgs
+	}()
+
+	fs := flag.NewFlagSet("testGrpcServerOptions", flag.ContinueOnError)
+	os.Args = []string{"cmd", "-port", "8080", "-noTLS", "-keepalive_time", "60", "-keepalive_min_time", "10",
+		"-keepalive_permit_without_stream", "-max_connection_age", "3600", "-max_concurrent_streams", "100",
+		"-max_recv_msg_size", "67108864"}
+	cfg, _, err := setupFlags(fs)
+	if err != nil {
+		t.Fatalf("Expected err to be nil, got err %v", err)
+	}
+	if *cfg.KeepaliveTime != 60 || *cfg.KeepaliveTimeout != 20 || *cfg.KeepaliveMinTime != 10 || !*cfg.KeepaliveNoStream {
+		t.Errorf("Unexpected keepalive flags: time %d, timeout %d, min time %d, without stream %v",
+			*cfg.KeepaliveTime, *cfg.KeepaliveTimeout, *cfg.KeepaliveMinTime, *cfg.KeepaliveNoStream)
+	}
+	if *cfg.MaxConnAge != 3600 || *cfg.MaxConnAgeGrace != 0 || *cfg.MaxConcurrentStreams != 100 || *cfg.MaxRecvMsgSize != 64*1024*1024 {
+		t.Errorf("Unexpected connection flags: age %d, grace %d, streams %d, recv size %d",
+			*cfg.MaxConnAge, *cfg.MaxConnAgeGrace, *cfg.MaxConcurrentStreams, *cfg.MaxRecvMsgSize)
+	}
+	// Keepalive, enforcement, recv size, send size and max streams
+	if opts := grpcServerOptions(cfg); len(opts) != 5 {
+		t.Errorf("Expected 5 server options, got %d", len(opts))
+	}
+
+	for _, args := range [][]string{
+		{"-keepalive_time", "0"},
+		{"-keepalive_timeout", "0"},
+		{"-keepalive_min_time", "-1"},
+		{"-max_connection_age", "-1"},
+		{"-max_connection_age_grace", "-1"},
+		{"-max_concurrent_streams", "-1"},
+		{"-max_recv_msg_size", "0"},
+		{"-max_send_msg_size", "0"},
+	} {
+		fs := flag.NewFlagSet("testGrpcServerOptions", flag.ContinueOnError)
+		os.Args = append([]string{"cmd", "-port", "8080", "-noTLS"}, args...)
+		if _, _, err := setupFlags(fs); err == nil || !strings.Contains(err.Error(), strings.TrimPrefix(args[0], "-")) {
+			t.Errorf("Expected %s error, got %v", args[0], err)
+		}
+	}
+}
+
+func TestDpuTLSFlag(t *testing.T) {
+	originalArgs := os.Args
+	defer func() { os.Args = originalArgs }()
+
+	// Credentials are never forwarded in plaintext, so dpu_tls needs TLS
+	fs := flag.NewFlagSet("testDpuTLSFlag", flag.ContinueOnError)
+	os.Args = []string{"cmd", "-port", "8080", "-noTLS", "-dpu_tls"}
+	if _, _, err := setupFlags(fs); err == nil || !strings.Contains(err.Error(), "dpu_tls requires TLS") {
+		t.Errorf("Expected dpu_tls error, got %v", err)
+	}
+
+	fs = flag.NewFlagSet("testDpuTLSFlag", flag.ContinueOnError)
+	os.Args = []string{"cmd", "-port", "8080", "-server_crt", "cert.pem", "-server_key", "key.pem", "-dpu_tls"}
+	if _, _, err := setupFlags(fs); err != nil {
+		t.Errorf("Expected dpu_tls to be accepted with TLS, got %v", err)
+	}
+}
+
+func TestClientCertIdentityFlag(t *testing.T) {
+	originalArgs := os.Args
+	defer func() {
+		os.Args = originalArgs
+		gnmi.SetCertIdentitySources(nil)
+	}()
+
+	fs := flag.NewFlagSet("testClientCertIdentityFlag", flag.ContinueOnError)
+	os.Args = []string{"cmd", "-port", "8080", "-noTLS", "-client_cert_identity", "SPIFFE, cn"}
+	if _, _, err := setupFlags(fs); err != nil {
+		t.Fatalf("Expected err to be nil, got err %v", err)
+	}
+	if sources := gnmi.GetCertIdentitySources(); !reflect.DeepEqual(sources, []string{"spiffe", "cn"}) {
+		t.Errorf("Unexpected identity sources %v", sources)
+	}
+
+	fs = flag.NewFlagSet("testClientCertIdentityFlag", flag.ContinueOnError)
+	os.Args = []string{"cmd", "-port", "8080", "-noTLS", "-client_cert_identity", "cn,ip"}
+	if _, _, err := setupFlags(fs); err == nil || !strings.Contains(err.Error(), "client_cert_identity") {
+		t.Errorf("Expected client_cert_identity error, got %v", err)
+	}
+}
+
 func TestMain(m *testing.M) {
 	defer test_utils.MemLeakCheck()
 	m.Run()
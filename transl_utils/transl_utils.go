@@ -167,13 +167,16 @@ func ConvertToURI(prefix, path *gnmipb.Path, opts ...pathutil.PathValidatorOpt)
 	return ygot.PathToString(fullPath)
 }
 
-/* Fill the values from TransLib. */
-func TranslProcessGet(uriPath string, op *string, ctx context.Context) (*gnmipb.TypedValue, error) {
+/* Fill the values from TransLib. A non empty content restricts the data to config, nonconfig or operational nodes. */
+func TranslProcessGet(uriPath string, op *string, ctx context.Context, content string) (*gnmipb.TypedValue, error) {
 	var jv []byte
 	var data []byte
 	rc, _ := common_utils.GetContext(ctx)
 
 	req := translib.GetRequest{Path: uriPath, User: translib.UserRoles{Name: rc.Auth.User, Roles: rc.Auth.Roles}}
+	if content != "" {
+		req.QueryParams.Content = content
+	}
 	if rc.BundleVersion != nil {
 		nver, err := translib.NewVersion(*rc.BundleVersion)
 		if err != nil {